@@ -0,0 +1,52 @@
+package enums
+
+// BorrowerDocumentType names a document a borrower has to sign before a loan
+// can move on.
+type BorrowerDocumentType int
+
+const (
+	BorrowerDocumentTypeLoanAgreement BorrowerDocumentType = iota + 1
+)
+
+func (t BorrowerDocumentType) String() string {
+	switch t {
+	case BorrowerDocumentTypeLoanAgreement:
+		return "LOAN_AGREEMENT"
+	default:
+		return "UNKNOWN"
+	}
+}
+
+func (t BorrowerDocumentType) Int() int {
+	return int(t)
+}
+
+func BorrowerDocumentTypeFromString(value string) BorrowerDocumentType {
+	switch value {
+	case "LOAN_AGREEMENT":
+		return BorrowerDocumentTypeLoanAgreement
+	default:
+		return 0
+	}
+}
+
+func BorrowerDocumentTypeFromInt(value int) BorrowerDocumentType {
+	switch value {
+	case 1:
+		return BorrowerDocumentTypeLoanAgreement
+	default:
+		return 0
+	}
+}
+
+func GetAllBorrowerDocumentTypes() []BorrowerDocumentType {
+	return []BorrowerDocumentType{
+		BorrowerDocumentTypeLoanAgreement,
+	}
+}
+
+func GetBorrowerDocumentTypeMap() map[int]string {
+	return map[int]string{
+		1: "LOAN_AGREEMENT",
+	}
+}
@@ -0,0 +1,69 @@
+package enums
+
+// CollectionStatus tracks an investor payment collected through the payment gateway.
+type CollectionStatus int
+
+const (
+	CollectionStatusPending CollectionStatus = iota + 1
+	CollectionStatusSucceeded
+	CollectionStatusFailed
+)
+
+func (s CollectionStatus) String() string {
+	switch s {
+	case CollectionStatusPending:
+		return "PENDING"
+	case CollectionStatusSucceeded:
+		return "SUCCEEDED"
+	case CollectionStatusFailed:
+		return "FAILED"
+	default:
+		return "UNKNOWN"
+	}
+}
+
+func (s CollectionStatus) Int() int {
+	return int(s)
+}
+
+func CollectionStatusFromString(value string) CollectionStatus {
+	switch value {
+	case "PENDING":
+		return CollectionStatusPending
+	case "SUCCEEDED":
+		return CollectionStatusSucceeded
+	case "FAILED":
+		return CollectionStatusFailed
+	default:
+		return 0
+	}
+}
+
+func CollectionStatusFromInt(value int) CollectionStatus {
+	switch value {
+	case 1:
+		return CollectionStatusPending
+	case 2:
+		return CollectionStatusSucceeded
+	case 3:
+		return CollectionStatusFailed
+	default:
+		return 0
+	}
+}
+
+func GetAllCollectionStatuses() []CollectionStatus {
+	return []CollectionStatus{
+		CollectionStatusPending,
+		CollectionStatusSucceeded,
+		CollectionStatusFailed,
+	}
+}
+
+func GetCollectionStatusMap() map[int]string {
+	return map[int]string{
+		1: "PENDING",
+		2: "SUCCEEDED",
+		3: "FAILED",
+	}
+}
@@ -0,0 +1,70 @@
+package enums
+
+// DayCountConvention decides how much of a year's interest a single day
+// earns.
+type DayCountConvention int
+
+const (
+	DayCountConventionAct365 DayCountConvention = iota + 1
+	DayCountConventionAct360
+	DayCountConvention30360
+)
+
+func (c DayCountConvention) String() string {
+	switch c {
+	case DayCountConventionAct365:
+		return "ACT/365"
+	case DayCountConventionAct360:
+		return "ACT/360"
+	case DayCountConvention30360:
+		return "30/360"
+	default:
+		return "UNKNOWN"
+	}
+}
+
+func (c DayCountConvention) Int() int {
+	return int(c)
+}
+
+func DayCountConventionFromString(value string) DayCountConvention {
+	switch value {
+	case "ACT/365":
+		return DayCountConventionAct365
+	case "ACT/360":
+		return DayCountConventionAct360
+	case "30/360":
+		return DayCountConvention30360
+	default:
+		return 0
+	}
+}
+
+func DayCountConventionFromInt(value int) DayCountConvention {
+	switch value {
+	case 1:
+		return DayCountConventionAct365
+	case 2:
+		return DayCountConventionAct360
+	case 3:
+		return DayCountConvention30360
+	default:
+		return 0
+	}
+}
+
+func GetAllDayCountConventions() []DayCountConvention {
+	return []DayCountConvention{
+		DayCountConventionAct365,
+		DayCountConventionAct360,
+		DayCountConvention30360,
+	}
+}
+
+func GetDayCountConventionMap() map[int]string {
+	return map[int]string{
+		1: "ACT/365",
+		2: "ACT/360",
+		3: "30/360",
+	}
+}
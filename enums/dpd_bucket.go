@@ -0,0 +1,104 @@
+package enums
+
+// DPDBucket groups loans by how many days their oldest unpaid instalment is
+// past due.
+type DPDBucket int
+
+const (
+	DPDBucketCurrent DPDBucket = iota + 1
+	DPDBucket1To30
+	DPDBucket31To60
+	DPDBucket61To90
+	DPDBucketOver90
+)
+
+func (b DPDBucket) String() string {
+	switch b {
+	case DPDBucketCurrent:
+		return "CURRENT"
+	case DPDBucket1To30:
+		return "DPD_1_30"
+	case DPDBucket31To60:
+		return "DPD_31_60"
+	case DPDBucket61To90:
+		return "DPD_61_90"
+	case DPDBucketOver90:
+		return "DPD_90_PLUS"
+	default:
+		return "UNKNOWN"
+	}
+}
+
+func (b DPDBucket) Int() int {
+	return int(b)
+}
+
+func DPDBucketFromString(value string) DPDBucket {
+	switch value {
+	case "CURRENT":
+		return DPDBucketCurrent
+	case "DPD_1_30":
+		return DPDBucket1To30
+	case "DPD_31_60":
+		return DPDBucket31To60
+	case "DPD_61_90":
+		return DPDBucket61To90
+	case "DPD_90_PLUS":
+		return DPDBucketOver90
+	default:
+		return 0
+	}
+}
+
+func DPDBucketFromInt(value int) DPDBucket {
+	switch value {
+	case 1:
+		return DPDBucketCurrent
+	case 2:
+		return DPDBucket1To30
+	case 3:
+		return DPDBucket31To60
+	case 4:
+		return DPDBucket61To90
+	case 5:
+		return DPDBucketOver90
+	default:
+		return 0
+	}
+}
+
+// DPDBucketFromDays returns the bucket for the given days past due.
+func DPDBucketFromDays(daysPastDue int) DPDBucket {
+	switch {
+	case daysPastDue <= 0:
+		return DPDBucketCurrent
+	case daysPastDue <= 30:
+		return DPDBucket1To30
+	case daysPastDue <= 60:
+		return DPDBucket31To60
+	case daysPastDue <= 90:
+		return DPDBucket61To90
+	default:
+		return DPDBucketOver90
+	}
+}
+
+func GetAllDPDBuckets() []DPDBucket {
+	return []DPDBucket{
+		DPDBucketCurrent,
+		DPDBucket1To30,
+		DPDBucket31To60,
+		DPDBucket61To90,
+		DPDBucketOver90,
+	}
+}
+
+func GetDPDBucketMap() map[int]string {
+	return map[int]string{
+		1: "CURRENT",
+		2: "DPD_1_30",
+		3: "DPD_31_60",
+		4: "DPD_61_90",
+		5: "DPD_90_PLUS",
+	}
+}
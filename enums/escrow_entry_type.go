@@ -0,0 +1,70 @@
+package enums
+
+// EscrowEntryType classifies a movement of investor money held in escrow for
+// a loan.
+type EscrowEntryType int
+
+const (
+	EscrowEntryTypeDeposit EscrowEntryType = iota + 1
+	EscrowEntryTypeRelease
+	EscrowEntryTypeRefund
+)
+
+func (t EscrowEntryType) String() string {
+	switch t {
+	case EscrowEntryTypeDeposit:
+		return "DEPOSIT"
+	case EscrowEntryTypeRelease:
+		return "RELEASE"
+	case EscrowEntryTypeRefund:
+		return "REFUND"
+	default:
+		return "UNKNOWN"
+	}
+}
+
+func (t EscrowEntryType) Int() int {
+	return int(t)
+}
+
+func EscrowEntryTypeFromString(value string) EscrowEntryType {
+	switch value {
+	case "DEPOSIT":
+		return EscrowEntryTypeDeposit
+	case "RELEASE":
+		return EscrowEntryTypeRelease
+	case "REFUND":
+		return EscrowEntryTypeRefund
+	default:
+		return 0
+	}
+}
+
+func EscrowEntryTypeFromInt(value int) EscrowEntryType {
+	switch value {
+	case 1:
+		return EscrowEntryTypeDeposit
+	case 2:
+		return EscrowEntryTypeRelease
+	case 3:
+		return EscrowEntryTypeRefund
+	default:
+		return 0
+	}
+}
+
+func GetAllEscrowEntryTypes() []EscrowEntryType {
+	return []EscrowEntryType{
+		EscrowEntryTypeDeposit,
+		EscrowEntryTypeRelease,
+		EscrowEntryTypeRefund,
+	}
+}
+
+func GetEscrowEntryTypeMap() map[int]string {
+	return map[int]string{
+		1: "DEPOSIT",
+		2: "RELEASE",
+		3: "REFUND",
+	}
+}
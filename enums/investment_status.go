@@ -0,0 +1,91 @@
+package enums
+
+// InvestmentStatus tracks whether an investor's money is still committed to
+// a loan. An investment starts out PENDING_FUNDING, reserving its amount
+// until the investor's payment arrives; it becomes FUNDED once the payment
+// is confirmed, or EXPIRED if it doesn't arrive in time. An investor can
+// withdraw during the cooling-off period, which makes it CANCELLED.
+type InvestmentStatus int
+
+const (
+	InvestmentStatusFunded InvestmentStatus = iota + 1
+	InvestmentStatusRefunded
+	InvestmentStatusPendingFunding
+	InvestmentStatusExpired
+	InvestmentStatusCancelled
+)
+
+func (is InvestmentStatus) String() string {
+	switch is {
+	case InvestmentStatusFunded:
+		return "FUNDED"
+	case InvestmentStatusRefunded:
+		return "REFUNDED"
+	case InvestmentStatusPendingFunding:
+		return "PENDING_FUNDING"
+	case InvestmentStatusExpired:
+		return "EXPIRED"
+	case InvestmentStatusCancelled:
+		return "CANCELLED"
+	default:
+		return "UNKNOWN"
+	}
+}
+
+func (is InvestmentStatus) Int() int {
+	return int(is)
+}
+
+func InvestmentStatusFromString(value string) InvestmentStatus {
+	switch value {
+	case "FUNDED":
+		return InvestmentStatusFunded
+	case "REFUNDED":
+		return InvestmentStatusRefunded
+	case "PENDING_FUNDING":
+		return InvestmentStatusPendingFunding
+	case "EXPIRED":
+		return InvestmentStatusExpired
+	case "CANCELLED":
+		return InvestmentStatusCancelled
+	default:
+		return 0
+	}
+}
+
+func InvestmentStatusFromInt(value int) InvestmentStatus {
+	switch value {
+	case 1:
+		return InvestmentStatusFunded
+	case 2:
+		return InvestmentStatusRefunded
+	case 3:
+		return InvestmentStatusPendingFunding
+	case 4:
+		return InvestmentStatusExpired
+	case 5:
+		return InvestmentStatusCancelled
+	default:
+		return 0
+	}
+}
+
+func GetAllInvestmentStatuses() []InvestmentStatus {
+	return []InvestmentStatus{
+		InvestmentStatusFunded,
+		InvestmentStatusRefunded,
+		InvestmentStatusPendingFunding,
+		InvestmentStatusExpired,
+		InvestmentStatusCancelled,
+	}
+}
+
+func GetInvestmentStatusMap() map[int]string {
+	return map[int]string{
+		1: "FUNDED",
+		2: "REFUNDED",
+		3: "PENDING_FUNDING",
+		4: "EXPIRED",
+		5: "CANCELLED",
+	}
+}
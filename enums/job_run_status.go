@@ -0,0 +1,69 @@
+package enums
+
+// JobRunStatus is the outcome of a single background job execution.
+type JobRunStatus int
+
+const (
+	JobRunStatusRunning JobRunStatus = iota + 1
+	JobRunStatusSucceeded
+	JobRunStatusFailed
+)
+
+func (jrs JobRunStatus) String() string {
+	switch jrs {
+	case JobRunStatusRunning:
+		return "RUNNING"
+	case JobRunStatusSucceeded:
+		return "SUCCEEDED"
+	case JobRunStatusFailed:
+		return "FAILED"
+	default:
+		return "UNKNOWN"
+	}
+}
+
+func (jrs JobRunStatus) Int() int {
+	return int(jrs)
+}
+
+func JobRunStatusFromString(value string) JobRunStatus {
+	switch value {
+	case "RUNNING":
+		return JobRunStatusRunning
+	case "SUCCEEDED":
+		return JobRunStatusSucceeded
+	case "FAILED":
+		return JobRunStatusFailed
+	default:
+		return 0
+	}
+}
+
+func JobRunStatusFromInt(value int) JobRunStatus {
+	switch value {
+	case 1:
+		return JobRunStatusRunning
+	case 2:
+		return JobRunStatusSucceeded
+	case 3:
+		return JobRunStatusFailed
+	default:
+		return 0
+	}
+}
+
+func GetAllJobRunStatuses() []JobRunStatus {
+	return []JobRunStatus{
+		JobRunStatusRunning,
+		JobRunStatusSucceeded,
+		JobRunStatusFailed,
+	}
+}
+
+func GetJobRunStatusMap() map[int]string {
+	return map[int]string{
+		1: "RUNNING",
+		2: "SUCCEEDED",
+		3: "FAILED",
+	}
+}
@@ -0,0 +1,87 @@
+package enums
+
+// LedgerEntryType classifies an entry in a loan's ledger.
+type LedgerEntryType int
+
+const (
+	LedgerEntryTypeLateFee LedgerEntryType = iota + 1
+	LedgerEntryTypeWriteOff
+	LedgerEntryTypePrepayment
+	LedgerEntryTypePrepaymentPenalty
+	LedgerEntryTypeInterestAccrual
+)
+
+func (t LedgerEntryType) String() string {
+	switch t {
+	case LedgerEntryTypeLateFee:
+		return "LATE_FEE"
+	case LedgerEntryTypeWriteOff:
+		return "WRITE_OFF"
+	case LedgerEntryTypePrepayment:
+		return "PREPAYMENT"
+	case LedgerEntryTypePrepaymentPenalty:
+		return "PREPAYMENT_PENALTY"
+	case LedgerEntryTypeInterestAccrual:
+		return "INTEREST_ACCRUAL"
+	default:
+		return "UNKNOWN"
+	}
+}
+
+func (t LedgerEntryType) Int() int {
+	return int(t)
+}
+
+func LedgerEntryTypeFromString(value string) LedgerEntryType {
+	switch value {
+	case "LATE_FEE":
+		return LedgerEntryTypeLateFee
+	case "WRITE_OFF":
+		return LedgerEntryTypeWriteOff
+	case "PREPAYMENT":
+		return LedgerEntryTypePrepayment
+	case "PREPAYMENT_PENALTY":
+		return LedgerEntryTypePrepaymentPenalty
+	case "INTEREST_ACCRUAL":
+		return LedgerEntryTypeInterestAccrual
+	default:
+		return 0
+	}
+}
+
+func LedgerEntryTypeFromInt(value int) LedgerEntryType {
+	switch value {
+	case 1:
+		return LedgerEntryTypeLateFee
+	case 2:
+		return LedgerEntryTypeWriteOff
+	case 3:
+		return LedgerEntryTypePrepayment
+	case 4:
+		return LedgerEntryTypePrepaymentPenalty
+	case 5:
+		return LedgerEntryTypeInterestAccrual
+	default:
+		return 0
+	}
+}
+
+func GetAllLedgerEntryTypes() []LedgerEntryType {
+	return []LedgerEntryType{
+		LedgerEntryTypeLateFee,
+		LedgerEntryTypeWriteOff,
+		LedgerEntryTypePrepayment,
+		LedgerEntryTypePrepaymentPenalty,
+		LedgerEntryTypeInterestAccrual,
+	}
+}
+
+func GetLedgerEntryTypeMap() map[int]string {
+	return map[int]string{
+		1: "LATE_FEE",
+		2: "WRITE_OFF",
+		3: "PREPAYMENT",
+		4: "PREPAYMENT_PENALTY",
+		5: "INTEREST_ACCRUAL",
+	}
+}
@@ -0,0 +1,114 @@
+package enums
+
+// LoanPurpose is the category of what the borrower will use the funds for.
+type LoanPurpose int
+
+const (
+	LoanPurposeWorkingCapital LoanPurpose = iota + 1
+	LoanPurposeInventory
+	LoanPurposeEquipment
+	LoanPurposeBusinessExpansion
+	LoanPurposeEducation
+	LoanPurposeHealthcare
+	LoanPurposeHomeImprovement
+	LoanPurposeOther
+)
+
+func (lp LoanPurpose) String() string {
+	switch lp {
+	case LoanPurposeWorkingCapital:
+		return "WORKING_CAPITAL"
+	case LoanPurposeInventory:
+		return "INVENTORY"
+	case LoanPurposeEquipment:
+		return "EQUIPMENT"
+	case LoanPurposeBusinessExpansion:
+		return "BUSINESS_EXPANSION"
+	case LoanPurposeEducation:
+		return "EDUCATION"
+	case LoanPurposeHealthcare:
+		return "HEALTHCARE"
+	case LoanPurposeHomeImprovement:
+		return "HOME_IMPROVEMENT"
+	case LoanPurposeOther:
+		return "OTHER"
+	default:
+		return "UNKNOWN"
+	}
+}
+
+func (lp LoanPurpose) Int() int {
+	return int(lp)
+}
+
+func LoanPurposeFromString(value string) LoanPurpose {
+	switch value {
+	case "WORKING_CAPITAL":
+		return LoanPurposeWorkingCapital
+	case "INVENTORY":
+		return LoanPurposeInventory
+	case "EQUIPMENT":
+		return LoanPurposeEquipment
+	case "BUSINESS_EXPANSION":
+		return LoanPurposeBusinessExpansion
+	case "EDUCATION":
+		return LoanPurposeEducation
+	case "HEALTHCARE":
+		return LoanPurposeHealthcare
+	case "HOME_IMPROVEMENT":
+		return LoanPurposeHomeImprovement
+	case "OTHER":
+		return LoanPurposeOther
+	default:
+		return 0
+	}
+}
+
+func LoanPurposeFromInt(value int) LoanPurpose {
+	switch value {
+	case 1:
+		return LoanPurposeWorkingCapital
+	case 2:
+		return LoanPurposeInventory
+	case 3:
+		return LoanPurposeEquipment
+	case 4:
+		return LoanPurposeBusinessExpansion
+	case 5:
+		return LoanPurposeEducation
+	case 6:
+		return LoanPurposeHealthcare
+	case 7:
+		return LoanPurposeHomeImprovement
+	case 8:
+		return LoanPurposeOther
+	default:
+		return 0
+	}
+}
+
+func GetAllLoanPurposes() []LoanPurpose {
+	return []LoanPurpose{
+		LoanPurposeWorkingCapital,
+		LoanPurposeInventory,
+		LoanPurposeEquipment,
+		LoanPurposeBusinessExpansion,
+		LoanPurposeEducation,
+		LoanPurposeHealthcare,
+		LoanPurposeHomeImprovement,
+		LoanPurposeOther,
+	}
+}
+
+func GetLoanPurposeMap() map[int]string {
+	return map[int]string{
+		1: "WORKING_CAPITAL",
+		2: "INVENTORY",
+		3: "EQUIPMENT",
+		4: "BUSINESS_EXPANSION",
+		5: "EDUCATION",
+		6: "HEALTHCARE",
+		7: "HOME_IMPROVEMENT",
+		8: "OTHER",
+	}
+}
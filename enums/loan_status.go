@@ -8,6 +8,12 @@ const (
 	LoanStatusRejected
 	LoanStatusInvested
 	LoanStatusDisbursed
+	LoanStatusExpired
+	LoanStatusDelinquent
+	LoanStatusDefaulted
+	LoanStatusWrittenOff
+	LoanStatusRepaid
+	LoanStatusPartiallyDisbursed
 )
 
 func (ls LoanStatus) String() string {
@@ -22,6 +28,18 @@ func (ls LoanStatus) String() string {
 		return "INVESTED"
 	case LoanStatusDisbursed:
 		return "DISBURSED"
+	case LoanStatusExpired:
+		return "EXPIRED"
+	case LoanStatusDelinquent:
+		return "DELINQUENT"
+	case LoanStatusDefaulted:
+		return "DEFAULTED"
+	case LoanStatusWrittenOff:
+		return "WRITTEN_OFF"
+	case LoanStatusRepaid:
+		return "REPAID"
+	case LoanStatusPartiallyDisbursed:
+		return "PARTIALLY_DISBURSED"
 	default:
 		return "UNKNOWN"
 	}
@@ -43,6 +61,18 @@ func LoanStatusFromString(status string) LoanStatus {
 		return LoanStatusInvested
 	case "DISBURSED":
 		return LoanStatusDisbursed
+	case "EXPIRED":
+		return LoanStatusExpired
+	case "DELINQUENT":
+		return LoanStatusDelinquent
+	case "DEFAULTED":
+		return LoanStatusDefaulted
+	case "WRITTEN_OFF":
+		return LoanStatusWrittenOff
+	case "REPAID":
+		return LoanStatusRepaid
+	case "PARTIALLY_DISBURSED":
+		return LoanStatusPartiallyDisbursed
 	default:
 		return LoanStatusProposed
 	}
@@ -60,6 +90,18 @@ func LoanStatusFromInt(status int) LoanStatus {
 		return LoanStatusInvested
 	case 5:
 		return LoanStatusDisbursed
+	case 6:
+		return LoanStatusExpired
+	case 7:
+		return LoanStatusDelinquent
+	case 8:
+		return LoanStatusDefaulted
+	case 9:
+		return LoanStatusWrittenOff
+	case 10:
+		return LoanStatusRepaid
+	case 11:
+		return LoanStatusPartiallyDisbursed
 	default:
 		return LoanStatusProposed
 	}
@@ -72,15 +114,27 @@ func GetAllLoanStatuses() []LoanStatus {
 		LoanStatusRejected,
 		LoanStatusInvested,
 		LoanStatusDisbursed,
+		LoanStatusExpired,
+		LoanStatusDelinquent,
+		LoanStatusDefaulted,
+		LoanStatusWrittenOff,
+		LoanStatusRepaid,
+		LoanStatusPartiallyDisbursed,
 	}
 }
 
 func GetLoanStatusMap() map[int]string {
 	return map[int]string{
-		1: "PROPOSED",
-		2: "APPROVED",
-		3: "REJECTED",
-		4: "INVESTED",
-		5: "DISBURSED",
+		1:  "PROPOSED",
+		2:  "APPROVED",
+		3:  "REJECTED",
+		4:  "INVESTED",
+		5:  "DISBURSED",
+		6:  "EXPIRED",
+		7:  "DELINQUENT",
+		8:  "DEFAULTED",
+		9:  "WRITTEN_OFF",
+		10: "REPAID",
+		11: "PARTIALLY_DISBURSED",
 	}
 }
@@ -0,0 +1,69 @@
+package enums
+
+// PayoutStatus tracks a disbursement's payout through the payment gateway.
+type PayoutStatus int
+
+const (
+	PayoutStatusPending PayoutStatus = iota + 1
+	PayoutStatusSucceeded
+	PayoutStatusFailed
+)
+
+func (s PayoutStatus) String() string {
+	switch s {
+	case PayoutStatusPending:
+		return "PENDING"
+	case PayoutStatusSucceeded:
+		return "SUCCEEDED"
+	case PayoutStatusFailed:
+		return "FAILED"
+	default:
+		return "UNKNOWN"
+	}
+}
+
+func (s PayoutStatus) Int() int {
+	return int(s)
+}
+
+func PayoutStatusFromString(value string) PayoutStatus {
+	switch value {
+	case "PENDING":
+		return PayoutStatusPending
+	case "SUCCEEDED":
+		return PayoutStatusSucceeded
+	case "FAILED":
+		return PayoutStatusFailed
+	default:
+		return 0
+	}
+}
+
+func PayoutStatusFromInt(value int) PayoutStatus {
+	switch value {
+	case 1:
+		return PayoutStatusPending
+	case 2:
+		return PayoutStatusSucceeded
+	case 3:
+		return PayoutStatusFailed
+	default:
+		return 0
+	}
+}
+
+func GetAllPayoutStatuses() []PayoutStatus {
+	return []PayoutStatus{
+		PayoutStatusPending,
+		PayoutStatusSucceeded,
+		PayoutStatusFailed,
+	}
+}
+
+func GetPayoutStatusMap() map[int]string {
+	return map[int]string{
+		1: "PENDING",
+		2: "SUCCEEDED",
+		3: "FAILED",
+	}
+}
@@ -0,0 +1,70 @@
+package enums
+
+// RepaymentFrequency is how often the borrower repays. BULLET means the
+// whole amount is repaid at maturity.
+type RepaymentFrequency int
+
+const (
+	RepaymentFrequencyWeekly RepaymentFrequency = iota + 1
+	RepaymentFrequencyMonthly
+	RepaymentFrequencyBullet
+)
+
+func (rf RepaymentFrequency) String() string {
+	switch rf {
+	case RepaymentFrequencyWeekly:
+		return "WEEKLY"
+	case RepaymentFrequencyMonthly:
+		return "MONTHLY"
+	case RepaymentFrequencyBullet:
+		return "BULLET"
+	default:
+		return "UNKNOWN"
+	}
+}
+
+func (rf RepaymentFrequency) Int() int {
+	return int(rf)
+}
+
+func RepaymentFrequencyFromString(value string) RepaymentFrequency {
+	switch value {
+	case "WEEKLY":
+		return RepaymentFrequencyWeekly
+	case "MONTHLY":
+		return RepaymentFrequencyMonthly
+	case "BULLET":
+		return RepaymentFrequencyBullet
+	default:
+		return 0
+	}
+}
+
+func RepaymentFrequencyFromInt(value int) RepaymentFrequency {
+	switch value {
+	case 1:
+		return RepaymentFrequencyWeekly
+	case 2:
+		return RepaymentFrequencyMonthly
+	case 3:
+		return RepaymentFrequencyBullet
+	default:
+		return 0
+	}
+}
+
+func GetAllRepaymentFrequencies() []RepaymentFrequency {
+	return []RepaymentFrequency{
+		RepaymentFrequencyWeekly,
+		RepaymentFrequencyMonthly,
+		RepaymentFrequencyBullet,
+	}
+}
+
+func GetRepaymentFrequencyMap() map[int]string {
+	return map[int]string{
+		1: "WEEKLY",
+		2: "MONTHLY",
+		3: "BULLET",
+	}
+}
@@ -0,0 +1,60 @@
+package enums
+
+// TenorUnit is the unit the loan tenor is expressed in.
+type TenorUnit int
+
+const (
+	TenorUnitMonth TenorUnit = iota + 1
+	TenorUnitDay
+)
+
+func (tu TenorUnit) String() string {
+	switch tu {
+	case TenorUnitMonth:
+		return "MONTH"
+	case TenorUnitDay:
+		return "DAY"
+	default:
+		return "UNKNOWN"
+	}
+}
+
+func (tu TenorUnit) Int() int {
+	return int(tu)
+}
+
+func TenorUnitFromString(value string) TenorUnit {
+	switch value {
+	case "MONTH":
+		return TenorUnitMonth
+	case "DAY":
+		return TenorUnitDay
+	default:
+		return 0
+	}
+}
+
+func TenorUnitFromInt(value int) TenorUnit {
+	switch value {
+	case 1:
+		return TenorUnitMonth
+	case 2:
+		return TenorUnitDay
+	default:
+		return 0
+	}
+}
+
+func GetAllTenorUnits() []TenorUnit {
+	return []TenorUnit{
+		TenorUnitMonth,
+		TenorUnitDay,
+	}
+}
+
+func GetTenorUnitMap() map[int]string {
+	return map[int]string{
+		1: "MONTH",
+		2: "DAY",
+	}
+}
@@ -0,0 +1,117 @@
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// FakePaymentGateway is an in-memory PaymentGatewayClientInterface for tests
+// and local development. Payouts stay pending until Callback is used to
+// produce the signed callback the real gateway would send.
+type FakePaymentGateway struct {
+	mu            sync.Mutex
+	webhookSecret string
+	payouts       map[string]fakePayout
+	collections   int
+	// Reject makes every new payout fail with ErrPayoutRejected.
+	Reject bool
+}
+
+type fakePayout struct {
+	id      string
+	request InitiatePayoutRequest
+}
+
+// Ensure FakePaymentGateway implements PaymentGatewayClientInterface
+var _ PaymentGatewayClientInterface = (*FakePaymentGateway)(nil)
+
+func NewFakePaymentGateway(webhookSecret string) *FakePaymentGateway {
+	return &FakePaymentGateway{
+		webhookSecret: webhookSecret,
+		payouts:       map[string]fakePayout{},
+	}
+}
+
+func (f *FakePaymentGateway) InitiatePayout(_ context.Context, req InitiatePayoutRequest) (InitiatePayoutResponse, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if payout, ok := f.payouts[req.Reference]; ok {
+		return InitiatePayoutResponse{PayoutID: payout.id, Status: "PENDING"}, nil
+	}
+	if f.Reject {
+		return InitiatePayoutResponse{}, fmt.Errorf("%w: status 422", ErrPayoutRejected)
+	}
+
+	payout := fakePayout{id: fmt.Sprintf("payout-%d", len(f.payouts)+1), request: req}
+	f.payouts[req.Reference] = payout
+	return InitiatePayoutResponse{PayoutID: payout.id, Status: "PENDING"}, nil
+}
+
+func (f *FakePaymentGateway) ParseCallback(payload []byte, signature string) (PayoutCallback, error) {
+	return parsePayoutCallback(f.webhookSecret, payload, signature)
+}
+
+func (f *FakePaymentGateway) ParseCollectionCallback(payload []byte, signature string) (CollectionCallback, error) {
+	return parseCollectionCallback(f.webhookSecret, payload, signature)
+}
+
+// Payouts returns the payouts initiated so far, keyed by reference.
+func (f *FakePaymentGateway) Payouts() map[string]InitiatePayoutRequest {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	payouts := make(map[string]InitiatePayoutRequest, len(f.payouts))
+	for reference, payout := range f.payouts {
+		payouts[reference] = payout.request
+	}
+	return payouts
+}
+
+// Callback settles the payout initiated with reference and returns the
+// signed callback payload and signature announcing status.
+func (f *FakePaymentGateway) Callback(reference, status, failureReason string, completedAt time.Time) ([]byte, string, error) {
+	f.mu.Lock()
+	payout, ok := f.payouts[reference]
+	f.mu.Unlock()
+	if !ok {
+		return nil, "", fmt.Errorf("no payout with reference %s", reference)
+	}
+
+	payload, err := json.Marshal(PayoutCallback{
+		PayoutID:      payout.id,
+		Reference:     reference,
+		Status:        status,
+		FailureReason: failureReason,
+		CompletedAt:   completedAt,
+	})
+	if err != nil {
+		return nil, "", err
+	}
+	return payload, SignPayload(f.webhookSecret, payload), nil
+}
+
+// CollectionCallback returns the signed callback payload and signature the
+// gateway would send after collecting amount from an investor paying for
+// the investment with the given reference.
+func (f *FakePaymentGateway) CollectionCallback(reference, status string, amount float64, paidAt time.Time) ([]byte, string, error) {
+	f.mu.Lock()
+	f.collections++
+	collectionID := fmt.Sprintf("collection-%d", f.collections)
+	f.mu.Unlock()
+
+	payload, err := json.Marshal(CollectionCallback{
+		CollectionID: collectionID,
+		Reference:    reference,
+		Status:       status,
+		Amount:       amount,
+		PaidAt:       paidAt,
+	})
+	if err != nil {
+		return nil, "", err
+	}
+	return payload, SignPayload(f.webhookSecret, payload), nil
+}
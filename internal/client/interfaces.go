@@ -4,4 +4,19 @@ import "context"
 
 type NotificationClientInterface interface {
 	SendEmail(ctx context.Context, req SendEmailRequest) error
+	Ping(ctx context.Context) error
+}
+
+type PaymentGatewayClientInterface interface {
+	// InitiatePayout asks the gateway to pay req.Amount out to the bank
+	// account. The payout completes asynchronously; its outcome arrives as a
+	// callback. Initiating a payout again with the same Reference does not
+	// pay out twice.
+	InitiatePayout(ctx context.Context, req InitiatePayoutRequest) (InitiatePayoutResponse, error)
+	// ParseCallback verifies the signature of a payout status callback and
+	// decodes it.
+	ParseCallback(payload []byte, signature string) (PayoutCallback, error)
+	// ParseCollectionCallback verifies the signature of a callback reporting
+	// a payment collected from an investor and decodes it.
+	ParseCollectionCallback(payload []byte, signature string) (CollectionCallback, error)
 }
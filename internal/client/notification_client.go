@@ -6,8 +6,16 @@ import (
 	"encoding/json"
 	"fmt"
 	"loan-service/internal/config"
+	"loan-service/internal/logging"
+	"loan-service/internal/tracing"
 	"net/http"
 	"time"
+
+	"github.com/sirupsen/logrus"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/propagation"
+	semconv "go.opentelemetry.io/otel/semconv/v1.26.0"
+	"go.opentelemetry.io/otel/trace"
 )
 
 type NotificationClient struct {
@@ -43,9 +51,22 @@ func NewNotificationClient(cfg *config.NotificationConfig) *NotificationClient {
 	}
 }
 
-func (c *NotificationClient) SendEmail(ctx context.Context, req SendEmailRequest) error {
+func (c *NotificationClient) SendEmail(ctx context.Context, req SendEmailRequest) (err error) {
 	url := fmt.Sprintf("%s/api/v1/notifications/email", c.baseURL)
 
+	ctx, span := tracing.Tracer().Start(ctx, "NotificationClient.SendEmail",
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(
+			semconv.HTTPRequestMethodKey.String(http.MethodPost),
+			semconv.URLFull(url),
+		),
+	)
+	defer func() { tracing.EndSpan(span, err) }()
+	log := logging.FromContext(ctx).WithFields(logrus.Fields{
+		"recipient": req.To,
+		"subject":   req.Subject,
+	})
+
 	payload, err := json.Marshal(req)
 	if err != nil {
 		return fmt.Errorf("failed to marshal request: %w", err)
@@ -57,6 +78,37 @@ func (c *NotificationClient) SendEmail(ctx context.Context, req SendEmailRequest
 	}
 
 	httpReq.Header.Set("Content-Type", "application/json")
+	httpReq.Header.Set("Authorization", fmt.Sprintf("Bearer %s", c.apiKey))
+	otel.GetTextMapPropagator().Inject(ctx, propagation.HeaderCarrier(httpReq.Header))
+
+	resp, err := c.httpClient.Do(httpReq)
+	if err != nil {
+		log.WithError(err).Warn("failed to reach notification service")
+		return fmt.Errorf("failed to send request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	span.SetAttributes(semconv.HTTPResponseStatusCode(resp.StatusCode))
+
+	if resp.StatusCode != http.StatusOK {
+		log.WithField("status", resp.StatusCode).Warn("notification service rejected email")
+		return fmt.Errorf("notification service returned status: %d", resp.StatusCode)
+	}
+
+	log.Debug("email sent")
+
+	return nil
+}
+
+// Ping checks that the notification service is reachable and healthy.
+func (c *NotificationClient) Ping(ctx context.Context) error {
+	url := fmt.Sprintf("%s/health", c.baseURL)
+
+	httpReq, err := http.NewRequestWithContext(ctx, "GET", url, nil)
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+
 	httpReq.Header.Set("Authorization", fmt.Sprintf("Bearer %s", c.apiKey))
 
 	resp, err := c.httpClient.Do(httpReq)
@@ -0,0 +1,75 @@
+package client
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"loan-service/internal/config"
+	"loan-service/internal/tracing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+func setupTestTracer(t *testing.T) *tracetest.InMemoryExporter {
+	exporter := tracetest.NewInMemoryExporter()
+	previousProvider := otel.GetTracerProvider()
+	previousPropagator := otel.GetTextMapPropagator()
+	otel.SetTracerProvider(sdktrace.NewTracerProvider(sdktrace.WithSyncer(exporter)))
+	otel.SetTextMapPropagator(propagation.TraceContext{})
+	t.Cleanup(func() {
+		otel.SetTracerProvider(previousProvider)
+		otel.SetTextMapPropagator(previousPropagator)
+	})
+	return exporter
+}
+
+func TestNotificationClient_SendEmail_PropagatesTraceContext(t *testing.T) {
+	exporter := setupTestTracer(t)
+
+	var traceparent string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		traceparent = r.Header.Get("traceparent")
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	c := NewNotificationClient(&config.NotificationConfig{BaseURL: server.URL, APIKey: "key"})
+
+	ctx, parent := tracing.StartSpan(context.Background(), "LoanService.InvestLoan")
+	err := c.SendEmail(ctx, SendEmailRequest{To: "investor1", Subject: "Loan Agreement Letter"})
+	parent.End()
+	require.NoError(t, err)
+
+	spans := exporter.GetSpans()
+	require.Len(t, spans, 2)
+	sendSpan := spans[0]
+	assert.Equal(t, "NotificationClient.SendEmail", sendSpan.Name)
+	assert.Equal(t, parent.SpanContext().SpanID(), sendSpan.Parent.SpanID())
+	assert.Equal(t, "00-"+sendSpan.SpanContext.TraceID().String()+"-"+sendSpan.SpanContext.SpanID().String()+"-01", traceparent)
+}
+
+func TestNotificationClient_SendEmail_RecordsFailureOnSpan(t *testing.T) {
+	exporter := setupTestTracer(t)
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusBadGateway)
+	}))
+	defer server.Close()
+
+	c := NewNotificationClient(&config.NotificationConfig{BaseURL: server.URL, APIKey: "key"})
+
+	err := c.SendEmail(context.Background(), SendEmailRequest{To: "investor1"})
+	assert.Error(t, err)
+
+	spans := exporter.GetSpans()
+	require.Len(t, spans, 1)
+	assert.Equal(t, codes.Error, spans[0].Status.Code)
+}
@@ -0,0 +1,205 @@
+package client
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"loan-service/internal/config"
+	"loan-service/internal/logging"
+	"loan-service/internal/tracing"
+	"net/http"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/propagation"
+	semconv "go.opentelemetry.io/otel/semconv/v1.26.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+var (
+	// ErrPayoutRejected is returned when the gateway refused a payout, so no
+	// money will move for it. Any other InitiatePayout error leaves the
+	// outcome unknown until the gateway calls back.
+	ErrPayoutRejected = errors.New("payment gateway rejected the payout")
+	// ErrInvalidSignature is returned for a callback that was not signed
+	// with the webhook secret.
+	ErrInvalidSignature = errors.New("invalid payment gateway callback signature")
+	// ErrMalformedCallback is returned for a correctly signed callback that
+	// cannot be decoded.
+	ErrMalformedCallback = errors.New("malformed payment gateway callback")
+)
+
+type PaymentGatewayClient struct {
+	baseURL       string
+	httpClient    *http.Client
+	apiKey        string
+	webhookSecret string
+}
+
+// Ensure PaymentGatewayClient implements PaymentGatewayClientInterface
+var _ PaymentGatewayClientInterface = (*PaymentGatewayClient)(nil)
+
+type BankAccount struct {
+	AccountHolderName string `json:"account_holder_name"`
+	BankCode          string `json:"bank_code"`
+	AccountNumber     string `json:"account_number"`
+}
+
+type InitiatePayoutRequest struct {
+	Reference   string      `json:"reference"`
+	Amount      float64     `json:"amount"`
+	BankAccount BankAccount `json:"bank_account"`
+	Description string      `json:"description,omitempty"`
+}
+
+type InitiatePayoutResponse struct {
+	PayoutID string `json:"payout_id"`
+	Status   string `json:"status"`
+}
+
+// PayoutCallback reports the outcome of a payout. Reference is the one the
+// payout was initiated with and Status one of PENDING, SUCCEEDED or FAILED.
+type PayoutCallback struct {
+	PayoutID      string    `json:"payout_id"`
+	Reference     string    `json:"reference"`
+	Status        string    `json:"status"`
+	FailureReason string    `json:"failure_reason,omitempty"`
+	CompletedAt   time.Time `json:"completed_at"`
+}
+
+// CollectionCallback reports a payment an investor made towards an
+// investment. Reference is the investment's UUID, which investors quote
+// when they pay.
+type CollectionCallback struct {
+	CollectionID string    `json:"collection_id"`
+	Reference    string    `json:"reference"`
+	Status       string    `json:"status"`
+	Amount       float64   `json:"amount"`
+	PaidAt       time.Time `json:"paid_at"`
+}
+
+func NewPaymentGatewayClient(cfg *config.PaymentGatewayConfig) *PaymentGatewayClient {
+	return &PaymentGatewayClient{
+		baseURL: cfg.BaseURL,
+		httpClient: &http.Client{
+			Timeout: 30 * time.Second,
+		},
+		apiKey:        cfg.APIKey,
+		webhookSecret: cfg.WebhookSecret,
+	}
+}
+
+func (c *PaymentGatewayClient) InitiatePayout(ctx context.Context, req InitiatePayoutRequest) (response InitiatePayoutResponse, err error) {
+	url := fmt.Sprintf("%s/api/v1/payouts", c.baseURL)
+
+	ctx, span := tracing.Tracer().Start(ctx, "PaymentGatewayClient.InitiatePayout",
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(
+			semconv.HTTPRequestMethodKey.String(http.MethodPost),
+			semconv.URLFull(url),
+		),
+	)
+	defer func() { tracing.EndSpan(span, err) }()
+	log := logging.FromContext(ctx).WithFields(logrus.Fields{
+		"payout_reference": req.Reference,
+		"amount":           req.Amount,
+	})
+
+	payload, err := json.Marshal(req)
+	if err != nil {
+		return InitiatePayoutResponse{}, fmt.Errorf("failed to marshal request: %w", err)
+	}
+
+	httpReq, err := http.NewRequestWithContext(ctx, "POST", url, bytes.NewBuffer(payload))
+	if err != nil {
+		return InitiatePayoutResponse{}, fmt.Errorf("failed to create request: %w", err)
+	}
+
+	httpReq.Header.Set("Content-Type", "application/json")
+	httpReq.Header.Set("Authorization", fmt.Sprintf("Bearer %s", c.apiKey))
+	httpReq.Header.Set("Idempotency-Key", req.Reference)
+	otel.GetTextMapPropagator().Inject(ctx, propagation.HeaderCarrier(httpReq.Header))
+
+	resp, err := c.httpClient.Do(httpReq)
+	if err != nil {
+		log.WithError(err).Warn("failed to reach payment gateway")
+		return InitiatePayoutResponse{}, fmt.Errorf("failed to send request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	span.SetAttributes(semconv.HTTPResponseStatusCode(resp.StatusCode))
+
+	switch {
+	case resp.StatusCode >= 400 && resp.StatusCode < 500:
+		log.WithField("status", resp.StatusCode).Warn("payment gateway rejected payout")
+		return InitiatePayoutResponse{}, fmt.Errorf("%w: status %d", ErrPayoutRejected, resp.StatusCode)
+	case resp.StatusCode != http.StatusOK && resp.StatusCode != http.StatusCreated && resp.StatusCode != http.StatusAccepted:
+		log.WithField("status", resp.StatusCode).Warn("payment gateway failed to initiate payout")
+		return InitiatePayoutResponse{}, fmt.Errorf("payment gateway returned status: %d", resp.StatusCode)
+	}
+
+	if err := json.NewDecoder(resp.Body).Decode(&response); err != nil {
+		return InitiatePayoutResponse{}, fmt.Errorf("failed to decode response: %w", err)
+	}
+
+	log.WithField("payout_id", response.PayoutID).Debug("payout initiated")
+
+	return response, nil
+}
+
+func (c *PaymentGatewayClient) ParseCallback(payload []byte, signature string) (PayoutCallback, error) {
+	return parsePayoutCallback(c.webhookSecret, payload, signature)
+}
+
+// SignPayload computes the signature the gateway sends with a callback: the
+// hex-encoded HMAC-SHA256 of the raw payload keyed with the webhook secret.
+func SignPayload(secret string, payload []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write(payload)
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+func (c *PaymentGatewayClient) ParseCollectionCallback(payload []byte, signature string) (CollectionCallback, error) {
+	return parseCollectionCallback(c.webhookSecret, payload, signature)
+}
+
+func parsePayoutCallback(secret string, payload []byte, signature string) (PayoutCallback, error) {
+	var callback PayoutCallback
+	if err := parseCallback(secret, payload, signature, &callback); err != nil {
+		return PayoutCallback{}, err
+	}
+	if callback.Reference == "" {
+		return PayoutCallback{}, fmt.Errorf("%w: missing reference", ErrMalformedCallback)
+	}
+	return callback, nil
+}
+
+func parseCollectionCallback(secret string, payload []byte, signature string) (CollectionCallback, error) {
+	var callback CollectionCallback
+	if err := parseCallback(secret, payload, signature, &callback); err != nil {
+		return CollectionCallback{}, err
+	}
+	if callback.Reference == "" {
+		return CollectionCallback{}, fmt.Errorf("%w: missing reference", ErrMalformedCallback)
+	}
+	return callback, nil
+}
+
+// parseCallback checks that payload was signed with secret before decoding
+// it into v.
+func parseCallback(secret string, payload []byte, signature string, v any) error {
+	if secret == "" || !hmac.Equal([]byte(SignPayload(secret, payload)), []byte(signature)) {
+		return ErrInvalidSignature
+	}
+
+	if err := json.Unmarshal(payload, v); err != nil {
+		return fmt.Errorf("%w: %v", ErrMalformedCallback, err)
+	}
+	return nil
+}
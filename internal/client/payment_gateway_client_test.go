@@ -0,0 +1,144 @@
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"loan-service/internal/config"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPaymentGatewayClient_InitiatePayout(t *testing.T) {
+	var received InitiatePayoutRequest
+	var idempotencyKey, authorization string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/api/v1/payouts", r.URL.Path)
+		idempotencyKey = r.Header.Get("Idempotency-Key")
+		authorization = r.Header.Get("Authorization")
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&received))
+		w.WriteHeader(http.StatusAccepted)
+		json.NewEncoder(w).Encode(InitiatePayoutResponse{PayoutID: "payout-1", Status: "PENDING"})
+	}))
+	defer server.Close()
+
+	c := NewPaymentGatewayClient(&config.PaymentGatewayConfig{BaseURL: server.URL, APIKey: "key"})
+
+	response, err := c.InitiatePayout(context.Background(), InitiatePayoutRequest{
+		Reference:   "disbursement-uuid",
+		Amount:      400,
+		BankAccount: BankAccount{AccountHolderName: "Budi Santoso", BankCode: "BCA", AccountNumber: "1234567890"},
+	})
+	require.NoError(t, err)
+
+	assert.Equal(t, "payout-1", response.PayoutID)
+	assert.Equal(t, "disbursement-uuid", idempotencyKey)
+	assert.Equal(t, "Bearer key", authorization)
+	assert.Equal(t, "1234567890", received.BankAccount.AccountNumber)
+}
+
+func TestPaymentGatewayClient_InitiatePayout_Errors(t *testing.T) {
+	tests := []struct {
+		name         string
+		status       int
+		wantRejected bool
+	}{
+		{name: "rejected", status: http.StatusUnprocessableEntity, wantRejected: true},
+		{name: "gateway error", status: http.StatusServiceUnavailable, wantRejected: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(tt.status)
+			}))
+			defer server.Close()
+
+			c := NewPaymentGatewayClient(&config.PaymentGatewayConfig{BaseURL: server.URL, APIKey: "key"})
+
+			_, err := c.InitiatePayout(context.Background(), InitiatePayoutRequest{Reference: "disbursement-uuid", Amount: 400})
+			require.Error(t, err)
+			assert.Equal(t, tt.wantRejected, errors.Is(err, ErrPayoutRejected))
+		})
+	}
+}
+
+func TestPaymentGatewayClient_ParseCallback(t *testing.T) {
+	c := NewPaymentGatewayClient(&config.PaymentGatewayConfig{WebhookSecret: "secret"})
+	payload := []byte(`{"payout_id":"payout-1","reference":"disbursement-uuid","status":"SUCCEEDED","completed_at":"2025-10-01T09:00:00Z"}`)
+
+	callback, err := c.ParseCallback(payload, SignPayload("secret", payload))
+	require.NoError(t, err)
+	assert.Equal(t, "disbursement-uuid", callback.Reference)
+	assert.Equal(t, "SUCCEEDED", callback.Status)
+	assert.Equal(t, time.Date(2025, 10, 1, 9, 0, 0, 0, time.UTC), callback.CompletedAt)
+
+	_, err = c.ParseCallback(payload, SignPayload("other", payload))
+	assert.ErrorIs(t, err, ErrInvalidSignature)
+
+	_, err = c.ParseCallback(payload, "")
+	assert.ErrorIs(t, err, ErrInvalidSignature)
+
+	malformed := []byte(`{"status":"SUCCEEDED"}`)
+	_, err = c.ParseCallback(malformed, SignPayload("secret", malformed))
+	assert.ErrorIs(t, err, ErrMalformedCallback)
+
+	unsigned := NewPaymentGatewayClient(&config.PaymentGatewayConfig{})
+	_, err = unsigned.ParseCallback(payload, SignPayload("", payload))
+	assert.ErrorIs(t, err, ErrInvalidSignature)
+}
+
+func TestPaymentGatewayClient_ParseCollectionCallback(t *testing.T) {
+	c := NewPaymentGatewayClient(&config.PaymentGatewayConfig{WebhookSecret: "secret"})
+	payload := []byte(`{"collection_id":"collection-1","reference":"investment-uuid","status":"SUCCEEDED","amount":500,"paid_at":"2025-10-01T09:00:00Z"}`)
+
+	callback, err := c.ParseCollectionCallback(payload, SignPayload("secret", payload))
+	require.NoError(t, err)
+	assert.Equal(t, "investment-uuid", callback.Reference)
+	assert.Equal(t, 500.0, callback.Amount)
+	assert.Equal(t, time.Date(2025, 10, 1, 9, 0, 0, 0, time.UTC), callback.PaidAt)
+
+	_, err = c.ParseCollectionCallback(payload, SignPayload("other", payload))
+	assert.ErrorIs(t, err, ErrInvalidSignature)
+
+	malformed := []byte(`{"collection_id":"collection-1","status":"SUCCEEDED"}`)
+	_, err = c.ParseCollectionCallback(malformed, SignPayload("secret", malformed))
+	assert.ErrorIs(t, err, ErrMalformedCallback)
+}
+
+func TestFakePaymentGateway(t *testing.T) {
+	gateway := NewFakePaymentGateway("secret")
+
+	first, err := gateway.InitiatePayout(context.Background(), InitiatePayoutRequest{Reference: "disbursement-uuid", Amount: 400})
+	require.NoError(t, err)
+	again, err := gateway.InitiatePayout(context.Background(), InitiatePayoutRequest{Reference: "disbursement-uuid", Amount: 400})
+	require.NoError(t, err)
+	assert.Equal(t, first.PayoutID, again.PayoutID)
+	assert.Len(t, gateway.Payouts(), 1)
+
+	payload, signature, err := gateway.Callback("disbursement-uuid", "FAILED", "account closed", time.Now())
+	require.NoError(t, err)
+	callback, err := gateway.ParseCallback(payload, signature)
+	require.NoError(t, err)
+	assert.Equal(t, first.PayoutID, callback.PayoutID)
+	assert.Equal(t, "account closed", callback.FailureReason)
+
+	_, _, err = gateway.Callback("unknown", "SUCCEEDED", "", time.Now())
+	assert.Error(t, err)
+
+	payload, signature, err = gateway.CollectionCallback("investment-uuid", "SUCCEEDED", 500, time.Now())
+	require.NoError(t, err)
+	collection, err := gateway.ParseCollectionCallback(payload, signature)
+	require.NoError(t, err)
+	assert.Equal(t, "collection-1", collection.CollectionID)
+	assert.Equal(t, 500.0, collection.Amount)
+
+	gateway.Reject = true
+	_, err = gateway.InitiatePayout(context.Background(), InitiatePayoutRequest{Reference: "other-uuid", Amount: 100})
+	assert.ErrorIs(t, err, ErrPayoutRejected)
+}
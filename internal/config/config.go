@@ -2,6 +2,10 @@ package config
 
 import (
 	"os"
+	"strconv"
+	"time"
+
+	"loan-service/enums"
 
 	"github.com/joho/godotenv"
 )
@@ -10,11 +14,22 @@ type Config struct {
 	Server       ServerConfig
 	Database     DatabaseConfig
 	Notification NotificationConfig
+	Payment      PaymentGatewayConfig
+	Health       HealthConfig
+	Log          LogConfig
+	Tracing      TracingConfig
+	Loan         LoanConfig
+	Jobs         JobsConfig
 }
 
 type ServerConfig struct {
-	Port string
-	Host string
+	Port              string
+	Host              string
+	ReadTimeout       time.Duration
+	ReadHeaderTimeout time.Duration
+	WriteTimeout      time.Duration
+	IdleTimeout       time.Duration
+	ShutdownTimeout   time.Duration
 }
 
 type DatabaseConfig struct {
@@ -30,6 +45,94 @@ type NotificationConfig struct {
 	APIKey  string
 }
 
+// PaymentGatewayConfig configures the gateway that pays disbursements out to
+// borrowers. WebhookSecret is shared with the gateway to sign its payout
+// status callbacks.
+type PaymentGatewayConfig struct {
+	BaseURL       string
+	APIKey        string
+	WebhookSecret string
+}
+
+type HealthConfig struct {
+	CheckTimeout             time.Duration
+	NotificationProbeEnabled bool
+}
+
+type LogConfig struct {
+	Level  string
+	Format string
+}
+
+type TracingConfig struct {
+	Exporter     string
+	OTLPEndpoint string
+	ServiceName  string
+	SampleRatio  float64
+}
+
+type LoanConfig struct {
+	// FundingWindow is how long an approved loan stays open for investment.
+	FundingWindow time.Duration
+	// LateFeeFlat and LateFeeRate make up the fee charged once on every
+	// instalment that is still unpaid LateFeeGracePeriod after its due date:
+	// a flat amount plus a percentage of the instalment's unpaid amount.
+	LateFeeFlat        float64
+	LateFeeRate        float64
+	LateFeeGracePeriod time.Duration
+	// PrepaymentPenaltyRate is the percentage of the outstanding principal
+	// charged when a loan is paid off early, as long as the payoff falls
+	// within PrepaymentPenaltyPeriod of disbursement. A zero period applies
+	// the penalty for the whole tenor.
+	PrepaymentPenaltyRate   float64
+	PrepaymentPenaltyPeriod time.Duration
+	// DayCountConvention decides how much of the annual interest rate a
+	// disbursed loan accrues each day. Each interest accrual run recomputes
+	// the last InterestAccrualLookback worth of days, so days missed while
+	// the service was down are caught up.
+	DayCountConvention      enums.DayCountConvention
+	InterestAccrualLookback time.Duration
+	// InvestmentReservationTTL is how long an investment holds its share of
+	// a loan while waiting for the investor's payment.
+	InvestmentReservationTTL time.Duration
+	// InvestmentCoolingOffPeriod is how long after an investment is funded,
+	// or reserved while it is still pending, the investor may still cancel
+	// it, as long as the loan isn't disbursed yet.
+	InvestmentCoolingOffPeriod time.Duration
+	// MinInvestmentAmount and MaxInvestmentAmount bound the size of a single
+	// investment. MaxInvestorLoanShare caps the percentage of a loan's
+	// principal one investor may hold, and MaxInvestorExposure the total an
+	// investor may have in loans that are still outstanding. Zero disables
+	// a limit.
+	MinInvestmentAmount  float64
+	MaxInvestmentAmount  float64
+	MaxInvestorLoanShare float64
+	MaxInvestorExposure  float64
+	// MaxBorrowerOpenLoans caps how many loans a borrower may have that are
+	// neither closed nor rejected, and MaxBorrowerOutstandingPrincipal the
+	// principal they may owe or have requested across them.
+	// BorrowerRejectionCoolDown is how long a borrower has to wait after a
+	// rejection before proposing another loan. Zero disables a policy.
+	MaxBorrowerOpenLoans            int
+	MaxBorrowerOutstandingPrincipal float64
+	BorrowerRejectionCoolDown       time.Duration
+}
+
+type JobsConfig struct {
+	FundingExpiry     JobConfig
+	Delinquency       JobConfig
+	InterestAccrual   JobConfig
+	ReservationExpiry JobConfig
+}
+
+// JobConfig configures a single background job. Every job reads
+// JOB_<NAME>_ENABLED, JOB_<NAME>_INTERVAL and JOB_<NAME>_TIMEOUT.
+type JobConfig struct {
+	Enabled  bool
+	Interval time.Duration
+	Timeout  time.Duration
+}
+
 func LoadEnv() error {
 	return godotenv.Load()
 }
@@ -37,8 +140,13 @@ func LoadEnv() error {
 func New() *Config {
 	return &Config{
 		Server: ServerConfig{
-			Port: getEnv("SERVER_PORT", "8080"),
-			Host: getEnv("SERVER_HOST", "localhost"),
+			Port:              getEnv("SERVER_PORT", "8080"),
+			Host:              getEnv("SERVER_HOST", "localhost"),
+			ReadTimeout:       getEnvDuration("SERVER_READ_TIMEOUT", 15*time.Second),
+			ReadHeaderTimeout: getEnvDuration("SERVER_READ_HEADER_TIMEOUT", 5*time.Second),
+			WriteTimeout:      getEnvDuration("SERVER_WRITE_TIMEOUT", 30*time.Second),
+			IdleTimeout:       getEnvDuration("SERVER_IDLE_TIMEOUT", 60*time.Second),
+			ShutdownTimeout:   getEnvDuration("SERVER_SHUTDOWN_TIMEOUT", 30*time.Second),
 		},
 		Database: DatabaseConfig{
 			Host:     getEnv("DB_HOST", "localhost"),
@@ -51,6 +159,58 @@ func New() *Config {
 			BaseURL: getEnv("NOTIFICATION_BASE_URL", "http://localhost:8080"),
 			APIKey:  getEnv("NOTIFICATION_API_KEY", "1234567890"),
 		},
+		Payment: PaymentGatewayConfig{
+			BaseURL:       getEnv("PAYMENT_GATEWAY_BASE_URL", "http://localhost:8081"),
+			APIKey:        getEnv("PAYMENT_GATEWAY_API_KEY", ""),
+			WebhookSecret: getEnv("PAYMENT_GATEWAY_WEBHOOK_SECRET", ""),
+		},
+		Health: HealthConfig{
+			CheckTimeout:             getEnvDuration("HEALTH_CHECK_TIMEOUT", 2*time.Second),
+			NotificationProbeEnabled: getEnvBool("HEALTH_CHECK_NOTIFICATION_ENABLED", false),
+		},
+		Log: LogConfig{
+			Level:  getEnv("LOG_LEVEL", "info"),
+			Format: getEnv("LOG_FORMAT", "json"),
+		},
+		Tracing: TracingConfig{
+			Exporter:     getEnv("TRACING_EXPORTER", "none"),
+			OTLPEndpoint: getEnv("TRACING_OTLP_ENDPOINT", ""),
+			ServiceName:  getEnv("TRACING_SERVICE_NAME", "loan-service"),
+			SampleRatio:  getEnvFloat("TRACING_SAMPLE_RATIO", 1),
+		},
+		Loan: LoanConfig{
+			FundingWindow:                   getEnvDuration("LOAN_FUNDING_WINDOW", 14*24*time.Hour),
+			LateFeeFlat:                     getEnvFloat("LOAN_LATE_FEE_FLAT", 0),
+			LateFeeRate:                     getEnvFloat("LOAN_LATE_FEE_RATE", 0),
+			LateFeeGracePeriod:              getEnvDuration("LOAN_LATE_FEE_GRACE_PERIOD", 3*24*time.Hour),
+			PrepaymentPenaltyRate:           getEnvFloat("LOAN_PREPAYMENT_PENALTY_RATE", 0),
+			PrepaymentPenaltyPeriod:         getEnvDuration("LOAN_PREPAYMENT_PENALTY_PERIOD", 0),
+			DayCountConvention:              getEnvDayCountConvention("LOAN_DAY_COUNT_CONVENTION", enums.DayCountConventionAct365),
+			InterestAccrualLookback:         getEnvDuration("LOAN_INTEREST_ACCRUAL_LOOKBACK", 7*24*time.Hour),
+			InvestmentReservationTTL:        getEnvDuration("LOAN_INVESTMENT_RESERVATION_TTL", 24*time.Hour),
+			InvestmentCoolingOffPeriod:      getEnvDuration("LOAN_INVESTMENT_COOLING_OFF_PERIOD", 48*time.Hour),
+			MinInvestmentAmount:             getEnvFloat("LOAN_MIN_INVESTMENT_AMOUNT", 0),
+			MaxInvestmentAmount:             getEnvFloat("LOAN_MAX_INVESTMENT_AMOUNT", 0),
+			MaxInvestorLoanShare:            getEnvFloat("LOAN_MAX_INVESTOR_LOAN_SHARE", 0),
+			MaxInvestorExposure:             getEnvFloat("LOAN_MAX_INVESTOR_EXPOSURE", 0),
+			MaxBorrowerOpenLoans:            getEnvInt("LOAN_MAX_BORROWER_OPEN_LOANS", 0),
+			MaxBorrowerOutstandingPrincipal: getEnvFloat("LOAN_MAX_BORROWER_OUTSTANDING_PRINCIPAL", 0),
+			BorrowerRejectionCoolDown:       getEnvDuration("LOAN_BORROWER_REJECTION_COOL_DOWN", 0),
+		},
+		Jobs: JobsConfig{
+			FundingExpiry:     newJobConfig("FUNDING_EXPIRY", time.Hour, 5*time.Minute),
+			Delinquency:       newJobConfig("DELINQUENCY", 24*time.Hour, 30*time.Minute),
+			InterestAccrual:   newJobConfig("INTEREST_ACCRUAL", 24*time.Hour, 30*time.Minute),
+			ReservationExpiry: newJobConfig("RESERVATION_EXPIRY", 15*time.Minute, 5*time.Minute),
+		},
+	}
+}
+
+func newJobConfig(name string, interval, timeout time.Duration) JobConfig {
+	return JobConfig{
+		Enabled:  getEnvBool("JOB_"+name+"_ENABLED", true),
+		Interval: getEnvDuration("JOB_"+name+"_INTERVAL", interval),
+		Timeout:  getEnvDuration("JOB_"+name+"_TIMEOUT", timeout),
 	}
 }
 
@@ -60,3 +220,48 @@ func getEnv(key, defaultValue string) string {
 	}
 	return defaultValue
 }
+
+func getEnvDuration(key string, defaultValue time.Duration) time.Duration {
+	if value := os.Getenv(key); value != "" {
+		if duration, err := time.ParseDuration(value); err == nil {
+			return duration
+		}
+	}
+	return defaultValue
+}
+
+func getEnvBool(key string, defaultValue bool) bool {
+	if value := os.Getenv(key); value != "" {
+		if parsed, err := strconv.ParseBool(value); err == nil {
+			return parsed
+		}
+	}
+	return defaultValue
+}
+
+func getEnvInt(key string, defaultValue int) int {
+	if value := os.Getenv(key); value != "" {
+		if parsed, err := strconv.Atoi(value); err == nil {
+			return parsed
+		}
+	}
+	return defaultValue
+}
+
+func getEnvFloat(key string, defaultValue float64) float64 {
+	if value := os.Getenv(key); value != "" {
+		if parsed, err := strconv.ParseFloat(value, 64); err == nil {
+			return parsed
+		}
+	}
+	return defaultValue
+}
+
+func getEnvDayCountConvention(key string, defaultValue enums.DayCountConvention) enums.DayCountConvention {
+	if value := os.Getenv(key); value != "" {
+		if convention := enums.DayCountConventionFromString(value); convention != 0 {
+			return convention
+		}
+	}
+	return defaultValue
+}
@@ -2,20 +2,21 @@ package database
 
 import (
 	"fmt"
-	"log"
 
 	"loan-service/internal/config"
+	"loan-service/internal/logging"
+	"loan-service/internal/tracing"
 
+	"github.com/sirupsen/logrus"
 	"gorm.io/driver/mysql"
 	"gorm.io/gorm"
-	"gorm.io/gorm/logger"
 )
 
 type Database struct {
 	DB *gorm.DB
 }
 
-func New(cfg *config.Config) (*Database, error) {
+func New(cfg *config.Config, logger *logrus.Logger) (*Database, error) {
 	dsn := fmt.Sprintf("%s:%s@tcp(%s:%s)/%s?charset=utf8mb4&parseTime=True&loc=Local",
 		cfg.Database.User,
 		cfg.Database.Password,
@@ -25,13 +26,17 @@ func New(cfg *config.Config) (*Database, error) {
 	)
 
 	db, err := gorm.Open(mysql.Open(dsn), &gorm.Config{
-		Logger: logger.Default.LogMode(logger.Info),
+		Logger: logging.NewGormLogger(logger),
 	})
 	if err != nil {
 		return nil, fmt.Errorf("failed to connect to database: %w", err)
 	}
 
-	log.Println("Database connected successfully")
+	if err := db.Use(tracing.NewGormPlugin("mysql")); err != nil {
+		return nil, fmt.Errorf("failed to register tracing plugin: %w", err)
+	}
+
+	logger.Info("Database connected successfully")
 
 	return &Database{DB: db}, nil
 }
@@ -0,0 +1,46 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	"github.com/pressly/goose/v3"
+)
+
+// Ping verifies that a connection to the database can be established.
+func (d *Database) Ping(ctx context.Context) error {
+	sqlDB, err := d.DB.DB()
+	if err != nil {
+		return fmt.Errorf("failed to get underlying sql.DB: %w", err)
+	}
+	return sqlDB.PingContext(ctx)
+}
+
+// CheckMigrations compares the latest version recorded in goose's version
+// table with the newest migration file and reports any pending migrations.
+// Unlike goose.GetDBVersion it never creates the version table, so it is safe
+// to call from a readiness probe.
+func (d *Database) CheckMigrations(ctx context.Context) error {
+	migrations, err := goose.CollectMigrations(migrationsDir, 0, goose.MaxVersion)
+	if err != nil {
+		return fmt.Errorf("failed to collect migrations: %w", err)
+	}
+
+	latest, err := migrations.Last()
+	if err != nil {
+		return fmt.Errorf("failed to get latest migration: %w", err)
+	}
+
+	var applied sql.NullInt64
+	query := fmt.Sprintf("SELECT MAX(version_id) FROM %s WHERE is_applied = ?", goose.TableName())
+	if err := d.DB.WithContext(ctx).Raw(query, true).Scan(&applied).Error; err != nil {
+		return fmt.Errorf("failed to read migration version: %w", err)
+	}
+
+	if applied.Int64 < latest.Version {
+		return fmt.Errorf("database is at migration %d, latest is %d", applied.Int64, latest.Version)
+	}
+
+	return nil
+}
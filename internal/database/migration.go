@@ -2,16 +2,18 @@ package database
 
 import (
 	"fmt"
-	"log"
 
 	"loan-service/internal/config"
 
 	"github.com/pressly/goose/v3"
+	"github.com/sirupsen/logrus"
 	"gorm.io/driver/mysql"
 	"gorm.io/gorm"
 )
 
-func RunMigrations(cfg *config.Config) error {
+const migrationsDir = "migrations"
+
+func RunMigrations(cfg *config.Config, logger *logrus.Logger) error {
 	dsn := fmt.Sprintf("%s:%s@tcp(%s:%s)/%s?charset=utf8mb4&parseTime=True&loc=Local",
 		cfg.Database.User,
 		cfg.Database.Password,
@@ -30,19 +32,20 @@ func RunMigrations(cfg *config.Config) error {
 		return fmt.Errorf("failed to get underlying sql.DB: %w", err)
 	}
 
+	goose.SetLogger(logger)
 	if err := goose.SetDialect("mysql"); err != nil {
 		return fmt.Errorf("failed to set dialect: %w", err)
 	}
 
-	if err := goose.Up(sqlDB, "migrations"); err != nil {
+	if err := goose.Up(sqlDB, migrationsDir); err != nil {
 		return fmt.Errorf("failed to run migrations: %w", err)
 	}
 
-	log.Println("Database migrations completed successfully")
+	logger.Info("Database migrations completed successfully")
 	return nil
 }
 
-func RollbackMigration(cfg *config.Config) error {
+func RollbackMigration(cfg *config.Config, logger *logrus.Logger) error {
 	dsn := fmt.Sprintf("%s:%s@tcp(%s:%s)/%s?charset=utf8mb4&parseTime=True&loc=Local",
 		cfg.Database.User,
 		cfg.Database.Password,
@@ -61,14 +64,15 @@ func RollbackMigration(cfg *config.Config) error {
 		return fmt.Errorf("failed to get underlying sql.DB: %w", err)
 	}
 
+	goose.SetLogger(logger)
 	if err := goose.SetDialect("mysql"); err != nil {
 		return fmt.Errorf("failed to set dialect: %w", err)
 	}
 
-	if err := goose.Down(sqlDB, "migrations"); err != nil {
+	if err := goose.Down(sqlDB, migrationsDir); err != nil {
 		return fmt.Errorf("failed to rollback migration: %w", err)
 	}
 
-	log.Println("Database migration rollback completed successfully")
+	logger.Info("Database migration rollback completed successfully")
 	return nil
 }
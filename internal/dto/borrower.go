@@ -0,0 +1,44 @@
+package dto
+
+import (
+	"loan-service/enums"
+	"time"
+)
+
+type BorrowerLoansResponse struct {
+	Loans []BorrowerLoanItem `json:"loans"`
+	Page  PageInfo           `json:"page"`
+}
+
+// BorrowerLoanItem is a loan as its borrower sees it: where it stands, what
+// is due next, how much is still owed and what still has to be signed.
+type BorrowerLoanItem struct {
+	LoanUUID           string                   `json:"loan_uuid"`
+	Status             enums.LoanStatus         `json:"status"`
+	PrincipalAmount    float64                  `json:"principal_amount"`
+	InterestRate       float64                  `json:"interest_rate"`
+	Tenor              int                      `json:"tenor"`
+	TenorUnit          enums.TenorUnit          `json:"tenor_unit"`
+	RepaymentFrequency enums.RepaymentFrequency `json:"repayment_frequency"`
+	Purpose            enums.LoanPurpose        `json:"purpose"`
+	DisbursedAmount    float64                  `json:"disbursed_amount"`
+	OutstandingBalance float64                  `json:"outstanding_balance"`
+	DaysPastDue        int                      `json:"days_past_due"`
+	NextInstalment     *BorrowerInstalmentItem  `json:"next_instalment,omitempty"`
+	DocumentsToSign    []BorrowerDocumentItem   `json:"documents_to_sign"`
+	CreatedAt          time.Time                `json:"created_at"`
+}
+
+// BorrowerInstalmentItem is the earliest instalment that has not been paid
+// in full. AmountDue is what is left to pay on it.
+type BorrowerInstalmentItem struct {
+	Sequence  int       `json:"sequence"`
+	DueDate   time.Time `json:"due_date"`
+	AmountDue float64   `json:"amount_due"`
+	Overdue   bool      `json:"overdue"`
+}
+
+type BorrowerDocumentItem struct {
+	Type   enums.BorrowerDocumentType `json:"type"`
+	Amount float64                    `json:"amount,omitempty"`
+}
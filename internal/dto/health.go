@@ -0,0 +1,14 @@
+package dto
+
+type HealthReport struct {
+	Status     string                     `json:"status"`
+	Service    string                     `json:"service"`
+	Components map[string]ComponentHealth `json:"components,omitempty"`
+}
+
+type ComponentHealth struct {
+	Status    string  `json:"status"`
+	Critical  bool    `json:"critical"`
+	LatencyMs float64 `json:"latency_ms"`
+	Error     string  `json:"error,omitempty"`
+}
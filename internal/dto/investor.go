@@ -0,0 +1,46 @@
+package dto
+
+import (
+	"loan-service/enums"
+	"time"
+)
+
+type InvestorInvestmentsResponse struct {
+	Investments []InvestorInvestmentItem `json:"investments"`
+	Page        PageInfo                 `json:"page"`
+}
+
+type InvestorInvestmentItem struct {
+	InvestmentUUID   string                 `json:"investment_uuid"`
+	LoanUUID         string                 `json:"loan_uuid"`
+	Amount           float64                `json:"amount"`
+	Status           enums.InvestmentStatus `json:"status"`
+	FundingExpiresAt *time.Time             `json:"funding_expires_at,omitempty"`
+	FundedAt         *time.Time             `json:"funded_at,omitempty"`
+	RefundedAt       *time.Time             `json:"refunded_at,omitempty"`
+	CancelledAt      *time.Time             `json:"cancelled_at,omitempty"`
+	CreatedAt        time.Time              `json:"created_at"`
+}
+
+type InvestorPortfolioResponse struct {
+	Holdings []PortfolioHolding `json:"holdings"`
+	Page     PageInfo           `json:"page"`
+}
+
+// PortfolioHolding is a funded investment as the investor sees it: what was
+// put in, what it is expected to earn over the loan's tenor, what has been
+// paid out so far and how much of the principal is still owed.
+type PortfolioHolding struct {
+	InvestmentUUID       string           `json:"investment_uuid"`
+	LoanUUID             string           `json:"loan_uuid"`
+	LoanStatus           enums.LoanStatus `json:"loan_status"`
+	InvestedAmount       float64          `json:"invested_amount"`
+	ROIRate              float64          `json:"roi_rate"`
+	ExpectedReturn       float64          `json:"expected_return"`
+	ReceivedPrincipal    float64          `json:"received_principal"`
+	ReceivedInterest     float64          `json:"received_interest"`
+	ReceivedPayouts      float64          `json:"received_payouts"`
+	OutstandingPrincipal float64          `json:"outstanding_principal"`
+	RealizedLoss         float64          `json:"realized_loss"`
+	FundedAt             *time.Time       `json:"funded_at,omitempty"`
+}
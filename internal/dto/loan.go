@@ -6,26 +6,128 @@ import (
 )
 
 type CreateLoanRequest struct {
-	BorrowerID      string  `json:"user_id" validate:"required"`
-	PrincipalAmount float64 `json:"principal_amount" validate:"required,gt=0"`
-	InterestRate    float64 `json:"interest_rate" validate:"required,gt=0"`
-	ROIRate         float64 `json:"roi_rate" validate:"required,gt=0"`
+	BorrowerID                string                   `json:"user_id" validate:"required"`
+	PrincipalAmount           float64                  `json:"principal_amount" validate:"required,gt=0"`
+	InterestRate              float64                  `json:"interest_rate" validate:"required,gt=0"`
+	ROIRate                   float64                  `json:"roi_rate" validate:"required,gt=0"`
+	Tenor                     int                      `json:"tenor" validate:"required,gt=0"`
+	TenorUnit                 enums.TenorUnit          `json:"tenor_unit" validate:"required"`
+	RepaymentFrequency        enums.RepaymentFrequency `json:"repayment_frequency" validate:"required"`
+	Purpose                   enums.LoanPurpose        `json:"purpose" validate:"required"`
+	RequestedDisbursementDate time.Time                `json:"requested_disbursement_date" validate:"required"`
 }
 
 type GetLoansResponseItem struct {
-	UUID            string           `json:"uuid"`
-	BorrowerID      string           `json:"borrower_id"`
-	PrincipalAmount float64          `json:"principal_amount"`
-	InterestRate    float64          `json:"interest_rate"`
-	ROIRate         float64          `json:"roi_rate"`
-	Status          enums.LoanStatus `json:"status"`
+	UUID                      string                   `json:"uuid"`
+	BorrowerID                string                   `json:"borrower_id"`
+	PrincipalAmount           float64                  `json:"principal_amount"`
+	InterestRate              float64                  `json:"interest_rate"`
+	ROIRate                   float64                  `json:"roi_rate"`
+	Tenor                     int                      `json:"tenor"`
+	TenorUnit                 enums.TenorUnit          `json:"tenor_unit"`
+	RepaymentFrequency        enums.RepaymentFrequency `json:"repayment_frequency"`
+	Purpose                   enums.LoanPurpose        `json:"purpose"`
+	RequestedDisbursementDate *time.Time               `json:"requested_disbursement_date,omitempty"`
+	Status                    enums.LoanStatus         `json:"status"`
+	DaysPastDue               int                      `json:"days_past_due"`
+	DPDBucket                 enums.DPDBucket          `json:"dpd_bucket"`
+	DisbursedAmount           float64                  `json:"disbursed_amount"`
+	UndisbursedAmount         float64                  `json:"undisbursed_amount"`
+	EscrowBalance             float64                  `json:"escrow_balance"`
+	FlaggedAt                 *time.Time               `json:"flagged_at,omitempty"`
+	FlagReason                string                   `json:"flag_reason,omitempty"`
+	Version                   int                      `json:"version"`
+}
+
+// GetLoansFilter narrows the loan listing. Zero-valued fields don't filter.
+type GetLoansFilter struct {
+	DPDBucket enums.DPDBucket
+}
+
+type GetLoanDetailResponse struct {
+	GetLoansResponseItem
+	StatusTimeline []LoanStatusTransitionItem `json:"status_timeline"`
+	TermSheets     []LoanTermSheetItem        `json:"term_sheets"`
+	Disbursements  []LoanDisbursementItem     `json:"disbursements"`
+	Instalments    []LoanInstalmentItem       `json:"instalments"`
+	LedgerEntries  []LoanLedgerEntryItem      `json:"ledger_entries"`
+	EscrowEntries  []LoanEscrowEntryItem      `json:"escrow_entries"`
+}
+
+type LoanStatusTransitionItem struct {
+	FromStatus *enums.LoanStatus `json:"from_status,omitempty"`
+	ToStatus   enums.LoanStatus  `json:"to_status"`
+	Actor      string            `json:"actor"`
+	Reason     string            `json:"reason,omitempty"`
+	At         time.Time         `json:"at"`
+}
+
+type LoanTermSheetItem struct {
+	Version         int       `json:"version"`
+	PrincipalAmount float64   `json:"principal_amount"`
+	InterestRate    float64   `json:"interest_rate"`
+	ROIRate         float64   `json:"roi_rate"`
+	Actor           string    `json:"actor"`
+	Reason          string    `json:"reason,omitempty"`
+	CreatedAt       time.Time `json:"created_at"`
+}
+
+type LoanDisbursementItem struct {
+	Amount                 float64            `json:"amount"`
+	FieldOfficerEmployeeID string             `json:"field_officer_employee_id"`
+	PayoutStatus           enums.PayoutStatus `json:"payout_status"`
+	FailureReason          string             `json:"failure_reason,omitempty"`
+	DisbursedAt            time.Time          `json:"disbursed_at"`
+}
+
+type LoanInstalmentItem struct {
+	Sequence     int        `json:"sequence"`
+	DueDate      time.Time  `json:"due_date"`
+	PrincipalDue float64    `json:"principal_due"`
+	InterestDue  float64    `json:"interest_due"`
+	PaidAmount   float64    `json:"paid_amount"`
+	PaidAt       *time.Time `json:"paid_at,omitempty"`
+}
+
+type LoanLedgerEntryItem struct {
+	EntryType     enums.LedgerEntryType `json:"entry_type"`
+	Amount        float64               `json:"amount"`
+	EffectiveDate time.Time             `json:"effective_date"`
+	Description   string                `json:"description,omitempty"`
+}
+
+type LoanEscrowEntryItem struct {
+	EntryType    enums.EscrowEntryType `json:"entry_type"`
+	Amount       float64               `json:"amount"`
+	BalanceAfter float64               `json:"balance_after"`
+	EffectiveAt  time.Time             `json:"effective_at"`
+}
+
+// AmendLoanRequest changes the terms of a loan that has not been approved
+// yet. Omitted fields keep their current value.
+type AmendLoanRequest struct {
+	LoanUUID        string   `json:"-"`
+	EmployeeID      string   `json:"employee_id" validate:"required"`
+	PrincipalAmount *float64 `json:"principal_amount" validate:"omitempty,gt=0"`
+	InterestRate    *float64 `json:"interest_rate" validate:"omitempty,gt=0"`
+	ROIRate         *float64 `json:"roi_rate" validate:"omitempty,gt=0"`
+	Reason          string   `json:"reason" validate:"max=255"`
+	ExpectedVersion *int     `json:"-"`
 }
 
 type ApproveLoanRequest struct {
-	LoanUUID   string                       `json:"-"`
-	EmployeeID string                       `json:"employee_id" validate:"required"`
-	Proofs     []LoanApprovalValidatorProof `json:"proofs" validate:"required"`
-	ApprovedAt time.Time                    `json:"approved_at" validate:"required"`
+	LoanUUID        string                       `json:"-"`
+	EmployeeID      string                       `json:"employee_id" validate:"required"`
+	Proofs          []LoanApprovalValidatorProof `json:"proofs" validate:"required"`
+	ApprovedAt      time.Time                    `json:"approved_at" validate:"required"`
+	ExpectedVersion *int                         `json:"-"`
+}
+
+type RejectLoanRequest struct {
+	LoanUUID        string `json:"-"`
+	EmployeeID      string `json:"employee_id" validate:"required"`
+	Reason          string `json:"reason" validate:"required,max=255"`
+	ExpectedVersion *int   `json:"-"`
 }
 
 type LoanApprovalValidatorProof struct {
@@ -34,14 +136,98 @@ type LoanApprovalValidatorProof struct {
 }
 
 type InvestLoanRequest struct {
-	LoanUUID   string  `json:"loan_uuid" validate:"required"`
-	InvestorID string  `json:"investor_id" validate:"required"`
-	Amount     float64 `json:"amount" validate:"required,gt=0"`
+	LoanUUID        string  `json:"loan_uuid" validate:"required"`
+	InvestorID      string  `json:"investor_id" validate:"required"`
+	Amount          float64 `json:"amount" validate:"required,gt=0"`
+	ExpectedVersion *int    `json:"-"`
+}
+
+// InvestLoanResponse describes the reservation an investment holds until
+// the investor's payment, quoting InvestmentUUID as its reference, arrives.
+type InvestLoanResponse struct {
+	InvestmentUUID   string                 `json:"investment_uuid"`
+	Amount           float64                `json:"amount"`
+	Status           enums.InvestmentStatus `json:"status"`
+	FundingExpiresAt time.Time              `json:"funding_expires_at"`
+}
+
+// ConfirmInvestmentFundingRequest records that an investor's payment was
+// received outside the payment gateway.
+type ConfirmInvestmentFundingRequest struct {
+	LoanUUID         string    `json:"-"`
+	InvestmentUUID   string    `json:"-"`
+	EmployeeID       string    `json:"employee_id" validate:"required"`
+	PaymentReference string    `json:"payment_reference" validate:"required,max=255"`
+	PaidAt           time.Time `json:"paid_at" validate:"required"`
 }
 
+// CancelInvestmentRequest withdraws an investment during its cooling-off
+// period. InvestorID has to be the investor who made it.
+type CancelInvestmentRequest struct {
+	InvestmentUUID string `json:"-"`
+	InvestorID     string `json:"investor_id" validate:"required"`
+	Reason         string `json:"reason" validate:"max=255"`
+}
+
+// CreateLoanDisbursementRequest pays out a tranche of a funded loan to the
+// borrower's bank account. Amount defaults to everything that has not been
+// disbursed yet.
 type CreateLoanDisbursementRequest struct {
-	LoanUUID                 string    `json:"loan_uuid" validate:"required"`
-	EmployeeID               string    `json:"employee_id" validate:"required"`
-	SignedAgreementLetterURL string    `json:"signed_agreement_letter_url" validate:"required"`
-	DisbursedAt              time.Time `json:"disbursed_at" validate:"required"`
+	LoanUUID                 string      `json:"loan_uuid" validate:"required"`
+	EmployeeID               string      `json:"employee_id" validate:"required"`
+	Amount                   float64     `json:"amount" validate:"omitempty,gt=0"`
+	SignedAgreementLetterURL string      `json:"signed_agreement_letter_url" validate:"required"`
+	BankAccount              BankAccount `json:"bank_account" validate:"required"`
+	DisbursedAt              time.Time   `json:"disbursed_at" validate:"required"`
+	ExpectedVersion          *int        `json:"-"`
+}
+
+type BankAccount struct {
+	AccountHolderName string `json:"account_holder_name" validate:"required,max=255"`
+	BankCode          string `json:"bank_code" validate:"required,max=50"`
+	AccountNumber     string `json:"account_number" validate:"required,max=50"`
+}
+
+// DeclareDefaultRequest declares that a disbursed loan will not be repaid.
+type DeclareDefaultRequest struct {
+	LoanUUID        string                `json:"-"`
+	EmployeeID      string                `json:"employee_id" validate:"required"`
+	Reason          string                `json:"reason" validate:"required,max=255"`
+	Evidence        []LoanDefaultEvidence `json:"evidence" validate:"required,min=1,dive"`
+	DeclaredAt      time.Time             `json:"declared_at" validate:"required"`
+	ExpectedVersion *int                  `json:"-"`
+}
+
+type LoanDefaultEvidence struct {
+	EvidenceURL string `json:"evidence_url" validate:"required"`
+	Category    string `json:"category" validate:"required"`
+}
+
+// WriteOffLoanRequest writes off the outstanding principal of a defaulted
+// loan.
+type WriteOffLoanRequest struct {
+	LoanUUID        string    `json:"-"`
+	EmployeeID      string    `json:"employee_id" validate:"required"`
+	Reason          string    `json:"reason" validate:"max=255"`
+	WrittenOffAt    time.Time `json:"written_off_at" validate:"required"`
+	ExpectedVersion *int      `json:"-"`
+}
+
+type PayoffQuoteResponse struct {
+	LoanUUID             string    `json:"loan_uuid"`
+	AsOf                 time.Time `json:"as_of"`
+	OutstandingPrincipal float64   `json:"outstanding_principal"`
+	AccruedInterest      float64   `json:"accrued_interest"`
+	LateFees             float64   `json:"late_fees"`
+	PrepaymentPenalty    float64   `json:"prepayment_penalty"`
+	Total                float64   `json:"total"`
+}
+
+// PrepayLoanRequest settles a loan early. Amount must match the payoff quote
+// for PaidAt.
+type PrepayLoanRequest struct {
+	LoanUUID        string    `json:"-"`
+	Amount          float64   `json:"amount" validate:"required,gt=0"`
+	PaidAt          time.Time `json:"paid_at" validate:"required"`
+	ExpectedVersion *int      `json:"-"`
 }
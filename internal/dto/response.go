@@ -9,3 +9,18 @@ type APIError struct {
 	Message string `json:"message"`
 	Error   string `json:"error"`
 }
+
+// PageRequest selects one page of a list. Pages are numbered from 1.
+type PageRequest struct {
+	Page     int
+	PageSize int
+}
+
+// PageInfo tells which page of a list a response holds and how many items
+// the list has in total.
+type PageInfo struct {
+	Page       int   `json:"page"`
+	PageSize   int   `json:"page_size"`
+	TotalItems int64 `json:"total_items"`
+	TotalPages int   `json:"total_pages"`
+}
@@ -1,18 +1,43 @@
 package handlers
 
 import (
+	"context"
 	"encoding/json"
 	"loan-service/internal/dto"
 	"net/http"
+	"sync"
+	"time"
 )
 
-type HealthHandler struct{}
+const (
+	healthStatusOK          = "ok"
+	healthStatusDegraded    = "degraded"
+	healthStatusUnavailable = "unavailable"
+	healthStatusFail        = "fail"
+)
+
+// HealthCheck is a single dependency probed by the readiness endpoint. A
+// failing critical check makes the service unready; a failing non-critical
+// check only degrades the report.
+type HealthCheck struct {
+	Name     string
+	Critical bool
+	Check    func(ctx context.Context) error
+}
+
+type HealthHandler struct {
+	checks  []HealthCheck
+	timeout time.Duration
+}
 
 // Ensure HealthHandler implements HealthHandlerInterface
 var _ HealthHandlerInterface = (*HealthHandler)(nil)
 
-func NewHealthHandler() *HealthHandler {
-	return &HealthHandler{}
+func NewHealthHandler(timeout time.Duration, checks ...HealthCheck) *HealthHandler {
+	return &HealthHandler{
+		checks:  checks,
+		timeout: timeout,
+	}
 }
 
 func (h *HealthHandler) HealthCheck(w http.ResponseWriter, r *http.Request) {
@@ -28,3 +53,82 @@ func (h *HealthHandler) HealthCheck(w http.ResponseWriter, r *http.Request) {
 
 	json.NewEncoder(w).Encode(response)
 }
+
+// Liveness reports whether the process is up and able to serve requests. It
+// deliberately does not touch any dependency, so a database outage does not
+// get the pod restarted.
+func (h *HealthHandler) Liveness(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(dto.HealthReport{
+		Status:  healthStatusOK,
+		Service: "loan-service",
+	})
+}
+
+// Readiness probes every registered dependency concurrently and returns 503
+// when any critical dependency is failing.
+func (h *HealthHandler) Readiness(w http.ResponseWriter, r *http.Request) {
+	report := dto.HealthReport{
+		Status:     healthStatusOK,
+		Service:    "loan-service",
+		Components: make(map[string]dto.ComponentHealth, len(h.checks)),
+	}
+
+	var (
+		mu sync.Mutex
+		wg sync.WaitGroup
+	)
+	for _, check := range h.checks {
+		wg.Add(1)
+		go func(check HealthCheck) {
+			defer wg.Done()
+			component := h.runCheck(r.Context(), check)
+
+			mu.Lock()
+			defer mu.Unlock()
+			report.Components[check.Name] = component
+		}(check)
+	}
+	wg.Wait()
+
+	statusCode := http.StatusOK
+	for _, component := range report.Components {
+		if component.Status == healthStatusOK {
+			continue
+		}
+		if component.Critical {
+			report.Status = healthStatusUnavailable
+			statusCode = http.StatusServiceUnavailable
+			break
+		}
+		report.Status = healthStatusDegraded
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(statusCode)
+	json.NewEncoder(w).Encode(report)
+}
+
+func (h *HealthHandler) runCheck(ctx context.Context, check HealthCheck) dto.ComponentHealth {
+	if h.timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, h.timeout)
+		defer cancel()
+	}
+
+	start := time.Now()
+	err := check.Check(ctx)
+	latency := time.Since(start)
+
+	component := dto.ComponentHealth{
+		Status:    healthStatusOK,
+		Critical:  check.Critical,
+		LatencyMs: float64(latency.Microseconds()) / 1000,
+	}
+	if err != nil {
+		component.Status = healthStatusFail
+		component.Error = err.Error()
+	}
+
+	return component
+}
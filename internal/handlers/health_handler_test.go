@@ -0,0 +1,108 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"loan-service/internal/dto"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func passingCheck(_ context.Context) error { return nil }
+
+func failingCheck(_ context.Context) error { return errors.New("connection refused") }
+
+func decodeHealthReport(t *testing.T, w *httptest.ResponseRecorder) dto.HealthReport {
+	var report dto.HealthReport
+	err := json.NewDecoder(w.Body).Decode(&report)
+	assert.NoError(t, err)
+	return report
+}
+
+func TestHealthHandler_Liveness_IgnoresDependencies(t *testing.T) {
+	handler := NewHealthHandler(time.Second, HealthCheck{Name: "database", Critical: true, Check: failingCheck})
+
+	req := httptest.NewRequest("GET", "/health/live", nil)
+	w := httptest.NewRecorder()
+
+	handler.Liveness(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "ok", decodeHealthReport(t, w).Status)
+}
+
+func TestHealthHandler_Readiness_AllHealthy(t *testing.T) {
+	handler := NewHealthHandler(time.Second,
+		HealthCheck{Name: "database", Critical: true, Check: passingCheck},
+		HealthCheck{Name: "migrations", Critical: true, Check: passingCheck},
+	)
+
+	req := httptest.NewRequest("GET", "/health/ready", nil)
+	w := httptest.NewRecorder()
+
+	handler.Readiness(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	report := decodeHealthReport(t, w)
+	assert.Equal(t, "ok", report.Status)
+	assert.Len(t, report.Components, 2)
+	assert.Equal(t, "ok", report.Components["database"].Status)
+	assert.True(t, report.Components["database"].Critical)
+}
+
+func TestHealthHandler_Readiness_CriticalFailure(t *testing.T) {
+	handler := NewHealthHandler(time.Second,
+		HealthCheck{Name: "database", Critical: true, Check: failingCheck},
+		HealthCheck{Name: "notification", Critical: false, Check: passingCheck},
+	)
+
+	req := httptest.NewRequest("GET", "/health/ready", nil)
+	w := httptest.NewRecorder()
+
+	handler.Readiness(w, req)
+
+	assert.Equal(t, http.StatusServiceUnavailable, w.Code)
+	report := decodeHealthReport(t, w)
+	assert.Equal(t, "unavailable", report.Status)
+	assert.Equal(t, "fail", report.Components["database"].Status)
+	assert.Equal(t, "connection refused", report.Components["database"].Error)
+}
+
+func TestHealthHandler_Readiness_NonCriticalFailureDegrades(t *testing.T) {
+	handler := NewHealthHandler(time.Second,
+		HealthCheck{Name: "database", Critical: true, Check: passingCheck},
+		HealthCheck{Name: "notification", Critical: false, Check: failingCheck},
+	)
+
+	req := httptest.NewRequest("GET", "/health/ready", nil)
+	w := httptest.NewRecorder()
+
+	handler.Readiness(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	report := decodeHealthReport(t, w)
+	assert.Equal(t, "degraded", report.Status)
+	assert.Equal(t, "fail", report.Components["notification"].Status)
+}
+
+func TestHealthHandler_Readiness_CheckTimeout(t *testing.T) {
+	slowCheck := func(ctx context.Context) error {
+		<-ctx.Done()
+		return ctx.Err()
+	}
+	handler := NewHealthHandler(20*time.Millisecond, HealthCheck{Name: "database", Critical: true, Check: slowCheck})
+
+	req := httptest.NewRequest("GET", "/health/ready", nil)
+	w := httptest.NewRecorder()
+
+	handler.Readiness(w, req)
+
+	assert.Equal(t, http.StatusServiceUnavailable, w.Code)
+	report := decodeHealthReport(t, w)
+	assert.Equal(t, context.DeadlineExceeded.Error(), report.Components["database"].Error)
+}
@@ -6,11 +6,26 @@ type LoanHandlerInterface interface {
 	CreateLoan(w http.ResponseWriter, r *http.Request)
 	GetAllLoans(w http.ResponseWriter, r *http.Request)
 	GetLoanByUUID(w http.ResponseWriter, r *http.Request)
+	AmendLoan(w http.ResponseWriter, r *http.Request)
 	ApproveLoan(w http.ResponseWriter, r *http.Request)
+	RejectLoan(w http.ResponseWriter, r *http.Request)
 	InvestLoan(w http.ResponseWriter, r *http.Request)
+	ConfirmInvestmentFunding(w http.ResponseWriter, r *http.Request)
+	CancelInvestment(w http.ResponseWriter, r *http.Request)
+	GetInvestorInvestments(w http.ResponseWriter, r *http.Request)
+	GetInvestorPortfolio(w http.ResponseWriter, r *http.Request)
+	GetBorrowerLoans(w http.ResponseWriter, r *http.Request)
 	DisburseLoan(w http.ResponseWriter, r *http.Request)
+	DeclareDefault(w http.ResponseWriter, r *http.Request)
+	WriteOffLoan(w http.ResponseWriter, r *http.Request)
+	GetPayoffQuote(w http.ResponseWriter, r *http.Request)
+	PrepayLoan(w http.ResponseWriter, r *http.Request)
+	PaymentGatewayWebhook(w http.ResponseWriter, r *http.Request)
+	CollectionWebhook(w http.ResponseWriter, r *http.Request)
 }
 
 type HealthHandlerInterface interface {
 	HealthCheck(w http.ResponseWriter, r *http.Request)
+	Liveness(w http.ResponseWriter, r *http.Request)
+	Readiness(w http.ResponseWriter, r *http.Request)
 }
@@ -2,24 +2,51 @@ package handlers
 
 import (
 	"encoding/json"
+	"errors"
+	"io"
 	"net/http"
+	"strconv"
+	"strings"
+	"time"
 
+	"loan-service/enums"
+	"loan-service/internal/client"
 	"loan-service/internal/dto"
+	"loan-service/internal/logging"
+	"loan-service/internal/repository"
 	"loan-service/internal/service"
 
 	"github.com/go-playground/validator/v10"
 	"github.com/gorilla/mux"
+	"github.com/sirupsen/logrus"
+)
+
+// paymentGatewaySignatureHeader carries the HMAC-SHA256 of a payout
+// callback's body, hex encoded.
+const paymentGatewaySignatureHeader = "X-Signature"
+
+// maxWebhookBodyBytes bounds what the unauthenticated webhook endpoints read
+// before the signature is checked; gateway callbacks are far smaller.
+const maxWebhookBodyBytes = 8 << 10
+
+// Lists are served defaultPageSize items at a time unless the client asks
+// for a different page_size, which can't exceed maxPageSize. Pages past
+// maxPage are refused so the offset they translate to stays in range.
+const (
+	defaultPageSize = 20
+	maxPageSize     = 100
+	maxPage         = 10000
 )
 
 type LoanHandler struct {
-	loanService *service.LoanService
+	loanService service.LoanServiceInterface
 	validator   *validator.Validate
 }
 
 // Ensure LoanHandler implements LoanHandlerInterface
 var _ LoanHandlerInterface = (*LoanHandler)(nil)
 
-func NewLoanHandler(loanService *service.LoanService, validator *validator.Validate) *LoanHandler {
+func NewLoanHandler(loanService service.LoanServiceInterface, validator *validator.Validate) *LoanHandler {
 	return &LoanHandler{
 		loanService: loanService,
 		validator:   validator,
@@ -38,9 +65,12 @@ func (h *LoanHandler) CreateLoan(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	r = withLogFields(r, logrus.Fields{"actor": req.BorrowerID})
+
 	err := h.loanService.CreateLoan(r.Context(), &req)
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		logging.FromContext(r.Context()).WithError(err).Error("failed to create loan")
+		writeServiceError(w, err)
 		return
 	}
 
@@ -52,9 +82,19 @@ func (h *LoanHandler) CreateLoan(w http.ResponseWriter, r *http.Request) {
 }
 
 func (h *LoanHandler) GetAllLoans(w http.ResponseWriter, r *http.Request) {
-	// TODO: implement filter and pagination
-	loans, err := h.loanService.GetAllLoans(r.Context())
+	// TODO: implement pagination
+	var filter dto.GetLoansFilter
+	if value := r.URL.Query().Get("dpd_bucket"); value != "" {
+		filter.DPDBucket = enums.DPDBucketFromString(value)
+		if filter.DPDBucket == 0 {
+			http.Error(w, "Invalid dpd_bucket", http.StatusBadRequest)
+			return
+		}
+	}
+
+	loans, err := h.loanService.GetAllLoans(r.Context(), filter)
 	if err != nil {
+		logging.FromContext(r.Context()).WithError(err).Error("failed to retrieve loans")
 		http.Error(w, "Failed to retrieve loans", http.StatusInternalServerError)
 		return
 	}
@@ -76,19 +116,65 @@ func (h *LoanHandler) GetLoanByUUID(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	r = withLogFields(r, logrus.Fields{"loan_uuid": uuid})
+
 	loan, err := h.loanService.GetLoanByUUID(r.Context(), uuid)
 	if err != nil {
+		logging.FromContext(r.Context()).WithError(err).Error("failed to retrieve loan")
 		http.Error(w, "Loan not found", http.StatusInternalServerError)
 		return
 	}
 
 	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("ETag", formatETag(loan.Version))
 	json.NewEncoder(w).Encode(dto.APIResponse{
 		Message: "Loan retrieved successfully",
 		Data:    loan,
 	})
 }
 
+func (h *LoanHandler) AmendLoan(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	uuid := vars["uuid"]
+	if uuid == "" {
+		http.Error(w, "Missing loan UUID", http.StatusBadRequest)
+		return
+	}
+
+	expectedVersion, ok := parseIfMatch(r.Header.Get("If-Match"))
+	if !ok {
+		http.Error(w, service.ErrPreconditionFailed.Error(), http.StatusPreconditionFailed)
+		return
+	}
+
+	var req dto.AmendLoanRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+	req.LoanUUID = uuid
+	req.ExpectedVersion = expectedVersion
+
+	if err := h.validator.Struct(req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	r = withLogFields(r, logrus.Fields{"loan_uuid": uuid, "actor": req.EmployeeID})
+
+	err := h.loanService.AmendLoan(r.Context(), req)
+	if err != nil {
+		logging.FromContext(r.Context()).WithError(err).Error("failed to amend loan")
+		writeServiceError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(dto.APIResponse{
+		Message: "Loan amended successfully",
+	})
+}
+
 func (h *LoanHandler) ApproveLoan(w http.ResponseWriter, r *http.Request) {
 	vars := mux.Vars(r)
 	uuid := vars["uuid"]
@@ -97,20 +183,31 @@ func (h *LoanHandler) ApproveLoan(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	expectedVersion, ok := parseIfMatch(r.Header.Get("If-Match"))
+	if !ok {
+		http.Error(w, service.ErrPreconditionFailed.Error(), http.StatusPreconditionFailed)
+		return
+	}
+
 	var req dto.ApproveLoanRequest
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
 		http.Error(w, "Invalid request body", http.StatusBadRequest)
 		return
 	}
+	req.LoanUUID = uuid
+	req.ExpectedVersion = expectedVersion
 
 	if err := h.validator.Struct(req); err != nil {
 		http.Error(w, "Invalid request body", http.StatusBadRequest)
 		return
 	}
 
+	r = withLogFields(r, logrus.Fields{"loan_uuid": uuid, "actor": req.EmployeeID})
+
 	err := h.loanService.ApproveLoanWithValidators(r.Context(), req)
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		logging.FromContext(r.Context()).WithError(err).Error("failed to approve loan")
+		writeServiceError(w, err)
 		return
 	}
 
@@ -120,6 +217,48 @@ func (h *LoanHandler) ApproveLoan(w http.ResponseWriter, r *http.Request) {
 	})
 }
 
+func (h *LoanHandler) RejectLoan(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	uuid := vars["uuid"]
+	if uuid == "" {
+		http.Error(w, "Missing loan UUID", http.StatusBadRequest)
+		return
+	}
+
+	expectedVersion, ok := parseIfMatch(r.Header.Get("If-Match"))
+	if !ok {
+		http.Error(w, service.ErrPreconditionFailed.Error(), http.StatusPreconditionFailed)
+		return
+	}
+
+	var req dto.RejectLoanRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+	req.LoanUUID = uuid
+	req.ExpectedVersion = expectedVersion
+
+	if err := h.validator.Struct(req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	r = withLogFields(r, logrus.Fields{"loan_uuid": uuid, "actor": req.EmployeeID})
+
+	err := h.loanService.RejectLoan(r.Context(), req)
+	if err != nil {
+		logging.FromContext(r.Context()).WithError(err).Error("failed to reject loan")
+		writeServiceError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(dto.APIResponse{
+		Message: "Loan rejected successfully",
+	})
+}
+
 func (h *LoanHandler) InvestLoan(w http.ResponseWriter, r *http.Request) {
 	vars := mux.Vars(r)
 	uuid := vars["uuid"]
@@ -128,26 +267,198 @@ func (h *LoanHandler) InvestLoan(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	expectedVersion, ok := parseIfMatch(r.Header.Get("If-Match"))
+	if !ok {
+		http.Error(w, service.ErrPreconditionFailed.Error(), http.StatusPreconditionFailed)
+		return
+	}
+
 	var req dto.InvestLoanRequest
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
 		http.Error(w, "Invalid request body", http.StatusBadRequest)
 		return
 	}
+	req.LoanUUID = uuid
+	req.ExpectedVersion = expectedVersion
 
 	if err := h.validator.Struct(req); err != nil {
 		http.Error(w, "Invalid request body", http.StatusBadRequest)
 		return
 	}
 
-	err := h.loanService.InvestLoan(r.Context(), req)
+	r = withLogFields(r, logrus.Fields{"loan_uuid": uuid, "actor": req.InvestorID})
+
+	investment, err := h.loanService.InvestLoan(r.Context(), req)
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		logging.FromContext(r.Context()).WithError(err).Error("failed to invest in loan")
+		writeServiceError(w, err)
 		return
 	}
 
 	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusAccepted)
 	json.NewEncoder(w).Encode(dto.APIResponse{
-		Message: "Loan invested successfully",
+		Message: "Investment reserved, awaiting payment",
+		Data:    investment,
+	})
+}
+
+func (h *LoanHandler) ConfirmInvestmentFunding(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	uuid := vars["uuid"]
+	investmentUUID := vars["investment_uuid"]
+	if uuid == "" || investmentUUID == "" {
+		http.Error(w, "Missing loan or investment UUID", http.StatusBadRequest)
+		return
+	}
+
+	var req dto.ConfirmInvestmentFundingRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+	req.LoanUUID = uuid
+	req.InvestmentUUID = investmentUUID
+
+	if err := h.validator.Struct(req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	r = withLogFields(r, logrus.Fields{"loan_uuid": uuid, "investment_uuid": investmentUUID, "actor": req.EmployeeID})
+
+	err := h.loanService.ConfirmInvestmentFunding(r.Context(), req)
+	if err != nil {
+		logging.FromContext(r.Context()).WithError(err).Error("failed to confirm investment funding")
+		writeServiceError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(dto.APIResponse{
+		Message: "Investment funded successfully",
+	})
+}
+
+func (h *LoanHandler) CancelInvestment(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	uuid := vars["uuid"]
+	if uuid == "" {
+		http.Error(w, "Missing investment UUID", http.StatusBadRequest)
+		return
+	}
+
+	var req dto.CancelInvestmentRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+	req.InvestmentUUID = uuid
+
+	if err := h.validator.Struct(req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	r = withLogFields(r, logrus.Fields{"investment_uuid": uuid, "actor": req.InvestorID})
+
+	err := h.loanService.CancelInvestment(r.Context(), req)
+	if err != nil {
+		logging.FromContext(r.Context()).WithError(err).Error("failed to cancel investment")
+		writeServiceError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(dto.APIResponse{
+		Message: "Investment cancelled successfully",
+	})
+}
+
+func (h *LoanHandler) GetInvestorInvestments(w http.ResponseWriter, r *http.Request) {
+	investorID := mux.Vars(r)["id"]
+	if investorID == "" {
+		http.Error(w, "Missing investor ID", http.StatusBadRequest)
+		return
+	}
+
+	page, ok := parsePageRequest(r)
+	if !ok {
+		http.Error(w, "Invalid page or page_size", http.StatusBadRequest)
+		return
+	}
+
+	r = withLogFields(r, logrus.Fields{"investor_id": investorID})
+
+	investments, err := h.loanService.GetInvestorInvestments(r.Context(), investorID, page)
+	if err != nil {
+		logging.FromContext(r.Context()).WithError(err).Error("failed to retrieve investor investments")
+		writeServiceError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(dto.APIResponse{
+		Message: "Investments retrieved successfully",
+		Data:    investments,
+	})
+}
+
+func (h *LoanHandler) GetInvestorPortfolio(w http.ResponseWriter, r *http.Request) {
+	investorID := mux.Vars(r)["id"]
+	if investorID == "" {
+		http.Error(w, "Missing investor ID", http.StatusBadRequest)
+		return
+	}
+
+	page, ok := parsePageRequest(r)
+	if !ok {
+		http.Error(w, "Invalid page or page_size", http.StatusBadRequest)
+		return
+	}
+
+	r = withLogFields(r, logrus.Fields{"investor_id": investorID})
+
+	portfolio, err := h.loanService.GetInvestorPortfolio(r.Context(), investorID, page)
+	if err != nil {
+		logging.FromContext(r.Context()).WithError(err).Error("failed to retrieve investor portfolio")
+		writeServiceError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(dto.APIResponse{
+		Message: "Portfolio retrieved successfully",
+		Data:    portfolio,
+	})
+}
+
+func (h *LoanHandler) GetBorrowerLoans(w http.ResponseWriter, r *http.Request) {
+	borrowerID := mux.Vars(r)["id"]
+	if borrowerID == "" {
+		http.Error(w, "Missing borrower ID", http.StatusBadRequest)
+		return
+	}
+
+	page, ok := parsePageRequest(r)
+	if !ok {
+		http.Error(w, "Invalid page or page_size", http.StatusBadRequest)
+		return
+	}
+
+	r = withLogFields(r, logrus.Fields{"borrower_id": borrowerID})
+
+	loans, err := h.loanService.GetBorrowerLoans(r.Context(), borrowerID, page)
+	if err != nil {
+		logging.FromContext(r.Context()).WithError(err).Error("failed to retrieve borrower loans")
+		writeServiceError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(dto.APIResponse{
+		Message: "Loans retrieved successfully",
+		Data:    loans,
 	})
 }
 
@@ -159,25 +470,344 @@ func (h *LoanHandler) DisburseLoan(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	expectedVersion, ok := parseIfMatch(r.Header.Get("If-Match"))
+	if !ok {
+		http.Error(w, service.ErrPreconditionFailed.Error(), http.StatusPreconditionFailed)
+		return
+	}
+
 	var req dto.CreateLoanDisbursementRequest
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
 		http.Error(w, "Invalid request body", http.StatusBadRequest)
 		return
 	}
+	req.LoanUUID = uuid
+	req.ExpectedVersion = expectedVersion
 
 	if err := h.validator.Struct(req); err != nil {
 		http.Error(w, "Invalid request body", http.StatusBadRequest)
 		return
 	}
 
+	r = withLogFields(r, logrus.Fields{"loan_uuid": uuid, "actor": req.EmployeeID})
+
 	err := h.loanService.CreateLoanDisbursement(r.Context(), req)
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		logging.FromContext(r.Context()).WithError(err).Error("failed to disburse loan")
+		writeServiceError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusAccepted)
+	json.NewEncoder(w).Encode(dto.APIResponse{
+		Message: "Loan disbursement initiated",
+	})
+}
+
+func (h *LoanHandler) DeclareDefault(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	uuid := vars["uuid"]
+	if uuid == "" {
+		http.Error(w, "Missing loan UUID", http.StatusBadRequest)
+		return
+	}
+
+	expectedVersion, ok := parseIfMatch(r.Header.Get("If-Match"))
+	if !ok {
+		http.Error(w, service.ErrPreconditionFailed.Error(), http.StatusPreconditionFailed)
+		return
+	}
+
+	var req dto.DeclareDefaultRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+	req.LoanUUID = uuid
+	req.ExpectedVersion = expectedVersion
+
+	if err := h.validator.Struct(req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	r = withLogFields(r, logrus.Fields{"loan_uuid": uuid, "actor": req.EmployeeID})
+
+	err := h.loanService.DeclareDefault(r.Context(), req)
+	if err != nil {
+		logging.FromContext(r.Context()).WithError(err).Error("failed to declare loan default")
+		writeServiceError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(dto.APIResponse{
+		Message: "Loan declared in default successfully",
+	})
+}
+
+func (h *LoanHandler) WriteOffLoan(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	uuid := vars["uuid"]
+	if uuid == "" {
+		http.Error(w, "Missing loan UUID", http.StatusBadRequest)
+		return
+	}
+
+	expectedVersion, ok := parseIfMatch(r.Header.Get("If-Match"))
+	if !ok {
+		http.Error(w, service.ErrPreconditionFailed.Error(), http.StatusPreconditionFailed)
+		return
+	}
+
+	var req dto.WriteOffLoanRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+	req.LoanUUID = uuid
+	req.ExpectedVersion = expectedVersion
+
+	if err := h.validator.Struct(req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	r = withLogFields(r, logrus.Fields{"loan_uuid": uuid, "actor": req.EmployeeID})
+
+	err := h.loanService.WriteOffLoan(r.Context(), req)
+	if err != nil {
+		logging.FromContext(r.Context()).WithError(err).Error("failed to write off loan")
+		writeServiceError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(dto.APIResponse{
+		Message: "Loan written off successfully",
+	})
+}
+
+func (h *LoanHandler) GetPayoffQuote(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	uuid := vars["uuid"]
+	if uuid == "" {
+		http.Error(w, "Missing loan UUID", http.StatusBadRequest)
+		return
+	}
+
+	asOf := time.Now()
+	if value := r.URL.Query().Get("as_of"); value != "" {
+		parsed, err := time.Parse(time.DateOnly, value)
+		if err != nil {
+			http.Error(w, "Invalid as_of, expected YYYY-MM-DD", http.StatusBadRequest)
+			return
+		}
+		asOf = parsed
+	}
+
+	r = withLogFields(r, logrus.Fields{"loan_uuid": uuid})
+
+	quote, err := h.loanService.GetPayoffQuote(r.Context(), uuid, asOf)
+	if err != nil {
+		logging.FromContext(r.Context()).WithError(err).Error("failed to quote loan payoff")
+		writeServiceError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(dto.APIResponse{
+		Message: "Payoff quote retrieved successfully",
+		Data:    quote,
+	})
+}
+
+func (h *LoanHandler) PrepayLoan(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	uuid := vars["uuid"]
+	if uuid == "" {
+		http.Error(w, "Missing loan UUID", http.StatusBadRequest)
+		return
+	}
+
+	expectedVersion, ok := parseIfMatch(r.Header.Get("If-Match"))
+	if !ok {
+		http.Error(w, service.ErrPreconditionFailed.Error(), http.StatusPreconditionFailed)
+		return
+	}
+
+	var req dto.PrepayLoanRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+	req.LoanUUID = uuid
+	req.ExpectedVersion = expectedVersion
+
+	if err := h.validator.Struct(req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	r = withLogFields(r, logrus.Fields{"loan_uuid": uuid})
+
+	err := h.loanService.PrepayLoan(r.Context(), req)
+	if err != nil {
+		logging.FromContext(r.Context()).WithError(err).Error("failed to prepay loan")
+		writeServiceError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(dto.APIResponse{
+		Message: "Loan prepaid successfully",
+	})
+}
+
+// PaymentGatewayWebhook receives payout status callbacks from the payment
+// gateway. The body is passed on untouched since the signature covers its
+// exact bytes.
+func (h *LoanHandler) PaymentGatewayWebhook(w http.ResponseWriter, r *http.Request) {
+	payload, ok := readWebhookBody(w, r)
+	if !ok {
+		return
+	}
+
+	err := h.loanService.HandlePayoutCallback(r.Context(), payload, r.Header.Get(paymentGatewaySignatureHeader))
+	if err != nil {
+		logging.FromContext(r.Context()).WithError(err).Error("failed to handle payout callback")
+		writeServiceError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(dto.APIResponse{
+		Message: "Payout callback processed",
+	})
+}
+
+// CollectionWebhook receives callbacks from the payment gateway for
+// payments collected from investors. Like PaymentGatewayWebhook, it hands
+// the body on untouched for signature verification.
+func (h *LoanHandler) CollectionWebhook(w http.ResponseWriter, r *http.Request) {
+	payload, ok := readWebhookBody(w, r)
+	if !ok {
+		return
+	}
+
+	err := h.loanService.HandleCollectionCallback(r.Context(), payload, r.Header.Get(paymentGatewaySignatureHeader))
+	if err != nil {
+		logging.FromContext(r.Context()).WithError(err).Error("failed to handle collection callback")
+		writeServiceError(w, err)
 		return
 	}
 
 	w.Header().Set("Content-Type", "application/json")
 	json.NewEncoder(w).Encode(dto.APIResponse{
-		Message: "Loan disbursement created successfully",
+		Message: "Collection callback processed",
 	})
 }
+
+// readWebhookBody reads at most maxWebhookBodyBytes of a webhook's body,
+// answering 413 for anything larger and 400 if the body can't be read.
+func readWebhookBody(w http.ResponseWriter, r *http.Request) ([]byte, bool) {
+	payload, err := io.ReadAll(http.MaxBytesReader(w, r.Body, maxWebhookBodyBytes))
+	if err != nil {
+		var tooLarge *http.MaxBytesError
+		if errors.As(err, &tooLarge) {
+			http.Error(w, "Request body too large", http.StatusRequestEntityTooLarge)
+			return nil, false
+		}
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return nil, false
+	}
+	return payload, true
+}
+
+// writeServiceError maps the service's typed errors to their HTTP status
+// codes and falls back to 500 for everything else.
+func writeServiceError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, service.ErrPreconditionFailed):
+		http.Error(w, err.Error(), http.StatusPreconditionFailed)
+	case errors.Is(err, repository.ErrVersionConflict), errors.Is(err, service.ErrLoanNotAmendable), errors.Is(err, service.ErrFundingClosed),
+		errors.Is(err, service.ErrInvalidLoanStatus), errors.Is(err, service.ErrPayoffAmountMismatch), errors.Is(err, service.ErrDisbursementExceedsFunding),
+		errors.Is(err, service.ErrReservationExpired), errors.Is(err, service.ErrFundingAmountMismatch), errors.Is(err, service.ErrInvestmentExceedsPrincipal),
+		errors.Is(err, service.ErrInvestorLoanShareExceeded), errors.Is(err, service.ErrInvestorExposureExceeded),
+		errors.Is(err, service.ErrInvestmentNotCancellable), errors.Is(err, service.ErrCoolingOffPeriodEnded),
+		errors.Is(err, service.ErrBorrowerOpenLoanLimit), errors.Is(err, service.ErrBorrowerPrincipalLimit), errors.Is(err, service.ErrBorrowerCoolingDown):
+		http.Error(w, err.Error(), http.StatusConflict)
+	case errors.Is(err, service.ErrNoTermsChanged), errors.Is(err, service.ErrInvalidLoanTerms),
+		errors.Is(err, service.ErrInvalidPayoffDate), errors.Is(err, client.ErrMalformedCallback),
+		errors.Is(err, service.ErrInvestmentBelowMinimum), errors.Is(err, service.ErrInvestmentAboveMaximum):
+		http.Error(w, err.Error(), http.StatusBadRequest)
+	case errors.Is(err, client.ErrInvalidSignature):
+		http.Error(w, err.Error(), http.StatusUnauthorized)
+	case errors.Is(err, service.ErrPayoutNotFound), errors.Is(err, service.ErrInvestmentNotFound), errors.Is(err, service.ErrLoanNotFound):
+		http.Error(w, err.Error(), http.StatusNotFound)
+	case errors.Is(err, service.ErrPayoutFailed):
+		http.Error(w, err.Error(), http.StatusBadGateway)
+	default:
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+	}
+}
+
+// formatETag renders a loan version as a strong entity tag.
+func formatETag(version int) string {
+	return `"` + strconv.Itoa(version) + `"`
+}
+
+// parsePageRequest reads the page and page_size query parameters, defaulting
+// to the first page of defaultPageSize items. It reports false for values
+// that aren't positive numbers, a page above maxPage or a page_size above
+// maxPageSize.
+func parsePageRequest(r *http.Request) (dto.PageRequest, bool) {
+	page := dto.PageRequest{Page: 1, PageSize: defaultPageSize}
+
+	query := r.URL.Query()
+	if value := query.Get("page"); value != "" {
+		number, err := strconv.Atoi(value)
+		if err != nil || number < 1 || number > maxPage {
+			return dto.PageRequest{}, false
+		}
+		page.Page = number
+	}
+	if value := query.Get("page_size"); value != "" {
+		size, err := strconv.Atoi(value)
+		if err != nil || size < 1 || size > maxPageSize {
+			return dto.PageRequest{}, false
+		}
+		page.PageSize = size
+	}
+
+	return page, true
+}
+
+// parseIfMatch returns the loan version the client expects, or nil when the
+// header is absent or "*". Only a single strong entity tag produced by
+// formatETag can match; anything else is reported as not ok, which callers
+// answer with 412 since no loan version can satisfy it.
+func parseIfMatch(header string) (*int, bool) {
+	header = strings.TrimSpace(header)
+	if header == "" || header == "*" {
+		return nil, true
+	}
+
+	if len(header) < 2 || header[0] != '"' || header[len(header)-1] != '"' {
+		return nil, false
+	}
+
+	version, err := strconv.Atoi(header[1 : len(header)-1])
+	if err != nil {
+		return nil, false
+	}
+	return &version, true
+}
+
+// withLogFields tags the request context with fields so that every log line
+// written further down the call chain carries them.
+func withLogFields(r *http.Request, fields logrus.Fields) *http.Request {
+	ctx, _ := logging.WithFields(r.Context(), fields)
+	return r.WithContext(ctx)
+}
@@ -3,7 +3,14 @@ package handlers
 import (
 	"bytes"
 	"encoding/json"
+	"errors"
+	"fmt"
+	"loan-service/enums"
+	"loan-service/internal/client"
 	"loan-service/internal/dto"
+	"loan-service/internal/repository"
+	"loan-service/internal/service"
+	"loan-service/mocks"
 	"net/http"
 	"net/http/httptest"
 	"testing"
@@ -12,6 +19,7 @@ import (
 	"github.com/go-playground/validator/v10"
 	"github.com/gorilla/mux"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
 )
 
 func setupTestHandler() *LoanHandler {
@@ -67,6 +75,44 @@ func TestLoanHandler_CreateLoan_ValidationError(t *testing.T) {
 	assert.Contains(t, w.Body.String(), "Invalid request body")
 }
 
+func TestLoanHandler_CreateLoan_BorrowerPolicyErrors(t *testing.T) {
+	reqBody := dto.CreateLoanRequest{
+		BorrowerID:                "borrower123",
+		PrincipalAmount:           1000,
+		InterestRate:              5,
+		ROIRate:                   3,
+		Tenor:                     12,
+		TenorUnit:                 enums.TenorUnitMonth,
+		RepaymentFrequency:        enums.RepaymentFrequencyMonthly,
+		Purpose:                   enums.LoanPurposeWorkingCapital,
+		RequestedDisbursementDate: time.Now().AddDate(0, 0, 7),
+	}
+
+	tests := []struct {
+		name       string
+		serviceErr error
+	}{
+		{name: "too many open loans", serviceErr: fmt.Errorf("%w: 3 open, maximum is 3", service.ErrBorrowerOpenLoanLimit)},
+		{name: "above outstanding principal", serviceErr: service.ErrBorrowerPrincipalLimit},
+		{name: "rejected too recently", serviceErr: service.ErrBorrowerCoolingDown},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			loanService := mocks.NewLoanServiceInterface(t)
+			loanService.On("CreateLoan", mock.Anything, mock.Anything).Return(tt.serviceErr)
+			handler := NewLoanHandler(loanService, validator.New())
+
+			req := createTestRequest("POST", "/v1/loans", reqBody)
+			w := httptest.NewRecorder()
+
+			handler.CreateLoan(w, req)
+
+			assert.Equal(t, http.StatusConflict, w.Code)
+			assert.Contains(t, w.Body.String(), tt.serviceErr.Error())
+		})
+	}
+}
+
 func TestLoanHandler_GetLoanByUUID_MissingUUID(t *testing.T) {
 	handler := setupTestHandler()
 
@@ -171,3 +217,692 @@ func TestLoanHandler_DisburseLoan_ValidationError(t *testing.T) {
 	assert.Equal(t, http.StatusBadRequest, w.Code)
 	assert.Contains(t, w.Body.String(), "Invalid request body")
 }
+
+func TestLoanHandler_DisburseLoan_ExceedsFunding(t *testing.T) {
+	loanService := mocks.NewLoanServiceInterface(t)
+	loanService.On("CreateLoanDisbursement", mock.Anything, mock.MatchedBy(func(req dto.CreateLoanDisbursementRequest) bool {
+		return req.LoanUUID == "test-uuid" && req.Amount == 600
+	})).Return(fmt.Errorf("%w: 600.00 requested, 400.00 undisbursed", service.ErrDisbursementExceedsFunding))
+	handler := NewLoanHandler(loanService, validator.New())
+
+	reqBody := dto.CreateLoanDisbursementRequest{
+		EmployeeID:               "emp123",
+		Amount:                   600,
+		SignedAgreementLetterURL: "https://example.com/signed.pdf",
+		BankAccount:              dto.BankAccount{AccountHolderName: "Budi Santoso", BankCode: "BCA", AccountNumber: "1234567890"},
+		DisbursedAt:              time.Now(),
+	}
+	req := mux.SetURLVars(createTestRequest("POST", "/v1/loans/test-uuid/disburse", reqBody), map[string]string{"uuid": "test-uuid"})
+	w := httptest.NewRecorder()
+
+	handler.DisburseLoan(w, req)
+
+	assert.Equal(t, http.StatusConflict, w.Code)
+}
+
+func TestLoanHandler_DisburseLoan_Accepted(t *testing.T) {
+	loanService := mocks.NewLoanServiceInterface(t)
+	loanService.On("CreateLoanDisbursement", mock.Anything, mock.MatchedBy(func(req dto.CreateLoanDisbursementRequest) bool {
+		return req.LoanUUID == "test-uuid" && req.BankAccount.AccountNumber == "1234567890"
+	})).Return(nil)
+	handler := NewLoanHandler(loanService, validator.New())
+
+	reqBody := dto.CreateLoanDisbursementRequest{
+		EmployeeID:               "emp123",
+		SignedAgreementLetterURL: "https://example.com/signed.pdf",
+		BankAccount:              dto.BankAccount{AccountHolderName: "Budi Santoso", BankCode: "BCA", AccountNumber: "1234567890"},
+		DisbursedAt:              time.Now(),
+	}
+	req := mux.SetURLVars(createTestRequest("POST", "/v1/loans/test-uuid/disburse", reqBody), map[string]string{"uuid": "test-uuid"})
+	w := httptest.NewRecorder()
+
+	handler.DisburseLoan(w, req)
+
+	assert.Equal(t, http.StatusAccepted, w.Code)
+}
+
+func TestLoanHandler_DisburseLoan_RequiresBankAccount(t *testing.T) {
+	handler := setupTestHandler()
+
+	reqBody := dto.CreateLoanDisbursementRequest{
+		EmployeeID:               "emp123",
+		SignedAgreementLetterURL: "https://example.com/signed.pdf",
+		DisbursedAt:              time.Now(),
+	}
+	req := mux.SetURLVars(createTestRequest("POST", "/v1/loans/test-uuid/disburse", reqBody), map[string]string{"uuid": "test-uuid"})
+	w := httptest.NewRecorder()
+
+	handler.DisburseLoan(w, req)
+
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+}
+
+func TestLoanHandler_PaymentGatewayWebhook(t *testing.T) {
+	payload := []byte(`{"payout_id":"payout-1","reference":"disbursement-uuid","status":"SUCCEEDED"}`)
+
+	tests := []struct {
+		name       string
+		serviceErr error
+		wantStatus int
+	}{
+		{name: "processed", wantStatus: http.StatusOK},
+		{name: "invalid signature", serviceErr: client.ErrInvalidSignature, wantStatus: http.StatusUnauthorized},
+		{name: "malformed callback", serviceErr: client.ErrMalformedCallback, wantStatus: http.StatusBadRequest},
+		{name: "unknown payout", serviceErr: service.ErrPayoutNotFound, wantStatus: http.StatusNotFound},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			loanService := mocks.NewLoanServiceInterface(t)
+			loanService.On("HandlePayoutCallback", mock.Anything, payload, "signature").Return(tt.serviceErr)
+			handler := NewLoanHandler(loanService, validator.New())
+
+			req := httptest.NewRequest(http.MethodPost, "/v1/webhooks/payment-gateway", bytes.NewReader(payload))
+			req.Header.Set("X-Signature", "signature")
+			w := httptest.NewRecorder()
+
+			handler.PaymentGatewayWebhook(w, req)
+
+			assert.Equal(t, tt.wantStatus, w.Code)
+		})
+	}
+}
+
+func TestLoanHandler_Webhooks_RejectOversizedBodies(t *testing.T) {
+	payload := bytes.Repeat([]byte("a"), maxWebhookBodyBytes+1)
+	handler := NewLoanHandler(mocks.NewLoanServiceInterface(t), validator.New())
+
+	for name, webhook := range map[string]http.HandlerFunc{
+		"payouts":     handler.PaymentGatewayWebhook,
+		"collections": handler.CollectionWebhook,
+	} {
+		t.Run(name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodPost, "/v1/webhooks/payment-gateway", bytes.NewReader(payload))
+			req.Header.Set("X-Signature", "signature")
+			w := httptest.NewRecorder()
+
+			webhook(w, req)
+
+			assert.Equal(t, http.StatusRequestEntityTooLarge, w.Code)
+		})
+	}
+}
+
+func TestLoanHandler_InvestLoan_Accepted(t *testing.T) {
+	loanService := mocks.NewLoanServiceInterface(t)
+	loanService.On("InvestLoan", mock.Anything, mock.MatchedBy(func(req dto.InvestLoanRequest) bool {
+		return req.LoanUUID == "test-uuid" && req.ExpectedVersion != nil && *req.ExpectedVersion == 3
+	})).Return(dto.InvestLoanResponse{
+		InvestmentUUID: "investment-uuid",
+		Amount:         500,
+		Status:         enums.InvestmentStatusPendingFunding,
+	}, nil)
+	handler := NewLoanHandler(loanService, validator.New())
+
+	reqBody := dto.InvestLoanRequest{InvestorID: "investor123", Amount: 500}
+	req := mux.SetURLVars(createTestRequest("POST", "/v1/loans/test-uuid/invest", reqBody), map[string]string{"uuid": "test-uuid"})
+	req.Header.Set("If-Match", `"3"`)
+	w := httptest.NewRecorder()
+
+	handler.InvestLoan(w, req)
+
+	assert.Equal(t, http.StatusAccepted, w.Code)
+	assert.Contains(t, w.Body.String(), "investment-uuid")
+	assert.Contains(t, w.Body.String(), `"status":3`)
+}
+
+func TestLoanHandler_InvestLoan_LimitErrors(t *testing.T) {
+	tests := []struct {
+		name       string
+		serviceErr error
+		wantStatus int
+	}{
+		{name: "below minimum ticket", serviceErr: fmt.Errorf("%w: 50.00 requested, minimum is 100.00", service.ErrInvestmentBelowMinimum), wantStatus: http.StatusBadRequest},
+		{name: "above maximum ticket", serviceErr: service.ErrInvestmentAboveMaximum, wantStatus: http.StatusBadRequest},
+		{name: "exceeds principal", serviceErr: service.ErrInvestmentExceedsPrincipal, wantStatus: http.StatusConflict},
+		{name: "exceeds loan share", serviceErr: service.ErrInvestorLoanShareExceeded, wantStatus: http.StatusConflict},
+		{name: "exceeds exposure", serviceErr: service.ErrInvestorExposureExceeded, wantStatus: http.StatusConflict},
+		{name: "loan not approved", serviceErr: fmt.Errorf("%w: only approved loans can be invested in", service.ErrInvalidLoanStatus), wantStatus: http.StatusConflict},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			loanService := mocks.NewLoanServiceInterface(t)
+			loanService.On("InvestLoan", mock.Anything, mock.Anything).Return(dto.InvestLoanResponse{}, tt.serviceErr)
+			handler := NewLoanHandler(loanService, validator.New())
+
+			reqBody := dto.InvestLoanRequest{InvestorID: "investor123", Amount: 500}
+			req := mux.SetURLVars(createTestRequest("POST", "/v1/loans/test-uuid/invest", reqBody), map[string]string{"uuid": "test-uuid"})
+			w := httptest.NewRecorder()
+
+			handler.InvestLoan(w, req)
+
+			assert.Equal(t, tt.wantStatus, w.Code)
+			assert.Contains(t, w.Body.String(), tt.serviceErr.Error())
+		})
+	}
+}
+
+func TestLoanHandler_ConfirmInvestmentFunding(t *testing.T) {
+	validBody := dto.ConfirmInvestmentFundingRequest{
+		EmployeeID:       "emp123",
+		PaymentReference: "TRF-001",
+		PaidAt:           time.Now(),
+	}
+
+	tests := []struct {
+		name       string
+		body       dto.ConfirmInvestmentFundingRequest
+		serviceErr error
+		callsSvc   bool
+		wantStatus int
+	}{
+		{name: "funded", body: validBody, callsSvc: true, wantStatus: http.StatusOK},
+		{name: "missing payment reference", body: dto.ConfirmInvestmentFundingRequest{EmployeeID: "emp123", PaidAt: time.Now()}, wantStatus: http.StatusBadRequest},
+		{name: "unknown investment", body: validBody, serviceErr: service.ErrInvestmentNotFound, callsSvc: true, wantStatus: http.StatusNotFound},
+		{name: "expired reservation", body: validBody, serviceErr: service.ErrReservationExpired, callsSvc: true, wantStatus: http.StatusConflict},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			loanService := mocks.NewLoanServiceInterface(t)
+			if tt.callsSvc {
+				loanService.On("ConfirmInvestmentFunding", mock.Anything, mock.MatchedBy(func(req dto.ConfirmInvestmentFundingRequest) bool {
+					return req.LoanUUID == "test-uuid" && req.InvestmentUUID == "investment-uuid"
+				})).Return(tt.serviceErr)
+			}
+			handler := NewLoanHandler(loanService, validator.New())
+
+			req := createTestRequest("POST", "/v1/loans/test-uuid/investments/investment-uuid/confirm-funding", tt.body)
+			req = mux.SetURLVars(req, map[string]string{"uuid": "test-uuid", "investment_uuid": "investment-uuid"})
+			w := httptest.NewRecorder()
+
+			handler.ConfirmInvestmentFunding(w, req)
+
+			assert.Equal(t, tt.wantStatus, w.Code)
+		})
+	}
+}
+
+func TestLoanHandler_CancelInvestment(t *testing.T) {
+	tests := []struct {
+		name       string
+		body       dto.CancelInvestmentRequest
+		serviceErr error
+		callsSvc   bool
+		wantStatus int
+	}{
+		{name: "cancelled", body: dto.CancelInvestmentRequest{InvestorID: "investor123"}, callsSvc: true, wantStatus: http.StatusOK},
+		{name: "missing investor", body: dto.CancelInvestmentRequest{}, wantStatus: http.StatusBadRequest},
+		{name: "unknown investment", body: dto.CancelInvestmentRequest{InvestorID: "investor123"}, serviceErr: service.ErrInvestmentNotFound, callsSvc: true, wantStatus: http.StatusNotFound},
+		{name: "cooling-off period ended", body: dto.CancelInvestmentRequest{InvestorID: "investor123"}, serviceErr: service.ErrCoolingOffPeriodEnded, callsSvc: true, wantStatus: http.StatusConflict},
+		{name: "loan disbursed", body: dto.CancelInvestmentRequest{InvestorID: "investor123"}, serviceErr: service.ErrInvalidLoanStatus, callsSvc: true, wantStatus: http.StatusConflict},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			loanService := mocks.NewLoanServiceInterface(t)
+			if tt.callsSvc {
+				loanService.On("CancelInvestment", mock.Anything, mock.MatchedBy(func(req dto.CancelInvestmentRequest) bool {
+					return req.InvestmentUUID == "investment-uuid" && req.InvestorID == "investor123"
+				})).Return(tt.serviceErr)
+			}
+			handler := NewLoanHandler(loanService, validator.New())
+
+			req := mux.SetURLVars(createTestRequest("POST", "/v1/investments/investment-uuid/cancel", tt.body), map[string]string{"uuid": "investment-uuid"})
+			w := httptest.NewRecorder()
+
+			handler.CancelInvestment(w, req)
+
+			assert.Equal(t, tt.wantStatus, w.Code)
+		})
+	}
+}
+
+func TestLoanHandler_GetInvestorPortfolio_Pagination(t *testing.T) {
+	tests := []struct {
+		name       string
+		query      string
+		wantPage   dto.PageRequest
+		wantStatus int
+	}{
+		{name: "defaults", query: "", wantPage: dto.PageRequest{Page: 1, PageSize: 20}, wantStatus: http.StatusOK},
+		{name: "explicit page", query: "?page=3&page_size=50", wantPage: dto.PageRequest{Page: 3, PageSize: 50}, wantStatus: http.StatusOK},
+		{name: "page below one", query: "?page=0", wantStatus: http.StatusBadRequest},
+		{name: "last allowed page", query: "?page=10000&page_size=100", wantPage: dto.PageRequest{Page: 10000, PageSize: 100}, wantStatus: http.StatusOK},
+		{name: "page above maximum", query: "?page=10001", wantStatus: http.StatusBadRequest},
+		{name: "page that would overflow the offset", query: "?page=92233720368547758&page_size=100", wantStatus: http.StatusBadRequest},
+		{name: "page size above maximum", query: "?page_size=101", wantStatus: http.StatusBadRequest},
+		{name: "not a number", query: "?page=abc", wantStatus: http.StatusBadRequest},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			loanService := mocks.NewLoanServiceInterface(t)
+			if tt.wantStatus == http.StatusOK {
+				loanService.On("GetInvestorPortfolio", mock.Anything, "investor123", tt.wantPage).Return(dto.InvestorPortfolioResponse{
+					Holdings: []dto.PortfolioHolding{{InvestmentUUID: "investment-uuid", InvestedAmount: 250}},
+					Page:     dto.PageInfo{Page: tt.wantPage.Page, PageSize: tt.wantPage.PageSize, TotalItems: 1, TotalPages: 1},
+				}, nil)
+			}
+			handler := NewLoanHandler(loanService, validator.New())
+
+			req := mux.SetURLVars(createTestRequest("GET", "/v1/investors/investor123/portfolio"+tt.query, nil), map[string]string{"id": "investor123"})
+			w := httptest.NewRecorder()
+
+			handler.GetInvestorPortfolio(w, req)
+
+			assert.Equal(t, tt.wantStatus, w.Code)
+			if tt.wantStatus == http.StatusOK {
+				assert.Contains(t, w.Body.String(), `"investment_uuid":"investment-uuid"`)
+				assert.Contains(t, w.Body.String(), `"total_items":1`)
+			}
+		})
+	}
+}
+
+func TestLoanHandler_GetInvestorInvestments(t *testing.T) {
+	loanService := mocks.NewLoanServiceInterface(t)
+	loanService.On("GetInvestorInvestments", mock.Anything, "investor123", dto.PageRequest{Page: 2, PageSize: 10}).Return(dto.InvestorInvestmentsResponse{
+		Investments: []dto.InvestorInvestmentItem{{InvestmentUUID: "investment-uuid", LoanUUID: "loan-uuid", Status: enums.InvestmentStatusFunded}},
+		Page:        dto.PageInfo{Page: 2, PageSize: 10, TotalItems: 11, TotalPages: 2},
+	}, nil)
+	handler := NewLoanHandler(loanService, validator.New())
+
+	req := mux.SetURLVars(createTestRequest("GET", "/v1/investors/investor123/investments?page=2&page_size=10", nil), map[string]string{"id": "investor123"})
+	w := httptest.NewRecorder()
+
+	handler.GetInvestorInvestments(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Body.String(), `"loan_uuid":"loan-uuid"`)
+	assert.Contains(t, w.Body.String(), `"total_pages":2`)
+}
+
+func TestLoanHandler_InvestorEndpoints_ServiceErrors(t *testing.T) {
+	loanService := mocks.NewLoanServiceInterface(t)
+	loanService.On("GetInvestorInvestments", mock.Anything, "investor123", mock.Anything).
+		Return(dto.InvestorInvestmentsResponse{}, fmt.Errorf("%w: loan-uuid", service.ErrInvalidLoanStatus))
+	loanService.On("GetInvestorPortfolio", mock.Anything, "investor123", mock.Anything).
+		Return(dto.InvestorPortfolioResponse{}, errors.New("database down"))
+	handler := NewLoanHandler(loanService, validator.New())
+
+	req := mux.SetURLVars(createTestRequest("GET", "/v1/investors/investor123/investments", nil), map[string]string{"id": "investor123"})
+	w := httptest.NewRecorder()
+	handler.GetInvestorInvestments(w, req)
+	assert.Equal(t, http.StatusConflict, w.Code)
+
+	req = mux.SetURLVars(createTestRequest("GET", "/v1/investors/investor123/portfolio", nil), map[string]string{"id": "investor123"})
+	w = httptest.NewRecorder()
+	handler.GetInvestorPortfolio(w, req)
+	assert.Equal(t, http.StatusInternalServerError, w.Code)
+}
+
+func TestLoanHandler_GetBorrowerLoans(t *testing.T) {
+	loanService := mocks.NewLoanServiceInterface(t)
+	loanService.On("GetBorrowerLoans", mock.Anything, "borrower123", dto.PageRequest{Page: 1, PageSize: 20}).Return(dto.BorrowerLoansResponse{
+		Loans: []dto.BorrowerLoanItem{{
+			LoanUUID:           "loan-uuid",
+			Status:             enums.LoanStatusPartiallyDisbursed,
+			OutstandingBalance: 400,
+			DocumentsToSign:    []dto.BorrowerDocumentItem{{Type: enums.BorrowerDocumentTypeLoanAgreement, Amount: 600}},
+		}},
+		Page: dto.PageInfo{Page: 1, PageSize: 20, TotalItems: 1, TotalPages: 1},
+	}, nil)
+	handler := NewLoanHandler(loanService, validator.New())
+
+	req := mux.SetURLVars(createTestRequest("GET", "/v1/borrowers/borrower123/loans", nil), map[string]string{"id": "borrower123"})
+	w := httptest.NewRecorder()
+
+	handler.GetBorrowerLoans(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Body.String(), `"outstanding_balance":400`)
+	assert.Contains(t, w.Body.String(), `"documents_to_sign":[{"type":1,"amount":600}]`)
+	assert.NotContains(t, w.Body.String(), `"next_instalment"`)
+
+	req = mux.SetURLVars(createTestRequest("GET", "/v1/borrowers/borrower123/loans?page_size=0", nil), map[string]string{"id": "borrower123"})
+	w = httptest.NewRecorder()
+
+	handler.GetBorrowerLoans(w, req)
+
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+}
+
+func TestLoanHandler_GetBorrowerLoans_ServiceError(t *testing.T) {
+	loanService := mocks.NewLoanServiceInterface(t)
+	loanService.On("GetBorrowerLoans", mock.Anything, "borrower123", mock.Anything).
+		Return(dto.BorrowerLoansResponse{}, fmt.Errorf("%w: loan-uuid", service.ErrInvalidLoanStatus))
+	handler := NewLoanHandler(loanService, validator.New())
+
+	req := mux.SetURLVars(createTestRequest("GET", "/v1/borrowers/borrower123/loans", nil), map[string]string{"id": "borrower123"})
+	w := httptest.NewRecorder()
+
+	handler.GetBorrowerLoans(w, req)
+
+	assert.Equal(t, http.StatusConflict, w.Code)
+}
+
+func TestLoanHandler_CollectionWebhook(t *testing.T) {
+	payload := []byte(`{"collection_id":"collection-1","reference":"investment-uuid","status":"SUCCEEDED","amount":500}`)
+
+	tests := []struct {
+		name       string
+		serviceErr error
+		wantStatus int
+	}{
+		{name: "processed", wantStatus: http.StatusOK},
+		{name: "invalid signature", serviceErr: client.ErrInvalidSignature, wantStatus: http.StatusUnauthorized},
+		{name: "unknown investment", serviceErr: service.ErrInvestmentNotFound, wantStatus: http.StatusNotFound},
+		{name: "amount mismatch", serviceErr: service.ErrFundingAmountMismatch, wantStatus: http.StatusConflict},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			loanService := mocks.NewLoanServiceInterface(t)
+			loanService.On("HandleCollectionCallback", mock.Anything, payload, "signature").Return(tt.serviceErr)
+			handler := NewLoanHandler(loanService, validator.New())
+
+			req := httptest.NewRequest(http.MethodPost, "/v1/webhooks/payment-gateway/collections", bytes.NewReader(payload))
+			req.Header.Set("X-Signature", "signature")
+			w := httptest.NewRecorder()
+
+			handler.CollectionWebhook(w, req)
+
+			assert.Equal(t, tt.wantStatus, w.Code)
+		})
+	}
+}
+
+func TestLoanHandler_GetLoanByUUID_SetsETag(t *testing.T) {
+	loanService := mocks.NewLoanServiceInterface(t)
+	loanService.On("GetLoanByUUID", mock.Anything, "test-uuid").Return(dto.GetLoanDetailResponse{
+		GetLoansResponseItem: dto.GetLoansResponseItem{UUID: "test-uuid", Version: 3},
+	}, nil)
+	handler := NewLoanHandler(loanService, validator.New())
+
+	req := mux.SetURLVars(createTestRequest("GET", "/v1/loans/test-uuid", nil), map[string]string{"uuid": "test-uuid"})
+	w := httptest.NewRecorder()
+
+	handler.GetLoanByUUID(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, `"3"`, w.Header().Get("ETag"))
+}
+
+func TestLoanHandler_ApproveLoan_PassesIfMatchToService(t *testing.T) {
+	loanService := mocks.NewLoanServiceInterface(t)
+	loanService.On("ApproveLoanWithValidators", mock.Anything, mock.MatchedBy(func(req dto.ApproveLoanRequest) bool {
+		return req.LoanUUID == "test-uuid" && req.ExpectedVersion != nil && *req.ExpectedVersion == 3
+	})).Return(nil)
+	handler := NewLoanHandler(loanService, validator.New())
+
+	reqBody := dto.ApproveLoanRequest{
+		EmployeeID: "emp1",
+		Proofs:     []dto.LoanApprovalValidatorProof{},
+		ApprovedAt: time.Now(),
+	}
+	req := mux.SetURLVars(createTestRequest("POST", "/v1/loans/test-uuid/approve", reqBody), map[string]string{"uuid": "test-uuid"})
+	req.Header.Set("If-Match", `"3"`)
+	w := httptest.NewRecorder()
+
+	handler.ApproveLoan(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+}
+
+func TestLoanHandler_ApproveLoan_ConcurrencyErrors(t *testing.T) {
+	tests := []struct {
+		name       string
+		ifMatch    string
+		serviceErr error
+		wantStatus int
+	}{
+		{name: "stale If-Match", ifMatch: `"2"`, serviceErr: service.ErrPreconditionFailed, wantStatus: http.StatusPreconditionFailed},
+		{name: "concurrent update", serviceErr: repository.ErrVersionConflict, wantStatus: http.StatusConflict},
+		{name: "weak If-Match", ifMatch: `W/"3"`, wantStatus: http.StatusPreconditionFailed},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			loanService := mocks.NewLoanServiceInterface(t)
+			if tt.serviceErr != nil {
+				loanService.On("ApproveLoanWithValidators", mock.Anything, mock.Anything).Return(tt.serviceErr)
+			}
+			handler := NewLoanHandler(loanService, validator.New())
+
+			reqBody := dto.ApproveLoanRequest{
+				EmployeeID: "emp1",
+				Proofs:     []dto.LoanApprovalValidatorProof{},
+				ApprovedAt: time.Now(),
+			}
+			req := mux.SetURLVars(createTestRequest("POST", "/v1/loans/test-uuid/approve", reqBody), map[string]string{"uuid": "test-uuid"})
+			if tt.ifMatch != "" {
+				req.Header.Set("If-Match", tt.ifMatch)
+			}
+			w := httptest.NewRecorder()
+
+			handler.ApproveLoan(w, req)
+
+			assert.Equal(t, tt.wantStatus, w.Code)
+		})
+	}
+}
+
+func TestLoanHandler_RejectLoan(t *testing.T) {
+	tests := []struct {
+		name       string
+		body       dto.RejectLoanRequest
+		ifMatch    string
+		serviceErr error
+		wantStatus int
+	}{
+		{name: "rejected", body: dto.RejectLoanRequest{EmployeeID: "emp1", Reason: "income not verified"}, ifMatch: `"3"`, wantStatus: http.StatusOK},
+		{name: "missing reason", body: dto.RejectLoanRequest{EmployeeID: "emp1"}, wantStatus: http.StatusBadRequest},
+		{name: "not proposed", body: dto.RejectLoanRequest{EmployeeID: "emp1", Reason: "income not verified"},
+			serviceErr: service.ErrInvalidLoanStatus, wantStatus: http.StatusConflict},
+		{name: "stale If-Match", body: dto.RejectLoanRequest{EmployeeID: "emp1", Reason: "income not verified"}, ifMatch: `"2"`,
+			serviceErr: service.ErrPreconditionFailed, wantStatus: http.StatusPreconditionFailed},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			loanService := mocks.NewLoanServiceInterface(t)
+			if tt.wantStatus != http.StatusBadRequest {
+				loanService.On("RejectLoan", mock.Anything, mock.MatchedBy(func(req dto.RejectLoanRequest) bool {
+					return req.LoanUUID == "test-uuid" && req.Reason == tt.body.Reason && (tt.ifMatch == "") == (req.ExpectedVersion == nil)
+				})).Return(tt.serviceErr)
+			}
+			handler := NewLoanHandler(loanService, validator.New())
+
+			req := mux.SetURLVars(createTestRequest("POST", "/v1/loans/test-uuid/reject", tt.body), map[string]string{"uuid": "test-uuid"})
+			if tt.ifMatch != "" {
+				req.Header.Set("If-Match", tt.ifMatch)
+			}
+			w := httptest.NewRecorder()
+
+			handler.RejectLoan(w, req)
+
+			assert.Equal(t, tt.wantStatus, w.Code)
+		})
+	}
+}
+
+func TestLoanHandler_AmendLoan_ValidationError(t *testing.T) {
+	handler := setupTestHandler()
+
+	principal := -100.0
+	reqBody := dto.AmendLoanRequest{
+		EmployeeID:      "emp123",
+		PrincipalAmount: &principal,
+	}
+
+	req := mux.SetURLVars(createTestRequest("PATCH", "/v1/loans/test-uuid", reqBody), map[string]string{"uuid": "test-uuid"})
+	w := httptest.NewRecorder()
+
+	handler.AmendLoan(w, req)
+
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	assert.Contains(t, w.Body.String(), "Invalid request body")
+}
+
+func TestLoanHandler_AmendLoan_UnknownLoan(t *testing.T) {
+	loanService := mocks.NewLoanServiceInterface(t)
+	loanService.On("AmendLoan", mock.Anything, mock.MatchedBy(func(req dto.AmendLoanRequest) bool {
+		return req.LoanUUID == "unknown-uuid"
+	})).Return(fmt.Errorf("%w: unknown-uuid", service.ErrLoanNotFound))
+	handler := NewLoanHandler(loanService, validator.New())
+
+	principal := 1500.0
+	reqBody := dto.AmendLoanRequest{
+		EmployeeID:      "emp123",
+		PrincipalAmount: &principal,
+	}
+
+	req := mux.SetURLVars(createTestRequest("PATCH", "/v1/loans/unknown-uuid", reqBody), map[string]string{"uuid": "unknown-uuid"})
+	w := httptest.NewRecorder()
+
+	handler.AmendLoan(w, req)
+
+	assert.Equal(t, http.StatusNotFound, w.Code)
+	assert.Contains(t, w.Body.String(), "loan not found")
+}
+
+func TestLoanHandler_AmendLoan_NotAmendable(t *testing.T) {
+	loanService := mocks.NewLoanServiceInterface(t)
+	loanService.On("AmendLoan", mock.Anything, mock.MatchedBy(func(req dto.AmendLoanRequest) bool {
+		return req.LoanUUID == "test-uuid"
+	})).Return(service.ErrLoanNotAmendable)
+	handler := NewLoanHandler(loanService, validator.New())
+
+	principal := 1500.0
+	reqBody := dto.AmendLoanRequest{
+		EmployeeID:      "emp123",
+		PrincipalAmount: &principal,
+	}
+
+	req := mux.SetURLVars(createTestRequest("PATCH", "/v1/loans/test-uuid", reqBody), map[string]string{"uuid": "test-uuid"})
+	w := httptest.NewRecorder()
+
+	handler.AmendLoan(w, req)
+
+	assert.Equal(t, http.StatusConflict, w.Code)
+}
+
+func TestLoanHandler_CreateLoan_UnknownEnumValue(t *testing.T) {
+	loanService := mocks.NewLoanServiceInterface(t)
+	loanService.On("CreateLoan", mock.Anything, mock.Anything).Return(fmt.Errorf("%w: unknown repayment frequency 9", service.ErrInvalidLoanTerms))
+	handler := NewLoanHandler(loanService, validator.New())
+
+	reqBody := dto.CreateLoanRequest{
+		BorrowerID:                "user123",
+		PrincipalAmount:           1000.0,
+		InterestRate:              5,
+		ROIRate:                   3,
+		Tenor:                     12,
+		TenorUnit:                 enums.TenorUnitMonth,
+		RepaymentFrequency:        enums.RepaymentFrequency(9),
+		Purpose:                   enums.LoanPurposeEducation,
+		RequestedDisbursementDate: time.Now().AddDate(0, 0, 7),
+	}
+
+	req := createTestRequest("POST", "/v1/loans", reqBody)
+	w := httptest.NewRecorder()
+
+	handler.CreateLoan(w, req)
+
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	assert.Contains(t, w.Body.String(), "unknown repayment frequency")
+}
+
+func TestLoanHandler_GetAllLoans_FiltersByDPDBucket(t *testing.T) {
+	loanService := mocks.NewLoanServiceInterface(t)
+	loanService.On("GetAllLoans", mock.Anything, dto.GetLoansFilter{DPDBucket: enums.DPDBucket31To60}).
+		Return([]dto.GetLoansResponseItem{{UUID: "loan-uuid-123", DaysPastDue: 42, DPDBucket: enums.DPDBucket31To60}}, nil)
+	handler := NewLoanHandler(loanService, validator.New())
+
+	req := createTestRequest("GET", "/v1/loans?dpd_bucket=DPD_31_60", nil)
+	w := httptest.NewRecorder()
+
+	handler.GetAllLoans(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Body.String(), `"days_past_due":42`)
+}
+
+func TestLoanHandler_GetAllLoans_UnknownDPDBucket(t *testing.T) {
+	handler := setupTestHandler()
+
+	req := createTestRequest("GET", "/v1/loans?dpd_bucket=DPD_LOTS", nil)
+	w := httptest.NewRecorder()
+
+	handler.GetAllLoans(w, req)
+
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	assert.Contains(t, w.Body.String(), "Invalid dpd_bucket")
+}
+
+func TestLoanHandler_DeclareDefault_RequiresEvidence(t *testing.T) {
+	handler := setupTestHandler()
+
+	reqBody := map[string]interface{}{
+		"employee_id": "emp123",
+		"reason":      "borrower unreachable",
+		"evidence":    []interface{}{},
+		"declared_at": time.Now(),
+	}
+	req := mux.SetURLVars(createTestRequest("POST", "/v1/loans/test-uuid/default", reqBody), map[string]string{"uuid": "test-uuid"})
+	w := httptest.NewRecorder()
+
+	handler.DeclareDefault(w, req)
+
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+}
+
+func TestLoanHandler_WriteOffLoan_NotDefaulted(t *testing.T) {
+	loanService := mocks.NewLoanServiceInterface(t)
+	loanService.On("WriteOffLoan", mock.Anything, mock.MatchedBy(func(req dto.WriteOffLoanRequest) bool {
+		return req.LoanUUID == "test-uuid"
+	})).Return(service.ErrInvalidLoanStatus)
+	handler := NewLoanHandler(loanService, validator.New())
+
+	reqBody := dto.WriteOffLoanRequest{EmployeeID: "emp123", WrittenOffAt: time.Now()}
+	req := mux.SetURLVars(createTestRequest("POST", "/v1/loans/test-uuid/write-off", reqBody), map[string]string{"uuid": "test-uuid"})
+	w := httptest.NewRecorder()
+
+	handler.WriteOffLoan(w, req)
+
+	assert.Equal(t, http.StatusConflict, w.Code)
+}
+
+func TestLoanHandler_GetPayoffQuote_InvalidAsOf(t *testing.T) {
+	handler := setupTestHandler()
+
+	req := mux.SetURLVars(createTestRequest("GET", "/v1/loans/test-uuid/payoff-quote?as_of=20-03-2026", nil), map[string]string{"uuid": "test-uuid"})
+	w := httptest.NewRecorder()
+
+	handler.GetPayoffQuote(w, req)
+
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+}
+
+func TestLoanHandler_GetPayoffQuote_PassesAsOf(t *testing.T) {
+	loanService := mocks.NewLoanServiceInterface(t)
+	loanService.On("GetPayoffQuote", mock.Anything, "test-uuid", time.Date(2026, time.March, 20, 0, 0, 0, 0, time.UTC)).
+		Return(dto.PayoffQuoteResponse{LoanUUID: "test-uuid", Total: 610.45}, nil)
+	handler := NewLoanHandler(loanService, validator.New())
+
+	req := mux.SetURLVars(createTestRequest("GET", "/v1/loans/test-uuid/payoff-quote?as_of=2026-03-20", nil), map[string]string{"uuid": "test-uuid"})
+	w := httptest.NewRecorder()
+
+	handler.GetPayoffQuote(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Body.String(), `"total":610.45`)
+}
+
+func TestLoanHandler_PrepayLoan_StaleQuote(t *testing.T) {
+	loanService := mocks.NewLoanServiceInterface(t)
+	loanService.On("PrepayLoan", mock.Anything, mock.Anything).Return(service.ErrPayoffAmountMismatch)
+	handler := NewLoanHandler(loanService, validator.New())
+
+	reqBody := dto.PrepayLoanRequest{Amount: 609, PaidAt: time.Now()}
+	req := mux.SetURLVars(createTestRequest("POST", "/v1/loans/test-uuid/prepay", reqBody), map[string]string{"uuid": "test-uuid"})
+	w := httptest.NewRecorder()
+
+	handler.PrepayLoan(w, req)
+
+	assert.Equal(t, http.StatusConflict, w.Code)
+}
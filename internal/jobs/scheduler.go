@@ -0,0 +1,241 @@
+package jobs
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"os"
+	"sync"
+	"time"
+
+	"loan-service/enums"
+	"loan-service/internal/config"
+	"loan-service/internal/logging"
+	"loan-service/internal/models"
+	"loan-service/internal/repository"
+	"loan-service/internal/tracing"
+
+	"github.com/google/uuid"
+	"github.com/sirupsen/logrus"
+	"go.opentelemetry.io/otel/attribute"
+)
+
+// defaultDelayedTimeout bounds delayed jobs scheduled without a timeout.
+const defaultDelayedTimeout = 5 * time.Minute
+
+// Func is the work a job performs. The context is cancelled when the job's
+// timeout expires or the scheduler stops.
+type Func func(ctx context.Context) error
+
+type periodicJob struct {
+	name string
+	cfg  config.JobConfig
+	fn   Func
+}
+
+// Scheduler runs periodic and delayed jobs in-process. Before every run it
+// takes a lease on the job in the database so that, with several replicas,
+// each run happens on only one of them, and it records the outcome of every
+// run in the job_runs table.
+type Scheduler struct {
+	repo   repository.JobRepositoryInterface
+	logger *logrus.Logger
+	holder string
+	now    func() time.Time
+
+	mu       sync.Mutex
+	periodic []periodicJob
+	started  bool
+	ctx      context.Context
+	cancel   context.CancelFunc
+	wg       sync.WaitGroup
+}
+
+func New(repo repository.JobRepositoryInterface, logger *logrus.Logger) *Scheduler {
+	ctx, cancel := context.WithCancel(context.Background())
+	return &Scheduler{
+		repo:   repo,
+		logger: logger,
+		holder: newHolderID(),
+		now:    time.Now,
+		ctx:    ctx,
+		cancel: cancel,
+	}
+}
+
+// newHolderID identifies this process in leases and job runs.
+func newHolderID() string {
+	hostname, err := os.Hostname()
+	if err != nil {
+		hostname = "unknown"
+	}
+	return fmt.Sprintf("%s-%d-%s", hostname, os.Getpid(), uuid.New().String()[:8])
+}
+
+// Every registers a job that runs once per cfg.Interval across all replicas.
+// Disabled jobs, and jobs without a positive interval, are ignored. Jobs must
+// be registered before Start.
+func (s *Scheduler) Every(name string, cfg config.JobConfig, fn Func) {
+	if !cfg.Enabled {
+		s.logger.WithField("job", name).Info("job disabled")
+		return
+	}
+	if cfg.Interval <= 0 {
+		s.logger.WithFields(logrus.Fields{"job": name, "interval": cfg.Interval}).Error("job not scheduled: interval must be positive")
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.periodic = append(s.periodic, periodicJob{name: name, cfg: cfg, fn: fn})
+}
+
+// After runs fn once, delay from now, unless the scheduler is stopped first.
+// The lease is released when the run finishes, so the same name can be
+// scheduled again.
+func (s *Scheduler) After(name string, delay, timeout time.Duration, fn Func) {
+	if timeout <= 0 {
+		timeout = defaultDelayedTimeout
+	}
+
+	s.wg.Add(1)
+	go func() {
+		defer s.wg.Done()
+
+		timer := time.NewTimer(delay)
+		defer timer.Stop()
+
+		select {
+		case <-s.ctx.Done():
+			return
+		case <-timer.C:
+			s.runOnce(name, timeout, timeout, true, fn)
+		}
+	}()
+}
+
+// Start launches the registered periodic jobs.
+func (s *Scheduler) Start() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.started {
+		return
+	}
+	s.started = true
+
+	for _, job := range s.periodic {
+		s.wg.Add(1)
+		go s.loop(job)
+	}
+
+	s.logger.WithField("holder", s.holder).Infof("Started job scheduler with %d periodic jobs", len(s.periodic))
+}
+
+// Stop cancels running jobs and waits for them to return, or for ctx to
+// expire.
+func (s *Scheduler) Stop(ctx context.Context) error {
+	s.cancel()
+
+	done := make(chan struct{})
+	go func() {
+		s.wg.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+func (s *Scheduler) loop(job periodicJob) {
+	defer s.wg.Done()
+
+	ticker := time.NewTicker(job.cfg.Interval)
+	defer ticker.Stop()
+
+	// The lease outlives the run by the rest of the interval so that other
+	// replicas, whose tickers are out of phase, skip this period.
+	leaseTTL := job.cfg.Interval
+	if job.cfg.Timeout > leaseTTL {
+		leaseTTL = job.cfg.Timeout
+	}
+
+	for {
+		select {
+		case <-s.ctx.Done():
+			return
+		case <-ticker.C:
+			s.runOnce(job.name, leaseTTL, job.cfg.Timeout, false, job.fn)
+		}
+	}
+}
+
+func (s *Scheduler) runOnce(name string, leaseTTL, timeout time.Duration, releaseLease bool, fn Func) {
+	ctx := logging.WithContext(s.ctx, s.logger.WithFields(logrus.Fields{"job": name, "holder": s.holder}))
+	log := logging.FromContext(ctx)
+
+	now := s.now()
+	acquired, err := s.repo.AcquireJobLease(ctx, name, s.holder, now.Add(leaseTTL), now)
+	if err != nil {
+		log.WithError(err).Error("failed to acquire job lease")
+		return
+	}
+	if !acquired {
+		log.Debug("job lease held by another replica, skipping run")
+		return
+	}
+	if releaseLease {
+		defer func() {
+			if err := s.repo.ReleaseJobLease(context.WithoutCancel(ctx), name, s.holder); err != nil {
+				log.WithError(err).Error("failed to release job lease")
+			}
+		}()
+	}
+
+	run := &models.JobRun{
+		JobName:   name,
+		Holder:    s.holder,
+		Status:    enums.JobRunStatusRunning,
+		StartedAt: now,
+	}
+	if err := s.repo.CreateJobRun(ctx, run); err != nil {
+		log.WithError(err).Error("failed to record job run, skipping run")
+		return
+	}
+
+	runErr := s.execute(ctx, name, timeout, fn)
+
+	run.Status = enums.JobRunStatusSucceeded
+	if runErr != nil {
+		run.Status = enums.JobRunStatusFailed
+		run.Error = runErr.Error()
+		log.WithError(runErr).Error("job run failed")
+	}
+	run.FinishedAt = sql.NullTime{Time: s.now(), Valid: true}
+
+	if err := s.repo.FinishJobRun(context.WithoutCancel(ctx), run); err != nil {
+		log.WithError(err).Error("failed to record job run outcome")
+	}
+}
+
+// execute runs fn under the job's timeout and turns a panic into an error so
+// that one misbehaving job can't take the process down.
+func (s *Scheduler) execute(ctx context.Context, name string, timeout time.Duration, fn Func) (err error) {
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	ctx, span := tracing.StartSpan(ctx, "Job "+name, attribute.String("job.name", name))
+	defer func() { tracing.EndSpan(span, err) }()
+
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("job panicked: %v", r)
+		}
+	}()
+
+	return fn(ctx)
+}
@@ -0,0 +1,225 @@
+package jobs
+
+import (
+	"context"
+	"errors"
+	"io"
+	"sync"
+	"testing"
+	"time"
+
+	"loan-service/enums"
+	"loan-service/internal/config"
+	"loan-service/internal/models"
+
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type fakeLease struct {
+	holder    string
+	expiresAt time.Time
+}
+
+// fakeJobRepository keeps leases and runs in memory with the same semantics
+// as repository.JobRepository.
+type fakeJobRepository struct {
+	mu     sync.Mutex
+	leases map[string]fakeLease
+	runs   []models.JobRun
+}
+
+func newFakeJobRepository() *fakeJobRepository {
+	return &fakeJobRepository{leases: map[string]fakeLease{}}
+}
+
+func (f *fakeJobRepository) AcquireJobLease(_ context.Context, name, holder string, until, now time.Time) (bool, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if lease, ok := f.leases[name]; ok && lease.holder != holder && lease.expiresAt.After(now) {
+		return false, nil
+	}
+	f.leases[name] = fakeLease{holder: holder, expiresAt: until}
+	return true, nil
+}
+
+func (f *fakeJobRepository) ReleaseJobLease(_ context.Context, name, holder string) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if lease, ok := f.leases[name]; ok && lease.holder == holder {
+		delete(f.leases, name)
+	}
+	return nil
+}
+
+func (f *fakeJobRepository) CreateJobRun(_ context.Context, run *models.JobRun) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	run.ID = len(f.runs) + 1
+	f.runs = append(f.runs, *run)
+	return nil
+}
+
+func (f *fakeJobRepository) FinishJobRun(_ context.Context, run *models.JobRun) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	f.runs[run.ID-1] = *run
+	return nil
+}
+
+func (f *fakeJobRepository) GetJobRunsByName(_ context.Context, name string, _ int) ([]models.JobRun, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	var runs []models.JobRun
+	for _, run := range f.runs {
+		if run.JobName == name {
+			runs = append(runs, run)
+		}
+	}
+	return runs, nil
+}
+
+func (f *fakeJobRepository) finishedRuns(name string) []models.JobRun {
+	runs, _ := f.GetJobRunsByName(context.Background(), name, 0)
+	var finished []models.JobRun
+	for _, run := range runs {
+		if run.FinishedAt.Valid {
+			finished = append(finished, run)
+		}
+	}
+	return finished
+}
+
+func setupTestScheduler(repo *fakeJobRepository) *Scheduler {
+	logger := logrus.New()
+	logger.SetOutput(io.Discard)
+	return New(repo, logger)
+}
+
+func TestScheduler_Every_RecordsRunOutcomes(t *testing.T) {
+	repo := newFakeJobRepository()
+	scheduler := setupTestScheduler(repo)
+
+	cfg := config.JobConfig{Enabled: true, Interval: 10 * time.Millisecond, Timeout: time.Second}
+	scheduler.Every("succeeds", cfg, func(ctx context.Context) error { return nil })
+	scheduler.Every("fails", cfg, func(ctx context.Context) error { return errors.New("database down") })
+	scheduler.Every("panics", cfg, func(ctx context.Context) error { panic("boom") })
+	scheduler.Start()
+
+	assert.Eventually(t, func() bool {
+		return len(repo.finishedRuns("succeeds")) > 0 &&
+			len(repo.finishedRuns("fails")) > 0 &&
+			len(repo.finishedRuns("panics")) > 0
+	}, time.Second, 5*time.Millisecond)
+	require.NoError(t, scheduler.Stop(context.Background()))
+
+	assert.Equal(t, enums.JobRunStatusSucceeded, repo.finishedRuns("succeeds")[0].Status)
+
+	failed := repo.finishedRuns("fails")[0]
+	assert.Equal(t, enums.JobRunStatusFailed, failed.Status)
+	assert.Equal(t, "database down", failed.Error)
+
+	panicked := repo.finishedRuns("panics")[0]
+	assert.Equal(t, enums.JobRunStatusFailed, panicked.Status)
+	assert.Contains(t, panicked.Error, "boom")
+}
+
+func TestScheduler_Every_SkipsWhenAnotherReplicaHoldsTheLease(t *testing.T) {
+	repo := newFakeJobRepository()
+	repo.leases["funding-expiry"] = fakeLease{holder: "other-replica", expiresAt: time.Now().Add(time.Hour)}
+	scheduler := setupTestScheduler(repo)
+
+	ran := make(chan struct{}, 1)
+	scheduler.Every("funding-expiry", config.JobConfig{Enabled: true, Interval: 5 * time.Millisecond, Timeout: time.Second}, func(ctx context.Context) error {
+		ran <- struct{}{}
+		return nil
+	})
+	scheduler.Start()
+
+	select {
+	case <-ran:
+		t.Fatal("job ran while another replica held the lease")
+	case <-time.After(50 * time.Millisecond):
+	}
+	require.NoError(t, scheduler.Stop(context.Background()))
+
+	runs, _ := repo.GetJobRunsByName(context.Background(), "funding-expiry", 0)
+	assert.Empty(t, runs)
+}
+
+func TestScheduler_Every_IgnoresDisabledJobs(t *testing.T) {
+	scheduler := setupTestScheduler(newFakeJobRepository())
+
+	scheduler.Every("disabled", config.JobConfig{Enabled: false, Interval: time.Millisecond}, func(ctx context.Context) error {
+		return nil
+	})
+
+	assert.Empty(t, scheduler.periodic)
+}
+
+func TestScheduler_Every_IgnoresNonPositiveIntervals(t *testing.T) {
+	scheduler := setupTestScheduler(newFakeJobRepository())
+
+	for _, interval := range []time.Duration{0, -time.Minute} {
+		scheduler.Every("misconfigured", config.JobConfig{Enabled: true, Interval: interval}, func(ctx context.Context) error {
+			return nil
+		})
+	}
+
+	assert.Empty(t, scheduler.periodic)
+	scheduler.Start()
+	require.NoError(t, scheduler.Stop(context.Background()))
+}
+
+func TestScheduler_After_RunsOnceAndReleasesLease(t *testing.T) {
+	repo := newFakeJobRepository()
+	scheduler := setupTestScheduler(repo)
+
+	var calls int
+	var mu sync.Mutex
+	scheduler.After("retry-notification", 10*time.Millisecond, time.Second, func(ctx context.Context) error {
+		mu.Lock()
+		defer mu.Unlock()
+		calls++
+		return nil
+	})
+
+	assert.Eventually(t, func() bool {
+		return len(repo.finishedRuns("retry-notification")) == 1
+	}, time.Second, 5*time.Millisecond)
+	require.NoError(t, scheduler.Stop(context.Background()))
+
+	mu.Lock()
+	assert.Equal(t, 1, calls)
+	mu.Unlock()
+
+	repo.mu.Lock()
+	_, held := repo.leases["retry-notification"]
+	repo.mu.Unlock()
+	assert.False(t, held)
+}
+
+func TestScheduler_Stop_CancelsRunningJobs(t *testing.T) {
+	repo := newFakeJobRepository()
+	scheduler := setupTestScheduler(repo)
+
+	started := make(chan struct{})
+	scheduler.After("long-running", 0, time.Hour, func(ctx context.Context) error {
+		close(started)
+		<-ctx.Done()
+		return ctx.Err()
+	})
+	<-started
+
+	require.NoError(t, scheduler.Stop(context.Background()))
+
+	runs := repo.finishedRuns("long-running")
+	require.Len(t, runs, 1)
+	assert.Equal(t, enums.JobRunStatusFailed, runs[0].Status)
+}
@@ -0,0 +1,82 @@
+package logging
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"gorm.io/gorm"
+	gormlogger "gorm.io/gorm/logger"
+)
+
+const slowQueryThreshold = 200 * time.Millisecond
+
+// GormLogger adapts GORM's logger to the context-carried logrus entry, so SQL
+// statements are logged with the request ID and domain fields of the caller.
+type GormLogger struct {
+	level gormlogger.LogLevel
+}
+
+// Ensure GormLogger implements gormlogger.Interface
+var _ gormlogger.Interface = (*GormLogger)(nil)
+
+// NewGormLogger maps the logrus level onto GORM's: statements are only traced
+// at debug level, errors are always reported.
+func NewGormLogger(logger *logrus.Logger) *GormLogger {
+	level := gormlogger.Warn
+	switch {
+	case logger.IsLevelEnabled(logrus.DebugLevel):
+		level = gormlogger.Info
+	case !logger.IsLevelEnabled(logrus.WarnLevel):
+		level = gormlogger.Error
+	}
+	return &GormLogger{level: level}
+}
+
+func (l *GormLogger) LogMode(level gormlogger.LogLevel) gormlogger.Interface {
+	return &GormLogger{level: level}
+}
+
+func (l *GormLogger) Info(ctx context.Context, msg string, args ...interface{}) {
+	if l.level >= gormlogger.Info {
+		FromContext(ctx).Infof(msg, args...)
+	}
+}
+
+func (l *GormLogger) Warn(ctx context.Context, msg string, args ...interface{}) {
+	if l.level >= gormlogger.Warn {
+		FromContext(ctx).Warnf(msg, args...)
+	}
+}
+
+func (l *GormLogger) Error(ctx context.Context, msg string, args ...interface{}) {
+	if l.level >= gormlogger.Error {
+		FromContext(ctx).Errorf(msg, args...)
+	}
+}
+
+func (l *GormLogger) Trace(ctx context.Context, begin time.Time, fc func() (sql string, rowsAffected int64), err error) {
+	if l.level <= gormlogger.Silent {
+		return
+	}
+
+	elapsed := time.Since(begin)
+	entry := func() *logrus.Entry {
+		sql, rows := fc()
+		return FromContext(ctx).WithFields(logrus.Fields{
+			"sql":        sql,
+			"rows":       rows,
+			"elapsed_ms": float64(elapsed.Microseconds()) / 1000,
+		})
+	}
+
+	switch {
+	case err != nil && !errors.Is(err, gorm.ErrRecordNotFound) && l.level >= gormlogger.Error:
+		entry().WithError(err).Error("database query failed")
+	case elapsed > slowQueryThreshold && l.level >= gormlogger.Warn:
+		entry().Warn("slow database query")
+	case l.level >= gormlogger.Info:
+		entry().Debug("database query")
+	}
+}
@@ -0,0 +1,64 @@
+package logging
+
+import (
+	"context"
+	"os"
+	"strings"
+
+	"loan-service/internal/config"
+
+	"github.com/sirupsen/logrus"
+)
+
+type contextKey struct{}
+
+var defaultEntry = logrus.NewEntry(logrus.StandardLogger())
+
+// New builds the application logger from cfg. Unknown levels fall back to
+// info and unknown formats fall back to JSON.
+func New(cfg config.LogConfig) *logrus.Logger {
+	logger := logrus.New()
+	logger.SetOutput(os.Stdout)
+
+	level, err := logrus.ParseLevel(cfg.Level)
+	if err != nil {
+		level = logrus.InfoLevel
+	}
+	logger.SetLevel(level)
+
+	switch strings.ToLower(cfg.Format) {
+	case "text":
+		logger.SetFormatter(&logrus.TextFormatter{FullTimestamp: true})
+	default:
+		logger.SetFormatter(&logrus.JSONFormatter{})
+	}
+
+	return logger
+}
+
+// SetDefault makes logger the fallback returned by FromContext for contexts
+// that carry no entry, such as background jobs started outside a request.
+func SetDefault(logger *logrus.Logger) {
+	defaultEntry = logrus.NewEntry(logger)
+}
+
+// WithContext returns a copy of ctx carrying entry.
+func WithContext(ctx context.Context, entry *logrus.Entry) context.Context {
+	return context.WithValue(ctx, contextKey{}, entry)
+}
+
+// FromContext returns the entry carried by ctx, or the default entry.
+func FromContext(ctx context.Context) *logrus.Entry {
+	if entry, ok := ctx.Value(contextKey{}).(*logrus.Entry); ok {
+		return entry
+	}
+	return defaultEntry
+}
+
+// WithFields adds fields to the entry carried by ctx and returns the derived
+// context together with the new entry, so that everything logged further down
+// the call chain is tagged with them.
+func WithFields(ctx context.Context, fields logrus.Fields) (context.Context, *logrus.Entry) {
+	entry := FromContext(ctx).WithFields(fields)
+	return WithContext(ctx, entry), entry
+}
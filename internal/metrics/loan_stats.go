@@ -0,0 +1,81 @@
+package metrics
+
+import (
+	"context"
+	"time"
+
+	"loan-service/enums"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+const loanStatsTimeout = 5 * time.Second
+
+// LoanStatsSource provides the aggregates behind the business gauges. It is
+// satisfied by repository.LoanRepositoryInterface.
+type LoanStatsSource interface {
+	CountLoansByStatus(ctx context.Context) (map[enums.LoanStatus]int64, error)
+	GetTotalFundedAmount(ctx context.Context) (float64, error)
+}
+
+// loanStatsCollector queries the source at scrape time instead of keeping
+// gauges in sync with every write, so the values are always consistent with
+// the database across replicas.
+type loanStatsCollector struct {
+	source       LoanStatsSource
+	loans        *prometheus.Desc
+	fundedAmount *prometheus.Desc
+	scrapeErrors *prometheus.Desc
+}
+
+func newLoanStatsCollector(source LoanStatsSource) *loanStatsCollector {
+	return &loanStatsCollector{
+		source: source,
+		loans: prometheus.NewDesc(
+			prometheus.BuildFQName(namespace, "", "loans"),
+			"Number of loans by status.",
+			[]string{"status"}, nil,
+		),
+		fundedAmount: prometheus.NewDesc(
+			prometheus.BuildFQName(namespace, "", "loans_funded_amount"),
+			"Total amount invested in loans that have not expired.",
+			nil, nil,
+		),
+		scrapeErrors: prometheus.NewDesc(
+			prometheus.BuildFQName(namespace, "", "loan_stats_scrape_error"),
+			"1 if the last scrape of loan statistics failed, 0 otherwise.",
+			nil, nil,
+		),
+	}
+}
+
+func (c *loanStatsCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- c.loans
+	ch <- c.fundedAmount
+	ch <- c.scrapeErrors
+}
+
+func (c *loanStatsCollector) Collect(ch chan<- prometheus.Metric) {
+	ctx, cancel := context.WithTimeout(context.Background(), loanStatsTimeout)
+	defer cancel()
+
+	scrapeError := 0.0
+
+	counts, err := c.source.CountLoansByStatus(ctx)
+	if err != nil {
+		scrapeError = 1
+	} else {
+		for _, status := range enums.GetAllLoanStatuses() {
+			ch <- prometheus.MustNewConstMetric(c.loans, prometheus.GaugeValue, float64(counts[status]), status.String())
+		}
+	}
+
+	fundedAmount, err := c.source.GetTotalFundedAmount(ctx)
+	if err != nil {
+		scrapeError = 1
+	} else {
+		ch <- prometheus.MustNewConstMetric(c.fundedAmount, prometheus.GaugeValue, fundedAmount)
+	}
+
+	ch <- prometheus.MustNewConstMetric(c.scrapeErrors, prometheus.GaugeValue, scrapeError)
+}
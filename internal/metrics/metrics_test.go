@@ -0,0 +1,132 @@
+package metrics
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"loan-service/enums"
+	"loan-service/internal/client"
+	"loan-service/internal/middleware"
+
+	"github.com/gorilla/mux"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+)
+
+type fakeLoanStatsSource struct {
+	counts       map[enums.LoanStatus]int64
+	fundedAmount float64
+	err          error
+}
+
+func (f *fakeLoanStatsSource) CountLoansByStatus(_ context.Context) (map[enums.LoanStatus]int64, error) {
+	return f.counts, f.err
+}
+
+func (f *fakeLoanStatsSource) GetTotalFundedAmount(_ context.Context) (float64, error) {
+	return f.fundedAmount, f.err
+}
+
+type fakeNotificationClient struct {
+	err error
+}
+
+func (f *fakeNotificationClient) SendEmail(_ context.Context, _ client.SendEmailRequest) error {
+	return f.err
+}
+
+func (f *fakeNotificationClient) Ping(_ context.Context) error {
+	return nil
+}
+
+func TestMetrics_MetricsMiddleware_LabelsByRouteTemplate(t *testing.T) {
+	m := New()
+
+	router := mux.NewRouter()
+	router.Use(middleware.MetricsMiddleware(m))
+	router.HandleFunc("/v1/loans/{uuid}", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNotFound)
+	}).Methods(http.MethodGet)
+
+	for _, uuid := range []string{"a", "b", "c"} {
+		router.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/v1/loans/"+uuid, nil))
+	}
+
+	assert.Equal(t, 3.0, testutil.ToFloat64(m.httpRequests.WithLabelValues("GET", "/v1/loans/{uuid}", "404")))
+	assert.Equal(t, 1, testutil.CollectAndCount(m.httpRequestDuration))
+}
+
+func TestMetrics_Handler_ExposesTextFormat(t *testing.T) {
+	m := New()
+	m.ObserveHTTPRequest("POST", "/v1/loans", http.StatusCreated, 0)
+
+	w := httptest.NewRecorder()
+	m.Handler().ServeHTTP(w, httptest.NewRequest("GET", "/metrics", nil))
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.True(t, strings.HasPrefix(w.Header().Get("Content-Type"), "text/plain"))
+	assert.Contains(t, w.Body.String(), `loan_service_http_requests_total{method="POST",route="/v1/loans",status="201"} 1`)
+}
+
+func TestMetrics_RegisterLoanStats(t *testing.T) {
+	m := New()
+	m.RegisterLoanStats(&fakeLoanStatsSource{
+		counts: map[enums.LoanStatus]int64{
+			enums.LoanStatusProposed: 4,
+			enums.LoanStatusApproved: 2,
+		},
+		fundedAmount: 1500,
+	})
+
+	expected := `
+# HELP loan_service_loans Number of loans by status.
+# TYPE loan_service_loans gauge
+loan_service_loans{status="APPROVED"} 2
+loan_service_loans{status="DEFAULTED"} 0
+loan_service_loans{status="DELINQUENT"} 0
+loan_service_loans{status="DISBURSED"} 0
+loan_service_loans{status="EXPIRED"} 0
+loan_service_loans{status="INVESTED"} 0
+loan_service_loans{status="PARTIALLY_DISBURSED"} 0
+loan_service_loans{status="PROPOSED"} 4
+loan_service_loans{status="REJECTED"} 0
+loan_service_loans{status="REPAID"} 0
+loan_service_loans{status="WRITTEN_OFF"} 0
+# HELP loan_service_loans_funded_amount Total amount invested in loans that have not expired.
+# TYPE loan_service_loans_funded_amount gauge
+loan_service_loans_funded_amount 1500
+`
+	err := testutil.GatherAndCompare(m.registry, strings.NewReader(expected), "loan_service_loans", "loan_service_loans_funded_amount")
+	assert.NoError(t, err)
+}
+
+func TestMetrics_RegisterLoanStats_ScrapeError(t *testing.T) {
+	m := New()
+	m.RegisterLoanStats(&fakeLoanStatsSource{err: errors.New("database down")})
+
+	expected := `
+# HELP loan_service_loan_stats_scrape_error 1 if the last scrape of loan statistics failed, 0 otherwise.
+# TYPE loan_service_loan_stats_scrape_error gauge
+loan_service_loan_stats_scrape_error 1
+`
+	err := testutil.GatherAndCompare(m.registry, strings.NewReader(expected), "loan_service_loan_stats_scrape_error", "loan_service_loans")
+	assert.NoError(t, err)
+}
+
+func TestMetrics_InstrumentNotificationClient(t *testing.T) {
+	m := New()
+
+	ok := m.InstrumentNotificationClient(&fakeNotificationClient{})
+	failing := m.InstrumentNotificationClient(&fakeNotificationClient{err: errors.New("timeout")})
+
+	assert.NoError(t, ok.SendEmail(context.Background(), client.SendEmailRequest{}))
+	assert.NoError(t, ok.SendEmail(context.Background(), client.SendEmailRequest{}))
+	assert.Error(t, failing.SendEmail(context.Background(), client.SendEmailRequest{}))
+
+	assert.Equal(t, 2.0, testutil.ToFloat64(m.notificationsSent.WithLabelValues("email", "success")))
+	assert.Equal(t, 1.0, testutil.ToFloat64(m.notificationsSent.WithLabelValues("email", "failure")))
+}
@@ -0,0 +1,40 @@
+package metrics
+
+import (
+	"context"
+
+	"loan-service/internal/client"
+)
+
+type instrumentedNotificationClient struct {
+	next    client.NotificationClientInterface
+	metrics *Metrics
+}
+
+// Ensure instrumentedNotificationClient implements NotificationClientInterface
+var _ client.NotificationClientInterface = (*instrumentedNotificationClient)(nil)
+
+// InstrumentNotificationClient wraps next so that every send is counted by
+// outcome.
+func (m *Metrics) InstrumentNotificationClient(next client.NotificationClientInterface) client.NotificationClientInterface {
+	return &instrumentedNotificationClient{
+		next:    next,
+		metrics: m,
+	}
+}
+
+func (c *instrumentedNotificationClient) SendEmail(ctx context.Context, req client.SendEmailRequest) error {
+	err := c.next.SendEmail(ctx, req)
+
+	result := "success"
+	if err != nil {
+		result = "failure"
+	}
+	c.metrics.notificationsSent.WithLabelValues("email", result).Inc()
+
+	return err
+}
+
+func (c *instrumentedNotificationClient) Ping(ctx context.Context) error {
+	return c.next.Ping(ctx)
+}
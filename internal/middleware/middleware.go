@@ -2,16 +2,36 @@ package middleware
 
 import (
 	"encoding/json"
-	"log"
 	"net/http"
 	"time"
 
 	"loan-service/internal/dto"
+	"loan-service/internal/logging"
+	"loan-service/internal/tracing"
+
+	"github.com/google/uuid"
+	"github.com/gorilla/mux"
+	"github.com/sirupsen/logrus"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	semconv "go.opentelemetry.io/otel/semconv/v1.26.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const (
+	RequestIDHeader    = "X-Request-ID"
+	maxRequestIDLength = 128
 )
 
 type ResponseWriter struct {
 	http.ResponseWriter
-	statusCode int
+	statusCode   int
+	bytesWritten int
+}
+
+func NewResponseWriter(w http.ResponseWriter) *ResponseWriter {
+	return &ResponseWriter{ResponseWriter: w, statusCode: http.StatusOK}
 }
 
 func (rw *ResponseWriter) WriteHeader(code int) {
@@ -19,30 +39,145 @@ func (rw *ResponseWriter) WriteHeader(code int) {
 	rw.ResponseWriter.WriteHeader(code)
 }
 
+func (rw *ResponseWriter) Write(b []byte) (int, error) {
+	n, err := rw.ResponseWriter.Write(b)
+	rw.bytesWritten += n
+	return n, err
+}
+
+// StatusCode returns the status written by the handler, defaulting to 200
+// when the handler never called WriteHeader explicitly.
+func (rw *ResponseWriter) StatusCode() int {
+	return rw.statusCode
+}
+
+func (rw *ResponseWriter) BytesWritten() int {
+	return rw.bytesWritten
+}
+
+// RequestRecorder receives one observation per completed HTTP request.
+type RequestRecorder interface {
+	ObserveHTTPRequest(method, route string, statusCode int, duration time.Duration)
+}
+
+// RequestIDMiddleware honours an incoming X-Request-ID header or generates a
+// new one, echoes it on the response and stores a request-scoped logger entry
+// tagged with it in the request context.
+func RequestIDMiddleware(logger *logrus.Logger) mux.MiddlewareFunc {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			requestID := r.Header.Get(RequestIDHeader)
+			if !isValidRequestID(requestID) {
+				requestID = uuid.New().String()
+			}
+
+			w.Header().Set(RequestIDHeader, requestID)
+
+			entry := logger.WithFields(logrus.Fields{
+				"request_id": requestID,
+				"method":     r.Method,
+				"path":       r.URL.Path,
+			})
+
+			next.ServeHTTP(w, r.WithContext(logging.WithContext(r.Context(), entry)))
+		})
+	}
+}
+
+func isValidRequestID(requestID string) bool {
+	if requestID == "" || len(requestID) > maxRequestIDLength {
+		return false
+	}
+	for _, c := range requestID {
+		if c < 0x21 || c > 0x7e {
+			return false
+		}
+	}
+	return true
+}
+
 func LoggingMiddleware(next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		start := time.Now()
 
-		rw := &ResponseWriter{ResponseWriter: w, statusCode: http.StatusOK}
+		rw := NewResponseWriter(w)
 		next.ServeHTTP(rw, r)
 
-		duration := time.Since(start)
+		logging.FromContext(r.Context()).WithFields(logrus.Fields{
+			"status":      rw.StatusCode(),
+			"bytes":       rw.BytesWritten(),
+			"duration_ms": float64(time.Since(start).Microseconds()) / 1000,
+		}).Info("request completed")
+	})
+}
+
+// MetricsMiddleware records request counts and latencies labelled by the
+// matched route template rather than the raw path, so that per-loan URLs do
+// not explode label cardinality. It must be installed with Router.Use so the
+// route has already been matched when it runs.
+func MetricsMiddleware(recorder RequestRecorder) mux.MiddlewareFunc {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			start := time.Now()
 
-		log.Printf(
-			"%s %s %d %v",
-			r.Method,
-			r.URL.Path,
-			rw.statusCode,
-			duration,
+			rw := NewResponseWriter(w)
+			next.ServeHTTP(rw, r)
+
+			recorder.ObserveHTTPRequest(r.Method, routeTemplate(r), rw.StatusCode(), time.Since(start))
+		})
+	}
+}
+
+// TracingMiddleware starts a server span for every request, continuing the
+// trace from an incoming W3C traceparent header when present. The trace and
+// span IDs are added to the request-scoped logger so log lines can be joined
+// with traces.
+func TracingMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		route := routeTemplate(r)
+
+		ctx := otel.GetTextMapPropagator().Extract(r.Context(), propagation.HeaderCarrier(r.Header))
+		ctx, span := tracing.Tracer().Start(ctx, r.Method+" "+route,
+			trace.WithSpanKind(trace.SpanKindServer),
+			trace.WithAttributes(
+				semconv.HTTPRequestMethodKey.String(r.Method),
+				semconv.HTTPRoute(route),
+				semconv.URLPath(r.URL.Path),
+			),
 		)
+		defer span.End()
+
+		if spanContext := span.SpanContext(); spanContext.IsValid() {
+			ctx, _ = logging.WithFields(ctx, logrus.Fields{
+				"trace_id": spanContext.TraceID().String(),
+				"span_id":  spanContext.SpanID().String(),
+			})
+		}
+
+		rw := NewResponseWriter(w)
+		next.ServeHTTP(rw, r.WithContext(ctx))
+
+		span.SetAttributes(semconv.HTTPResponseStatusCode(rw.StatusCode()))
+		if rw.StatusCode() >= http.StatusInternalServerError {
+			span.SetStatus(codes.Error, http.StatusText(rw.StatusCode()))
+		}
 	})
 }
 
+func routeTemplate(r *http.Request) string {
+	if current := mux.CurrentRoute(r); current != nil {
+		if template, err := current.GetPathTemplate(); err == nil {
+			return template
+		}
+	}
+	return "unmatched"
+}
+
 func ErrorMiddleware(next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		defer func() {
 			if err := recover(); err != nil {
-				log.Printf("Panic: %v", err)
+				logging.FromContext(r.Context()).WithField("panic", err).Error("recovered from panic")
 
 				w.Header().Set("Content-Type", "application/json")
 				w.WriteHeader(http.StatusInternalServerError)
@@ -3,29 +3,54 @@ package models
 import (
 	"database/sql"
 	"loan-service/enums"
+	"math"
 	"time"
 )
 
 type Loan struct {
-	ID               int              `json:"id" gorm:"primaryKey"`
-	UUID             string           `json:"uuid" gorm:"not null"`
-	BorrowerID       string           `json:"borrower_id" gorm:"not null"`
-	PrincipalAmount  float64          `json:"principal_amount" gorm:"not null"`
-	InterestRate     float64          `json:"interest_rate" gorm:"not null"`
-	ROIRate          float64          `json:"roi_rate" gorm:"not null"`
-	InvestmentAmount float64          `json:"investment_amount" gorm:"not null"`
-	Status           enums.LoanStatus `json:"status" gorm:"default:1"`
-	CreatedAt        time.Time        `json:"created_at" gorm:"autoCreateTime"`
-	UpdatedAt        time.Time        `json:"updated_at" gorm:"autoUpdateTime"`
+	ID                        int                      `json:"id" gorm:"primaryKey"`
+	UUID                      string                   `json:"uuid" gorm:"not null"`
+	BorrowerID                string                   `json:"borrower_id" gorm:"not null"`
+	PrincipalAmount           float64                  `json:"principal_amount" gorm:"not null"`
+	InterestRate              float64                  `json:"interest_rate" gorm:"not null"`
+	ROIRate                   float64                  `json:"roi_rate" gorm:"not null"`
+	Tenor                     int                      `json:"tenor" gorm:"not null"`
+	TenorUnit                 enums.TenorUnit          `json:"tenor_unit" gorm:"not null"`
+	RepaymentFrequency        enums.RepaymentFrequency `json:"repayment_frequency" gorm:"not null"`
+	Purpose                   enums.LoanPurpose        `json:"purpose" gorm:"not null"`
+	RequestedDisbursementDate sql.NullTime             `json:"requested_disbursement_date" gorm:"type:date"`
+	FundingDeadline           sql.NullTime             `json:"funding_deadline"`
+	DaysPastDue               int                      `json:"days_past_due" gorm:"not null;default:0"`
+	DPDBucket                 enums.DPDBucket          `json:"dpd_bucket" gorm:"column:dpd_bucket;not null;default:1"`
+	InvestmentAmount          float64                  `json:"investment_amount" gorm:"not null"`
+	DisbursedAmount           float64                  `json:"disbursed_amount" gorm:"not null;default:0"`
+	EscrowBalance             float64                  `json:"escrow_balance" gorm:"not null;default:0"`
+	FlaggedAt                 sql.NullTime             `json:"flagged_at"`
+	FlagReason                string                   `json:"flag_reason" gorm:"not null;default:''"`
+	Status                    enums.LoanStatus         `json:"status" gorm:"default:1"`
+	Version                   int                      `json:"version" gorm:"not null;default:1"`
+	CreatedAt                 time.Time                `json:"created_at" gorm:"autoCreateTime"`
+	UpdatedAt                 time.Time                `json:"updated_at" gorm:"autoUpdateTime"`
+}
+
+// UndisbursedAmount is the part of the funded amount that has not been paid
+// out to the borrower yet. Only loans waiting for (the rest of) their
+// disbursement have one.
+func (l *Loan) UndisbursedAmount() float64 {
+	if l.Status != enums.LoanStatusInvested && l.Status != enums.LoanStatusPartiallyDisbursed {
+		return 0
+	}
+	return math.Round((l.InvestmentAmount-l.DisbursedAmount)*100) / 100
 }
 
 type LoanApproval struct {
-	ID         int          `json:"id" gorm:"primaryKey"`
-	UUID       string       `json:"uuid" gorm:"not null"`
-	LoanID     int          `json:"loan_id" gorm:"not null"`
-	ApprovedAt sql.NullTime `json:"approved_at"`
-	CreatedAt  time.Time    `json:"created_at" gorm:"autoCreateTime"`
-	UpdatedAt  time.Time    `json:"updated_at" gorm:"autoUpdateTime"`
+	ID              int          `json:"id" gorm:"primaryKey"`
+	UUID            string       `json:"uuid" gorm:"not null"`
+	LoanID          int          `json:"loan_id" gorm:"not null"`
+	LoanTermSheetID *int         `json:"loan_term_sheet_id"`
+	ApprovedAt      sql.NullTime `json:"approved_at"`
+	CreatedAt       time.Time    `json:"created_at" gorm:"autoCreateTime"`
+	UpdatedAt       time.Time    `json:"updated_at" gorm:"autoUpdateTime"`
 }
 
 type LoanApprovalValidator struct {
@@ -47,24 +72,239 @@ type LoanApprovalValidatorProof struct {
 	UpdatedAt               time.Time `json:"updated_at" gorm:"autoUpdateTime"`
 }
 
+// Investment is an investor's share of a loan. Its amount is reserved
+// until FundingExpiresAt while the investor's payment is on its way, and
+// only counts towards the loan's investment amount once it is FUNDED.
+// Cancelling it during the cooling-off period voids its agreement letter.
 type Investment struct {
-	ID                 int       `json:"id" gorm:"primaryKey"`
-	UUID               string    `json:"uuid" gorm:"not null"`
-	LoanID             int       `json:"loan_id" gorm:"not null"`
-	InvestorID         string    `json:"investor_id" gorm:"not null"`
-	Amount             float64   `json:"amount" gorm:"not null"`
-	AgreementLetterURL string    `json:"agreement_letter_url" gorm:"not null"`
-	CreatedAt          time.Time `json:"created_at" gorm:"autoCreateTime"`
-	UpdatedAt          time.Time `json:"updated_at" gorm:"autoUpdateTime"`
+	ID                      int                    `json:"id" gorm:"primaryKey"`
+	UUID                    string                 `json:"uuid" gorm:"not null"`
+	LoanID                  int                    `json:"loan_id" gorm:"not null"`
+	InvestorID              string                 `json:"investor_id" gorm:"not null"`
+	Amount                  float64                `json:"amount" gorm:"not null"`
+	AgreementLetterURL      string                 `json:"agreement_letter_url" gorm:"not null"`
+	Status                  enums.InvestmentStatus `json:"status" gorm:"default:1"`
+	FundingExpiresAt        sql.NullTime           `json:"funding_expires_at"`
+	FundedAt                sql.NullTime           `json:"funded_at"`
+	PaymentReference        string                 `json:"payment_reference" gorm:"not null"`
+	RefundedAt              sql.NullTime           `json:"refunded_at"`
+	CancelledAt             sql.NullTime           `json:"cancelled_at"`
+	AgreementLetterVoidedAt sql.NullTime           `json:"agreement_letter_voided_at"`
+	RealizedLoss            float64                `json:"realized_loss" gorm:"not null;default:0"`
+	CreatedAt               time.Time              `json:"created_at" gorm:"autoCreateTime"`
+	UpdatedAt               time.Time              `json:"updated_at" gorm:"autoUpdateTime"`
 }
 
+// LoanDisbursement is a tranche of a loan paid out to the borrower's bank
+// account through the payment gateway. It only counts towards the loan's
+// disbursed amount once the gateway confirms the payout; DisbursedAt is then
+// the time the money moved.
 type LoanDisbursement struct {
-	ID                       int       `json:"id" gorm:"primaryKey"`
-	UUID                     string    `json:"uuid" gorm:"not null"`
-	LoanID                   int       `json:"loan_id" gorm:"not null"`
-	FieldOfficerEmployeeID   string    `json:"field_officer_employee_id" gorm:"not null"`
-	SignedAgreementLetterURL string    `json:"signed_agreement_letter_url" gorm:"not null"`
-	DisbursedAt              time.Time `json:"disbursed_at" gorm:"not null"`
-	CreatedAt                time.Time `json:"created_at" gorm:"autoCreateTime"`
-	UpdatedAt                time.Time `json:"updated_at" gorm:"autoUpdateTime"`
+	ID                       int                `json:"id" gorm:"primaryKey"`
+	UUID                     string             `json:"uuid" gorm:"not null"`
+	LoanID                   int                `json:"loan_id" gorm:"not null"`
+	Amount                   float64            `json:"amount" gorm:"not null"`
+	FieldOfficerEmployeeID   string             `json:"field_officer_employee_id" gorm:"not null"`
+	SignedAgreementLetterURL string             `json:"signed_agreement_letter_url" gorm:"not null"`
+	AccountHolderName        string             `json:"account_holder_name" gorm:"not null"`
+	BankCode                 string             `json:"bank_code" gorm:"not null"`
+	BankAccountNumber        string             `json:"bank_account_number" gorm:"not null"`
+	PayoutStatus             enums.PayoutStatus `json:"payout_status" gorm:"not null;default:1"`
+	GatewayPayoutID          *string            `json:"gateway_payout_id"`
+	FailureReason            string             `json:"failure_reason" gorm:"not null"`
+	DisbursedAt              time.Time          `json:"disbursed_at" gorm:"not null"`
+	CreatedAt                time.Time          `json:"created_at" gorm:"autoCreateTime"`
+	UpdatedAt                time.Time          `json:"updated_at" gorm:"autoUpdateTime"`
+}
+
+type LoanStatusTransition struct {
+	ID         int               `json:"id" gorm:"primaryKey"`
+	UUID       string            `json:"uuid" gorm:"not null"`
+	LoanID     int               `json:"loan_id" gorm:"not null"`
+	FromStatus *enums.LoanStatus `json:"from_status"`
+	ToStatus   enums.LoanStatus  `json:"to_status" gorm:"not null"`
+	Actor      string            `json:"actor" gorm:"not null"`
+	Reason     string            `json:"reason" gorm:"not null"`
+	At         time.Time         `json:"at" gorm:"not null"`
+	CreatedAt  time.Time         `json:"created_at" gorm:"autoCreateTime"`
+	UpdatedAt  time.Time         `json:"updated_at" gorm:"autoUpdateTime"`
+}
+
+// InvestorPayout is money passed on to an investor from a borrower's payment
+// on a loan they invested in.
+type InvestorPayout struct {
+	ID              int       `json:"id" gorm:"primaryKey"`
+	UUID            string    `json:"uuid" gorm:"not null"`
+	LoanID          int       `json:"loan_id" gorm:"not null"`
+	InvestmentID    int       `json:"investment_id" gorm:"not null"`
+	InvestorID      string    `json:"investor_id" gorm:"not null"`
+	PrincipalAmount float64   `json:"principal_amount" gorm:"not null"`
+	InterestAmount  float64   `json:"interest_amount" gorm:"not null"`
+	PenaltyAmount   float64   `json:"penalty_amount" gorm:"not null;default:0"`
+	PaidAt          time.Time `json:"paid_at" gorm:"not null"`
+	CreatedAt       time.Time `json:"created_at" gorm:"autoCreateTime"`
+	UpdatedAt       time.Time `json:"updated_at" gorm:"autoUpdateTime"`
+}
+
+// Amount is the total paid out.
+func (p *InvestorPayout) Amount() float64 {
+	return p.PrincipalAmount + p.InterestAmount + p.PenaltyAmount
+}
+
+// LoanDefault records the declaration that a loan will not be repaid, backed
+// by the evidence in LoanDefaultEvidence.
+type LoanDefault struct {
+	ID         int       `json:"id" gorm:"primaryKey"`
+	UUID       string    `json:"uuid" gorm:"not null"`
+	LoanID     int       `json:"loan_id" gorm:"not null"`
+	EmployeeID string    `json:"employee_id" gorm:"not null"`
+	Reason     string    `json:"reason" gorm:"not null"`
+	DeclaredAt time.Time `json:"declared_at" gorm:"not null"`
+	CreatedAt  time.Time `json:"created_at" gorm:"autoCreateTime"`
+	UpdatedAt  time.Time `json:"updated_at" gorm:"autoUpdateTime"`
+}
+
+type LoanDefaultEvidence struct {
+	ID            int       `json:"id" gorm:"primaryKey"`
+	UUID          string    `json:"uuid" gorm:"not null"`
+	LoanDefaultID int       `json:"loan_default_id" gorm:"not null"`
+	EvidenceURL   string    `json:"evidence_url" gorm:"not null"`
+	Category      string    `json:"category" gorm:"not null"`
+	CreatedAt     time.Time `json:"created_at" gorm:"autoCreateTime"`
+	UpdatedAt     time.Time `json:"updated_at" gorm:"autoUpdateTime"`
+}
+
+// LoanTermSheet is an immutable snapshot of a loan's commercial terms. A new
+// version is written on every amendment; the approval references the version
+// the validators signed off on.
+type LoanTermSheet struct {
+	ID              int       `json:"id" gorm:"primaryKey"`
+	UUID            string    `json:"uuid" gorm:"not null"`
+	LoanID          int       `json:"loan_id" gorm:"not null"`
+	Version         int       `json:"version" gorm:"not null"`
+	PrincipalAmount float64   `json:"principal_amount" gorm:"not null"`
+	InterestRate    float64   `json:"interest_rate" gorm:"not null"`
+	ROIRate         float64   `json:"roi_rate" gorm:"not null"`
+	Actor           string    `json:"actor" gorm:"not null"`
+	Reason          string    `json:"reason" gorm:"not null"`
+	CreatedAt       time.Time `json:"created_at" gorm:"autoCreateTime"`
+	UpdatedAt       time.Time `json:"updated_at" gorm:"autoUpdateTime"`
+}
+
+// LoanInstalment is one scheduled repayment of a disbursed loan. It is
+// settled once PaidAmount covers PrincipalDue plus InterestDue.
+type LoanInstalment struct {
+	ID           int          `json:"id" gorm:"primaryKey"`
+	UUID         string       `json:"uuid" gorm:"not null"`
+	LoanID       int          `json:"loan_id" gorm:"not null"`
+	Sequence     int          `json:"sequence" gorm:"not null"`
+	DueDate      time.Time    `json:"due_date" gorm:"type:date;not null"`
+	PrincipalDue float64      `json:"principal_due" gorm:"not null"`
+	InterestDue  float64      `json:"interest_due" gorm:"not null"`
+	PaidAmount   float64      `json:"paid_amount" gorm:"not null;default:0"`
+	PaidAt       sql.NullTime `json:"paid_at"`
+	CancelledAt  sql.NullTime `json:"cancelled_at"`
+	CreatedAt    time.Time    `json:"created_at" gorm:"autoCreateTime"`
+	UpdatedAt    time.Time    `json:"updated_at" gorm:"autoUpdateTime"`
+}
+
+// AmountDue is what the borrower owes on the instalment in total.
+func (i *LoanInstalment) AmountDue() float64 {
+	return i.PrincipalDue + i.InterestDue
+}
+
+// IsSettled reports whether the instalment has been paid in full or was
+// cancelled because the loan was settled early.
+func (i *LoanInstalment) IsSettled() bool {
+	return i.CancelledAt.Valid || i.PaidAmount >= i.AmountDue()
+}
+
+// LoanLedgerEntry records an amount charged to or credited on a loan outside
+// its schedule, such as a late fee.
+type LoanLedgerEntry struct {
+	ID               int                   `json:"id" gorm:"primaryKey"`
+	UUID             string                `json:"uuid" gorm:"not null"`
+	LoanID           int                   `json:"loan_id" gorm:"not null"`
+	LoanInstalmentID *int                  `json:"loan_instalment_id"`
+	EntryType        enums.LedgerEntryType `json:"entry_type" gorm:"not null"`
+	Amount           float64               `json:"amount" gorm:"not null"`
+	EffectiveDate    time.Time             `json:"effective_date" gorm:"type:date;not null"`
+	Description      string                `json:"description" gorm:"not null"`
+	CreatedAt        time.Time             `json:"created_at" gorm:"autoCreateTime"`
+	UpdatedAt        time.Time             `json:"updated_at" gorm:"autoUpdateTime"`
+}
+
+// LoanEscrowEntry is a movement of investor money held in escrow for a
+// loan: a deposit when an investment is funded, a release when a tranche is
+// paid out to the borrower, or a refund to an investor. BalanceAfter is the
+// loan's escrow balance once the entry was applied.
+type LoanEscrowEntry struct {
+	ID                 int                   `json:"id" gorm:"primaryKey"`
+	UUID               string                `json:"uuid" gorm:"not null"`
+	LoanID             int                   `json:"loan_id" gorm:"not null"`
+	InvestmentID       *int                  `json:"investment_id"`
+	LoanDisbursementID *int                  `json:"loan_disbursement_id"`
+	CollectionID       *string               `json:"collection_id" gorm:"uniqueIndex"`
+	EntryType          enums.EscrowEntryType `json:"entry_type" gorm:"not null"`
+	Amount             float64               `json:"amount" gorm:"not null"`
+	BalanceAfter       float64               `json:"balance_after" gorm:"not null"`
+	EffectiveAt        time.Time             `json:"effective_at" gorm:"not null"`
+	CreatedAt          time.Time             `json:"created_at" gorm:"autoCreateTime"`
+	UpdatedAt          time.Time             `json:"updated_at" gorm:"autoUpdateTime"`
+}
+
+// LoanInterestAccrual is the interest a disbursed loan earned on a single
+// day. Amount is kept unrounded; the ledger entry it was posted as carries
+// the rounded amount.
+type LoanInterestAccrual struct {
+	ID                 int                      `json:"id" gorm:"primaryKey"`
+	UUID               string                   `json:"uuid" gorm:"not null"`
+	LoanID             int                      `json:"loan_id" gorm:"not null"`
+	LoanLedgerEntryID  int                      `json:"loan_ledger_entry_id" gorm:"not null"`
+	AccrualDate        time.Time                `json:"accrual_date" gorm:"type:date;not null"`
+	DayCountConvention enums.DayCountConvention `json:"day_count_convention" gorm:"not null"`
+	PrincipalAmount    float64                  `json:"principal_amount" gorm:"not null"`
+	InterestRate       float64                  `json:"interest_rate" gorm:"not null"`
+	Amount             float64                  `json:"amount" gorm:"not null"`
+	CreatedAt          time.Time                `json:"created_at" gorm:"autoCreateTime"`
+	UpdatedAt          time.Time                `json:"updated_at" gorm:"autoUpdateTime"`
+}
+
+// InvestorLock is the row new investments lock before checking the
+// investor's exposure, so two of their investments can't both fit under the
+// limit at the same time.
+type InvestorLock struct {
+	InvestorID string    `json:"investor_id" gorm:"primaryKey"`
+	CreatedAt  time.Time `json:"created_at" gorm:"autoCreateTime"`
+}
+
+// BorrowerLock is the row new loan proposals lock before checking the
+// borrower's open loan and outstanding principal limits, so two proposals
+// can't both fit under them at the same time.
+type BorrowerLock struct {
+	BorrowerID string    `json:"borrower_id" gorm:"primaryKey"`
+	CreatedAt  time.Time `json:"created_at" gorm:"autoCreateTime"`
+}
+
+// JobLease grants one replica the right to run a background job until
+// ExpiresAt.
+type JobLease struct {
+	Name      string    `json:"name" gorm:"primaryKey"`
+	Holder    string    `json:"holder" gorm:"not null"`
+	ExpiresAt time.Time `json:"expires_at" gorm:"not null"`
+	CreatedAt time.Time `json:"created_at" gorm:"autoCreateTime"`
+	UpdatedAt time.Time `json:"updated_at" gorm:"autoUpdateTime"`
+}
+
+type JobRun struct {
+	ID         int                `json:"id" gorm:"primaryKey"`
+	UUID       string             `json:"uuid" gorm:"not null"`
+	JobName    string             `json:"job_name" gorm:"not null"`
+	Holder     string             `json:"holder" gorm:"not null"`
+	Status     enums.JobRunStatus `json:"status" gorm:"not null"`
+	Error      string             `json:"error"`
+	StartedAt  time.Time          `json:"started_at" gorm:"not null"`
+	FinishedAt sql.NullTime       `json:"finished_at"`
+	CreatedAt  time.Time          `json:"created_at" gorm:"autoCreateTime"`
+	UpdatedAt  time.Time          `json:"updated_at" gorm:"autoUpdateTime"`
 }
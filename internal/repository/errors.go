@@ -0,0 +1,7 @@
+package repository
+
+import "errors"
+
+// ErrVersionConflict is returned by UpdateLoan when the loan was changed by
+// someone else after it was read, i.e. the caller's copy is stale.
+var ErrVersionConflict = errors.New("loan was modified concurrently")
@@ -2,23 +2,117 @@ package repository
 
 import (
 	"context"
+	"loan-service/enums"
 	"loan-service/internal/models"
-
-	"gorm.io/gorm"
+	"time"
 )
 
+// LoanFilter narrows GetAllLoans. Zero-valued fields don't filter.
+type LoanFilter struct {
+	DPDBucket enums.DPDBucket
+}
+
+// Page limits a list query to Limit rows, skipping the first Offset.
+type Page struct {
+	Limit  int
+	Offset int
+}
+
 type LoanRepositoryInterface interface {
-	BeginTransaction(ctx context.Context) (*gorm.DB, error)
-	Commit(ctx context.Context, db *gorm.DB) error
-	Rollback(ctx context.Context, db *gorm.DB) error
+	// WithinTx runs fn in a database transaction and hands it a repository
+	// bound to that transaction. The transaction is committed when fn returns
+	// nil and rolled back when it returns an error or panics (the panic is
+	// re-raised after the rollback). Calling WithinTx on a transaction-bound
+	// repository nests the work in a savepoint. Reads made through txRepo run
+	// on the transaction's connection and see its uncommitted writes.
+	WithinTx(ctx context.Context, fn func(txRepo LoanRepositoryInterface) error) error
 	CreateLoan(ctx context.Context, loan *models.Loan) error
 	GetLoanByUUID(ctx context.Context, uuid string) (*models.Loan, error)
-	GetAllLoans(ctx context.Context) ([]models.Loan, error)
-	CreateLoanApproval(ctx context.Context, db *gorm.DB, loanApproval *models.LoanApproval) error
-	CreateLoanApprovalValidator(ctx context.Context, db *gorm.DB, loanApprovalValidator *models.LoanApprovalValidator) error
-	CreateLoanApprovalValidatorProof(ctx context.Context, db *gorm.DB, loanApprovalValidatorProof *models.LoanApprovalValidatorProof) error
-	CreateInvestment(ctx context.Context, db *gorm.DB, investment *models.Investment) error
-	UpdateLoan(ctx context.Context, db *gorm.DB, loan *models.Loan, fields []string) error
+	GetLoanByID(ctx context.Context, id int) (*models.Loan, error)
+	GetAllLoans(ctx context.Context, filter LoanFilter) ([]models.Loan, error)
+	GetLoansByStatuses(ctx context.Context, statuses []enums.LoanStatus) ([]models.Loan, error)
+	GetLoansByIDs(ctx context.Context, ids []int) ([]models.Loan, error)
+	// GetLoansByBorrowerID returns a page of the borrower's loans, newest
+	// first, and how many there are in total.
+	GetLoansByBorrowerID(ctx context.Context, borrowerID string, page Page) ([]models.Loan, int64, error)
+	GetBorrowerLoansByStatuses(ctx context.Context, borrowerID string, statuses []enums.LoanStatus) ([]models.Loan, error)
+	// GetLatestBorrowerLoanTransition returns the borrower's most recent
+	// transition of any loan into status, or nil if there is none.
+	GetLatestBorrowerLoanTransition(ctx context.Context, borrowerID string, status enums.LoanStatus) (*models.LoanStatusTransition, error)
+	// LockBorrower holds the borrower's lock row until the surrounding
+	// transaction ends, creating it on first use. Outside a transaction it
+	// locks nothing.
+	LockBorrower(ctx context.Context, borrowerID string) error
+	// GetLoansPastFundingDeadline returns APPROVED loans whose funding
+	// deadline is before now.
+	GetLoansPastFundingDeadline(ctx context.Context, now time.Time) ([]models.Loan, error)
+	CreateLoanApproval(ctx context.Context, loanApproval *models.LoanApproval) error
+	CreateLoanApprovalValidator(ctx context.Context, loanApprovalValidator *models.LoanApprovalValidator) error
+	CreateLoanApprovalValidatorProof(ctx context.Context, loanApprovalValidatorProof *models.LoanApprovalValidatorProof) error
+	CreateInvestment(ctx context.Context, investment *models.Investment) error
+	UpdateLoan(ctx context.Context, loan *models.Loan, fields []string) error
 	GetInvestmentsByLoanID(ctx context.Context, loanID int) ([]models.Investment, error)
-	CreateLoanDisbursement(ctx context.Context, db *gorm.DB, loanDisbursement *models.LoanDisbursement) error
+	// GetInvestmentsByInvestorID returns a page of the investor's
+	// investments, newest first, and how many there are in total. Only
+	// investments in one of statuses are listed; no statuses lists them all.
+	GetInvestmentsByInvestorID(ctx context.Context, investorID string, statuses []enums.InvestmentStatus, page Page) ([]models.Investment, int64, error)
+	// GetInvestmentByUUID returns nil if there is no such investment.
+	GetInvestmentByUUID(ctx context.Context, uuid string) (*models.Investment, error)
+	// GetInvestmentsPastFundingExpiry returns PENDING_FUNDING investments
+	// whose reservation expired before now.
+	GetInvestmentsPastFundingExpiry(ctx context.Context, now time.Time) ([]models.Investment, error)
+	// GetInvestorExposure returns the total the investor has funded or
+	// reserved in loans that are still outstanding, i.e. not rejected,
+	// expired, written off or repaid.
+	GetInvestorExposure(ctx context.Context, investorID string) (float64, error)
+	// LockInvestor holds the investor's lock row until the surrounding
+	// transaction ends, creating it on first use. Outside a transaction it
+	// locks nothing.
+	LockInvestor(ctx context.Context, investorID string) error
+	UpdateInvestment(ctx context.Context, investment *models.Investment, fields []string) error
+	// RefundInvestmentsByLoanID marks every FUNDED investment of the loan as
+	// REFUNDED at refundedAt.
+	RefundInvestmentsByLoanID(ctx context.Context, loanID int, refundedAt time.Time) error
+	CreateLoanDisbursement(ctx context.Context, loanDisbursement *models.LoanDisbursement) error
+	GetLoanDisbursementsByLoanID(ctx context.Context, loanID int) ([]models.LoanDisbursement, error)
+	GetLoanDisbursementByUUID(ctx context.Context, uuid string) (*models.LoanDisbursement, error)
+	UpdateLoanDisbursement(ctx context.Context, loanDisbursement *models.LoanDisbursement, fields []string) error
+	CreateInvestorPayout(ctx context.Context, payout *models.InvestorPayout) error
+	GetInvestorPayoutsByInvestmentIDs(ctx context.Context, investmentIDs []int) ([]models.InvestorPayout, error)
+	CreateLoanDefault(ctx context.Context, loanDefault *models.LoanDefault) error
+	CreateLoanDefaultEvidence(ctx context.Context, evidence *models.LoanDefaultEvidence) error
+	CreateLoanStatusTransition(ctx context.Context, transition *models.LoanStatusTransition) error
+	GetLoanStatusTransitionsByLoanID(ctx context.Context, loanID int) ([]models.LoanStatusTransition, error)
+	CreateLoanTermSheet(ctx context.Context, termSheet *models.LoanTermSheet) error
+	GetLatestLoanTermSheet(ctx context.Context, loanID int) (*models.LoanTermSheet, error)
+	GetLoanTermSheetsByLoanID(ctx context.Context, loanID int) ([]models.LoanTermSheet, error)
+	CreateLoanInstalments(ctx context.Context, instalments []models.LoanInstalment) error
+	GetLoanInstalmentsByLoanID(ctx context.Context, loanID int) ([]models.LoanInstalment, error)
+	GetLoanInstalmentsByLoanIDs(ctx context.Context, loanIDs []int) ([]models.LoanInstalment, error)
+	UpdateLoanInstalment(ctx context.Context, instalment *models.LoanInstalment, fields []string) error
+	CreateLoanLedgerEntry(ctx context.Context, entry *models.LoanLedgerEntry) error
+	GetLoanLedgerEntriesByLoanID(ctx context.Context, loanID int) ([]models.LoanLedgerEntry, error)
+	GetLoanLedgerEntriesByLoanIDs(ctx context.Context, loanIDs []int, entryTypes []enums.LedgerEntryType) ([]models.LoanLedgerEntry, error)
+	UpdateLoanLedgerEntry(ctx context.Context, entry *models.LoanLedgerEntry, fields []string) error
+	CreateLoanEscrowEntry(ctx context.Context, entry *models.LoanEscrowEntry) error
+	GetLoanEscrowEntriesByLoanID(ctx context.Context, loanID int) ([]models.LoanEscrowEntry, error)
+	// GetLoanEscrowEntryByCollectionID returns the deposit recorded for a
+	// payment gateway collection, or nil if there is none.
+	GetLoanEscrowEntryByCollectionID(ctx context.Context, collectionID string) (*models.LoanEscrowEntry, error)
+	CreateLoanInterestAccrual(ctx context.Context, accrual *models.LoanInterestAccrual) error
+	GetLoanInterestAccruals(ctx context.Context, loanID int, from, to time.Time) ([]models.LoanInterestAccrual, error)
+	UpdateLoanInterestAccrual(ctx context.Context, accrual *models.LoanInterestAccrual, fields []string) error
+	CountLoansByStatus(ctx context.Context) (map[enums.LoanStatus]int64, error)
+	GetTotalFundedAmount(ctx context.Context) (float64, error)
+}
+
+type JobRepositoryInterface interface {
+	// AcquireJobLease gives holder the lease on the named job until the given
+	// time if nobody else holds an unexpired lease on it. It reports whether
+	// the lease was obtained; a holder can extend its own lease.
+	AcquireJobLease(ctx context.Context, name, holder string, until, now time.Time) (bool, error)
+	ReleaseJobLease(ctx context.Context, name, holder string) error
+	CreateJobRun(ctx context.Context, run *models.JobRun) error
+	FinishJobRun(ctx context.Context, run *models.JobRun) error
+	GetJobRunsByName(ctx context.Context, name string, limit int) ([]models.JobRun, error)
 }
@@ -0,0 +1,66 @@
+package repository
+
+import (
+	"context"
+	"loan-service/internal/models"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type JobRepository struct {
+	db *gorm.DB
+}
+
+// Ensure JobRepository implements JobRepositoryInterface
+var _ JobRepositoryInterface = (*JobRepository)(nil)
+
+func NewJobRepository(db *gorm.DB) *JobRepository {
+	return &JobRepository{db: db}
+}
+
+// AcquireJobLease first tries to take over an expired (or its own) lease and
+// otherwise inserts a new one. Both statements are atomic on their own, so two
+// replicas racing for the same lease can't both succeed.
+func (r *JobRepository) AcquireJobLease(ctx context.Context, name, holder string, until, now time.Time) (bool, error) {
+	result := r.db.WithContext(ctx).Model(&models.JobLease{}).
+		Where("name = ? AND (holder = ? OR expires_at <= ?)", name, holder, now).
+		Updates(map[string]interface{}{"holder": holder, "expires_at": until})
+	if result.Error != nil {
+		return false, result.Error
+	}
+	if result.RowsAffected > 0 {
+		return true, nil
+	}
+
+	result = r.db.WithContext(ctx).Clauses(clause.OnConflict{DoNothing: true}).Create(&models.JobLease{
+		Name:      name,
+		Holder:    holder,
+		ExpiresAt: until,
+	})
+	if result.Error != nil {
+		return false, result.Error
+	}
+	return result.RowsAffected > 0, nil
+}
+
+func (r *JobRepository) ReleaseJobLease(ctx context.Context, name, holder string) error {
+	return r.db.WithContext(ctx).Where("name = ? AND holder = ?", name, holder).Delete(&models.JobLease{}).Error
+}
+
+func (r *JobRepository) CreateJobRun(ctx context.Context, run *models.JobRun) error {
+	run.UUID = uuid.New().String()
+	return r.db.WithContext(ctx).Create(run).Error
+}
+
+func (r *JobRepository) FinishJobRun(ctx context.Context, run *models.JobRun) error {
+	return r.db.WithContext(ctx).Model(run).Select("status", "error", "finished_at").Updates(run).Error
+}
+
+func (r *JobRepository) GetJobRunsByName(ctx context.Context, name string, limit int) ([]models.JobRun, error) {
+	var runs []models.JobRun
+	err := r.db.WithContext(ctx).Where("job_name = ?", name).Order("started_at DESC, id DESC").Limit(limit).Find(&runs).Error
+	return runs, err
+}
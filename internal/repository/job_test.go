@@ -0,0 +1,93 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"loan-service/enums"
+	"loan-service/internal/models"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+)
+
+func setupJobTestDB(t *testing.T) *gorm.DB {
+	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{})
+	assert.NoError(t, err)
+
+	err = db.AutoMigrate(&models.JobLease{}, &models.JobRun{})
+	assert.NoError(t, err)
+
+	return db
+}
+
+func TestJobRepository_AcquireJobLease(t *testing.T) {
+	db := setupJobTestDB(t)
+	repo := NewJobRepository(db)
+
+	ctx := context.Background()
+	now := time.Now()
+
+	acquired, err := repo.AcquireJobLease(ctx, "funding-expiry", "replica-a", now.Add(time.Minute), now)
+	assert.NoError(t, err)
+	assert.True(t, acquired)
+
+	acquired, err = repo.AcquireJobLease(ctx, "funding-expiry", "replica-b", now.Add(time.Minute), now)
+	assert.NoError(t, err)
+	assert.False(t, acquired, "lease is still held by replica-a")
+
+	acquired, err = repo.AcquireJobLease(ctx, "funding-expiry", "replica-a", now.Add(2*time.Minute), now)
+	assert.NoError(t, err)
+	assert.True(t, acquired, "holder can extend its own lease")
+
+	later := now.Add(3 * time.Minute)
+	acquired, err = repo.AcquireJobLease(ctx, "funding-expiry", "replica-b", later.Add(time.Minute), later)
+	assert.NoError(t, err)
+	assert.True(t, acquired, "expired lease can be taken over")
+}
+
+func TestJobRepository_ReleaseJobLease(t *testing.T) {
+	db := setupJobTestDB(t)
+	repo := NewJobRepository(db)
+
+	ctx := context.Background()
+	now := time.Now()
+
+	_, err := repo.AcquireJobLease(ctx, "reminder", "replica-a", now.Add(time.Hour), now)
+	assert.NoError(t, err)
+
+	assert.NoError(t, repo.ReleaseJobLease(ctx, "reminder", "replica-b"))
+	acquired, err := repo.AcquireJobLease(ctx, "reminder", "replica-b", now.Add(time.Hour), now)
+	assert.NoError(t, err)
+	assert.False(t, acquired, "only the holder can release a lease")
+
+	assert.NoError(t, repo.ReleaseJobLease(ctx, "reminder", "replica-a"))
+	acquired, err = repo.AcquireJobLease(ctx, "reminder", "replica-b", now.Add(time.Hour), now)
+	assert.NoError(t, err)
+	assert.True(t, acquired)
+}
+
+func TestJobRepository_JobRuns(t *testing.T) {
+	db := setupJobTestDB(t)
+	repo := NewJobRepository(db)
+
+	ctx := context.Background()
+	startedAt := time.Now()
+	run := &models.JobRun{JobName: "funding-expiry", Holder: "replica-a", Status: enums.JobRunStatusRunning, StartedAt: startedAt}
+	assert.NoError(t, repo.CreateJobRun(ctx, run))
+	assert.NotEmpty(t, run.UUID)
+
+	run.Status = enums.JobRunStatusFailed
+	run.Error = "database down"
+	run.FinishedAt = sql.NullTime{Time: startedAt.Add(time.Second), Valid: true}
+	assert.NoError(t, repo.FinishJobRun(ctx, run))
+
+	runs, err := repo.GetJobRunsByName(ctx, "funding-expiry", 10)
+	assert.NoError(t, err)
+	assert.Len(t, runs, 1)
+	assert.Equal(t, enums.JobRunStatusFailed, runs[0].Status)
+	assert.Equal(t, "database down", runs[0].Error)
+	assert.True(t, runs[0].FinishedAt.Valid)
+}
@@ -2,10 +2,13 @@ package repository
 
 import (
 	"context"
+	"loan-service/enums"
 	"loan-service/internal/models"
+	"time"
 
 	"github.com/google/uuid"
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
 type LoanRepository struct {
@@ -19,25 +22,62 @@ func NewLoanRepository(db *gorm.DB) *LoanRepository {
 	return &LoanRepository{db: db}
 }
 
-func (r *LoanRepository) BeginTransaction(ctx context.Context) (*gorm.DB, error) {
-	return r.db.WithContext(ctx).Begin(), nil
-}
-
-func (r *LoanRepository) Commit(ctx context.Context, db *gorm.DB) error {
-	return db.WithContext(ctx).Commit().Error
-}
-
-func (r *LoanRepository) Rollback(ctx context.Context, db *gorm.DB) error {
-	return db.WithContext(ctx).Rollback().Error
+func (r *LoanRepository) WithinTx(ctx context.Context, fn func(txRepo LoanRepositoryInterface) error) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		return fn(&LoanRepository{db: tx})
+	})
 }
 
+// CreateLoan persists the loan together with the transition into its initial
+// status and its first term sheet, so every loan's timeline and term history
+// start at the moment it was proposed.
 func (r *LoanRepository) CreateLoan(ctx context.Context, loan *models.Loan) error {
 	loan.UUID = uuid.New().String()
-	return r.db.WithContext(ctx).Create(loan).Error
+	loan.Version = 1
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(loan).Error; err != nil {
+			return err
+		}
+
+		txRepo := &LoanRepository{db: tx}
+		if err := txRepo.CreateLoanStatusTransition(ctx, &models.LoanStatusTransition{
+			LoanID:   loan.ID,
+			ToStatus: loan.Status,
+			Actor:    loan.BorrowerID,
+			At:       loan.CreatedAt,
+		}); err != nil {
+			return err
+		}
+
+		return txRepo.CreateLoanTermSheet(ctx, &models.LoanTermSheet{
+			LoanID:          loan.ID,
+			Version:         1,
+			PrincipalAmount: loan.PrincipalAmount,
+			InterestRate:    loan.InterestRate,
+			ROIRate:         loan.ROIRate,
+			Actor:           loan.BorrowerID,
+		})
+	})
 }
 
-func (r *LoanRepository) UpdateLoan(ctx context.Context, tx *gorm.DB, loan *models.Loan, fields []string) error {
-	return tx.WithContext(ctx).Model(loan).Select(fields).UpdateColumns(loan).Error
+// UpdateLoan writes the given fields only if the row still carries
+// loan.Version, and bumps the version on success. A stale loan yields
+// ErrVersionConflict and is left untouched.
+func (r *LoanRepository) UpdateLoan(ctx context.Context, loan *models.Loan, fields []string) error {
+	expectedVersion := loan.Version
+	loan.Version++
+
+	columns := append(append([]string{}, fields...), "version")
+	result := r.db.WithContext(ctx).Model(loan).Where("version = ?", expectedVersion).Select(columns).UpdateColumns(loan)
+	if result.Error != nil {
+		loan.Version = expectedVersion
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		loan.Version = expectedVersion
+		return ErrVersionConflict
+	}
+	return nil
 }
 
 func (r *LoanRepository) GetLoanByUUID(ctx context.Context, uuid string) (*models.Loan, error) {
@@ -49,30 +89,102 @@ func (r *LoanRepository) GetLoanByUUID(ctx context.Context, uuid string) (*model
 	return &loan, nil
 }
 
-func (r *LoanRepository) GetAllLoans(ctx context.Context) ([]models.Loan, error) {
+func (r *LoanRepository) GetLoanByID(ctx context.Context, id int) (*models.Loan, error) {
+	var loan models.Loan
+	err := r.db.WithContext(ctx).Where("id = ?", id).First(&loan).Error
+	if err != nil {
+		return nil, err
+	}
+	return &loan, nil
+}
+
+func (r *LoanRepository) GetAllLoans(ctx context.Context, filter LoanFilter) ([]models.Loan, error) {
+	query := r.db.WithContext(ctx)
+	if filter.DPDBucket != 0 {
+		query = query.Where("dpd_bucket = ?", filter.DPDBucket)
+	}
+
+	var loans []models.Loan
+	err := query.Find(&loans).Error
+	return loans, err
+}
+
+func (r *LoanRepository) GetLoansByStatuses(ctx context.Context, statuses []enums.LoanStatus) ([]models.Loan, error) {
+	var loans []models.Loan
+	err := r.db.WithContext(ctx).Where("status IN ?", statuses).Order("id ASC").Find(&loans).Error
+	return loans, err
+}
+
+func (r *LoanRepository) GetLoansByIDs(ctx context.Context, ids []int) ([]models.Loan, error) {
+	var loans []models.Loan
+	err := r.db.WithContext(ctx).Where("id IN ?", ids).Find(&loans).Error
+	return loans, err
+}
+
+func (r *LoanRepository) GetLoansByBorrowerID(ctx context.Context, borrowerID string, page Page) ([]models.Loan, int64, error) {
+	// Both queries filter on borrower_id alone so they can use
+	// idx_borrower_id, which also orders the matches by id.
+	var total int64
+	if err := r.db.WithContext(ctx).Model(&models.Loan{}).Where("borrower_id = ?", borrowerID).Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	var loans []models.Loan
+	err := r.db.WithContext(ctx).Where("borrower_id = ?", borrowerID).Order("id DESC").Limit(page.Limit).Offset(page.Offset).Find(&loans).Error
+	return loans, total, err
+}
+
+func (r *LoanRepository) GetBorrowerLoansByStatuses(ctx context.Context, borrowerID string, statuses []enums.LoanStatus) ([]models.Loan, error) {
+	var loans []models.Loan
+	err := r.db.WithContext(ctx).Where("borrower_id = ? AND status IN ?", borrowerID, statuses).Order("id ASC").Find(&loans).Error
+	return loans, err
+}
+
+func (r *LoanRepository) GetLatestBorrowerLoanTransition(ctx context.Context, borrowerID string, status enums.LoanStatus) (*models.LoanStatusTransition, error) {
+	var transitions []models.LoanStatusTransition
+	err := r.db.WithContext(ctx).
+		Joins("JOIN loans ON loans.id = loan_status_transitions.loan_id").
+		Where("loans.borrower_id = ? AND loan_status_transitions.to_status = ?", borrowerID, status).
+		Order("loan_status_transitions.at DESC, loan_status_transitions.id DESC").
+		Limit(1).
+		Find(&transitions).Error
+	if err != nil || len(transitions) == 0 {
+		return nil, err
+	}
+	return &transitions[0], nil
+}
+
+func (r *LoanRepository) LockBorrower(ctx context.Context, borrowerID string) error {
+	return r.lockRow(ctx, &models.BorrowerLock{BorrowerID: borrowerID}, "borrower_id = ?", borrowerID)
+}
+
+func (r *LoanRepository) GetLoansPastFundingDeadline(ctx context.Context, now time.Time) ([]models.Loan, error) {
 	var loans []models.Loan
-	err := r.db.WithContext(ctx).Find(&loans).Error
+	err := r.db.WithContext(ctx).
+		Where("status = ? AND funding_deadline < ?", enums.LoanStatusApproved, now).
+		Order("funding_deadline ASC").
+		Find(&loans).Error
 	return loans, err
 }
 
-func (r *LoanRepository) CreateLoanApproval(ctx context.Context, db *gorm.DB, loanApproval *models.LoanApproval) error {
+func (r *LoanRepository) CreateLoanApproval(ctx context.Context, loanApproval *models.LoanApproval) error {
 	loanApproval.UUID = uuid.New().String()
-	return db.WithContext(ctx).Create(loanApproval).Error
+	return r.db.WithContext(ctx).Create(loanApproval).Error
 }
 
-func (r *LoanRepository) CreateLoanApprovalValidator(ctx context.Context, db *gorm.DB, loanApprovalValidator *models.LoanApprovalValidator) error {
+func (r *LoanRepository) CreateLoanApprovalValidator(ctx context.Context, loanApprovalValidator *models.LoanApprovalValidator) error {
 	loanApprovalValidator.UUID = uuid.New().String()
-	return db.WithContext(ctx).Create(loanApprovalValidator).Error
+	return r.db.WithContext(ctx).Create(loanApprovalValidator).Error
 }
 
-func (r *LoanRepository) CreateLoanApprovalValidatorProof(ctx context.Context, db *gorm.DB, loanApprovalValidatorProof *models.LoanApprovalValidatorProof) error {
+func (r *LoanRepository) CreateLoanApprovalValidatorProof(ctx context.Context, loanApprovalValidatorProof *models.LoanApprovalValidatorProof) error {
 	loanApprovalValidatorProof.UUID = uuid.New().String()
-	return db.WithContext(ctx).Create(loanApprovalValidatorProof).Error
+	return r.db.WithContext(ctx).Create(loanApprovalValidatorProof).Error
 }
 
-func (r *LoanRepository) CreateInvestment(ctx context.Context, db *gorm.DB, investment *models.Investment) error {
+func (r *LoanRepository) CreateInvestment(ctx context.Context, investment *models.Investment) error {
 	investment.UUID = uuid.New().String()
-	return db.WithContext(ctx).Create(investment).Error
+	return r.db.WithContext(ctx).Create(investment).Error
 }
 
 func (r *LoanRepository) GetInvestmentsByLoanID(ctx context.Context, loanID int) ([]models.Investment, error) {
@@ -81,7 +193,270 @@ func (r *LoanRepository) GetInvestmentsByLoanID(ctx context.Context, loanID int)
 	return investments, err
 }
 
-func (r *LoanRepository) CreateLoanDisbursement(ctx context.Context, db *gorm.DB, loanDisbursement *models.LoanDisbursement) error {
+func (r *LoanRepository) GetInvestmentsByInvestorID(ctx context.Context, investorID string, statuses []enums.InvestmentStatus, page Page) ([]models.Investment, int64, error) {
+	// Filtering on investor_id first lets both queries use idx_investor_id;
+	// the index carries the primary key, so ordering by id needs no sort.
+	byInvestor := func(db *gorm.DB) *gorm.DB {
+		db = db.Where("investor_id = ?", investorID)
+		if len(statuses) > 0 {
+			db = db.Where("status IN ?", statuses)
+		}
+		return db
+	}
+
+	var total int64
+	if err := r.db.WithContext(ctx).Model(&models.Investment{}).Scopes(byInvestor).Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	var investments []models.Investment
+	err := r.db.WithContext(ctx).Scopes(byInvestor).Order("id DESC").Limit(page.Limit).Offset(page.Offset).Find(&investments).Error
+	return investments, total, err
+}
+
+func (r *LoanRepository) GetInvestmentByUUID(ctx context.Context, uuid string) (*models.Investment, error) {
+	var investments []models.Investment
+	err := r.db.WithContext(ctx).Where("uuid = ?", uuid).Limit(1).Find(&investments).Error
+	if err != nil || len(investments) == 0 {
+		return nil, err
+	}
+	return &investments[0], nil
+}
+
+func (r *LoanRepository) GetInvestmentsPastFundingExpiry(ctx context.Context, now time.Time) ([]models.Investment, error) {
+	var investments []models.Investment
+	err := r.db.WithContext(ctx).
+		Where("status = ? AND funding_expires_at < ?", enums.InvestmentStatusPendingFunding, now).
+		Order("funding_expires_at ASC").
+		Find(&investments).Error
+	return investments, err
+}
+
+func (r *LoanRepository) GetInvestorExposure(ctx context.Context, investorID string) (float64, error) {
+	var total float64
+	err := r.db.WithContext(ctx).Model(&models.Investment{}).
+		Joins("JOIN loans ON loans.id = investments.loan_id").
+		Where("investments.investor_id = ? AND investments.status IN ?", investorID,
+			[]enums.InvestmentStatus{enums.InvestmentStatusFunded, enums.InvestmentStatusPendingFunding}).
+		Where("loans.status NOT IN ?", []enums.LoanStatus{enums.LoanStatusRejected, enums.LoanStatusExpired, enums.LoanStatusWrittenOff, enums.LoanStatusRepaid}).
+		Select("COALESCE(SUM(investments.amount), 0)").
+		Scan(&total).Error
+	return total, err
+}
+
+func (r *LoanRepository) LockInvestor(ctx context.Context, investorID string) error {
+	return r.lockRow(ctx, &models.InvestorLock{InvestorID: investorID}, "investor_id = ?", investorID)
+}
+
+// lockRow creates the lock row if it doesn't exist yet and then selects it
+// FOR UPDATE, which holds it until the surrounding transaction ends.
+func (r *LoanRepository) lockRow(ctx context.Context, row interface{}, query string, id string) error {
+	if err := r.db.WithContext(ctx).Clauses(clause.OnConflict{DoNothing: true}).Create(row).Error; err != nil {
+		return err
+	}
+	return r.db.WithContext(ctx).Clauses(clause.Locking{Strength: "UPDATE"}).Where(query, id).Take(row).Error
+}
+
+func (r *LoanRepository) UpdateInvestment(ctx context.Context, investment *models.Investment, fields []string) error {
+	return r.db.WithContext(ctx).Model(investment).Select(fields).Updates(investment).Error
+}
+
+func (r *LoanRepository) RefundInvestmentsByLoanID(ctx context.Context, loanID int, refundedAt time.Time) error {
+	return r.db.WithContext(ctx).Model(&models.Investment{}).
+		Where("loan_id = ? AND status = ?", loanID, enums.InvestmentStatusFunded).
+		Updates(map[string]interface{}{
+			"status":      enums.InvestmentStatusRefunded,
+			"refunded_at": refundedAt,
+		}).Error
+}
+
+func (r *LoanRepository) CreateLoanDisbursement(ctx context.Context, loanDisbursement *models.LoanDisbursement) error {
 	loanDisbursement.UUID = uuid.New().String()
-	return db.WithContext(ctx).Create(loanDisbursement).Error
+	return r.db.WithContext(ctx).Create(loanDisbursement).Error
+}
+
+func (r *LoanRepository) CreateLoanDefault(ctx context.Context, loanDefault *models.LoanDefault) error {
+	loanDefault.UUID = uuid.New().String()
+	return r.db.WithContext(ctx).Create(loanDefault).Error
+}
+
+func (r *LoanRepository) CreateLoanDefaultEvidence(ctx context.Context, evidence *models.LoanDefaultEvidence) error {
+	evidence.UUID = uuid.New().String()
+	return r.db.WithContext(ctx).Create(evidence).Error
+}
+
+func (r *LoanRepository) GetLoanDisbursementsByLoanID(ctx context.Context, loanID int) ([]models.LoanDisbursement, error) {
+	var disbursements []models.LoanDisbursement
+	err := r.db.WithContext(ctx).Where("loan_id = ?", loanID).Order("disbursed_at ASC, id ASC").Find(&disbursements).Error
+	return disbursements, err
+}
+
+// GetLoanDisbursementByUUID returns nil without an error when no disbursement
+// has the given UUID.
+func (r *LoanRepository) GetLoanDisbursementByUUID(ctx context.Context, uuid string) (*models.LoanDisbursement, error) {
+	var disbursements []models.LoanDisbursement
+	err := r.db.WithContext(ctx).Where("uuid = ?", uuid).Limit(1).Find(&disbursements).Error
+	if err != nil || len(disbursements) == 0 {
+		return nil, err
+	}
+	return &disbursements[0], nil
+}
+
+func (r *LoanRepository) UpdateLoanDisbursement(ctx context.Context, loanDisbursement *models.LoanDisbursement, fields []string) error {
+	return r.db.WithContext(ctx).Model(loanDisbursement).Select(fields).Updates(loanDisbursement).Error
+}
+
+func (r *LoanRepository) CreateInvestorPayout(ctx context.Context, payout *models.InvestorPayout) error {
+	payout.UUID = uuid.New().String()
+	return r.db.WithContext(ctx).Create(payout).Error
+}
+
+func (r *LoanRepository) GetInvestorPayoutsByInvestmentIDs(ctx context.Context, investmentIDs []int) ([]models.InvestorPayout, error) {
+	var payouts []models.InvestorPayout
+	err := r.db.WithContext(ctx).Where("investment_id IN ?", investmentIDs).Order("paid_at ASC, id ASC").Find(&payouts).Error
+	return payouts, err
+}
+
+func (r *LoanRepository) CreateLoanStatusTransition(ctx context.Context, transition *models.LoanStatusTransition) error {
+	transition.UUID = uuid.New().String()
+	return r.db.WithContext(ctx).Create(transition).Error
+}
+
+func (r *LoanRepository) GetLoanStatusTransitionsByLoanID(ctx context.Context, loanID int) ([]models.LoanStatusTransition, error) {
+	var transitions []models.LoanStatusTransition
+	err := r.db.WithContext(ctx).Where("loan_id = ?", loanID).Order("at ASC, id ASC").Find(&transitions).Error
+	return transitions, err
+}
+
+func (r *LoanRepository) CreateLoanTermSheet(ctx context.Context, termSheet *models.LoanTermSheet) error {
+	termSheet.UUID = uuid.New().String()
+	return r.db.WithContext(ctx).Create(termSheet).Error
+}
+
+func (r *LoanRepository) GetLatestLoanTermSheet(ctx context.Context, loanID int) (*models.LoanTermSheet, error) {
+	var termSheet models.LoanTermSheet
+	err := r.db.WithContext(ctx).Where("loan_id = ?", loanID).Order("version DESC").First(&termSheet).Error
+	if err != nil {
+		return nil, err
+	}
+	return &termSheet, nil
+}
+
+func (r *LoanRepository) GetLoanTermSheetsByLoanID(ctx context.Context, loanID int) ([]models.LoanTermSheet, error) {
+	var termSheets []models.LoanTermSheet
+	err := r.db.WithContext(ctx).Where("loan_id = ?", loanID).Order("version ASC").Find(&termSheets).Error
+	return termSheets, err
+}
+
+func (r *LoanRepository) CreateLoanInstalments(ctx context.Context, instalments []models.LoanInstalment) error {
+	if len(instalments) == 0 {
+		return nil
+	}
+	for i := range instalments {
+		instalments[i].UUID = uuid.New().String()
+	}
+	return r.db.WithContext(ctx).Create(&instalments).Error
+}
+
+func (r *LoanRepository) GetLoanInstalmentsByLoanID(ctx context.Context, loanID int) ([]models.LoanInstalment, error) {
+	var instalments []models.LoanInstalment
+	err := r.db.WithContext(ctx).Where("loan_id = ?", loanID).Order("sequence ASC").Find(&instalments).Error
+	return instalments, err
+}
+
+func (r *LoanRepository) GetLoanInstalmentsByLoanIDs(ctx context.Context, loanIDs []int) ([]models.LoanInstalment, error) {
+	var instalments []models.LoanInstalment
+	err := r.db.WithContext(ctx).Where("loan_id IN ?", loanIDs).Order("loan_id ASC, sequence ASC").Find(&instalments).Error
+	return instalments, err
+}
+
+func (r *LoanRepository) UpdateLoanInstalment(ctx context.Context, instalment *models.LoanInstalment, fields []string) error {
+	return r.db.WithContext(ctx).Model(instalment).Select(fields).Updates(instalment).Error
+}
+
+func (r *LoanRepository) CreateLoanLedgerEntry(ctx context.Context, entry *models.LoanLedgerEntry) error {
+	entry.UUID = uuid.New().String()
+	return r.db.WithContext(ctx).Create(entry).Error
+}
+
+func (r *LoanRepository) GetLoanLedgerEntriesByLoanID(ctx context.Context, loanID int) ([]models.LoanLedgerEntry, error) {
+	var entries []models.LoanLedgerEntry
+	err := r.db.WithContext(ctx).Where("loan_id = ?", loanID).Order("effective_date ASC, id ASC").Find(&entries).Error
+	return entries, err
+}
+
+func (r *LoanRepository) GetLoanLedgerEntriesByLoanIDs(ctx context.Context, loanIDs []int, entryTypes []enums.LedgerEntryType) ([]models.LoanLedgerEntry, error) {
+	var entries []models.LoanLedgerEntry
+	err := r.db.WithContext(ctx).
+		Where("loan_id IN ? AND entry_type IN ?", loanIDs, entryTypes).
+		Order("loan_id ASC, effective_date ASC, id ASC").
+		Find(&entries).Error
+	return entries, err
+}
+
+func (r *LoanRepository) UpdateLoanLedgerEntry(ctx context.Context, entry *models.LoanLedgerEntry, fields []string) error {
+	return r.db.WithContext(ctx).Model(entry).Select(fields).Updates(entry).Error
+}
+
+func (r *LoanRepository) CreateLoanEscrowEntry(ctx context.Context, entry *models.LoanEscrowEntry) error {
+	entry.UUID = uuid.New().String()
+	return r.db.WithContext(ctx).Create(entry).Error
+}
+
+func (r *LoanRepository) GetLoanEscrowEntriesByLoanID(ctx context.Context, loanID int) ([]models.LoanEscrowEntry, error) {
+	var entries []models.LoanEscrowEntry
+	err := r.db.WithContext(ctx).Where("loan_id = ?", loanID).Order("effective_at ASC, id ASC").Find(&entries).Error
+	return entries, err
+}
+
+func (r *LoanRepository) GetLoanEscrowEntryByCollectionID(ctx context.Context, collectionID string) (*models.LoanEscrowEntry, error) {
+	var entries []models.LoanEscrowEntry
+	err := r.db.WithContext(ctx).Where("collection_id = ?", collectionID).Limit(1).Find(&entries).Error
+	if err != nil || len(entries) == 0 {
+		return nil, err
+	}
+	return &entries[0], nil
+}
+
+func (r *LoanRepository) CreateLoanInterestAccrual(ctx context.Context, accrual *models.LoanInterestAccrual) error {
+	accrual.UUID = uuid.New().String()
+	return r.db.WithContext(ctx).Create(accrual).Error
+}
+
+// GetLoanInterestAccruals returns the loan's accruals dated from through to,
+// both inclusive, in date order.
+func (r *LoanRepository) GetLoanInterestAccruals(ctx context.Context, loanID int, from, to time.Time) ([]models.LoanInterestAccrual, error) {
+	var accruals []models.LoanInterestAccrual
+	err := r.db.WithContext(ctx).
+		Where("loan_id = ? AND accrual_date >= ? AND accrual_date <= ?", loanID, from, to).
+		Order("accrual_date ASC").
+		Find(&accruals).Error
+	return accruals, err
+}
+
+func (r *LoanRepository) UpdateLoanInterestAccrual(ctx context.Context, accrual *models.LoanInterestAccrual, fields []string) error {
+	return r.db.WithContext(ctx).Model(accrual).Select(fields).Updates(accrual).Error
+}
+
+func (r *LoanRepository) CountLoansByStatus(ctx context.Context) (map[enums.LoanStatus]int64, error) {
+	var rows []struct {
+		Status enums.LoanStatus
+		Count  int64
+	}
+	err := r.db.WithContext(ctx).Model(&models.Loan{}).Select("status, COUNT(*) AS count").Group("status").Scan(&rows).Error
+	if err != nil {
+		return nil, err
+	}
+
+	counts := make(map[enums.LoanStatus]int64, len(rows))
+	for _, row := range rows {
+		counts[row.Status] = row.Count
+	}
+	return counts, nil
+}
+
+func (r *LoanRepository) GetTotalFundedAmount(ctx context.Context) (float64, error) {
+	var total float64
+	err := r.db.WithContext(ctx).Model(&models.Loan{}).Select("COALESCE(SUM(investment_amount), 0)").Scan(&total).Error
+	return total, err
 }
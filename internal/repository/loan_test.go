@@ -2,11 +2,13 @@ package repository
 
 import (
 	"context"
+	"loan-service/enums"
 	"loan-service/internal/models"
 	"testing"
 	"time"
 
 	"database/sql"
+	"errors"
 
 	"github.com/stretchr/testify/assert"
 	"gorm.io/driver/sqlite"
@@ -18,7 +20,10 @@ func setupTestDB(t *testing.T) *gorm.DB {
 	assert.NoError(t, err)
 
 	err = db.AutoMigrate(&models.Loan{}, &models.LoanApproval{}, &models.LoanApprovalValidator{},
-		&models.LoanApprovalValidatorProof{}, &models.Investment{}, &models.LoanDisbursement{})
+		&models.LoanApprovalValidatorProof{}, &models.Investment{}, &models.LoanDisbursement{},
+		&models.LoanStatusTransition{}, &models.LoanTermSheet{}, &models.LoanInstalment{}, &models.LoanLedgerEntry{},
+		&models.LoanDefault{}, &models.LoanDefaultEvidence{}, &models.InvestorPayout{}, &models.LoanInterestAccrual{}, &models.LoanEscrowEntry{},
+		&models.InvestorLock{}, &models.BorrowerLock{})
 	assert.NoError(t, err)
 
 	return db
@@ -99,7 +104,31 @@ func TestLoanRepository_GetAllLoans(t *testing.T) {
 	err = repo.CreateLoan(ctx, loan2)
 	assert.NoError(t, err)
 
-	loans, err := repo.GetAllLoans(ctx)
+	loans, err := repo.GetAllLoans(ctx, LoanFilter{})
+	assert.NoError(t, err)
+	assert.Len(t, loans, 2)
+}
+
+func TestLoanRepository_GetAllLoans_FiltersByDPDBucket(t *testing.T) {
+	db := setupTestDB(t)
+	repo := NewLoanRepository(db)
+
+	ctx := context.Background()
+	current := &models.Loan{BorrowerID: "user1", PrincipalAmount: 1000.0, InterestRate: 5, ROIRate: 3, Status: enums.LoanStatusDisbursed,
+		DPDBucket: enums.DPDBucketCurrent}
+	late := &models.Loan{BorrowerID: "user2", PrincipalAmount: 1000.0, InterestRate: 5, ROIRate: 3, Status: enums.LoanStatusDelinquent,
+		DaysPastDue: 45, DPDBucket: enums.DPDBucket31To60}
+	for _, loan := range []*models.Loan{current, late} {
+		assert.NoError(t, repo.CreateLoan(ctx, loan))
+	}
+
+	loans, err := repo.GetAllLoans(ctx, LoanFilter{DPDBucket: enums.DPDBucket31To60})
+	assert.NoError(t, err)
+	assert.Len(t, loans, 1)
+	assert.Equal(t, late.UUID, loans[0].UUID)
+	assert.Equal(t, 45, loans[0].DaysPastDue)
+
+	loans, err = repo.GetLoansByStatuses(ctx, []enums.LoanStatus{enums.LoanStatusDisbursed, enums.LoanStatusDelinquent})
 	assert.NoError(t, err)
 	assert.Len(t, loans, 2)
 }
@@ -119,19 +148,61 @@ func TestLoanRepository_UpdateLoan(t *testing.T) {
 	err := repo.CreateLoan(ctx, loan)
 	assert.NoError(t, err)
 
-	tx, err := repo.BeginTransaction(ctx)
+	err = repo.WithinTx(ctx, func(txRepo LoanRepositoryInterface) error {
+		loan.PrincipalAmount = 1500.0
+		return txRepo.UpdateLoan(ctx, loan, []string{"principal_amount"})
+	})
 	assert.NoError(t, err)
 
-	loan.PrincipalAmount = 1500.0
-	err = repo.UpdateLoan(ctx, tx, loan, []string{"principal_amount"})
+	retrievedLoan, err := repo.GetLoanByUUID(ctx, loan.UUID)
 	assert.NoError(t, err)
+	assert.Equal(t, 1500.0, retrievedLoan.PrincipalAmount)
+}
 
-	err = repo.Commit(ctx, tx)
+func TestLoanRepository_UpdateLoan_BumpsVersion(t *testing.T) {
+	db := setupTestDB(t)
+	repo := NewLoanRepository(db)
+
+	ctx := context.Background()
+	loan := &models.Loan{BorrowerID: "user123", PrincipalAmount: 1000.0, InterestRate: 5, ROIRate: 3}
+	assert.NoError(t, repo.CreateLoan(ctx, loan))
+	assert.Equal(t, 1, loan.Version)
+
+	loan.Status = enums.LoanStatusApproved
+	assert.NoError(t, repo.UpdateLoan(ctx, loan, []string{"status"}))
+	assert.Equal(t, 2, loan.Version)
+
+	retrievedLoan, err := repo.GetLoanByUUID(ctx, loan.UUID)
 	assert.NoError(t, err)
+	assert.Equal(t, 2, retrievedLoan.Version)
+	assert.Equal(t, enums.LoanStatusApproved, retrievedLoan.Status)
+}
+
+func TestLoanRepository_UpdateLoan_StaleVersionConflicts(t *testing.T) {
+	db := setupTestDB(t)
+	repo := NewLoanRepository(db)
+
+	ctx := context.Background()
+	loan := &models.Loan{BorrowerID: "user123", PrincipalAmount: 1000.0, InterestRate: 5, ROIRate: 3}
+	assert.NoError(t, repo.CreateLoan(ctx, loan))
+
+	first, err := repo.GetLoanByUUID(ctx, loan.UUID)
+	assert.NoError(t, err)
+	second, err := repo.GetLoanByUUID(ctx, loan.UUID)
+	assert.NoError(t, err)
+
+	first.PrincipalAmount = 1500.0
+	assert.NoError(t, repo.UpdateLoan(ctx, first, []string{"principal_amount"}))
+
+	second.PrincipalAmount = 2000.0
+	err = repo.UpdateLoan(ctx, second, []string{"principal_amount"})
+	assert.ErrorIs(t, err, ErrVersionConflict)
+	assert.Equal(t, 1, second.Version)
 
 	retrievedLoan, err := repo.GetLoanByUUID(ctx, loan.UUID)
 	assert.NoError(t, err)
 	assert.Equal(t, 1500.0, retrievedLoan.PrincipalAmount)
+	assert.Equal(t, 2, retrievedLoan.Version)
 }
 
 func TestLoanRepository_CreateLoanApproval(t *testing.T) {
@@ -139,21 +210,15 @@ func TestLoanRepository_CreateLoanApproval(t *testing.T) {
 	repo := NewLoanRepository(db)
 
 	ctx := context.Background()
-	tx, err := repo.BeginTransaction(ctx)
-	assert.NoError(t, err)
-
 	loanApproval := &models.LoanApproval{
 		LoanID:     1,
 		ApprovedAt: sql.NullTime{Time: time.Now(), Valid: true},
 	}
 
-	err = repo.CreateLoanApproval(ctx, tx, loanApproval)
+	err := repo.CreateLoanApproval(ctx, loanApproval)
 	assert.NoError(t, err)
 	assert.NotEmpty(t, loanApproval.UUID)
 	assert.NotZero(t, loanApproval.ID)
-
-	err = repo.Commit(ctx, tx)
-	assert.NoError(t, err)
 }
 
 func TestLoanRepository_CreateLoanApprovalValidator(t *testing.T) {
@@ -161,21 +226,15 @@ func TestLoanRepository_CreateLoanApprovalValidator(t *testing.T) {
 	repo := NewLoanRepository(db)
 
 	ctx := context.Background()
-	tx, err := repo.BeginTransaction(ctx)
-	assert.NoError(t, err)
-
 	validator := &models.LoanApprovalValidator{
 		LoanApprovalID: 1,
 		EmployeeID:     "emp123",
 	}
 
-	err = repo.CreateLoanApprovalValidator(ctx, tx, validator)
+	err := repo.CreateLoanApprovalValidator(ctx, validator)
 	assert.NoError(t, err)
 	assert.NotEmpty(t, validator.UUID)
 	assert.NotZero(t, validator.ID)
-
-	err = repo.Commit(ctx, tx)
-	assert.NoError(t, err)
 }
 
 func TestLoanRepository_CreateLoanApprovalValidatorProof(t *testing.T) {
@@ -183,22 +242,16 @@ func TestLoanRepository_CreateLoanApprovalValidatorProof(t *testing.T) {
 	repo := NewLoanRepository(db)
 
 	ctx := context.Background()
-	tx, err := repo.BeginTransaction(ctx)
-	assert.NoError(t, err)
-
 	proof := &models.LoanApprovalValidatorProof{
 		LoanApprovalValidatorID: 1,
 		ProofURL:                "https://example.com/proof.pdf",
 		Category:                "identity",
 	}
 
-	err = repo.CreateLoanApprovalValidatorProof(ctx, tx, proof)
+	err := repo.CreateLoanApprovalValidatorProof(ctx, proof)
 	assert.NoError(t, err)
 	assert.NotEmpty(t, proof.UUID)
 	assert.NotZero(t, proof.ID)
-
-	err = repo.Commit(ctx, tx)
-	assert.NoError(t, err)
 }
 
 func TestLoanRepository_CreateInvestment(t *testing.T) {
@@ -206,22 +259,16 @@ func TestLoanRepository_CreateInvestment(t *testing.T) {
 	repo := NewLoanRepository(db)
 
 	ctx := context.Background()
-	tx, err := repo.BeginTransaction(ctx)
-	assert.NoError(t, err)
-
 	investment := &models.Investment{
 		LoanID:     1,
 		InvestorID: "investor123",
 		Amount:     500.0,
 	}
 
-	err = repo.CreateInvestment(ctx, tx, investment)
+	err := repo.CreateInvestment(ctx, investment)
 	assert.NoError(t, err)
 	assert.NotEmpty(t, investment.UUID)
 	assert.NotZero(t, investment.ID)
-
-	err = repo.Commit(ctx, tx)
-	assert.NoError(t, err)
 }
 
 func TestLoanRepository_GetInvestmentsByLoanID(t *testing.T) {
@@ -229,9 +276,6 @@ func TestLoanRepository_GetInvestmentsByLoanID(t *testing.T) {
 	repo := NewLoanRepository(db)
 
 	ctx := context.Background()
-	tx, err := repo.BeginTransaction(ctx)
-	assert.NoError(t, err)
-
 	investment1 := &models.Investment{
 		LoanID:     1,
 		InvestorID: "investor1",
@@ -244,12 +288,9 @@ func TestLoanRepository_GetInvestmentsByLoanID(t *testing.T) {
 		Amount:     300.0,
 	}
 
-	err = repo.CreateInvestment(ctx, tx, investment1)
+	err := repo.CreateInvestment(ctx, investment1)
 	assert.NoError(t, err)
-	err = repo.CreateInvestment(ctx, tx, investment2)
-	assert.NoError(t, err)
-
-	err = repo.Commit(ctx, tx)
+	err = repo.CreateInvestment(ctx, investment2)
 	assert.NoError(t, err)
 
 	investments, err := repo.GetInvestmentsByLoanID(ctx, 1)
@@ -262,9 +303,6 @@ func TestLoanRepository_CreateLoanDisbursement(t *testing.T) {
 	repo := NewLoanRepository(db)
 
 	ctx := context.Background()
-	tx, err := repo.BeginTransaction(ctx)
-	assert.NoError(t, err)
-
 	disbursement := &models.LoanDisbursement{
 		LoanID:                   1,
 		FieldOfficerEmployeeID:   "emp123",
@@ -272,30 +310,795 @@ func TestLoanRepository_CreateLoanDisbursement(t *testing.T) {
 		DisbursedAt:              time.Now(),
 	}
 
-	err = repo.CreateLoanDisbursement(ctx, tx, disbursement)
+	err := repo.CreateLoanDisbursement(ctx, disbursement)
 	assert.NoError(t, err)
 	assert.NotEmpty(t, disbursement.UUID)
 	assert.NotZero(t, disbursement.ID)
+}
+
+func TestLoanRepository_LoanDisbursementPayouts(t *testing.T) {
+	db := setupTestDB(t)
+	repo := NewLoanRepository(db)
+
+	ctx := context.Background()
+	loan := &models.Loan{BorrowerID: "user1", PrincipalAmount: 1000.0, InterestRate: 10, ROIRate: 8, Status: enums.LoanStatusInvested}
+	assert.NoError(t, repo.CreateLoan(ctx, loan))
+
+	disbursement := &models.LoanDisbursement{LoanID: loan.ID, Amount: 1000, FieldOfficerEmployeeID: "emp123",
+		SignedAgreementLetterURL: "https://example.com/signed.pdf", AccountHolderName: "Budi Santoso", BankCode: "BCA",
+		BankAccountNumber: "1234567890", PayoutStatus: enums.PayoutStatusPending, DisbursedAt: time.Now()}
+	assert.NoError(t, repo.CreateLoanDisbursement(ctx, disbursement))
+
+	found, err := repo.GetLoanDisbursementByUUID(ctx, disbursement.UUID)
+	assert.NoError(t, err)
+	assert.Equal(t, enums.PayoutStatusPending, found.PayoutStatus)
+	assert.Nil(t, found.GatewayPayoutID)
+
+	payoutID := "payout-1"
+	found.GatewayPayoutID = &payoutID
+	found.PayoutStatus = enums.PayoutStatusSucceeded
+	found.FieldOfficerEmployeeID = "ignored"
+	assert.NoError(t, repo.UpdateLoanDisbursement(ctx, found, []string{"payout_status", "gateway_payout_id"}))
 
-	err = repo.Commit(ctx, tx)
+	found, err = repo.GetLoanDisbursementByUUID(ctx, disbursement.UUID)
 	assert.NoError(t, err)
+	assert.Equal(t, enums.PayoutStatusSucceeded, found.PayoutStatus)
+	assert.Equal(t, "payout-1", *found.GatewayPayoutID)
+	assert.Equal(t, "emp123", found.FieldOfficerEmployeeID)
+
+	missing, err := repo.GetLoanDisbursementByUUID(ctx, "unknown")
+	assert.NoError(t, err)
+	assert.Nil(t, missing)
+
+	byID, err := repo.GetLoanByID(ctx, loan.ID)
+	assert.NoError(t, err)
+	assert.Equal(t, loan.UUID, byID.UUID)
 }
 
-func TestLoanRepository_TransactionOperations(t *testing.T) {
+func TestLoanRepository_WithinTx_Commits(t *testing.T) {
 	db := setupTestDB(t)
 	repo := NewLoanRepository(db)
 
 	ctx := context.Background()
+	loan := &models.Loan{BorrowerID: "user123", PrincipalAmount: 1000.0, InterestRate: 5, ROIRate: 3}
+	assert.NoError(t, repo.CreateLoan(ctx, loan))
+
+	err := repo.WithinTx(ctx, func(txRepo LoanRepositoryInterface) error {
+		loan.Status = enums.LoanStatusApproved
+		if err := txRepo.UpdateLoan(ctx, loan, []string{"status"}); err != nil {
+			return err
+		}
+		return txRepo.CreateLoanApproval(ctx, &models.LoanApproval{LoanID: loan.ID})
+	})
+	assert.NoError(t, err)
 
-	tx, err := repo.BeginTransaction(ctx)
+	retrievedLoan, err := repo.GetLoanByUUID(ctx, loan.UUID)
 	assert.NoError(t, err)
+	assert.Equal(t, enums.LoanStatusApproved, retrievedLoan.Status)
+
+	var approvals int64
+	assert.NoError(t, db.Model(&models.LoanApproval{}).Count(&approvals).Error)
+	assert.Equal(t, int64(1), approvals)
+}
+
+func TestLoanRepository_WithinTx_RollsBackOnError(t *testing.T) {
+	db := setupTestDB(t)
+	repo := NewLoanRepository(db)
+
+	ctx := context.Background()
+	loan := &models.Loan{BorrowerID: "user123", PrincipalAmount: 1000.0, InterestRate: 5, ROIRate: 3}
+	assert.NoError(t, repo.CreateLoan(ctx, loan))
+
+	errUnitOfWork := errors.New("validator not allowed")
+	err := repo.WithinTx(ctx, func(txRepo LoanRepositoryInterface) error {
+		loan.PrincipalAmount = 5000.0
+		if err := txRepo.UpdateLoan(ctx, loan, []string{"principal_amount"}); err != nil {
+			return err
+		}
+		return errUnitOfWork
+	})
+	assert.ErrorIs(t, err, errUnitOfWork)
+
+	retrievedLoan, err := repo.GetLoanByUUID(ctx, loan.UUID)
+	assert.NoError(t, err)
+	assert.Equal(t, 1000.0, retrievedLoan.PrincipalAmount)
+}
+
+func TestLoanRepository_WithinTx_RollsBackAndRepanics(t *testing.T) {
+	db := setupTestDB(t)
+	repo := NewLoanRepository(db)
+
+	ctx := context.Background()
+	loan := &models.Loan{BorrowerID: "user123", PrincipalAmount: 1000.0, InterestRate: 5, ROIRate: 3}
+	assert.NoError(t, repo.CreateLoan(ctx, loan))
+
+	assert.PanicsWithValue(t, "boom", func() {
+		_ = repo.WithinTx(ctx, func(txRepo LoanRepositoryInterface) error {
+			loan.PrincipalAmount = 5000.0
+			if err := txRepo.UpdateLoan(ctx, loan, []string{"principal_amount"}); err != nil {
+				return err
+			}
+			panic("boom")
+		})
+	})
+
+	retrievedLoan, err := repo.GetLoanByUUID(ctx, loan.UUID)
+	assert.NoError(t, err)
+	assert.Equal(t, 1000.0, retrievedLoan.PrincipalAmount)
+}
+
+func TestLoanRepository_CreateLoan_RecordsInitialStatusTransition(t *testing.T) {
+	db := setupTestDB(t)
+	repo := NewLoanRepository(db)
+
+	ctx := context.Background()
+	loan := &models.Loan{
+		BorrowerID:      "user123",
+		PrincipalAmount: 1000.0,
+		InterestRate:    0.05,
+		ROIRate:         0.08,
+		Status:          enums.LoanStatusProposed,
+	}
+
+	err := repo.CreateLoan(ctx, loan)
+	assert.NoError(t, err)
+
+	transitions, err := repo.GetLoanStatusTransitionsByLoanID(ctx, loan.ID)
+	assert.NoError(t, err)
+	assert.Len(t, transitions, 1)
+	assert.Nil(t, transitions[0].FromStatus)
+	assert.Equal(t, enums.LoanStatusProposed, transitions[0].ToStatus)
+	assert.Equal(t, "user123", transitions[0].Actor)
+}
+
+func TestLoanRepository_GetLoanStatusTransitionsByLoanID(t *testing.T) {
+	db := setupTestDB(t)
+	repo := NewLoanRepository(db)
+
+	ctx := context.Background()
+	proposed := enums.LoanStatusProposed
+	approved := enums.LoanStatusApproved
+	now := time.Now()
+
+	err := repo.CreateLoanStatusTransition(ctx, &models.LoanStatusTransition{
+		LoanID:     1,
+		FromStatus: &approved,
+		ToStatus:   enums.LoanStatusInvested,
+		Actor:      "investor1",
+		At:         now,
+	})
+	assert.NoError(t, err)
+	err = repo.CreateLoanStatusTransition(ctx, &models.LoanStatusTransition{
+		LoanID:     1,
+		FromStatus: &proposed,
+		ToStatus:   enums.LoanStatusApproved,
+		Actor:      "emp123",
+		At:         now.Add(-time.Hour),
+	})
+	assert.NoError(t, err)
+	err = repo.CreateLoanStatusTransition(ctx, &models.LoanStatusTransition{
+		LoanID:   2,
+		ToStatus: enums.LoanStatusProposed,
+		Actor:    "user2",
+		At:       now,
+	})
+	assert.NoError(t, err)
+
+	transitions, err := repo.GetLoanStatusTransitionsByLoanID(ctx, 1)
+	assert.NoError(t, err)
+	assert.Len(t, transitions, 2)
+	assert.Equal(t, enums.LoanStatusApproved, transitions[0].ToStatus)
+	assert.Equal(t, enums.LoanStatusInvested, transitions[1].ToStatus)
+	assert.NotEmpty(t, transitions[0].UUID)
+}
+
+func TestLoanRepository_CountLoansByStatusAndTotalFundedAmount(t *testing.T) {
+	db := setupTestDB(t)
+	repo := NewLoanRepository(db)
+
+	ctx := context.Background()
+	loans := []*models.Loan{
+		{BorrowerID: "user1", PrincipalAmount: 1000.0, InterestRate: 5, ROIRate: 3, Status: enums.LoanStatusProposed},
+		{BorrowerID: "user2", PrincipalAmount: 2000.0, InterestRate: 5, ROIRate: 3, Status: enums.LoanStatusApproved, InvestmentAmount: 500.0},
+		{BorrowerID: "user3", PrincipalAmount: 3000.0, InterestRate: 5, ROIRate: 3, Status: enums.LoanStatusApproved, InvestmentAmount: 1250.0},
+		{BorrowerID: "user4", PrincipalAmount: 4000.0, InterestRate: 5, ROIRate: 3, Status: enums.LoanStatusExpired},
+	}
+	for _, loan := range loans {
+		assert.NoError(t, repo.CreateLoan(ctx, loan))
+	}
+
+	counts, err := repo.CountLoansByStatus(ctx)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(1), counts[enums.LoanStatusProposed])
+	assert.Equal(t, int64(2), counts[enums.LoanStatusApproved])
+	assert.Equal(t, int64(1), counts[enums.LoanStatusExpired])
+	assert.Zero(t, counts[enums.LoanStatusDisbursed])
+
+	total, err := repo.GetTotalFundedAmount(ctx)
+	assert.NoError(t, err)
+	assert.Equal(t, 1750.0, total)
+}
+
+func TestLoanRepository_WithinTx_ReadsSeeUncommittedWrites(t *testing.T) {
+	db := setupTestDB(t)
+	repo := NewLoanRepository(db)
+
+	ctx := context.Background()
+	loan := &models.Loan{BorrowerID: "user123", PrincipalAmount: 1000.0, InterestRate: 5, ROIRate: 3, Status: enums.LoanStatusApproved}
+	assert.NoError(t, repo.CreateLoan(ctx, loan))
+
+	errUnitOfWork := errors.New("abort after reads")
+	err := repo.WithinTx(ctx, func(txRepo LoanRepositoryInterface) error {
+		loan.InvestmentAmount = 400.0
+		if err := txRepo.UpdateLoan(ctx, loan, []string{"investment_amount"}); err != nil {
+			return err
+		}
+		if err := txRepo.CreateInvestment(ctx, &models.Investment{LoanID: loan.ID, InvestorID: "investor123", Amount: 400.0}); err != nil {
+			return err
+		}
+
+		txLoan, err := txRepo.GetLoanByUUID(ctx, loan.UUID)
+		assert.NoError(t, err)
+		assert.Equal(t, 400.0, txLoan.InvestmentAmount)
+
+		investments, err := txRepo.GetInvestmentsByLoanID(ctx, loan.ID)
+		assert.NoError(t, err)
+		assert.Len(t, investments, 1)
+
+		return errUnitOfWork
+	})
+	assert.ErrorIs(t, err, errUnitOfWork)
+
+	retrievedLoan, err := repo.GetLoanByUUID(ctx, loan.UUID)
+	assert.NoError(t, err)
+	assert.Equal(t, 0.0, retrievedLoan.InvestmentAmount)
+
+	investments, err := repo.GetInvestmentsByLoanID(ctx, loan.ID)
+	assert.NoError(t, err)
+	assert.Empty(t, investments)
+}
+
+func TestLoanRepository_WithinTx_NestedReadsSeeOuterWrites(t *testing.T) {
+	db := setupTestDB(t)
+	repo := NewLoanRepository(db)
+
+	ctx := context.Background()
+	loan := &models.Loan{BorrowerID: "user123", PrincipalAmount: 1000.0, InterestRate: 5, ROIRate: 3}
+	assert.NoError(t, repo.CreateLoan(ctx, loan))
+
+	err := repo.WithinTx(ctx, func(txRepo LoanRepositoryInterface) error {
+		loan.Status = enums.LoanStatusApproved
+		if err := txRepo.UpdateLoan(ctx, loan, []string{"status"}); err != nil {
+			return err
+		}
+
+		return txRepo.WithinTx(ctx, func(nestedRepo LoanRepositoryInterface) error {
+			nestedLoan, err := nestedRepo.GetLoanByUUID(ctx, loan.UUID)
+			if err != nil {
+				return err
+			}
+			assert.Equal(t, enums.LoanStatusApproved, nestedLoan.Status)
+			return nil
+		})
+	})
+	assert.NoError(t, err)
+}
+
+func TestLoanRepository_LoanTermSheets(t *testing.T) {
+	db := setupTestDB(t)
+	repo := NewLoanRepository(db)
+
+	ctx := context.Background()
+	loan := &models.Loan{BorrowerID: "user123", PrincipalAmount: 1000.0, InterestRate: 5, ROIRate: 3}
+	assert.NoError(t, repo.CreateLoan(ctx, loan))
+
+	initial, err := repo.GetLatestLoanTermSheet(ctx, loan.ID)
+	assert.NoError(t, err)
+	assert.Equal(t, 1, initial.Version)
+	assert.Equal(t, 1000.0, initial.PrincipalAmount)
+	assert.Equal(t, "user123", initial.Actor)
+
+	err = repo.CreateLoanTermSheet(ctx, &models.LoanTermSheet{
+		LoanID:          loan.ID,
+		Version:         2,
+		PrincipalAmount: 1200.0,
+		InterestRate:    5,
+		ROIRate:         3,
+		Actor:           "emp123",
+		Reason:          "borrower asked for more",
+	})
+	assert.NoError(t, err)
+
+	latest, err := repo.GetLatestLoanTermSheet(ctx, loan.ID)
+	assert.NoError(t, err)
+	assert.Equal(t, 2, latest.Version)
+	assert.Equal(t, 1200.0, latest.PrincipalAmount)
+
+	termSheets, err := repo.GetLoanTermSheetsByLoanID(ctx, loan.ID)
+	assert.NoError(t, err)
+	assert.Len(t, termSheets, 2)
+	assert.Equal(t, 1, termSheets[0].Version)
+	assert.Equal(t, 2, termSheets[1].Version)
+}
+
+func TestLoanRepository_GetLoansPastFundingDeadline(t *testing.T) {
+	db := setupTestDB(t)
+	repo := NewLoanRepository(db)
+
+	ctx := context.Background()
+	now := time.Now()
+	overdue := &models.Loan{BorrowerID: "user1", PrincipalAmount: 1000.0, InterestRate: 5, ROIRate: 3, Status: enums.LoanStatusApproved,
+		FundingDeadline: sql.NullTime{Time: now.Add(-time.Hour), Valid: true}}
+	open := &models.Loan{BorrowerID: "user2", PrincipalAmount: 1000.0, InterestRate: 5, ROIRate: 3, Status: enums.LoanStatusApproved,
+		FundingDeadline: sql.NullTime{Time: now.Add(time.Hour), Valid: true}}
+	invested := &models.Loan{BorrowerID: "user3", PrincipalAmount: 1000.0, InterestRate: 5, ROIRate: 3, Status: enums.LoanStatusInvested,
+		FundingDeadline: sql.NullTime{Time: now.Add(-time.Hour), Valid: true}}
+	for _, loan := range []*models.Loan{overdue, open, invested} {
+		assert.NoError(t, repo.CreateLoan(ctx, loan))
+	}
 
-	err = repo.Commit(ctx, tx)
+	loans, err := repo.GetLoansPastFundingDeadline(ctx, now)
 	assert.NoError(t, err)
+	assert.Len(t, loans, 1)
+	assert.Equal(t, overdue.UUID, loans[0].UUID)
+}
+
+func TestLoanRepository_RefundInvestmentsByLoanID(t *testing.T) {
+	db := setupTestDB(t)
+	repo := NewLoanRepository(db)
+
+	ctx := context.Background()
+	loan := &models.Loan{BorrowerID: "user1", PrincipalAmount: 1000.0, InterestRate: 5, ROIRate: 3, Status: enums.LoanStatusApproved}
+	assert.NoError(t, repo.CreateLoan(ctx, loan))
+	for _, investorID := range []string{"investor1", "investor2"} {
+		assert.NoError(t, repo.CreateInvestment(ctx, &models.Investment{LoanID: loan.ID, InvestorID: investorID, Amount: 100.0}))
+	}
+
+	refundedAt := time.Now()
+	assert.NoError(t, repo.RefundInvestmentsByLoanID(ctx, loan.ID, refundedAt))
+
+	investments, err := repo.GetInvestmentsByLoanID(ctx, loan.ID)
+	assert.NoError(t, err)
+	assert.Len(t, investments, 2)
+	for _, investment := range investments {
+		assert.Equal(t, enums.InvestmentStatusRefunded, investment.Status)
+		assert.True(t, investment.RefundedAt.Valid)
+	}
+}
+
+func TestLoanRepository_InvestmentReservationsAndEscrowEntries(t *testing.T) {
+	db := setupTestDB(t)
+	repo := NewLoanRepository(db)
+
+	ctx := context.Background()
+	now := time.Now()
+	loan := &models.Loan{BorrowerID: "user1", PrincipalAmount: 1000.0, InterestRate: 5, ROIRate: 3, Status: enums.LoanStatusApproved}
+	assert.NoError(t, repo.CreateLoan(ctx, loan))
+
+	expired := &models.Investment{LoanID: loan.ID, InvestorID: "investor1", Amount: 300, Status: enums.InvestmentStatusPendingFunding,
+		FundingExpiresAt: sql.NullTime{Time: now.Add(-time.Hour), Valid: true}}
+	open := &models.Investment{LoanID: loan.ID, InvestorID: "investor2", Amount: 200, Status: enums.InvestmentStatusPendingFunding,
+		FundingExpiresAt: sql.NullTime{Time: now.Add(time.Hour), Valid: true}}
+	funded := &models.Investment{LoanID: loan.ID, InvestorID: "investor3", Amount: 100, Status: enums.InvestmentStatusFunded,
+		FundingExpiresAt: sql.NullTime{Time: now.Add(-time.Hour), Valid: true}, FundedAt: sql.NullTime{Time: now, Valid: true}}
+	for _, investment := range []*models.Investment{expired, open, funded} {
+		assert.NoError(t, repo.CreateInvestment(ctx, investment))
+	}
+
+	pastExpiry, err := repo.GetInvestmentsPastFundingExpiry(ctx, now)
+	assert.NoError(t, err)
+	assert.Len(t, pastExpiry, 1)
+	assert.Equal(t, expired.UUID, pastExpiry[0].UUID)
+
+	found, err := repo.GetInvestmentByUUID(ctx, funded.UUID)
+	assert.NoError(t, err)
+	assert.Equal(t, enums.InvestmentStatusFunded, found.Status)
+	assert.True(t, found.FundedAt.Valid)
+
+	missing, err := repo.GetInvestmentByUUID(ctx, "unknown")
+	assert.NoError(t, err)
+	assert.Nil(t, missing)
+
+	collectionID := "collection-1"
+	release := &models.LoanEscrowEntry{LoanID: loan.ID, EntryType: enums.EscrowEntryTypeRelease, Amount: 100, BalanceAfter: 0, EffectiveAt: now.Add(time.Hour)}
+	deposit := &models.LoanEscrowEntry{LoanID: loan.ID, InvestmentID: &funded.ID, CollectionID: &collectionID, EntryType: enums.EscrowEntryTypeDeposit, Amount: 100, BalanceAfter: 100, EffectiveAt: now}
+	for _, entry := range []*models.LoanEscrowEntry{release, deposit} {
+		assert.NoError(t, repo.CreateLoanEscrowEntry(ctx, entry))
+		assert.NotEmpty(t, entry.UUID)
+	}
+
+	entries, err := repo.GetLoanEscrowEntriesByLoanID(ctx, loan.ID)
+	assert.NoError(t, err)
+	assert.Len(t, entries, 2)
+	assert.Equal(t, enums.EscrowEntryTypeDeposit, entries[0].EntryType)
+	assert.Equal(t, funded.ID, *entries[0].InvestmentID)
+	assert.Equal(t, enums.EscrowEntryTypeRelease, entries[1].EntryType)
+
+	booked, err := repo.GetLoanEscrowEntryByCollectionID(ctx, "collection-1")
+	assert.NoError(t, err)
+	assert.Equal(t, deposit.ID, booked.ID)
+
+	booked, err = repo.GetLoanEscrowEntryByCollectionID(ctx, "collection-2")
+	assert.NoError(t, err)
+	assert.Nil(t, booked)
+
+	// A collection can only ever be booked once.
+	duplicate := &models.LoanEscrowEntry{LoanID: loan.ID, InvestmentID: &funded.ID, CollectionID: &collectionID, EntryType: enums.EscrowEntryTypeDeposit, Amount: 100, BalanceAfter: 200, EffectiveAt: now}
+	assert.Error(t, repo.CreateLoanEscrowEntry(ctx, duplicate))
+}
+
+func TestLoanRepository_GetInvestorExposure(t *testing.T) {
+	db := setupTestDB(t)
+	repo := NewLoanRepository(db)
+
+	ctx := context.Background()
+	outstanding := &models.Loan{BorrowerID: "user1", PrincipalAmount: 1000.0, InterestRate: 5, ROIRate: 3, Status: enums.LoanStatusDisbursed}
+	open := &models.Loan{BorrowerID: "user2", PrincipalAmount: 1000.0, InterestRate: 5, ROIRate: 3, Status: enums.LoanStatusApproved}
+	repaid := &models.Loan{BorrowerID: "user3", PrincipalAmount: 1000.0, InterestRate: 5, ROIRate: 3, Status: enums.LoanStatusRepaid}
+	for _, loan := range []*models.Loan{outstanding, open, repaid} {
+		assert.NoError(t, repo.CreateLoan(ctx, loan))
+	}
+
+	for _, investment := range []*models.Investment{
+		{LoanID: outstanding.ID, InvestorID: "investor1", Amount: 300, Status: enums.InvestmentStatusFunded},
+		{LoanID: open.ID, InvestorID: "investor1", Amount: 200, Status: enums.InvestmentStatusPendingFunding},
+		{LoanID: open.ID, InvestorID: "investor1", Amount: 150, Status: enums.InvestmentStatusExpired},
+		{LoanID: repaid.ID, InvestorID: "investor1", Amount: 400, Status: enums.InvestmentStatusFunded},
+		{LoanID: outstanding.ID, InvestorID: "investor2", Amount: 700, Status: enums.InvestmentStatusFunded},
+	} {
+		assert.NoError(t, repo.CreateInvestment(ctx, investment))
+	}
+
+	exposure, err := repo.GetInvestorExposure(ctx, "investor1")
+	assert.NoError(t, err)
+	assert.Equal(t, 500.0, exposure)
+
+	exposure, err = repo.GetInvestorExposure(ctx, "investor3")
+	assert.NoError(t, err)
+	assert.Equal(t, 0.0, exposure)
+}
+
+func TestLoanRepository_LockInvestor(t *testing.T) {
+	db := setupTestDB(t)
+	repo := NewLoanRepository(db)
+
+	ctx := context.Background()
+	err := repo.WithinTx(ctx, func(txRepo LoanRepositoryInterface) error {
+		return txRepo.LockInvestor(ctx, "investor1")
+	})
+	assert.NoError(t, err)
+
+	// Taking the lock again reuses the row created the first time.
+	err = repo.WithinTx(ctx, func(txRepo LoanRepositoryInterface) error {
+		return txRepo.LockInvestor(ctx, "investor1")
+	})
+	assert.NoError(t, err)
+
+	var count int64
+	assert.NoError(t, db.Model(&models.InvestorLock{}).Where("investor_id = ?", "investor1").Count(&count).Error)
+	assert.Equal(t, int64(1), count)
+}
+
+func TestLoanRepository_LockBorrower(t *testing.T) {
+	db := setupTestDB(t)
+	repo := NewLoanRepository(db)
+
+	ctx := context.Background()
+	for i := 0; i < 2; i++ {
+		err := repo.WithinTx(ctx, func(txRepo LoanRepositoryInterface) error {
+			return txRepo.LockBorrower(ctx, "borrower1")
+		})
+		assert.NoError(t, err)
+	}
+
+	var count int64
+	assert.NoError(t, db.Model(&models.BorrowerLock{}).Where("borrower_id = ?", "borrower1").Count(&count).Error)
+	assert.Equal(t, int64(1), count)
+}
+
+func TestLoanRepository_LoanInstalmentsAndLedgerEntries(t *testing.T) {
+	db := setupTestDB(t)
+	repo := NewLoanRepository(db)
+
+	ctx := context.Background()
+	loan := &models.Loan{BorrowerID: "user1", PrincipalAmount: 1000.0, InterestRate: 5, ROIRate: 3, Status: enums.LoanStatusDisbursed}
+	assert.NoError(t, repo.CreateLoan(ctx, loan))
+
+	firstDue := time.Date(2026, time.November, 1, 0, 0, 0, 0, time.UTC)
+	instalments := []models.LoanInstalment{
+		{LoanID: loan.ID, Sequence: 2, DueDate: firstDue.AddDate(0, 1, 0), PrincipalDue: 500, InterestDue: 25},
+		{LoanID: loan.ID, Sequence: 1, DueDate: firstDue, PrincipalDue: 500, InterestDue: 25},
+	}
+	assert.NoError(t, repo.CreateLoanInstalments(ctx, instalments))
+	assert.NotEmpty(t, instalments[0].UUID)
+
+	stored, err := repo.GetLoanInstalmentsByLoanID(ctx, loan.ID)
+	assert.NoError(t, err)
+	assert.Len(t, stored, 2)
+	assert.Equal(t, 1, stored[0].Sequence)
+	assert.Equal(t, 525.0, stored[0].AmountDue())
+	assert.False(t, stored[0].IsSettled())
+
+	entry := &models.LoanLedgerEntry{
+		LoanID:           loan.ID,
+		LoanInstalmentID: &stored[0].ID,
+		EntryType:        enums.LedgerEntryTypeLateFee,
+		Amount:           10,
+		EffectiveDate:    firstDue.AddDate(0, 0, 3),
+		Description:      "late fee",
+	}
+	assert.NoError(t, repo.CreateLoanLedgerEntry(ctx, entry))
+	assert.NotEmpty(t, entry.UUID)
+
+	entries, err := repo.GetLoanLedgerEntriesByLoanID(ctx, loan.ID)
+	assert.NoError(t, err)
+	assert.Len(t, entries, 1)
+	assert.Equal(t, enums.LedgerEntryTypeLateFee, entries[0].EntryType)
+	assert.Equal(t, stored[0].ID, *entries[0].LoanInstalmentID)
+}
+
+func TestLoanRepository_CreateLoanDefaultAndUpdateInvestment(t *testing.T) {
+	db := setupTestDB(t)
+	repo := NewLoanRepository(db)
+
+	ctx := context.Background()
+	loan := &models.Loan{BorrowerID: "user1", PrincipalAmount: 1000.0, InterestRate: 5, ROIRate: 3, Status: enums.LoanStatusDelinquent}
+	assert.NoError(t, repo.CreateLoan(ctx, loan))
+
+	loanDefault := &models.LoanDefault{LoanID: loan.ID, EmployeeID: "emp123", Reason: "borrower unreachable", DeclaredAt: time.Now()}
+	assert.NoError(t, repo.CreateLoanDefault(ctx, loanDefault))
+	assert.NotEmpty(t, loanDefault.UUID)
+
+	evidence := &models.LoanDefaultEvidence{LoanDefaultID: loanDefault.ID, EvidenceURL: "https://example.com/visit-report.pdf", Category: "field_visit"}
+	assert.NoError(t, repo.CreateLoanDefaultEvidence(ctx, evidence))
+	assert.NotEmpty(t, evidence.UUID)
+
+	investment := &models.Investment{LoanID: loan.ID, InvestorID: "investor1", Amount: 400.0}
+	assert.NoError(t, repo.CreateInvestment(ctx, investment))
+
+	investment.RealizedLoss = 250.5
+	investment.Amount = 1
+	assert.NoError(t, repo.UpdateInvestment(ctx, investment, []string{"realized_loss"}))
+
+	investments, err := repo.GetInvestmentsByLoanID(ctx, loan.ID)
+	assert.NoError(t, err)
+	assert.Len(t, investments, 1)
+	assert.Equal(t, 250.5, investments[0].RealizedLoss)
+	assert.Equal(t, 400.0, investments[0].Amount)
+}
+
+func TestLoanRepository_PrepaymentRecords(t *testing.T) {
+	db := setupTestDB(t)
+	repo := NewLoanRepository(db)
+
+	ctx := context.Background()
+	loan := &models.Loan{BorrowerID: "user1", PrincipalAmount: 1000.0, InterestRate: 5, ROIRate: 3, Status: enums.LoanStatusDisbursed}
+	assert.NoError(t, repo.CreateLoan(ctx, loan))
+
+	disbursedAt := time.Date(2026, time.January, 15, 0, 0, 0, 0, time.UTC)
+	assert.NoError(t, repo.CreateLoanDisbursement(ctx, &models.LoanDisbursement{LoanID: loan.ID, Amount: 1000, FieldOfficerEmployeeID: "emp123",
+		SignedAgreementLetterURL: "https://example.com/signed.pdf", DisbursedAt: disbursedAt}))
+	disbursements, err := repo.GetLoanDisbursementsByLoanID(ctx, loan.ID)
+	assert.NoError(t, err)
+	assert.Len(t, disbursements, 1)
+	assert.True(t, disbursements[0].DisbursedAt.Equal(disbursedAt))
+	assert.Equal(t, 1000.0, disbursements[0].Amount)
+
+	instalments := []models.LoanInstalment{
+		{LoanID: loan.ID, Sequence: 1, DueDate: disbursedAt.AddDate(0, 1, 0), PrincipalDue: 1000, InterestDue: 50},
+	}
+	assert.NoError(t, repo.CreateLoanInstalments(ctx, instalments))
+	instalments[0].CancelledAt = sql.NullTime{Time: time.Now(), Valid: true}
+	assert.NoError(t, repo.UpdateLoanInstalment(ctx, &instalments[0], []string{"cancelled_at"}))
+
+	stored, err := repo.GetLoanInstalmentsByLoanID(ctx, loan.ID)
+	assert.NoError(t, err)
+	assert.True(t, stored[0].CancelledAt.Valid)
+	assert.True(t, stored[0].IsSettled())
+
+	investment := &models.Investment{LoanID: loan.ID, InvestorID: "investor1", Amount: 1000.0}
+	assert.NoError(t, repo.CreateInvestment(ctx, investment))
+	payout := &models.InvestorPayout{LoanID: loan.ID, InvestmentID: investment.ID, InvestorID: "investor1",
+		PrincipalAmount: 1000, InterestAmount: 12.5, PaidAt: time.Now()}
+	assert.NoError(t, repo.CreateInvestorPayout(ctx, payout))
+	assert.NotEmpty(t, payout.UUID)
+	assert.Equal(t, 1012.5, payout.Amount())
+}
+
+func TestLoanRepository_InvestorPortfolioQueries(t *testing.T) {
+	db := setupTestDB(t)
+	repo := NewLoanRepository(db)
+
+	ctx := context.Background()
+	first := &models.Loan{BorrowerID: "user1", PrincipalAmount: 1000.0, InterestRate: 5, ROIRate: 3, Status: enums.LoanStatusDisbursed}
+	second := &models.Loan{BorrowerID: "user2", PrincipalAmount: 1000.0, InterestRate: 5, ROIRate: 3, Status: enums.LoanStatusApproved}
+	for _, loan := range []*models.Loan{first, second} {
+		assert.NoError(t, repo.CreateLoan(ctx, loan))
+	}
+
+	investments := []*models.Investment{
+		{LoanID: first.ID, InvestorID: "investor1", Amount: 100, Status: enums.InvestmentStatusFunded},
+		{LoanID: second.ID, InvestorID: "investor1", Amount: 200, Status: enums.InvestmentStatusPendingFunding},
+		{LoanID: second.ID, InvestorID: "investor1", Amount: 300, Status: enums.InvestmentStatusFunded},
+		{LoanID: first.ID, InvestorID: "investor2", Amount: 400, Status: enums.InvestmentStatusFunded},
+	}
+	for _, investment := range investments {
+		assert.NoError(t, repo.CreateInvestment(ctx, investment))
+	}
+
+	page, total, err := repo.GetInvestmentsByInvestorID(ctx, "investor1", nil, Page{Limit: 2, Offset: 0})
+	assert.NoError(t, err)
+	assert.Equal(t, int64(3), total)
+	assert.Len(t, page, 2)
+	assert.Equal(t, investments[2].UUID, page[0].UUID)
+	assert.Equal(t, investments[1].UUID, page[1].UUID)
+
+	page, total, err = repo.GetInvestmentsByInvestorID(ctx, "investor1", nil, Page{Limit: 2, Offset: 2})
+	assert.NoError(t, err)
+	assert.Equal(t, int64(3), total)
+	assert.Len(t, page, 1)
+	assert.Equal(t, investments[0].UUID, page[0].UUID)
+
+	funded, total, err := repo.GetInvestmentsByInvestorID(ctx, "investor1", []enums.InvestmentStatus{enums.InvestmentStatusFunded}, Page{Limit: 10})
+	assert.NoError(t, err)
+	assert.Equal(t, int64(2), total)
+	assert.Len(t, funded, 2)
+
+	loans, err := repo.GetLoansByIDs(ctx, []int{first.ID, second.ID, first.ID})
+	assert.NoError(t, err)
+	assert.Len(t, loans, 2)
+
+	assert.NoError(t, repo.CreateLoanInstalments(ctx, []models.LoanInstalment{
+		{LoanID: first.ID, Sequence: 2, DueDate: time.Now().AddDate(0, 2, 0), PrincipalDue: 500, InterestDue: 25},
+		{LoanID: first.ID, Sequence: 1, DueDate: time.Now().AddDate(0, 1, 0), PrincipalDue: 500, InterestDue: 25},
+		{LoanID: second.ID, Sequence: 1, DueDate: time.Now().AddDate(0, 1, 0), PrincipalDue: 1000, InterestDue: 50},
+	}))
+	instalments, err := repo.GetLoanInstalmentsByLoanIDs(ctx, []int{first.ID})
+	assert.NoError(t, err)
+	assert.Len(t, instalments, 2)
+	assert.Equal(t, 1, instalments[0].Sequence)
+
+	for _, investment := range []*models.Investment{investments[0], investments[3]} {
+		assert.NoError(t, repo.CreateInvestorPayout(ctx, &models.InvestorPayout{LoanID: first.ID, InvestmentID: investment.ID,
+			InvestorID: investment.InvestorID, PrincipalAmount: 50, InterestAmount: 5, PaidAt: time.Now()}))
+	}
+	payouts, err := repo.GetInvestorPayoutsByInvestmentIDs(ctx, []int{investments[0].ID, investments[2].ID})
+	assert.NoError(t, err)
+	assert.Len(t, payouts, 1)
+	assert.Equal(t, investments[0].ID, payouts[0].InvestmentID)
+}
+
+func TestLoanRepository_BorrowerDashboardQueries(t *testing.T) {
+	db := setupTestDB(t)
+	repo := NewLoanRepository(db)
+
+	ctx := context.Background()
+	loans := []*models.Loan{
+		{BorrowerID: "borrower1", PrincipalAmount: 1000.0, InterestRate: 5, ROIRate: 3, Status: enums.LoanStatusRepaid},
+		{BorrowerID: "borrower2", PrincipalAmount: 1000.0, InterestRate: 5, ROIRate: 3, Status: enums.LoanStatusProposed},
+		{BorrowerID: "borrower1", PrincipalAmount: 2000.0, InterestRate: 5, ROIRate: 3, Status: enums.LoanStatusDisbursed},
+		{BorrowerID: "borrower1", PrincipalAmount: 3000.0, InterestRate: 5, ROIRate: 3, Status: enums.LoanStatusProposed},
+	}
+	for _, loan := range loans {
+		assert.NoError(t, repo.CreateLoan(ctx, loan))
+	}
+
+	page, total, err := repo.GetLoansByBorrowerID(ctx, "borrower1", Page{Limit: 2, Offset: 0})
+	assert.NoError(t, err)
+	assert.Equal(t, int64(3), total)
+	assert.Len(t, page, 2)
+	assert.Equal(t, loans[3].UUID, page[0].UUID)
+	assert.Equal(t, loans[2].UUID, page[1].UUID)
+
+	page, total, err = repo.GetLoansByBorrowerID(ctx, "borrower1", Page{Limit: 2, Offset: 2})
+	assert.NoError(t, err)
+	assert.Equal(t, int64(3), total)
+	assert.Len(t, page, 1)
+	assert.Equal(t, loans[0].UUID, page[0].UUID)
+
+	page, total, err = repo.GetLoansByBorrowerID(ctx, "borrower3", Page{Limit: 2})
+	assert.NoError(t, err)
+	assert.Equal(t, int64(0), total)
+	assert.Empty(t, page)
+
+	today := time.Now()
+	entries := []*models.LoanLedgerEntry{
+		{LoanID: loans[2].ID, EntryType: enums.LedgerEntryTypeLateFee, Amount: 15, EffectiveDate: today, Description: "late fee"},
+		{LoanID: loans[2].ID, EntryType: enums.LedgerEntryTypeInterestAccrual, Amount: 1, EffectiveDate: today, Description: "interest accrual"},
+		{LoanID: loans[0].ID, EntryType: enums.LedgerEntryTypeLateFee, Amount: 10, EffectiveDate: today.AddDate(0, 0, -1), Description: "late fee"},
+		{LoanID: loans[1].ID, EntryType: enums.LedgerEntryTypeLateFee, Amount: 20, EffectiveDate: today, Description: "late fee"},
+	}
+	for _, entry := range entries {
+		assert.NoError(t, repo.CreateLoanLedgerEntry(ctx, entry))
+	}
+
+	lateFees, err := repo.GetLoanLedgerEntriesByLoanIDs(ctx, []int{loans[0].ID, loans[2].ID}, []enums.LedgerEntryType{enums.LedgerEntryTypeLateFee})
+	assert.NoError(t, err)
+	assert.Len(t, lateFees, 2)
+	assert.Equal(t, entries[2].UUID, lateFees[0].UUID)
+	assert.Equal(t, entries[0].UUID, lateFees[1].UUID)
+}
+
+func TestLoanRepository_BorrowerPolicyQueries(t *testing.T) {
+	db := setupTestDB(t)
+	repo := NewLoanRepository(db)
+
+	ctx := context.Background()
+	loans := []*models.Loan{
+		{BorrowerID: "borrower1", PrincipalAmount: 1000.0, InterestRate: 5, ROIRate: 3, Status: enums.LoanStatusExpired},
+		{BorrowerID: "borrower1", PrincipalAmount: 2000.0, InterestRate: 5, ROIRate: 3, Status: enums.LoanStatusDisbursed},
+		{BorrowerID: "borrower1", PrincipalAmount: 3000.0, InterestRate: 5, ROIRate: 3, Status: enums.LoanStatusExpired},
+		{BorrowerID: "borrower2", PrincipalAmount: 4000.0, InterestRate: 5, ROIRate: 3, Status: enums.LoanStatusProposed},
+	}
+	for _, loan := range loans {
+		assert.NoError(t, repo.CreateLoan(ctx, loan))
+	}
+
+	open, err := repo.GetBorrowerLoansByStatuses(ctx, "borrower1", []enums.LoanStatus{enums.LoanStatusProposed, enums.LoanStatusDisbursed})
+	assert.NoError(t, err)
+	assert.Len(t, open, 1)
+	assert.Equal(t, loans[1].UUID, open[0].UUID)
+
+	rejection, err := repo.GetLatestBorrowerLoanTransition(ctx, "borrower1", enums.LoanStatusRejected)
+	assert.NoError(t, err)
+	assert.Nil(t, rejection)
+
+	proposed := enums.LoanStatusProposed
+	rejectedAt := time.Date(2026, time.March, 1, 0, 0, 0, 0, time.UTC)
+	transitions := []*models.LoanStatusTransition{
+		{LoanID: loans[0].ID, FromStatus: &proposed, ToStatus: enums.LoanStatusRejected, Actor: "emp1", At: rejectedAt.AddDate(0, 1, 0)},
+		{LoanID: loans[2].ID, FromStatus: &proposed, ToStatus: enums.LoanStatusRejected, Actor: "emp1", At: rejectedAt},
+		{LoanID: loans[1].ID, FromStatus: &proposed, ToStatus: enums.LoanStatusApproved, Actor: "emp1", At: rejectedAt.AddDate(0, 2, 0)},
+		{LoanID: loans[3].ID, FromStatus: &proposed, ToStatus: enums.LoanStatusRejected, Actor: "emp1", At: rejectedAt.AddDate(0, 3, 0)},
+	}
+	for _, transition := range transitions {
+		assert.NoError(t, repo.CreateLoanStatusTransition(ctx, transition))
+	}
+
+	rejection, err = repo.GetLatestBorrowerLoanTransition(ctx, "borrower1", enums.LoanStatusRejected)
+	assert.NoError(t, err)
+	if assert.NotNil(t, rejection) {
+		assert.Equal(t, transitions[0].UUID, rejection.UUID)
+	}
+}
+
+func TestLoanRepository_LoanInterestAccruals(t *testing.T) {
+	db := setupTestDB(t)
+	repo := NewLoanRepository(db)
+
+	ctx := context.Background()
+	loan := &models.Loan{BorrowerID: "user1", PrincipalAmount: 1000.0, InterestRate: 36.5, ROIRate: 20, Status: enums.LoanStatusDisbursed}
+	assert.NoError(t, repo.CreateLoan(ctx, loan))
+
+	firstDay := time.Date(2026, time.January, 1, 0, 0, 0, 0, time.UTC)
+	for i := 0; i < 3; i++ {
+		day := firstDay.AddDate(0, 0, i)
+		entry := &models.LoanLedgerEntry{LoanID: loan.ID, EntryType: enums.LedgerEntryTypeInterestAccrual, Amount: 1, EffectiveDate: day, Description: "interest accrual (ACT/365)"}
+		assert.NoError(t, repo.CreateLoanLedgerEntry(ctx, entry))
+		accrual := &models.LoanInterestAccrual{LoanID: loan.ID, LoanLedgerEntryID: entry.ID, AccrualDate: day,
+			DayCountConvention: enums.DayCountConventionAct365, PrincipalAmount: 1000, InterestRate: 36.5, Amount: 1}
+		assert.NoError(t, repo.CreateLoanInterestAccrual(ctx, accrual))
+		assert.NotEmpty(t, accrual.UUID)
+	}
+
+	accruals, err := repo.GetLoanInterestAccruals(ctx, loan.ID, firstDay.AddDate(0, 0, 1), firstDay.AddDate(0, 0, 5))
+	assert.NoError(t, err)
+	assert.Len(t, accruals, 2)
+	assert.True(t, accruals[0].AccrualDate.Equal(firstDay.AddDate(0, 0, 1)))
+
+	accruals[0].Amount = 1.013889
+	accruals[0].DayCountConvention = enums.DayCountConventionAct360
+	assert.NoError(t, repo.UpdateLoanInterestAccrual(ctx, &accruals[0], []string{"amount", "day_count_convention"}))
+	assert.NoError(t, repo.UpdateLoanLedgerEntry(ctx, &models.LoanLedgerEntry{ID: accruals[0].LoanLedgerEntryID, Amount: 1.02}, []string{"amount"}))
 
-	tx2, err := repo.BeginTransaction(ctx)
+	accruals, err = repo.GetLoanInterestAccruals(ctx, loan.ID, firstDay.AddDate(0, 0, 1), firstDay.AddDate(0, 0, 1))
 	assert.NoError(t, err)
+	assert.Len(t, accruals, 1)
+	assert.Equal(t, 1.013889, accruals[0].Amount)
+	assert.Equal(t, enums.DayCountConventionAct360, accruals[0].DayCountConvention)
 
-	err = repo.Rollback(ctx, tx2)
+	entries, err := repo.GetLoanLedgerEntriesByLoanID(ctx, loan.ID)
 	assert.NoError(t, err)
+	assert.Equal(t, 1.02, entries[1].Amount)
+	assert.Equal(t, "interest accrual (ACT/365)", entries[1].Description)
 }
@@ -1,16 +1,23 @@
 package server
 
 import (
+	"context"
+	"errors"
 	"fmt"
+	"net"
 	"net/http"
+	"time"
 
 	"loan-service/internal/client"
 	"loan-service/internal/config"
 	"loan-service/internal/database"
 	"loan-service/internal/handlers"
+	"loan-service/internal/jobs"
+	"loan-service/internal/metrics"
 	"loan-service/internal/middleware"
 	"loan-service/internal/repository"
 	"loan-service/internal/service"
+	"loan-service/internal/tracing"
 
 	"github.com/go-playground/validator/v10"
 	"github.com/gorilla/mux"
@@ -21,62 +28,207 @@ type Server struct {
 	config        *config.Config
 	logger        *logrus.Logger
 	db            *database.Database
+	metrics       *metrics.Metrics
 	loanHandler   handlers.LoanHandlerInterface
 	healthHandler handlers.HealthHandlerInterface
+	scheduler     *jobs.Scheduler
+	shutdownHooks []shutdownHook
 }
 
-func New(cfg *config.Config) *Server {
-	logger := logrus.New()
-	logger.SetFormatter(&logrus.JSONFormatter{})
+type shutdownHook struct {
+	name string
+	fn   func(ctx context.Context) error
+}
 
-	db, err := database.New(cfg)
+func New(cfg *config.Config, logger *logrus.Logger) *Server {
+	shutdownTracing, err := tracing.Setup(context.Background(), cfg.Tracing)
+	if err != nil {
+		logger.Fatal("Failed to initialize tracing:", err)
+	}
+
+	db, err := database.New(cfg, logger)
 	if err != nil {
 		logger.Fatal("Failed to initialize database:", err)
 	}
 
+	appMetrics := metrics.New()
+	if sqlDB, err := db.DB.DB(); err == nil {
+		appMetrics.RegisterDBStats(sqlDB, cfg.Database.DBName)
+	}
+
 	loanRepo := repository.NewLoanRepository(db.DB)
-	notificationClient := client.NewNotificationClient(&cfg.Notification)
-	loanService := service.NewLoanService(loanRepo, notificationClient)
+	appMetrics.RegisterLoanStats(loanRepo)
+	notificationClient := appMetrics.InstrumentNotificationClient(client.NewNotificationClient(&cfg.Notification))
+	loanService := service.NewTracedLoanService(service.NewLoanService(loanRepo, notificationClient, client.NewPaymentGatewayClient(&cfg.Payment), cfg.Loan))
 	validator := validator.New()
 	loanHandler := handlers.NewLoanHandler(loanService, validator)
-	healthHandler := handlers.NewHealthHandler()
-
-	return &Server{
+	healthChecks := []handlers.HealthCheck{
+		{Name: "database", Critical: true, Check: db.Ping},
+		{Name: "migrations", Critical: true, Check: db.CheckMigrations},
+	}
+	if cfg.Health.NotificationProbeEnabled {
+		healthChecks = append(healthChecks, handlers.HealthCheck{Name: "notification", Critical: false, Check: notificationClient.Ping})
+	}
+	healthHandler := handlers.NewHealthHandler(cfg.Health.CheckTimeout, healthChecks...)
+
+	scheduler := jobs.New(repository.NewJobRepository(db.DB), logger)
+	scheduler.Every("funding-expiry", cfg.Jobs.FundingExpiry, func(ctx context.Context) error {
+		_, err := loanService.ExpireOverdueLoans(ctx)
+		return err
+	})
+	scheduler.Every("reservation-expiry", cfg.Jobs.ReservationExpiry, func(ctx context.Context) error {
+		_, err := loanService.ExpireInvestmentReservations(ctx)
+		return err
+	})
+	scheduler.Every("delinquency", cfg.Jobs.Delinquency, func(ctx context.Context) error {
+		_, err := loanService.RefreshDelinquency(ctx)
+		return err
+	})
+	scheduler.Every("interest-accrual", cfg.Jobs.InterestAccrual, func(ctx context.Context) error {
+		now := time.Now()
+		_, err := loanService.AccrueInterest(ctx, now.Add(-cfg.Loan.InterestAccrualLookback), now.AddDate(0, 0, -1))
+		return err
+	})
+
+	srv := &Server{
 		config:        cfg,
 		logger:        logger,
 		db:            db,
+		metrics:       appMetrics,
 		loanHandler:   loanHandler,
 		healthHandler: healthHandler,
+		scheduler:     scheduler,
 	}
+
+	srv.OnShutdown("tracing", shutdownTracing)
+	srv.OnShutdown("database", func(_ context.Context) error {
+		return db.Close()
+	})
+
+	return srv
+}
+
+// OnShutdown registers a hook that runs once the HTTP server has drained its
+// in-flight requests. Hooks run in reverse registration order, so components
+// registered later (such as background workers) stop before the dependencies
+// they were built on (such as the database pool).
+func (s *Server) OnShutdown(name string, fn func(ctx context.Context) error) {
+	s.shutdownHooks = append(s.shutdownHooks, shutdownHook{name: name, fn: fn})
 }
 
 func (s *Server) setupRoutes() http.Handler {
 	router := mux.NewRouter()
 
+	router.Use(middleware.RequestIDMiddleware(s.logger))
+	router.Use(middleware.TracingMiddleware)
 	router.Use(middleware.LoggingMiddleware)
+	router.Use(middleware.MetricsMiddleware(s.metrics))
 	router.Use(middleware.ErrorMiddleware)
 
+	router.Handle("/metrics", s.metrics.Handler()).Methods(http.MethodGet)
+
 	router.HandleFunc("/health", s.healthHandler.HealthCheck).Methods(http.MethodGet)
+	router.HandleFunc("/health/live", s.healthHandler.Liveness).Methods(http.MethodGet)
+	router.HandleFunc("/health/ready", s.healthHandler.Readiness).Methods(http.MethodGet)
 
 	api := router.PathPrefix("/v1").Subrouter()
 
 	api.HandleFunc("/loans", s.loanHandler.GetAllLoans).Methods(http.MethodGet)
 	api.HandleFunc("/loans", s.loanHandler.CreateLoan).Methods(http.MethodPost)
 	api.HandleFunc("/loans/{uuid}", s.loanHandler.GetLoanByUUID).Methods(http.MethodGet)
+	api.HandleFunc("/loans/{uuid}", s.loanHandler.AmendLoan).Methods(http.MethodPatch)
 
 	api.HandleFunc("/loans/{uuid}/approve", s.loanHandler.ApproveLoan).Methods(http.MethodPost)
+	api.HandleFunc("/loans/{uuid}/reject", s.loanHandler.RejectLoan).Methods(http.MethodPost)
 
 	api.HandleFunc("/loans/{uuid}/invest", s.loanHandler.InvestLoan).Methods(http.MethodPost)
+	api.HandleFunc("/loans/{uuid}/investments/{investment_uuid}/confirm-funding", s.loanHandler.ConfirmInvestmentFunding).Methods(http.MethodPost)
+	api.HandleFunc("/investments/{uuid}/cancel", s.loanHandler.CancelInvestment).Methods(http.MethodPost)
 	api.HandleFunc("/loans/{uuid}/disburse", s.loanHandler.DisburseLoan).Methods(http.MethodPost)
 
+	api.HandleFunc("/loans/{uuid}/default", s.loanHandler.DeclareDefault).Methods(http.MethodPost)
+	api.HandleFunc("/loans/{uuid}/write-off", s.loanHandler.WriteOffLoan).Methods(http.MethodPost)
+
+	api.HandleFunc("/loans/{uuid}/payoff-quote", s.loanHandler.GetPayoffQuote).Methods(http.MethodGet)
+	api.HandleFunc("/loans/{uuid}/prepay", s.loanHandler.PrepayLoan).Methods(http.MethodPost)
+
+	api.HandleFunc("/investors/{id}/investments", s.loanHandler.GetInvestorInvestments).Methods(http.MethodGet)
+	api.HandleFunc("/investors/{id}/portfolio", s.loanHandler.GetInvestorPortfolio).Methods(http.MethodGet)
+	api.HandleFunc("/borrowers/{id}/loans", s.loanHandler.GetBorrowerLoans).Methods(http.MethodGet)
+
+	api.HandleFunc("/webhooks/payment-gateway", s.loanHandler.PaymentGatewayWebhook).Methods(http.MethodPost)
+	api.HandleFunc("/webhooks/payment-gateway/collections", s.loanHandler.CollectionWebhook).Methods(http.MethodPost)
+
 	return router
 }
 
-func (s *Server) Start() error {
-	handler := s.setupRoutes()
-
+// Run serves HTTP until ctx is cancelled, then gracefully shuts down: the
+// listener stops accepting connections, in-flight requests are drained and the
+// shutdown hooks are run, all bounded by the configured shutdown timeout.
+func (s *Server) Run(ctx context.Context) error {
 	addr := fmt.Sprintf("%s:%s", s.config.Server.Host, s.config.Server.Port)
-	s.logger.Infof("Starting server on %s", addr)
 
-	return http.ListenAndServe(addr, handler)
+	ln, err := net.Listen("tcp", addr)
+	if err != nil {
+		return fmt.Errorf("failed to listen on %s: %w", addr, err)
+	}
+
+	s.logger.Infof("Starting server on %s", ln.Addr())
+
+	s.scheduler.Start()
+	s.OnShutdown("jobs", s.scheduler.Stop)
+
+	return s.serve(ctx, ln, s.setupRoutes())
+}
+
+func (s *Server) serve(ctx context.Context, ln net.Listener, handler http.Handler) error {
+	httpServer := &http.Server{
+		Handler:           handler,
+		ReadTimeout:       s.config.Server.ReadTimeout,
+		ReadHeaderTimeout: s.config.Server.ReadHeaderTimeout,
+		WriteTimeout:      s.config.Server.WriteTimeout,
+		IdleTimeout:       s.config.Server.IdleTimeout,
+	}
+
+	serveErr := make(chan error, 1)
+	go func() {
+		serveErr <- httpServer.Serve(ln)
+	}()
+
+	var errs []error
+	select {
+	case err := <-serveErr:
+		if !errors.Is(err, http.ErrServerClosed) {
+			errs = append(errs, fmt.Errorf("http server stopped unexpectedly: %w", err))
+		}
+	case <-ctx.Done():
+		s.logger.Info("Shutdown signal received, draining in-flight requests")
+	}
+
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), s.config.Server.ShutdownTimeout)
+	defer cancel()
+
+	if err := httpServer.Shutdown(shutdownCtx); err != nil {
+		errs = append(errs, fmt.Errorf("failed to shut down http server: %w", err))
+	}
+
+	errs = append(errs, s.runShutdownHooks(shutdownCtx)...)
+
+	if len(errs) == 0 {
+		s.logger.Info("Server stopped gracefully")
+	}
+
+	return errors.Join(errs...)
+}
+
+func (s *Server) runShutdownHooks(ctx context.Context) []error {
+	var errs []error
+	for i := len(s.shutdownHooks) - 1; i >= 0; i-- {
+		hook := s.shutdownHooks[i]
+		s.logger.Infof("Stopping %s", hook.name)
+		if err := hook.fn(ctx); err != nil {
+			errs = append(errs, fmt.Errorf("failed to stop %s: %w", hook.name, err))
+		}
+	}
+	return errs
 }
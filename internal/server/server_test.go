@@ -0,0 +1,145 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net"
+	"net/http"
+	"testing"
+	"time"
+
+	"loan-service/internal/config"
+
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func setupTestServer(shutdownTimeout time.Duration) *Server {
+	logger := logrus.New()
+	logger.SetOutput(io.Discard)
+
+	return &Server{
+		config: &config.Config{
+			Server: config.ServerConfig{
+				ReadTimeout:     time.Second,
+				WriteTimeout:    5 * time.Second,
+				IdleTimeout:     time.Second,
+				ShutdownTimeout: shutdownTimeout,
+			},
+		},
+		logger: logger,
+	}
+}
+
+func TestServer_Run_DrainsInFlightRequestsBeforeShutdownHooks(t *testing.T) {
+	srv := setupTestServer(5 * time.Second)
+
+	var stopped []string
+	srv.OnShutdown("database", func(_ context.Context) error {
+		stopped = append(stopped, "database")
+		return nil
+	})
+	srv.OnShutdown("workers", func(_ context.Context) error {
+		stopped = append(stopped, "workers")
+		return nil
+	})
+
+	entered := make(chan struct{})
+	release := make(chan struct{})
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		close(entered)
+		<-release
+		stopped = append(stopped, "request")
+		w.WriteHeader(http.StatusOK)
+	})
+
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	runErr := make(chan error, 1)
+	go func() {
+		runErr <- srv.serve(ctx, ln, handler)
+	}()
+
+	respStatus := make(chan int, 1)
+	go func() {
+		resp, err := http.Get("http://" + ln.Addr().String())
+		if err != nil {
+			respStatus <- 0
+			return
+		}
+		resp.Body.Close()
+		respStatus <- resp.StatusCode
+	}()
+
+	<-entered
+	cancel()
+
+	// give Shutdown a moment to close the listener before releasing the request
+	time.Sleep(50 * time.Millisecond)
+	close(release)
+
+	assert.Equal(t, http.StatusOK, <-respStatus)
+	assert.NoError(t, <-runErr)
+	assert.Equal(t, []string{"request", "workers", "database"}, stopped)
+}
+
+func TestServer_Run_ReturnsErrorWhenDrainExceedsShutdownTimeout(t *testing.T) {
+	srv := setupTestServer(50 * time.Millisecond)
+
+	hookCalled := false
+	srv.OnShutdown("database", func(_ context.Context) error {
+		hookCalled = true
+		return nil
+	})
+
+	entered := make(chan struct{})
+	release := make(chan struct{})
+	defer close(release)
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		close(entered)
+		<-release
+	})
+
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	runErr := make(chan error, 1)
+	go func() {
+		runErr <- srv.serve(ctx, ln, handler)
+	}()
+
+	go func() {
+		resp, err := http.Get("http://" + ln.Addr().String())
+		if err == nil {
+			resp.Body.Close()
+		}
+	}()
+
+	<-entered
+	cancel()
+
+	err = <-runErr
+	assert.True(t, errors.Is(err, context.DeadlineExceeded))
+	assert.True(t, hookCalled, "shutdown hooks must still run after a drain timeout")
+}
+
+func TestServer_Run_ReportsShutdownHookErrors(t *testing.T) {
+	srv := setupTestServer(time.Second)
+	srv.OnShutdown("database", func(_ context.Context) error {
+		return errors.New("close failed")
+	})
+
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	err = srv.serve(ctx, ln, http.NotFoundHandler())
+	assert.ErrorContains(t, err, "failed to stop database: close failed")
+}
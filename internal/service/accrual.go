@@ -0,0 +1,104 @@
+package service
+
+import (
+	"loan-service/enums"
+	"loan-service/internal/models"
+	"math"
+	"time"
+)
+
+// interestAccrual is the interest a loan earned on one day on the principal
+// disbursed by then, unrounded, and the amount that day posts to the ledger.
+type interestAccrual struct {
+	date      time.Time
+	principal float64
+	amount    float64
+	posted    float64
+}
+
+// accrualPeriod returns the days [start, end) on which a loan earns
+// interest: from its first disbursement until its maturity, or until it was
+// repaid, declared in default or written off if that happened first. A zero
+// end means the loan accrues until further notice, as loans without a
+// schedule have no maturity.
+func accrualPeriod(instalments []models.LoanInstalment, transitions []models.LoanStatusTransition, disbursedAt time.Time) (time.Time, time.Time) {
+	var end time.Time
+	for _, instalment := range instalments {
+		if dueDate := dateOf(instalment.DueDate); dueDate.After(end) {
+			end = dueDate
+		}
+	}
+
+	for _, transition := range transitions {
+		switch transition.ToStatus {
+		case enums.LoanStatusDefaulted, enums.LoanStatusWrittenOff, enums.LoanStatusRepaid:
+			if closedOn := dateOf(transition.At); end.IsZero() || closedOn.Before(end) {
+				end = closedOn
+			}
+		}
+	}
+
+	return dateOf(disbursedAt), end
+}
+
+// buildInterestAccruals computes the interest the loan earns on each day from
+// through to that falls within [start, end). InterestRate is charged flat on
+// the principal disbursed by that day, as in the instalment schedule, so a
+// loan paid out in tranches accrues on each tranche from its disbursement
+// date. disbursements must be in date order. Each day posts the change in
+// the rounded running total since start, so the ledger never drifts from
+// the unrounded interest by more than a cent and a day's posting depends
+// only on the loan, not on which other days were accrued.
+func buildInterestAccruals(loan *models.Loan, disbursements []models.LoanDisbursement, convention enums.DayCountConvention, start, end, from, to time.Time) []interestAccrual {
+	var accruals []interestAccrual
+	cumulative := 0.0
+	principal := 0.0
+	next := 0
+	for day := start; !day.After(to) && (end.IsZero() || day.Before(end)); day = day.AddDate(0, 0, 1) {
+		for next < len(disbursements) && !dateOf(disbursements[next].DisbursedAt).After(day) {
+			principal = roundAmount(principal + disbursements[next].Amount)
+			next++
+		}
+
+		amount := principal * loan.InterestRate / 100 * dayCountFraction(convention, day)
+		posted := roundAmount(roundAmount(cumulative+amount) - roundAmount(cumulative))
+		cumulative += amount
+		if day.Before(from) {
+			continue
+		}
+		accruals = append(accruals, interestAccrual{date: day, principal: principal, amount: roundAccrual(amount), posted: posted})
+	}
+	return accruals
+}
+
+// dayCountFraction is the fraction of a year that day counts for.
+func dayCountFraction(convention enums.DayCountConvention, day time.Time) float64 {
+	switch convention {
+	case enums.DayCountConventionAct360:
+		return 1.0 / 360
+	case enums.DayCountConvention30360:
+		return float64(days360(day, day.AddDate(0, 0, 1))) / 360
+	default:
+		return 1.0 / 365
+	}
+}
+
+// days360 counts the days between two dates as if every month had 30 days
+// (the 30/360 bond basis): the 31st of a month counts as the 30th, so the
+// 30th of a 31-day month earns nothing, and the last day of February makes
+// up for the days February lacks.
+func days360(from, to time.Time) int {
+	fromDay, toDay := from.Day(), to.Day()
+	if fromDay == 31 {
+		fromDay = 30
+	}
+	if toDay == 31 && fromDay == 30 {
+		toDay = 30
+	}
+	return (to.Year()-from.Year())*360 + (int(to.Month())-int(from.Month()))*30 + toDay - fromDay
+}
+
+// roundAccrual rounds a daily accrual to the precision it is stored at.
+func roundAccrual(amount float64) float64 {
+	return math.Round(amount*1e6) / 1e6
+}
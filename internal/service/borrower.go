@@ -0,0 +1,67 @@
+package service
+
+import (
+	"loan-service/enums"
+	"loan-service/internal/dto"
+	"loan-service/internal/models"
+	"math"
+	"time"
+)
+
+// buildBorrowerLoanItem summarises a loan for its borrower. Until the
+// repayment schedule exists the borrower owes what has been disbursed so
+// far; after that the outstanding balance is everything unpaid on the
+// schedule plus late fees. Nothing is owed on a loan that was repaid or
+// written off. Every tranche still to be paid out needs a signed loan
+// agreement.
+func buildBorrowerLoanItem(loan *models.Loan, instalments []models.LoanInstalment, lateFees float64, today time.Time) dto.BorrowerLoanItem {
+	item := dto.BorrowerLoanItem{
+		LoanUUID:           loan.UUID,
+		Status:             loan.Status,
+		PrincipalAmount:    loan.PrincipalAmount,
+		InterestRate:       loan.InterestRate,
+		Tenor:              loan.Tenor,
+		TenorUnit:          loan.TenorUnit,
+		RepaymentFrequency: loan.RepaymentFrequency,
+		Purpose:            loan.Purpose,
+		DisbursedAmount:    loan.DisbursedAmount,
+		DaysPastDue:        loan.DaysPastDue,
+		DocumentsToSign:    []dto.BorrowerDocumentItem{},
+		CreatedAt:          loan.CreatedAt,
+	}
+
+	if undisbursed := loan.UndisbursedAmount(); undisbursed > 0 {
+		item.DocumentsToSign = append(item.DocumentsToSign, dto.BorrowerDocumentItem{
+			Type:   enums.BorrowerDocumentTypeLoanAgreement,
+			Amount: undisbursed,
+		})
+	}
+
+	if loan.Status == enums.LoanStatusRepaid || loan.Status == enums.LoanStatusWrittenOff {
+		return item
+	}
+	if len(instalments) == 0 {
+		item.OutstandingBalance = loan.DisbursedAmount
+		return item
+	}
+
+	outstanding := lateFees
+	for _, instalment := range instalments {
+		if instalment.IsSettled() {
+			continue
+		}
+		remaining := roundAmount(math.Max(instalment.AmountDue()-instalment.PaidAmount, 0))
+		outstanding += remaining
+		if item.NextInstalment == nil {
+			item.NextInstalment = &dto.BorrowerInstalmentItem{
+				Sequence:  instalment.Sequence,
+				DueDate:   instalment.DueDate,
+				AmountDue: remaining,
+				Overdue:   dateOf(instalment.DueDate).Before(today),
+			}
+		}
+	}
+	item.OutstandingBalance = roundAmount(outstanding)
+
+	return item
+}
@@ -0,0 +1,83 @@
+package service
+
+import "errors"
+
+var (
+	// ErrPreconditionFailed is returned when the caller asked to modify a
+	// specific version of a loan and the loan has since moved on.
+	ErrPreconditionFailed = errors.New("loan version does not match the expected version")
+	// ErrLoanNotAmendable is returned when the terms of a loan that has
+	// already left the PROPOSED status are changed.
+	ErrLoanNotAmendable = errors.New("loan terms can only be amended before approval")
+	// ErrNoTermsChanged is returned by an amendment that leaves every term
+	// as it was.
+	ErrNoTermsChanged = errors.New("amendment does not change any loan terms")
+	// ErrInvalidLoanTerms is returned when the requested tenor, repayment
+	// frequency and disbursement date don't make sense together.
+	ErrInvalidLoanTerms = errors.New("invalid loan terms")
+	// ErrFundingClosed is returned when investing in a loan whose funding
+	// deadline has passed.
+	ErrFundingClosed = errors.New("loan funding window has closed")
+	// ErrInvestmentExceedsPrincipal is returned when an investment would take
+	// the loan's funded and reserved amount above its principal.
+	ErrInvestmentExceedsPrincipal = errors.New("investment exceeds the loan's remaining principal")
+	// ErrInvestmentBelowMinimum and ErrInvestmentAboveMaximum are returned
+	// when an investment is outside the allowed ticket size.
+	ErrInvestmentBelowMinimum = errors.New("investment is below the minimum amount")
+	ErrInvestmentAboveMaximum = errors.New("investment is above the maximum amount")
+	// ErrInvestorLoanShareExceeded is returned when an investment would give
+	// the investor more than the allowed share of the loan.
+	ErrInvestorLoanShareExceeded = errors.New("investment exceeds the investor's maximum share of the loan")
+	// ErrInvestorExposureExceeded is returned when an investment would take
+	// the investor's total across outstanding loans above the allowed
+	// exposure.
+	ErrInvestorExposureExceeded = errors.New("investment exceeds the investor's maximum exposure")
+	// ErrBorrowerOpenLoanLimit is returned when a borrower who already has
+	// the maximum number of open loans proposes another one.
+	ErrBorrowerOpenLoanLimit = errors.New("borrower has reached the maximum number of open loans")
+	// ErrBorrowerPrincipalLimit is returned when a proposal would take the
+	// principal across a borrower's open loans above the allowed maximum.
+	ErrBorrowerPrincipalLimit = errors.New("loan exceeds the borrower's maximum outstanding principal")
+	// ErrBorrowerCoolingDown is returned when a borrower proposes a loan too
+	// soon after one of their loans was rejected.
+	ErrBorrowerCoolingDown = errors.New("borrower's last loan was rejected too recently")
+	// ErrInvalidLoanStatus is returned when an action isn't allowed from the
+	// loan's current status.
+	ErrInvalidLoanStatus = errors.New("action not allowed in the loan's current status")
+	// ErrInvalidPayoffDate is returned when a payoff is priced for a date
+	// before the loan was disbursed.
+	ErrInvalidPayoffDate = errors.New("invalid payoff date")
+	// ErrPayoffAmountMismatch is returned when a prepayment doesn't match the
+	// payoff quote for its date, e.g. because the quote was stale.
+	ErrPayoffAmountMismatch = errors.New("prepayment amount does not match the payoff quote")
+	// ErrDisbursementExceedsFunding is returned when a disbursement would pay
+	// out more than the loan's investors put in.
+	ErrDisbursementExceedsFunding = errors.New("disbursement exceeds the undisbursed funded amount")
+	// ErrPayoutFailed is returned when a disbursement could not be handed to
+	// the payment gateway.
+	ErrPayoutFailed = errors.New("disbursement payout failed")
+	// ErrPayoutNotFound is returned for a payout callback that does not match
+	// any disbursement.
+	ErrPayoutNotFound = errors.New("no disbursement matches the payout")
+	// ErrLoanNotFound is returned when there is no loan with the requested
+	// UUID.
+	ErrLoanNotFound = errors.New("loan not found")
+	// ErrInvestmentNotFound is returned when confirming the funding of an
+	// investment that does not exist.
+	ErrInvestmentNotFound = errors.New("investment not found")
+	// ErrReservationExpired is returned when an investor's payment arrives
+	// for an investment that no longer holds its share of the loan.
+	ErrReservationExpired = errors.New("investment reservation has expired")
+	// ErrFundingAmountMismatch is returned when an investor paid a different
+	// amount than the investment reserved.
+	ErrFundingAmountMismatch = errors.New("payment amount does not match the investment")
+	// ErrInvestmentNotCancellable is returned when cancelling an investment
+	// that is no longer reserved or funded.
+	ErrInvestmentNotCancellable = errors.New("investment can no longer be cancelled")
+	// ErrCoolingOffPeriodEnded is returned when cancelling an investment after
+	// its cooling-off period.
+	ErrCoolingOffPeriodEnded = errors.New("investment cooling-off period has ended")
+	// ErrInvalidAccrualRange is returned when interest is accrued for a
+	// range that ends before it starts.
+	ErrInvalidAccrualRange = errors.New("invalid interest accrual range")
+)
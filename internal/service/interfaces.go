@@ -3,13 +3,31 @@ package service
 import (
 	"context"
 	"loan-service/internal/dto"
+	"time"
 )
 
 type LoanServiceInterface interface {
 	CreateLoan(ctx context.Context, req *dto.CreateLoanRequest) error
-	GetAllLoans(ctx context.Context) ([]dto.GetLoansResponseItem, error)
-	GetLoanByUUID(ctx context.Context, uuid string) (dto.GetLoansResponseItem, error)
+	GetAllLoans(ctx context.Context, filter dto.GetLoansFilter) ([]dto.GetLoansResponseItem, error)
+	GetLoanByUUID(ctx context.Context, uuid string) (dto.GetLoanDetailResponse, error)
+	AmendLoan(ctx context.Context, req dto.AmendLoanRequest) error
 	ApproveLoanWithValidators(ctx context.Context, req dto.ApproveLoanRequest) error
-	InvestLoan(ctx context.Context, req dto.InvestLoanRequest) error
+	RejectLoan(ctx context.Context, req dto.RejectLoanRequest) error
+	InvestLoan(ctx context.Context, req dto.InvestLoanRequest) (dto.InvestLoanResponse, error)
+	ConfirmInvestmentFunding(ctx context.Context, req dto.ConfirmInvestmentFundingRequest) error
+	HandleCollectionCallback(ctx context.Context, payload []byte, signature string) error
+	CancelInvestment(ctx context.Context, req dto.CancelInvestmentRequest) error
+	GetInvestorInvestments(ctx context.Context, investorID string, page dto.PageRequest) (dto.InvestorInvestmentsResponse, error)
+	GetInvestorPortfolio(ctx context.Context, investorID string, page dto.PageRequest) (dto.InvestorPortfolioResponse, error)
+	GetBorrowerLoans(ctx context.Context, borrowerID string, page dto.PageRequest) (dto.BorrowerLoansResponse, error)
 	CreateLoanDisbursement(ctx context.Context, req dto.CreateLoanDisbursementRequest) error
+	HandlePayoutCallback(ctx context.Context, payload []byte, signature string) error
+	DeclareDefault(ctx context.Context, req dto.DeclareDefaultRequest) error
+	WriteOffLoan(ctx context.Context, req dto.WriteOffLoanRequest) error
+	GetPayoffQuote(ctx context.Context, uuid string, asOf time.Time) (dto.PayoffQuoteResponse, error)
+	PrepayLoan(ctx context.Context, req dto.PrepayLoanRequest) error
+	ExpireOverdueLoans(ctx context.Context) (int, error)
+	ExpireInvestmentReservations(ctx context.Context) (int, error)
+	RefreshDelinquency(ctx context.Context) (int, error)
+	AccrueInterest(ctx context.Context, from, to time.Time) (int, error)
 }
@@ -4,265 +4,2303 @@ import (
 	"context"
 	"database/sql"
 	"errors"
+	"fmt"
 	"loan-service/enums"
 	"loan-service/internal/client"
+	"loan-service/internal/config"
 	"loan-service/internal/dto"
+	"loan-service/internal/logging"
 	"loan-service/internal/models"
 	"loan-service/internal/repository"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"gorm.io/gorm"
+)
+
+const (
+	maxTenorMonths = 120
+	maxTenorDays   = 3650
 )
 
+// systemActor is recorded as the actor of changes made by background jobs.
+const systemActor = "system"
+
 type LoanService struct {
 	repo               repository.LoanRepositoryInterface
 	notificationClient client.NotificationClientInterface
+	paymentGateway     client.PaymentGatewayClientInterface
+	config             config.LoanConfig
 }
 
 // Ensure LoanService implements LoanServiceInterface
 var _ LoanServiceInterface = (*LoanService)(nil)
 
-func NewLoanService(repo repository.LoanRepositoryInterface, notificationClient client.NotificationClientInterface) *LoanService {
+func NewLoanService(repo repository.LoanRepositoryInterface, notificationClient client.NotificationClientInterface, paymentGateway client.PaymentGatewayClientInterface, cfg config.LoanConfig) *LoanService {
+	if cfg.DayCountConvention == 0 {
+		cfg.DayCountConvention = enums.DayCountConventionAct365
+	}
 	return &LoanService{
 		repo:               repo,
 		notificationClient: notificationClient,
+		paymentGateway:     paymentGateway,
+		config:             cfg,
 	}
 }
 
 func (s *LoanService) CreateLoan(ctx context.Context, req *dto.CreateLoanRequest) error {
+	if err := validateLoanTerms(req, time.Now()); err != nil {
+		return err
+	}
+
 	loan := &models.Loan{
-		BorrowerID:      req.BorrowerID,
-		PrincipalAmount: req.PrincipalAmount,
-		InterestRate:    req.InterestRate,
-		ROIRate:         req.ROIRate,
-		Status:          enums.LoanStatusProposed,
+		BorrowerID:                req.BorrowerID,
+		PrincipalAmount:           req.PrincipalAmount,
+		InterestRate:              req.InterestRate,
+		ROIRate:                   req.ROIRate,
+		Tenor:                     req.Tenor,
+		TenorUnit:                 req.TenorUnit,
+		RepaymentFrequency:        req.RepaymentFrequency,
+		Purpose:                   req.Purpose,
+		RequestedDisbursementDate: sql.NullTime{Time: req.RequestedDisbursementDate, Valid: true},
+		Status:                    enums.LoanStatusProposed,
+	}
+
+	err := s.repo.WithinTx(ctx, func(txRepo repository.LoanRepositoryInterface) error {
+		if err := s.checkBorrowerPolicies(ctx, txRepo, req); err != nil {
+			return err
+		}
+		return txRepo.CreateLoan(ctx, loan)
+	})
+	if err != nil {
+		return err
+	}
+
+	logging.FromContext(ctx).WithFields(logrus.Fields{
+		"loan_uuid": loan.UUID,
+		"actor":     loan.BorrowerID,
+	}).Info("loan proposed")
+
+	return nil
+}
+
+// openLoanStatuses are the statuses of loans that are neither closed nor
+// rejected: proposals still under review, loans being funded and loans the
+// borrower is repaying.
+var openLoanStatuses = []enums.LoanStatus{
+	enums.LoanStatusProposed,
+	enums.LoanStatusApproved,
+	enums.LoanStatusInvested,
+	enums.LoanStatusPartiallyDisbursed,
+	enums.LoanStatusDisbursed,
+	enums.LoanStatusDelinquent,
+	enums.LoanStatusDefaulted,
+}
+
+// checkBorrowerPolicies keeps a borrower from proposing a loan too soon after
+// a rejection, or beyond the allowed number of open loans and outstanding
+// principal. Loans without a repayment schedule yet count with their full
+// principal. txRepo must be bound to the transaction creating the loan.
+func (s *LoanService) checkBorrowerPolicies(ctx context.Context, txRepo repository.LoanRepositoryInterface, req *dto.CreateLoanRequest) error {
+	if s.config.BorrowerRejectionCoolDown > 0 {
+		rejection, err := txRepo.GetLatestBorrowerLoanTransition(ctx, req.BorrowerID, enums.LoanStatusRejected)
+		if err != nil {
+			return err
+		}
+		if rejection != nil {
+			if until := rejection.At.Add(s.config.BorrowerRejectionCoolDown); time.Now().Before(until) {
+				return fmt.Errorf("%w: a new loan can be proposed from %s", ErrBorrowerCoolingDown, until.UTC().Format(time.RFC3339))
+			}
+		}
+	}
+
+	if s.config.MaxBorrowerOpenLoans <= 0 && s.config.MaxBorrowerOutstandingPrincipal <= 0 {
+		return nil
 	}
 
-	if err := s.repo.CreateLoan(ctx, loan); err != nil {
+	// A new loan doesn't touch any existing row, so the borrower's own lock
+	// keeps two proposals from both passing the limits before either is saved.
+	if err := txRepo.LockBorrower(ctx, req.BorrowerID); err != nil {
 		return err
 	}
+	loans, err := txRepo.GetBorrowerLoansByStatuses(ctx, req.BorrowerID, openLoanStatuses)
+	if err != nil {
+		return err
+	}
+
+	if s.config.MaxBorrowerOpenLoans > 0 && len(loans) >= s.config.MaxBorrowerOpenLoans {
+		return fmt.Errorf("%w: %d open, maximum is %d", ErrBorrowerOpenLoanLimit, len(loans), s.config.MaxBorrowerOpenLoans)
+	}
+
+	if s.config.MaxBorrowerOutstandingPrincipal > 0 {
+		var outstanding float64
+		if len(loans) > 0 {
+			loanIDs := make([]int, 0, len(loans))
+			for _, loan := range loans {
+				loanIDs = append(loanIDs, loan.ID)
+			}
+			instalments, err := txRepo.GetLoanInstalmentsByLoanIDs(ctx, loanIDs)
+			if err != nil {
+				return err
+			}
+			instalmentsByLoan := map[int][]models.LoanInstalment{}
+			for _, instalment := range instalments {
+				instalmentsByLoan[instalment.LoanID] = append(instalmentsByLoan[instalment.LoanID], instalment)
+			}
+			for i := range loans {
+				outstanding += outstandingPrincipal(&loans[i], instalmentsByLoan[loans[i].ID])
+			}
+		}
+		if roundAmount(outstanding+req.PrincipalAmount) > s.config.MaxBorrowerOutstandingPrincipal {
+			return fmt.Errorf("%w: %.2f requested with %.2f outstanding, maximum is %.2f",
+				ErrBorrowerPrincipalLimit, req.PrincipalAmount, roundAmount(outstanding), s.config.MaxBorrowerOutstandingPrincipal)
+		}
+	}
 
 	return nil
 }
 
-func (s *LoanService) GetAllLoans(ctx context.Context) (response []dto.GetLoansResponseItem, err error) {
-	loans, err := s.repo.GetAllLoans(ctx)
+func (s *LoanService) GetAllLoans(ctx context.Context, filter dto.GetLoansFilter) (response []dto.GetLoansResponseItem, err error) {
+	loans, err := s.repo.GetAllLoans(ctx, repository.LoanFilter{DPDBucket: filter.DPDBucket})
 	if err != nil {
 		return nil, err
 	}
 
 	for _, loan := range loans {
-		response = append(response, dto.GetLoansResponseItem{
-			UUID:            loan.UUID,
-			BorrowerID:      loan.BorrowerID,
-			PrincipalAmount: loan.PrincipalAmount,
-			InterestRate:    loan.InterestRate,
-			ROIRate:         loan.ROIRate,
-			Status:          loan.Status,
-		})
+		response = append(response, toLoanResponseItem(&loan))
 	}
 
 	return response, nil
 }
 
-func (s *LoanService) GetLoanByUUID(ctx context.Context, uuid string) (dto.GetLoansResponseItem, error) {
-	loan, err := s.repo.GetLoanByUUID(ctx, uuid)
+func (s *LoanService) GetLoanByUUID(ctx context.Context, uuid string) (dto.GetLoanDetailResponse, error) {
+	loan, err := getLoanByUUID(ctx, s.repo, uuid)
 	if err != nil {
-		return dto.GetLoansResponseItem{}, err
+		return dto.GetLoanDetailResponse{}, err
 	}
-	return dto.GetLoansResponseItem{
-		UUID:            loan.UUID,
-		BorrowerID:      loan.BorrowerID,
-		PrincipalAmount: loan.PrincipalAmount,
-		InterestRate:    loan.InterestRate,
-		ROIRate:         loan.ROIRate,
-		Status:          loan.Status,
-	}, nil
-}
 
-func (s *LoanService) ApproveLoanWithValidators(ctx context.Context, req dto.ApproveLoanRequest) error {
-	tx, err := s.repo.BeginTransaction(ctx)
+	transitions, err := s.repo.GetLoanStatusTransitionsByLoanID(ctx, loan.ID)
 	if err != nil {
-		return err
+		return dto.GetLoanDetailResponse{}, err
 	}
 
-	defer func() {
-		if r := recover(); r != nil || err != nil {
-			s.repo.Rollback(ctx, tx)
-		}
-	}()
+	termSheets, err := s.repo.GetLoanTermSheetsByLoanID(ctx, loan.ID)
+	if err != nil {
+		return dto.GetLoanDetailResponse{}, err
+	}
 
-	loan, err := s.repo.GetLoanByUUID(ctx, req.LoanUUID)
+	disbursements, err := s.repo.GetLoanDisbursementsByLoanID(ctx, loan.ID)
 	if err != nil {
-		return err
+		return dto.GetLoanDetailResponse{}, err
 	}
 
-	loanApproval := &models.LoanApproval{
-		LoanID:     loan.ID,
-		ApprovedAt: sql.NullTime{Time: req.ApprovedAt, Valid: true},
+	instalments, err := s.repo.GetLoanInstalmentsByLoanID(ctx, loan.ID)
+	if err != nil {
+		return dto.GetLoanDetailResponse{}, err
 	}
 
-	if err := s.repo.CreateLoanApproval(ctx, tx, loanApproval); err != nil {
-		return err
+	ledgerEntries, err := s.repo.GetLoanLedgerEntriesByLoanID(ctx, loan.ID)
+	if err != nil {
+		return dto.GetLoanDetailResponse{}, err
 	}
 
-	loanApprovalValidator := &models.LoanApprovalValidator{
-		LoanApprovalID: loanApproval.ID,
-		EmployeeID:     req.EmployeeID,
+	escrowEntries, err := s.repo.GetLoanEscrowEntriesByLoanID(ctx, loan.ID)
+	if err != nil {
+		return dto.GetLoanDetailResponse{}, err
 	}
 
-	if err := s.repo.CreateLoanApprovalValidator(ctx, tx, loanApprovalValidator); err != nil {
-		return err
+	timeline := make([]dto.LoanStatusTransitionItem, 0, len(transitions))
+	for _, transition := range transitions {
+		timeline = append(timeline, dto.LoanStatusTransitionItem{
+			FromStatus: transition.FromStatus,
+			ToStatus:   transition.ToStatus,
+			Actor:      transition.Actor,
+			Reason:     transition.Reason,
+			At:         transition.At,
+		})
 	}
 
-	for _, proof := range req.Proofs {
-		if err := s.repo.CreateLoanApprovalValidatorProof(ctx, tx, &models.LoanApprovalValidatorProof{
-			LoanApprovalValidatorID: loanApprovalValidator.ID,
-			ProofURL:                proof.ProofURL,
-			Category:                proof.Category,
-		}); err != nil {
-			return err
+	termSheetItems := make([]dto.LoanTermSheetItem, 0, len(termSheets))
+	for _, termSheet := range termSheets {
+		termSheetItems = append(termSheetItems, dto.LoanTermSheetItem{
+			Version:         termSheet.Version,
+			PrincipalAmount: termSheet.PrincipalAmount,
+			InterestRate:    termSheet.InterestRate,
+			ROIRate:         termSheet.ROIRate,
+			Actor:           termSheet.Actor,
+			Reason:          termSheet.Reason,
+			CreatedAt:       termSheet.CreatedAt,
+		})
+	}
+
+	disbursementItems := make([]dto.LoanDisbursementItem, 0, len(disbursements))
+	for _, disbursement := range disbursements {
+		disbursementItems = append(disbursementItems, dto.LoanDisbursementItem{
+			Amount:                 disbursement.Amount,
+			FieldOfficerEmployeeID: disbursement.FieldOfficerEmployeeID,
+			PayoutStatus:           disbursement.PayoutStatus,
+			FailureReason:          disbursement.FailureReason,
+			DisbursedAt:            disbursement.DisbursedAt,
+		})
+	}
+
+	instalmentItems := make([]dto.LoanInstalmentItem, 0, len(instalments))
+	for _, instalment := range instalments {
+		item := dto.LoanInstalmentItem{
+			Sequence:     instalment.Sequence,
+			DueDate:      instalment.DueDate,
+			PrincipalDue: instalment.PrincipalDue,
+			InterestDue:  instalment.InterestDue,
+			PaidAmount:   instalment.PaidAmount,
 		}
+		if instalment.PaidAt.Valid {
+			paidAt := instalment.PaidAt.Time
+			item.PaidAt = &paidAt
+		}
+		instalmentItems = append(instalmentItems, item)
 	}
 
-	loan.Status = enums.LoanStatusApproved
-	if err := s.repo.UpdateLoan(ctx, tx, loan, []string{"status"}); err != nil {
-		return err
+	ledgerEntryItems := make([]dto.LoanLedgerEntryItem, 0, len(ledgerEntries))
+	for _, entry := range ledgerEntries {
+		ledgerEntryItems = append(ledgerEntryItems, dto.LoanLedgerEntryItem{
+			EntryType:     entry.EntryType,
+			Amount:        entry.Amount,
+			EffectiveDate: entry.EffectiveDate,
+			Description:   entry.Description,
+		})
+	}
+
+	escrowEntryItems := make([]dto.LoanEscrowEntryItem, 0, len(escrowEntries))
+	for _, entry := range escrowEntries {
+		escrowEntryItems = append(escrowEntryItems, dto.LoanEscrowEntryItem{
+			EntryType:    entry.EntryType,
+			Amount:       entry.Amount,
+			BalanceAfter: entry.BalanceAfter,
+			EffectiveAt:  entry.EffectiveAt,
+		})
+	}
+
+	return dto.GetLoanDetailResponse{
+		GetLoansResponseItem: toLoanResponseItem(loan),
+		StatusTimeline:       timeline,
+		TermSheets:           termSheetItems,
+		Disbursements:        disbursementItems,
+		Instalments:          instalmentItems,
+		LedgerEntries:        ledgerEntryItems,
+		EscrowEntries:        escrowEntryItems,
+	}, nil
+}
+
+func toLoanResponseItem(loan *models.Loan) dto.GetLoansResponseItem {
+	item := dto.GetLoansResponseItem{
+		UUID:               loan.UUID,
+		BorrowerID:         loan.BorrowerID,
+		PrincipalAmount:    loan.PrincipalAmount,
+		InterestRate:       loan.InterestRate,
+		ROIRate:            loan.ROIRate,
+		Tenor:              loan.Tenor,
+		TenorUnit:          loan.TenorUnit,
+		RepaymentFrequency: loan.RepaymentFrequency,
+		Purpose:            loan.Purpose,
+		Status:             loan.Status,
+		DaysPastDue:        loan.DaysPastDue,
+		DPDBucket:          loan.DPDBucket,
+		DisbursedAmount:    loan.DisbursedAmount,
+		UndisbursedAmount:  loan.UndisbursedAmount(),
+		EscrowBalance:      loan.EscrowBalance,
+		FlaggedAt:          timeOrNil(loan.FlaggedAt),
+		FlagReason:         loan.FlagReason,
+		Version:            loan.Version,
+	}
+	if loan.RequestedDisbursementDate.Valid {
+		requestedDisbursementDate := loan.RequestedDisbursementDate.Time
+		item.RequestedDisbursementDate = &requestedDisbursementDate
+	}
+	return item
+}
+
+// validateLoanTerms checks that the enum fields hold known values and the
+// rules that span several fields of the request; other single-field rules
+// are enforced by the validator tags.
+func validateLoanTerms(req *dto.CreateLoanRequest, now time.Time) error {
+	if enums.TenorUnitFromInt(req.TenorUnit.Int()) == 0 {
+		return fmt.Errorf("%w: unknown tenor unit %d", ErrInvalidLoanTerms, req.TenorUnit)
+	}
+	if enums.RepaymentFrequencyFromInt(req.RepaymentFrequency.Int()) == 0 {
+		return fmt.Errorf("%w: unknown repayment frequency %d", ErrInvalidLoanTerms, req.RepaymentFrequency)
+	}
+	if enums.LoanPurposeFromInt(req.Purpose.Int()) == 0 {
+		return fmt.Errorf("%w: unknown purpose %d", ErrInvalidLoanTerms, req.Purpose)
+	}
+
+	switch req.TenorUnit {
+	case enums.TenorUnitMonth:
+		if req.Tenor > maxTenorMonths {
+			return fmt.Errorf("%w: tenor cannot exceed %d months", ErrInvalidLoanTerms, maxTenorMonths)
+		}
+	case enums.TenorUnitDay:
+		if req.Tenor > maxTenorDays {
+			return fmt.Errorf("%w: tenor cannot exceed %d days", ErrInvalidLoanTerms, maxTenorDays)
+		}
+		if req.RepaymentFrequency == enums.RepaymentFrequencyWeekly && req.Tenor < 7 {
+			return fmt.Errorf("%w: weekly repayments need a tenor of at least 7 days", ErrInvalidLoanTerms)
+		}
+		if req.RepaymentFrequency == enums.RepaymentFrequencyMonthly && req.Tenor < 30 {
+			return fmt.Errorf("%w: monthly repayments need a tenor of at least 30 days", ErrInvalidLoanTerms)
+		}
+	}
+
+	today := now.UTC().Truncate(24 * time.Hour)
+	if req.RequestedDisbursementDate.UTC().Before(today) {
+		return fmt.Errorf("%w: requested disbursement date is in the past", ErrInvalidLoanTerms)
 	}
 
-	return s.repo.Commit(ctx, tx)
+	return nil
 }
 
-func (s *LoanService) InvestLoan(ctx context.Context, req dto.InvestLoanRequest) error {
-	loan, err := s.repo.GetLoanByUUID(ctx, req.LoanUUID)
+// GetInvestorInvestments lists a page of the investor's investments in any
+// status, newest first.
+func (s *LoanService) GetInvestorInvestments(ctx context.Context, investorID string, page dto.PageRequest) (dto.InvestorInvestmentsResponse, error) {
+	investments, total, err := s.repo.GetInvestmentsByInvestorID(ctx, investorID, nil, toRepositoryPage(page))
 	if err != nil {
-		return err
+		return dto.InvestorInvestmentsResponse{}, err
 	}
 
-	if loan.Status != enums.LoanStatusApproved {
-		return errors.New("loan is not approved")
+	loans, err := s.loansOf(ctx, investments)
+	if err != nil {
+		return dto.InvestorInvestmentsResponse{}, err
 	}
 
-	if loan.InvestmentAmount+req.Amount > loan.PrincipalAmount {
-		return errors.New("loan investment amount is greater than principal amount")
+	response := dto.InvestorInvestmentsResponse{
+		Investments: []dto.InvestorInvestmentItem{},
+		Page:        toPageInfo(page, total),
+	}
+	for _, investment := range investments {
+		response.Investments = append(response.Investments, toInvestorInvestmentItem(investment, loans[investment.LoanID]))
 	}
 
-	tx, err := s.repo.BeginTransaction(ctx)
+	return response, nil
+}
+
+// GetInvestorPortfolio values a page of the investor's funded investments,
+// newest first, with what each is expected to return, what it paid out so
+// far and how much of its principal is still outstanding.
+func (s *LoanService) GetInvestorPortfolio(ctx context.Context, investorID string, page dto.PageRequest) (dto.InvestorPortfolioResponse, error) {
+	investments, total, err := s.repo.GetInvestmentsByInvestorID(ctx, investorID, []enums.InvestmentStatus{enums.InvestmentStatusFunded}, toRepositoryPage(page))
 	if err != nil {
-		return err
+		return dto.InvestorPortfolioResponse{}, err
 	}
 
-	defer func() {
-		if r := recover(); r != nil || err != nil {
-			s.repo.Rollback(ctx, tx)
-		}
-	}()
+	response := dto.InvestorPortfolioResponse{
+		Holdings: []dto.PortfolioHolding{},
+		Page:     toPageInfo(page, total),
+	}
+	if len(investments) == 0 {
+		return response, nil
+	}
 
-	loan.InvestmentAmount += req.Amount
-	if err := s.repo.UpdateLoan(ctx, tx, loan, []string{"investment_amount"}); err != nil {
-		return err
+	loans, err := s.loansOf(ctx, investments)
+	if err != nil {
+		return dto.InvestorPortfolioResponse{}, err
 	}
 
-	investment := &models.Investment{
-		LoanID:     loan.ID,
-		InvestorID: req.InvestorID,
-		Amount:     req.Amount,
+	loanIDs := make([]int, 0, len(loans))
+	for id := range loans {
+		loanIDs = append(loanIDs, id)
+	}
+	instalments, err := s.repo.GetLoanInstalmentsByLoanIDs(ctx, loanIDs)
+	if err != nil {
+		return dto.InvestorPortfolioResponse{}, err
+	}
+	instalmentsByLoan := map[int][]models.LoanInstalment{}
+	for _, instalment := range instalments {
+		instalmentsByLoan[instalment.LoanID] = append(instalmentsByLoan[instalment.LoanID], instalment)
 	}
 
-	agreementLetterURL, err := generateAgreementLetterURL(ctx, loan, investment)
+	investmentIDs := make([]int, 0, len(investments))
+	for _, investment := range investments {
+		investmentIDs = append(investmentIDs, investment.ID)
+	}
+	payouts, err := s.repo.GetInvestorPayoutsByInvestmentIDs(ctx, investmentIDs)
 	if err != nil {
-		return err
+		return dto.InvestorPortfolioResponse{}, err
+	}
+	payoutsByInvestment := map[int][]models.InvestorPayout{}
+	for _, payout := range payouts {
+		payoutsByInvestment[payout.InvestmentID] = append(payoutsByInvestment[payout.InvestmentID], payout)
 	}
 
-	investment.AgreementLetterURL = agreementLetterURL
+	for _, investment := range investments {
+		loan := loans[investment.LoanID]
+		response.Holdings = append(response.Holdings, buildPortfolioHolding(investment, loan, instalmentsByLoan[loan.ID], payoutsByInvestment[investment.ID]))
+	}
 
-	if err := s.repo.CreateInvestment(ctx, tx, investment); err != nil {
-		return err
+	return response, nil
+}
+
+// loansOf loads the loans the investments were made in, keyed by ID.
+func (s *LoanService) loansOf(ctx context.Context, investments []models.Investment) (map[int]*models.Loan, error) {
+	loans := map[int]*models.Loan{}
+	if len(investments) == 0 {
+		return loans, nil
 	}
 
-	if loan.InvestmentAmount == loan.PrincipalAmount {
-		loan.Status = enums.LoanStatusInvested
-		if err := s.repo.UpdateLoan(ctx, tx, loan, []string{"status"}); err != nil {
-			return err
+	ids := make([]int, 0, len(investments))
+	for _, investment := range investments {
+		ids = append(ids, investment.LoanID)
+	}
+	found, err := s.repo.GetLoansByIDs(ctx, ids)
+	if err != nil {
+		return nil, err
+	}
+	for i := range found {
+		loans[found[i].ID] = &found[i]
+	}
+
+	for _, investment := range investments {
+		if loans[investment.LoanID] == nil {
+			return nil, fmt.Errorf("loan %d of investment %s not found", investment.LoanID, investment.UUID)
 		}
 	}
+	return loans, nil
+}
+
+// GetBorrowerLoans lists a page of the borrower's loans, newest first, with
+// what is due next, how much is still owed and what is left to sign.
+func (s *LoanService) GetBorrowerLoans(ctx context.Context, borrowerID string, page dto.PageRequest) (dto.BorrowerLoansResponse, error) {
+	loans, total, err := s.repo.GetLoansByBorrowerID(ctx, borrowerID, toRepositoryPage(page))
+	if err != nil {
+		return dto.BorrowerLoansResponse{}, err
+	}
+
+	response := dto.BorrowerLoansResponse{
+		Loans: []dto.BorrowerLoanItem{},
+		Page:  toPageInfo(page, total),
+	}
+	if len(loans) == 0 {
+		return response, nil
+	}
 
-	err = s.repo.Commit(ctx, tx)
+	loanIDs := make([]int, 0, len(loans))
+	for _, loan := range loans {
+		loanIDs = append(loanIDs, loan.ID)
+	}
+	instalments, err := s.repo.GetLoanInstalmentsByLoanIDs(ctx, loanIDs)
 	if err != nil {
-		return err
+		return dto.BorrowerLoansResponse{}, err
+	}
+	instalmentsByLoan := map[int][]models.LoanInstalment{}
+	for _, instalment := range instalments {
+		instalmentsByLoan[instalment.LoanID] = append(instalmentsByLoan[instalment.LoanID], instalment)
 	}
 
-	// get all investment records
-	investments, err := s.repo.GetInvestmentsByLoanID(ctx, loan.ID)
+	lateFeeEntries, err := s.repo.GetLoanLedgerEntriesByLoanIDs(ctx, loanIDs, []enums.LedgerEntryType{enums.LedgerEntryTypeLateFee})
 	if err != nil {
-		return err
+		return dto.BorrowerLoansResponse{}, err
+	}
+	lateFees := map[int]float64{}
+	for _, entry := range lateFeeEntries {
+		lateFees[entry.LoanID] += entry.Amount
 	}
 
-	for _, investment := range investments {
-		// send agreement letter attached to email to investor
-		err = s.notificationClient.SendEmail(ctx, client.SendEmailRequest{
-			To:      investment.InvestorID, // notification service will get the email from the investor id
-			Subject: "Loan Agreement Letter",
-			Body:    "Please find the agreement letter attached to this email.",
-			Attachments: []client.Attachment{
-				{
-					Filename: "agreement_letter.pdf",
-					Content:  investment.AgreementLetterURL,
-					Type:     "application/pdf",
-				},
-			},
-		})
+	today := dateOf(time.Now())
+	for i := range loans {
+		loan := &loans[i]
+		response.Loans = append(response.Loans, buildBorrowerLoanItem(loan, instalmentsByLoan[loan.ID], lateFees[loan.ID], today))
+	}
+
+	return response, nil
+}
+
+// AmendLoan changes the terms of a PROPOSED loan and records the result as
+// the next term sheet version.
+func (s *LoanService) AmendLoan(ctx context.Context, req dto.AmendLoanRequest) error {
+	var loan *models.Loan
+	var termSheet *models.LoanTermSheet
+	err := s.repo.WithinTx(ctx, func(txRepo repository.LoanRepositoryInterface) error {
+		var err error
+		loan, err = getLoanByUUID(ctx, txRepo, req.LoanUUID)
 		if err != nil {
 			return err
 		}
-	}
 
-	return nil
-}
+		if err := checkExpectedVersion(loan, req.ExpectedVersion); err != nil {
+			return err
+		}
 
-func generateAgreementLetterURL(_ context.Context, _ *models.Loan, _ *models.Investment) (string, error) {
-	// generate agreement letter
-	return "", nil
-}
+		if loan.Status != enums.LoanStatusProposed {
+			return ErrLoanNotAmendable
+		}
 
-func (s *LoanService) CreateLoanDisbursement(ctx context.Context, req dto.CreateLoanDisbursementRequest) error {
-	loan, err := s.repo.GetLoanByUUID(ctx, req.LoanUUID)
+		var fields []string
+		if req.PrincipalAmount != nil && *req.PrincipalAmount != loan.PrincipalAmount {
+			loan.PrincipalAmount = *req.PrincipalAmount
+			fields = append(fields, "principal_amount")
+		}
+		if req.InterestRate != nil && *req.InterestRate != loan.InterestRate {
+			loan.InterestRate = *req.InterestRate
+			fields = append(fields, "interest_rate")
+		}
+		if req.ROIRate != nil && *req.ROIRate != loan.ROIRate {
+			loan.ROIRate = *req.ROIRate
+			fields = append(fields, "roi_rate")
+		}
+		if len(fields) == 0 {
+			return ErrNoTermsChanged
+		}
+
+		if err := txRepo.UpdateLoan(ctx, loan, fields); err != nil {
+			return err
+		}
+
+		latest, err := txRepo.GetLatestLoanTermSheet(ctx, loan.ID)
+		if err != nil {
+			return err
+		}
+
+		termSheet = &models.LoanTermSheet{
+			LoanID:          loan.ID,
+			Version:         latest.Version + 1,
+			PrincipalAmount: loan.PrincipalAmount,
+			InterestRate:    loan.InterestRate,
+			ROIRate:         loan.ROIRate,
+			Actor:           req.EmployeeID,
+			Reason:          req.Reason,
+		}
+		return txRepo.CreateLoanTermSheet(ctx, termSheet)
+	})
 	if err != nil {
 		return err
 	}
 
-	if loan.Status != enums.LoanStatusInvested {
-		return errors.New("loan is not invested")
-	}
+	logging.FromContext(ctx).WithFields(logrus.Fields{
+		"loan_uuid":          loan.UUID,
+		"actor":              req.EmployeeID,
+		"term_sheet_version": termSheet.Version,
+	}).Info("loan terms amended")
+
+	return nil
+}
+
+func (s *LoanService) ApproveLoanWithValidators(ctx context.Context, req dto.ApproveLoanRequest) error {
+	var loan *models.Loan
+	err := s.repo.WithinTx(ctx, func(txRepo repository.LoanRepositoryInterface) error {
+		var err error
+		loan, err = getLoanByUUID(ctx, txRepo, req.LoanUUID)
+		if err != nil {
+			return err
+		}
+
+		if err := checkExpectedVersion(loan, req.ExpectedVersion); err != nil {
+			return err
+		}
+
+		if loan.Status != enums.LoanStatusProposed {
+			return fmt.Errorf("%w: only proposed loans can be approved", ErrInvalidLoanStatus)
+		}
 
-	tx, err := s.repo.BeginTransaction(ctx)
+		// The approval points at the current term sheet; since amendments are
+		// refused from here on, those are the terms the validators signed off.
+		termSheet, err := txRepo.GetLatestLoanTermSheet(ctx, loan.ID)
+		if err != nil {
+			return err
+		}
+
+		loanApproval := &models.LoanApproval{
+			LoanID:          loan.ID,
+			LoanTermSheetID: &termSheet.ID,
+			ApprovedAt:      sql.NullTime{Time: req.ApprovedAt, Valid: true},
+		}
+
+		if err := txRepo.CreateLoanApproval(ctx, loanApproval); err != nil {
+			return err
+		}
+
+		loanApprovalValidator := &models.LoanApprovalValidator{
+			LoanApprovalID: loanApproval.ID,
+			EmployeeID:     req.EmployeeID,
+		}
+
+		if err := txRepo.CreateLoanApprovalValidator(ctx, loanApprovalValidator); err != nil {
+			return err
+		}
+
+		for _, proof := range req.Proofs {
+			if err := txRepo.CreateLoanApprovalValidatorProof(ctx, &models.LoanApprovalValidatorProof{
+				LoanApprovalValidatorID: loanApprovalValidator.ID,
+				ProofURL:                proof.ProofURL,
+				Category:                proof.Category,
+			}); err != nil {
+				return err
+			}
+		}
+
+		// The funding window runs from when the approval is recorded, not from
+		// the client-supplied approved_at, so a backdated or future-dated
+		// approval can't shorten or stretch it.
+		loan.FundingDeadline = sql.NullTime{Time: time.Now().Add(s.config.FundingWindow), Valid: true}
+		return s.transitionLoanStatus(ctx, txRepo, loan, enums.LoanStatusApproved, req.EmployeeID, "approved by field validator", "funding_deadline")
+	})
 	if err != nil {
 		return err
 	}
 
-	defer func() {
-		if r := recover(); r != nil || err != nil {
-			s.repo.Rollback(ctx, tx)
+	logging.FromContext(ctx).WithFields(logrus.Fields{
+		"loan_uuid": loan.UUID,
+		"actor":     req.EmployeeID,
+	}).Info("loan approved")
+
+	return nil
+}
+
+// RejectLoan turns down a PROPOSED loan. The reason is kept on the status
+// transition, whose timestamp starts the borrower's rejection cool-down.
+func (s *LoanService) RejectLoan(ctx context.Context, req dto.RejectLoanRequest) error {
+	var loan *models.Loan
+	err := s.repo.WithinTx(ctx, func(txRepo repository.LoanRepositoryInterface) error {
+		var err error
+		loan, err = getLoanByUUID(ctx, txRepo, req.LoanUUID)
+		if err != nil {
+			return err
 		}
-	}()
 
-	loanDisbursement := &models.LoanDisbursement{
-		LoanID:                   loan.ID,
-		FieldOfficerEmployeeID:   req.EmployeeID,
-		SignedAgreementLetterURL: req.SignedAgreementLetterURL,
-		DisbursedAt:              req.DisbursedAt,
-	}
+		if err := checkExpectedVersion(loan, req.ExpectedVersion); err != nil {
+			return err
+		}
+
+		if loan.Status != enums.LoanStatusProposed {
+			return fmt.Errorf("%w: only proposed loans can be rejected", ErrInvalidLoanStatus)
+		}
 
-	if err := s.repo.CreateLoanDisbursement(ctx, tx, loanDisbursement); err != nil {
+		return s.transitionLoanStatus(ctx, txRepo, loan, enums.LoanStatusRejected, req.EmployeeID, req.Reason)
+	})
+	if err != nil {
 		return err
 	}
 
-	loan.Status = enums.LoanStatusDisbursed
-	if err := s.repo.UpdateLoan(ctx, tx, loan, []string{"status"}); err != nil {
-		return err
+	logging.FromContext(ctx).WithFields(logrus.Fields{
+		"loan_uuid": loan.UUID,
+		"actor":     req.EmployeeID,
+		"reason":    req.Reason,
+	}).Info("loan rejected")
+
+	return nil
+}
+
+// InvestLoan reserves part of an approved loan for an investor. The
+// investment stays PENDING_FUNDING until the investor's payment is
+// confirmed, through the payment gateway or by hand, and only then counts
+// towards the loan's investment amount. Reservations that aren't paid for
+// in time are released by ExpireInvestmentReservations. The investment has
+// to respect the configured ticket size, loan share and exposure limits.
+func (s *LoanService) InvestLoan(ctx context.Context, req dto.InvestLoanRequest) (dto.InvestLoanResponse, error) {
+	if err := s.checkTicketSize(req.Amount); err != nil {
+		return dto.InvestLoanResponse{}, err
 	}
 
-	err = s.repo.Commit(ctx, tx)
+	var loan *models.Loan
+	var investment *models.Investment
+	err := s.repo.WithinTx(ctx, func(txRepo repository.LoanRepositoryInterface) error {
+		var err error
+		loan, err = getLoanByUUID(ctx, txRepo, req.LoanUUID)
+		if err != nil {
+			return err
+		}
+
+		if err := checkExpectedVersion(loan, req.ExpectedVersion); err != nil {
+			return err
+		}
+
+		if loan.Status != enums.LoanStatusApproved {
+			return fmt.Errorf("%w: only approved loans can be invested in", ErrInvalidLoanStatus)
+		}
+
+		now := time.Now()
+		if loan.FundingDeadline.Valid && now.After(loan.FundingDeadline.Time) {
+			return ErrFundingClosed
+		}
+
+		investments, err := txRepo.GetInvestmentsByLoanID(ctx, loan.ID)
+		if err != nil {
+			return err
+		}
+
+		if err := s.checkInvestmentLimits(ctx, txRepo, loan, investments, req); err != nil {
+			return err
+		}
+
+		expiresAt := now.Add(s.config.InvestmentReservationTTL)
+		if loan.FundingDeadline.Valid && loan.FundingDeadline.Time.Before(expiresAt) {
+			expiresAt = loan.FundingDeadline.Time
+		}
+
+		investment = &models.Investment{
+			LoanID:           loan.ID,
+			InvestorID:       req.InvestorID,
+			Amount:           req.Amount,
+			Status:           enums.InvestmentStatusPendingFunding,
+			FundingExpiresAt: sql.NullTime{Time: expiresAt, Valid: true},
+		}
+
+		agreementLetterURL, err := generateAgreementLetterURL(ctx, loan, investment)
+		if err != nil {
+			return err
+		}
+
+		investment.AgreementLetterURL = agreementLetterURL
+
+		if err := txRepo.CreateInvestment(ctx, investment); err != nil {
+			return err
+		}
+
+		// Bumping the version makes a concurrent investment that saw the same
+		// room on the loan fail instead of overbooking it.
+		return txRepo.UpdateLoan(ctx, loan, nil)
+	})
 	if err != nil {
-		return err
+		return dto.InvestLoanResponse{}, err
 	}
 
+	logging.FromContext(ctx).WithFields(logrus.Fields{
+		"loan_uuid":       loan.UUID,
+		"actor":           req.InvestorID,
+		"investment_uuid": investment.UUID,
+		"amount":          req.Amount,
+	}).Info("investment reserved")
+
+	return dto.InvestLoanResponse{
+		InvestmentUUID:   investment.UUID,
+		Amount:           investment.Amount,
+		Status:           investment.Status,
+		FundingExpiresAt: investment.FundingExpiresAt.Time,
+	}, nil
+}
+
+// checkTicketSize enforces the minimum and maximum size of a single
+// investment.
+func (s *LoanService) checkTicketSize(amount float64) error {
+	if s.config.MinInvestmentAmount > 0 && amount < s.config.MinInvestmentAmount {
+		return fmt.Errorf("%w: %.2f requested, minimum is %.2f", ErrInvestmentBelowMinimum, amount, s.config.MinInvestmentAmount)
+	}
+	if s.config.MaxInvestmentAmount > 0 && amount > s.config.MaxInvestmentAmount {
+		return fmt.Errorf("%w: %.2f requested, maximum is %.2f", ErrInvestmentAboveMaximum, amount, s.config.MaxInvestmentAmount)
+	}
+	return nil
+}
+
+// checkInvestmentLimits checks that the investment fits in what is left of
+// the loan's principal and keeps the investor within their share of the loan
+// and their exposure across loans. Funded investments and pending
+// reservations both count.
+func (s *LoanService) checkInvestmentLimits(ctx context.Context, txRepo repository.LoanRepositoryInterface, loan *models.Loan, investments []models.Investment, req dto.InvestLoanRequest) error {
+	reserved := reservedAmount(loan, investments)
+	if roundAmount(reserved+req.Amount) > loan.PrincipalAmount {
+		return fmt.Errorf("%w: %.2f requested, %.2f available", ErrInvestmentExceedsPrincipal, req.Amount, roundAmount(loan.PrincipalAmount-reserved))
+	}
+
+	if s.config.MaxInvestorLoanShare > 0 {
+		var held float64
+		for _, investment := range investments {
+			if investment.InvestorID == req.InvestorID && holdsShare(investment) {
+				held += investment.Amount
+			}
+		}
+		maxShare := roundAmount(loan.PrincipalAmount * s.config.MaxInvestorLoanShare / 100)
+		if roundAmount(held+req.Amount) > maxShare {
+			return fmt.Errorf("%w: %.2f requested with %.2f already invested, maximum is %.2f (%.2f%% of the principal)",
+				ErrInvestorLoanShareExceeded, req.Amount, roundAmount(held), maxShare, s.config.MaxInvestorLoanShare)
+		}
+	}
+
+	if s.config.MaxInvestorExposure > 0 {
+		// Investments in other loans don't touch this loan's version, so the
+		// investor's own lock keeps two of them from both passing the check.
+		if err := txRepo.LockInvestor(ctx, req.InvestorID); err != nil {
+			return err
+		}
+		exposure, err := txRepo.GetInvestorExposure(ctx, req.InvestorID)
+		if err != nil {
+			return err
+		}
+		if roundAmount(exposure+req.Amount) > s.config.MaxInvestorExposure {
+			return fmt.Errorf("%w: %.2f requested with %.2f already invested, maximum is %.2f",
+				ErrInvestorExposureExceeded, req.Amount, roundAmount(exposure), s.config.MaxInvestorExposure)
+		}
+	}
+
+	return nil
+}
+
+// holdsShare reports whether the investment takes up part of its loan.
+func holdsShare(investment models.Investment) bool {
+	return investment.Status == enums.InvestmentStatusFunded || investment.Status == enums.InvestmentStatusPendingFunding
+}
+
+// reservedAmount is the part of the loan's principal that investors have
+// taken up: what they funded plus what they reserved and haven't paid yet.
+func reservedAmount(loan *models.Loan, investments []models.Investment) float64 {
+	reserved := loan.InvestmentAmount
+	for _, investment := range investments {
+		if investment.Status == enums.InvestmentStatusPendingFunding {
+			reserved += investment.Amount
+		}
+	}
+	return roundAmount(reserved)
+}
+
+// ConfirmInvestmentFunding records by hand that the payment for an
+// investment was received, e.g. a bank transfer that didn't go through the
+// payment gateway. Confirming an investment that is already funded does
+// nothing.
+func (s *LoanService) ConfirmInvestmentFunding(ctx context.Context, req dto.ConfirmInvestmentFundingRequest) error {
+	var loan *models.Loan
+	var investment *models.Investment
+	funded := false
+	err := s.repo.WithinTx(ctx, func(txRepo repository.LoanRepositoryInterface) error {
+		var err error
+		loan, err = getLoanByUUID(ctx, txRepo, req.LoanUUID)
+		if err != nil {
+			return err
+		}
+
+		investment, err = txRepo.GetInvestmentByUUID(ctx, req.InvestmentUUID)
+		if err != nil {
+			return err
+		}
+		if investment == nil || investment.LoanID != loan.ID {
+			return fmt.Errorf("%w: %s", ErrInvestmentNotFound, req.InvestmentUUID)
+		}
+
+		funded, err = s.fundInvestment(ctx, txRepo, loan, investment, req.PaymentReference, "", req.PaidAt, req.EmployeeID)
+		return err
+	})
+	if err != nil || !funded {
+		return err
+	}
+
+	s.notifyInvestmentFunded(ctx, loan, investment, req.EmployeeID)
+
+	return nil
+}
+
+// HandleCollectionCallback funds the investment an investor paid for
+// through the payment gateway. Only collections the gateway reports as
+// SUCCEEDED fund an investment; a failed one leaves the reservation to run
+// out. Callbacks for investments that are already funded are ignored, so
+// the gateway can safely deliver them more than once: each collection is
+// recorded on its escrow deposit and only ever booked once. Money that
+// arrives after the reservation has lapsed or been cancelled, or a second
+// payment for an investment that is already funded, is booked into the
+// loan's escrow and refunded straight away rather than rejected, since the
+// gateway has already collected it.
+func (s *LoanService) HandleCollectionCallback(ctx context.Context, payload []byte, signature string) error {
+	callback, err := s.paymentGateway.ParseCollectionCallback(payload, signature)
+	if err != nil {
+		return err
+	}
+
+	log := logging.FromContext(ctx).WithFields(logrus.Fields{
+		"investment_uuid": callback.Reference,
+		"collection_id":   callback.CollectionID,
+	})
+	if enums.CollectionStatusFromString(callback.Status) != enums.CollectionStatusSucceeded {
+		log.WithField("status", callback.Status).Warn("investor payment not collected")
+		return nil
+	}
+
+	paidAt := callback.PaidAt
+	if paidAt.IsZero() {
+		paidAt = time.Now()
+	}
+
+	var loan *models.Loan
+	var investment *models.Investment
+	funded, refunded := false, false
+	err = s.repo.WithinTx(ctx, func(txRepo repository.LoanRepositoryInterface) error {
+		var err error
+		investment, err = txRepo.GetInvestmentByUUID(ctx, callback.Reference)
+		if err != nil {
+			return err
+		}
+		if investment == nil {
+			return fmt.Errorf("%w: %s", ErrInvestmentNotFound, callback.Reference)
+		}
+
+		if investment.PaymentReference == callback.CollectionID {
+			return nil
+		}
+		booked, err := txRepo.GetLoanEscrowEntryByCollectionID(ctx, callback.CollectionID)
+		if err != nil || booked != nil {
+			return err
+		}
+
+		loan, err = txRepo.GetLoanByID(ctx, investment.LoanID)
+		if err != nil {
+			return err
+		}
+
+		if investment.Status != enums.InvestmentStatusPendingFunding || reservationLapsed(investment, time.Now()) || loan.Status != enums.LoanStatusApproved {
+			refunded = true
+			return s.refundLateCollection(ctx, txRepo, loan, investment, callback.CollectionID, roundAmount(callback.Amount), paidAt)
+		}
+		if roundAmount(callback.Amount) != investment.Amount {
+			return fmt.Errorf("%w: paid %.2f, reserved %.2f", ErrFundingAmountMismatch, callback.Amount, investment.Amount)
+		}
+
+		funded, err = s.fundInvestment(ctx, txRepo, loan, investment, callback.CollectionID, callback.CollectionID, paidAt, investment.InvestorID)
+		return err
+	})
+	if err != nil {
+		return err
+	}
+	if refunded {
+		log.WithFields(logrus.Fields{
+			"loan_uuid":         loan.UUID,
+			"investment_status": investment.Status.String(),
+			"amount":            callback.Amount,
+		}).Warn("investor payment could not fund the investment, refunded from escrow")
+
+		err = s.notificationClient.SendEmail(ctx, client.SendEmailRequest{
+			To:      investment.InvestorID, // notification service will get the email from the investor id
+			Subject: "Payment Refunded",
+			Body:    fmt.Sprintf("We received your payment of %.2f after your investment could no longer be funded, so it has been refunded.", callback.Amount),
+		})
+		if err != nil {
+			log.WithError(err).WithField("investor_id", investment.InvestorID).Error("failed to send refund notification")
+		}
+		return nil
+	}
+	if !funded {
+		return nil
+	}
+
+	s.notifyInvestmentFunded(ctx, loan, investment, investment.InvestorID)
+
+	return nil
+}
+
+// fundInvestment marks a reserved investment as paid for, adds it to the
+// loan's investment amount and deposits the money in the loan's escrow. The
+// loan moves to INVESTED once its principal is fully funded. It reports
+// false for an investment that was already funded. collectionID is the
+// payment gateway collection that paid for it, if any.
+func (s *LoanService) fundInvestment(ctx context.Context, txRepo repository.LoanRepositoryInterface, loan *models.Loan, investment *models.Investment, paymentReference, collectionID string, paidAt time.Time, actor string) (bool, error) {
+	switch investment.Status {
+	case enums.InvestmentStatusFunded:
+		return false, nil
+	case enums.InvestmentStatusPendingFunding:
+	default:
+		return false, fmt.Errorf("%w: investment is %s", ErrReservationExpired, investment.Status)
+	}
+
+	if reservationLapsed(investment, time.Now()) {
+		return false, fmt.Errorf("%w: reservation ran out at %s", ErrReservationExpired, investment.FundingExpiresAt.Time.Format(time.RFC3339))
+	}
+	if loan.Status != enums.LoanStatusApproved {
+		return false, fmt.Errorf("%w: only approved loans can be funded", ErrInvalidLoanStatus)
+	}
+
+	investment.Status = enums.InvestmentStatusFunded
+	investment.FundedAt = sql.NullTime{Time: paidAt, Valid: true}
+	investment.PaymentReference = paymentReference
+	if err := txRepo.UpdateInvestment(ctx, investment, []string{"status", "funded_at", "payment_reference"}); err != nil {
+		return false, err
+	}
+
+	loan.InvestmentAmount = roundAmount(loan.InvestmentAmount + investment.Amount)
+	deposit := &models.LoanEscrowEntry{InvestmentID: &investment.ID, EntryType: enums.EscrowEntryTypeDeposit, Amount: investment.Amount, EffectiveAt: paidAt}
+	if collectionID != "" {
+		deposit.CollectionID = &collectionID
+	}
+	if err := s.createEscrowEntry(ctx, txRepo, loan, deposit); err != nil {
+		return false, err
+	}
+
+	if loan.InvestmentAmount >= loan.PrincipalAmount {
+		return true, s.transitionLoanStatus(ctx, txRepo, loan, enums.LoanStatusInvested, actor, "principal fully funded", "investment_amount", "escrow_balance")
+	}
+
+	return true, txRepo.UpdateLoan(ctx, loan, []string{"investment_amount", "escrow_balance"})
+}
+
+// reservationLapsed reports whether a reservation's funding window closed
+// before now, even if the expiry job hasn't released it yet.
+func reservationLapsed(investment *models.Investment, now time.Time) bool {
+	return investment.FundingExpiresAt.Valid && investment.FundingExpiresAt.Time.Before(now)
+}
+
+// refundLateCollection books a payment collected for an investment that can
+// no longer be funded into the loan's escrow and refunds it in the same
+// breath, so the money is accounted for without counting towards the loan.
+// A reservation still waiting for the payment is expired along with it, so
+// it stops holding a share of the loan and of the investor's exposure; an
+// investment's own payment reference is never touched. The collection id is
+// recorded on the deposit, which makes a redelivered callback a no-op.
+func (s *LoanService) refundLateCollection(ctx context.Context, txRepo repository.LoanRepositoryInterface, loan *models.Loan, investment *models.Investment, collectionID string, amount float64, paidAt time.Time) error {
+	now := time.Now()
+	if investment.Status == enums.InvestmentStatusPendingFunding || investment.Status == enums.InvestmentStatusExpired {
+		investment.Status = enums.InvestmentStatusExpired
+		investment.RefundedAt = sql.NullTime{Time: now, Valid: true}
+		if err := txRepo.UpdateInvestment(ctx, investment, []string{"status", "refunded_at"}); err != nil {
+			return err
+		}
+	}
+
+	deposit := &models.LoanEscrowEntry{InvestmentID: &investment.ID, CollectionID: &collectionID, EntryType: enums.EscrowEntryTypeDeposit, Amount: amount, EffectiveAt: paidAt}
+	if err := s.createEscrowEntry(ctx, txRepo, loan, deposit); err != nil {
+		return err
+	}
+	if err := s.recordEscrowEntry(ctx, txRepo, loan, enums.EscrowEntryTypeRefund, amount, &investment.ID, nil, now); err != nil {
+		return err
+	}
+
+	return txRepo.UpdateLoan(ctx, loan, []string{"escrow_balance"})
+}
+
+// recordEscrowEntry moves amount in or out of the loan's escrow and records
+// the movement. The caller persists the loan's new escrow balance.
+func (s *LoanService) recordEscrowEntry(ctx context.Context, txRepo repository.LoanRepositoryInterface, loan *models.Loan, entryType enums.EscrowEntryType, amount float64, investmentID, disbursementID *int, at time.Time) error {
+	return s.createEscrowEntry(ctx, txRepo, loan, &models.LoanEscrowEntry{
+		InvestmentID:       investmentID,
+		LoanDisbursementID: disbursementID,
+		EntryType:          entryType,
+		Amount:             amount,
+		EffectiveAt:        at,
+	})
+}
+
+// createEscrowEntry applies entry to the loan's escrow balance and records
+// it, for callers that need to set more than recordEscrowEntry takes. The
+// caller persists the loan's new escrow balance.
+func (s *LoanService) createEscrowEntry(ctx context.Context, txRepo repository.LoanRepositoryInterface, loan *models.Loan, entry *models.LoanEscrowEntry) error {
+	if entry.EntryType == enums.EscrowEntryTypeDeposit {
+		loan.EscrowBalance = roundAmount(loan.EscrowBalance + entry.Amount)
+	} else {
+		loan.EscrowBalance = roundAmount(loan.EscrowBalance - entry.Amount)
+	}
+
+	entry.LoanID = loan.ID
+	entry.BalanceAfter = loan.EscrowBalance
+	return txRepo.CreateLoanEscrowEntry(ctx, entry)
+}
+
+// notifyInvestmentFunded logs a funded investment and sends the investor
+// their agreement letter. The funding is already committed, so a failed
+// notification is only logged.
+func (s *LoanService) notifyInvestmentFunded(ctx context.Context, loan *models.Loan, investment *models.Investment, actor string) {
+	log := logging.FromContext(ctx).WithFields(logrus.Fields{
+		"loan_uuid":       loan.UUID,
+		"actor":           actor,
+		"investment_uuid": investment.UUID,
+	})
+	log.WithField("amount", investment.Amount).Info("investment funded")
+
+	// send agreement letter attached to email to investor
+	err := s.notificationClient.SendEmail(ctx, client.SendEmailRequest{
+		To:      investment.InvestorID, // notification service will get the email from the investor id
+		Subject: "Loan Agreement Letter",
+		Body:    "Please find the agreement letter attached to this email.",
+		Attachments: []client.Attachment{
+			{
+				Filename: "agreement_letter.pdf",
+				Content:  investment.AgreementLetterURL,
+				Type:     "application/pdf",
+			},
+		},
+	})
+	if err != nil {
+		log.WithError(err).WithField("investor_id", investment.InvestorID).Error("failed to send agreement letter")
+	}
+}
+
+// CancelInvestment withdraws an investment during its cooling-off period,
+// which runs from when it was funded, or from when it was reserved if it
+// hasn't been yet. The loan must not have been disbursed, nor have a payout
+// on its way to the borrower. A funded investment is taken
+// off the loan's investment amount and refunded from escrow, moving an
+// INVESTED loan back to APPROVED; a reservation simply frees its share. The
+// agreement letter is voided either way.
+func (s *LoanService) CancelInvestment(ctx context.Context, req dto.CancelInvestmentRequest) error {
+	var loan *models.Loan
+	var investment *models.Investment
+	err := s.repo.WithinTx(ctx, func(txRepo repository.LoanRepositoryInterface) error {
+		var err error
+		investment, err = txRepo.GetInvestmentByUUID(ctx, req.InvestmentUUID)
+		if err != nil {
+			return err
+		}
+		if investment == nil || investment.InvestorID != req.InvestorID {
+			return fmt.Errorf("%w: %s", ErrInvestmentNotFound, req.InvestmentUUID)
+		}
+
+		if investment.Status != enums.InvestmentStatusFunded && investment.Status != enums.InvestmentStatusPendingFunding {
+			return fmt.Errorf("%w: investment is %s", ErrInvestmentNotCancellable, investment.Status)
+		}
+
+		now := time.Now()
+		coolingOffStarts := investment.CreatedAt
+		if investment.Status == enums.InvestmentStatusFunded && investment.FundedAt.Valid {
+			coolingOffStarts = investment.FundedAt.Time
+		}
+		if coolingOffEnds := coolingOffStarts.Add(s.config.InvestmentCoolingOffPeriod); now.After(coolingOffEnds) {
+			return fmt.Errorf("%w: it ended at %s", ErrCoolingOffPeriodEnded, coolingOffEnds.Format(time.RFC3339))
+		}
+
+		loan, err = txRepo.GetLoanByID(ctx, investment.LoanID)
+		if err != nil {
+			return err
+		}
+		if loan.Status != enums.LoanStatusApproved && loan.Status != enums.LoanStatusInvested {
+			return fmt.Errorf("%w: investments can only be cancelled before the loan is disbursed", ErrInvalidLoanStatus)
+		}
+
+		disbursements, err := txRepo.GetLoanDisbursementsByLoanID(ctx, loan.ID)
+		if err != nil {
+			return err
+		}
+		for _, disbursement := range disbursements {
+			if disbursement.PayoutStatus == enums.PayoutStatusPending || disbursement.PayoutStatus == enums.PayoutStatusSucceeded {
+				return fmt.Errorf("%w: payout %s to the borrower is %s", ErrInvestmentNotCancellable, disbursement.UUID, disbursement.PayoutStatus)
+			}
+		}
+
+		funded := investment.Status == enums.InvestmentStatusFunded
+		investment.Status = enums.InvestmentStatusCancelled
+		investment.CancelledAt = sql.NullTime{Time: now, Valid: true}
+		investment.AgreementLetterVoidedAt = sql.NullTime{Time: now, Valid: true}
+		if err := txRepo.UpdateInvestment(ctx, investment, []string{"status", "cancelled_at", "agreement_letter_voided_at"}); err != nil {
+			return err
+		}
+
+		if !funded {
+			// Bumping the version keeps a concurrent investment from
+			// working with the share this reservation held.
+			return txRepo.UpdateLoan(ctx, loan, nil)
+		}
+
+		loan.InvestmentAmount = roundAmount(loan.InvestmentAmount - investment.Amount)
+		if err := s.recordEscrowEntry(ctx, txRepo, loan, enums.EscrowEntryTypeRefund, investment.Amount, &investment.ID, nil, now); err != nil {
+			return err
+		}
+
+		if loan.Status == enums.LoanStatusInvested {
+			return s.transitionLoanStatus(ctx, txRepo, loan, enums.LoanStatusApproved, req.InvestorID, "investment cancelled during cooling-off period", "investment_amount", "escrow_balance")
+		}
+		return txRepo.UpdateLoan(ctx, loan, []string{"investment_amount", "escrow_balance"})
+	})
+	if err != nil {
+		return err
+	}
+
+	log := logging.FromContext(ctx).WithFields(logrus.Fields{
+		"loan_uuid":       loan.UUID,
+		"actor":           req.InvestorID,
+		"investment_uuid": investment.UUID,
+	})
+	log.WithFields(logrus.Fields{"amount": investment.Amount, "reason": req.Reason}).Info("investment cancelled")
+
+	err = s.notificationClient.SendEmail(ctx, client.SendEmailRequest{
+		To:      investment.InvestorID, // notification service will get the email from the investor id
+		Subject: "Investment Cancelled",
+		Body:    fmt.Sprintf("Your investment of %.2f has been cancelled and its agreement letter is void.", investment.Amount),
+	})
+	if err != nil {
+		log.WithError(err).WithField("investor_id", investment.InvestorID).Error("failed to send cancellation notification")
+	}
+
+	return nil
+}
+
+func generateAgreementLetterURL(_ context.Context, _ *models.Loan, _ *models.Investment) (string, error) {
+	// generate agreement letter
+	return "", nil
+}
+
+// CreateLoanDisbursement pays out a tranche of a funded loan to the
+// borrower's bank account through the payment gateway. The payout completes
+// asynchronously: the tranche only counts as disbursed once the gateway
+// confirms it through HandlePayoutCallback. Pending payouts are reserved
+// against the funded amount so it can't be paid out twice.
+func (s *LoanService) CreateLoanDisbursement(ctx context.Context, req dto.CreateLoanDisbursementRequest) error {
+	var loan *models.Loan
+	var disbursement *models.LoanDisbursement
+	err := s.repo.WithinTx(ctx, func(txRepo repository.LoanRepositoryInterface) error {
+		var err error
+		loan, err = getLoanByUUID(ctx, txRepo, req.LoanUUID)
+		if err != nil {
+			return err
+		}
+
+		if err := checkExpectedVersion(loan, req.ExpectedVersion); err != nil {
+			return err
+		}
+
+		if loan.Status != enums.LoanStatusInvested && loan.Status != enums.LoanStatusPartiallyDisbursed {
+			return fmt.Errorf("%w: only invested or partially disbursed loans can be disbursed", ErrInvalidLoanStatus)
+		}
+
+		disbursements, err := txRepo.GetLoanDisbursementsByLoanID(ctx, loan.ID)
+		if err != nil {
+			return err
+		}
+
+		available := loan.UndisbursedAmount()
+		for _, existing := range disbursements {
+			if existing.PayoutStatus == enums.PayoutStatusPending {
+				available -= existing.Amount
+			}
+		}
+		available = roundAmount(available)
+
+		amount := req.Amount
+		if amount == 0 {
+			amount = available
+		}
+		if amount <= 0 || amount > available {
+			return fmt.Errorf("%w: %.2f requested, %.2f undisbursed", ErrDisbursementExceedsFunding, amount, available)
+		}
+
+		disbursement = &models.LoanDisbursement{
+			LoanID:                   loan.ID,
+			Amount:                   amount,
+			FieldOfficerEmployeeID:   req.EmployeeID,
+			SignedAgreementLetterURL: req.SignedAgreementLetterURL,
+			AccountHolderName:        req.BankAccount.AccountHolderName,
+			BankCode:                 req.BankAccount.BankCode,
+			BankAccountNumber:        req.BankAccount.AccountNumber,
+			PayoutStatus:             enums.PayoutStatusPending,
+			DisbursedAt:              req.DisbursedAt,
+		}
+
+		if err := txRepo.CreateLoanDisbursement(ctx, disbursement); err != nil {
+			return err
+		}
+
+		// Bumping the version makes a concurrent disbursement that read the
+		// same available amount fail instead of reserving it again.
+		return txRepo.UpdateLoan(ctx, loan, nil)
+	})
+	if err != nil {
+		return err
+	}
+
+	log := logging.FromContext(ctx).WithFields(logrus.Fields{
+		"loan_uuid":        loan.UUID,
+		"actor":            req.EmployeeID,
+		"amount":           disbursement.Amount,
+		"payout_reference": disbursement.UUID,
+	})
+
+	payout, err := s.paymentGateway.InitiatePayout(ctx, client.InitiatePayoutRequest{
+		Reference: disbursement.UUID,
+		Amount:    disbursement.Amount,
+		BankAccount: client.BankAccount{
+			AccountHolderName: disbursement.AccountHolderName,
+			BankCode:          disbursement.BankCode,
+			AccountNumber:     disbursement.BankAccountNumber,
+		},
+		Description: fmt.Sprintf("Disbursement of loan %s", loan.UUID),
+	})
+	if errors.Is(err, client.ErrPayoutRejected) {
+		disbursement.PayoutStatus = enums.PayoutStatusFailed
+		disbursement.FailureReason = err.Error()
+		if updateErr := s.repo.UpdateLoanDisbursement(ctx, disbursement, []string{"payout_status", "failure_reason"}); updateErr != nil {
+			log.WithError(updateErr).Error("failed to record rejected payout")
+		}
+		return fmt.Errorf("%w: %v", ErrPayoutFailed, err)
+	}
+	if err != nil {
+		// The gateway may have accepted the payout before failing to answer,
+		// so the disbursement stays pending until its callback arrives.
+		log.WithError(err).Error("payout outcome unknown")
+		return fmt.Errorf("%w: %v", ErrPayoutFailed, err)
+	}
+
+	disbursement.GatewayPayoutID = &payout.PayoutID
+	if err := s.repo.UpdateLoanDisbursement(ctx, disbursement, []string{"gateway_payout_id"}); err != nil {
+		log.WithError(err).Error("failed to record gateway payout id")
+	}
+
+	log.Info("loan payout initiated")
+
+	return nil
+}
+
+// HandlePayoutCallback settles a disbursement with the outcome the payment
+// gateway reported in a signed callback. A confirmed payout adds the tranche
+// to the loan's disbursed amount; the tranche that completes the funded
+// amount moves the loan to DISBURSED and starts its instalment schedule.
+// Callbacks for payouts that were already settled are ignored, so the
+// gateway can safely deliver them more than once.
+func (s *LoanService) HandlePayoutCallback(ctx context.Context, payload []byte, signature string) error {
+	callback, err := s.paymentGateway.ParseCallback(payload, signature)
+	if err != nil {
+		return err
+	}
+
+	status := enums.PayoutStatusFromString(callback.Status)
+	var loan *models.Loan
+	var disbursement *models.LoanDisbursement
+	settled, flagged := false, false
+	err = s.repo.WithinTx(ctx, func(txRepo repository.LoanRepositoryInterface) error {
+		var err error
+		disbursement, err = txRepo.GetLoanDisbursementByUUID(ctx, callback.Reference)
+		if err != nil {
+			return err
+		}
+		if disbursement == nil {
+			return fmt.Errorf("%w: %s", ErrPayoutNotFound, callback.Reference)
+		}
+
+		if disbursement.PayoutStatus != enums.PayoutStatusPending {
+			return nil
+		}
+
+		if callback.PayoutID != "" {
+			disbursement.GatewayPayoutID = &callback.PayoutID
+		}
+
+		switch status {
+		case enums.PayoutStatusFailed:
+			disbursement.PayoutStatus = enums.PayoutStatusFailed
+			disbursement.FailureReason = callback.FailureReason
+			settled = true
+			return txRepo.UpdateLoanDisbursement(ctx, disbursement, []string{"payout_status", "gateway_payout_id", "failure_reason"})
+		case enums.PayoutStatusSucceeded:
+		default:
+			return nil
+		}
+
+		loan, err = txRepo.GetLoanByID(ctx, disbursement.LoanID)
+		if err != nil {
+			return err
+		}
+
+		disbursement.PayoutStatus = enums.PayoutStatusSucceeded
+		if !callback.CompletedAt.IsZero() {
+			disbursement.DisbursedAt = callback.CompletedAt
+		}
+		if err := txRepo.UpdateLoanDisbursement(ctx, disbursement, []string{"payout_status", "gateway_payout_id", "disbursed_at"}); err != nil {
+			return err
+		}
+		settled = true
+
+		loan.DisbursedAmount = roundAmount(loan.DisbursedAmount + disbursement.Amount)
+		if err := s.recordEscrowEntry(ctx, txRepo, loan, enums.EscrowEntryTypeRelease, disbursement.Amount, nil, &disbursement.ID, disbursement.DisbursedAt); err != nil {
+			return err
+		}
+
+		// The gateway has already paid the borrower, so the payout is booked
+		// whatever state the loan got into in the meantime; the loan keeps
+		// its status and is flagged for someone to sort out.
+		if loan.Status != enums.LoanStatusInvested && loan.Status != enums.LoanStatusPartiallyDisbursed {
+			loan.FlaggedAt = sql.NullTime{Time: time.Now(), Valid: true}
+			loan.FlagReason = fmt.Sprintf("payout %s confirmed while the loan was %s", disbursement.UUID, loan.Status)
+			flagged = true
+			return txRepo.UpdateLoan(ctx, loan, []string{"disbursed_amount", "escrow_balance", "flagged_at", "flag_reason"})
+		}
+
+		if loan.DisbursedAmount < loan.InvestmentAmount {
+			if loan.Status == enums.LoanStatusPartiallyDisbursed {
+				return txRepo.UpdateLoan(ctx, loan, []string{"disbursed_amount", "escrow_balance"})
+			}
+			return s.transitionLoanStatus(ctx, txRepo, loan, enums.LoanStatusPartiallyDisbursed, disbursement.FieldOfficerEmployeeID, "tranche disbursed to borrower", "disbursed_amount", "escrow_balance")
+		}
+
+		if err := txRepo.CreateLoanInstalments(ctx, buildInstalmentSchedule(loan, disbursement.DisbursedAt)); err != nil {
+			return err
+		}
+
+		return s.transitionLoanStatus(ctx, txRepo, loan, enums.LoanStatusDisbursed, disbursement.FieldOfficerEmployeeID, "disbursed to borrower", "disbursed_amount", "escrow_balance")
+	})
+	if err != nil || !settled {
+		return err
+	}
+
+	log := logging.FromContext(ctx).WithFields(logrus.Fields{
+		"payout_reference": disbursement.UUID,
+		"amount":           disbursement.Amount,
+	})
+	switch {
+	case disbursement.PayoutStatus == enums.PayoutStatusFailed:
+		log.WithField("failure_reason", disbursement.FailureReason).Warn("loan payout failed")
+	case flagged:
+		log.WithFields(logrus.Fields{"loan_uuid": loan.UUID, "flag_reason": loan.FlagReason}).Error("loan payout confirmed in an unexpected status, loan flagged for review")
+	case loan.Status == enums.LoanStatusPartiallyDisbursed:
+		log.WithField("loan_uuid", loan.UUID).Info("loan tranche disbursed")
+	default:
+		log.WithField("loan_uuid", loan.UUID).Info("loan disbursed")
+	}
+
+	return nil
+}
+
+// paidOutDisbursements keeps the disbursements whose payout the gateway
+// confirmed, i.e. the money the borrower actually received.
+func paidOutDisbursements(disbursements []models.LoanDisbursement) []models.LoanDisbursement {
+	var paidOut []models.LoanDisbursement
+	for _, disbursement := range disbursements {
+		if disbursement.PayoutStatus == enums.PayoutStatusSucceeded {
+			paidOut = append(paidOut, disbursement)
+		}
+	}
+	return paidOut
+}
+
+// DeclareDefault moves a DISBURSED or DELINQUENT loan to DEFAULTED and keeps
+// the reason and supporting evidence.
+func (s *LoanService) DeclareDefault(ctx context.Context, req dto.DeclareDefaultRequest) error {
+	var loan *models.Loan
+	err := s.repo.WithinTx(ctx, func(txRepo repository.LoanRepositoryInterface) error {
+		var err error
+		loan, err = getLoanByUUID(ctx, txRepo, req.LoanUUID)
+		if err != nil {
+			return err
+		}
+
+		if err := checkExpectedVersion(loan, req.ExpectedVersion); err != nil {
+			return err
+		}
+
+		if loan.Status != enums.LoanStatusDisbursed && loan.Status != enums.LoanStatusDelinquent {
+			return fmt.Errorf("%w: only disbursed or delinquent loans can be declared in default", ErrInvalidLoanStatus)
+		}
+
+		loanDefault := &models.LoanDefault{
+			LoanID:     loan.ID,
+			EmployeeID: req.EmployeeID,
+			Reason:     req.Reason,
+			DeclaredAt: req.DeclaredAt,
+		}
+		if err := txRepo.CreateLoanDefault(ctx, loanDefault); err != nil {
+			return err
+		}
+
+		for _, evidence := range req.Evidence {
+			if err := txRepo.CreateLoanDefaultEvidence(ctx, &models.LoanDefaultEvidence{
+				LoanDefaultID: loanDefault.ID,
+				EvidenceURL:   evidence.EvidenceURL,
+				Category:      evidence.Category,
+			}); err != nil {
+				return err
+			}
+		}
+
+		return s.transitionLoanStatus(ctx, txRepo, loan, enums.LoanStatusDefaulted, req.EmployeeID, req.Reason)
+	})
+	if err != nil {
+		return err
+	}
+
+	logging.FromContext(ctx).WithFields(logrus.Fields{
+		"loan_uuid": loan.UUID,
+		"actor":     req.EmployeeID,
+	}).Info("loan defaulted")
+
+	return nil
+}
+
+// WriteOffLoan writes off the outstanding principal of a DEFAULTED loan. The
+// loss is booked on the loan's ledger and allocated to its investors in
+// proportion to their investments, and each investor is told about their
+// share.
+func (s *LoanService) WriteOffLoan(ctx context.Context, req dto.WriteOffLoanRequest) error {
+	reason := req.Reason
+	if reason == "" {
+		reason = "remaining balance written off"
+	}
+
+	var loan *models.Loan
+	var funded []models.Investment
+	var losses []float64
+	var amount float64
+	err := s.repo.WithinTx(ctx, func(txRepo repository.LoanRepositoryInterface) error {
+		var err error
+		loan, err = getLoanByUUID(ctx, txRepo, req.LoanUUID)
+		if err != nil {
+			return err
+		}
+
+		if err := checkExpectedVersion(loan, req.ExpectedVersion); err != nil {
+			return err
+		}
+
+		if loan.Status != enums.LoanStatusDefaulted {
+			return fmt.Errorf("%w: only defaulted loans can be written off", ErrInvalidLoanStatus)
+		}
+
+		instalments, err := txRepo.GetLoanInstalmentsByLoanID(ctx, loan.ID)
+		if err != nil {
+			return err
+		}
+		amount = outstandingPrincipal(loan, instalments)
+
+		investments, err := txRepo.GetInvestmentsByLoanID(ctx, loan.ID)
+		if err != nil {
+			return err
+		}
+		for _, investment := range investments {
+			if investment.Status == enums.InvestmentStatusFunded {
+				funded = append(funded, investment)
+			}
+		}
+
+		losses = allocateProRata(amount, funded)
+		for i := range funded {
+			funded[i].RealizedLoss = roundAmount(funded[i].RealizedLoss + losses[i])
+			if err := txRepo.UpdateInvestment(ctx, &funded[i], []string{"realized_loss"}); err != nil {
+				return err
+			}
+		}
+
+		if err := txRepo.CreateLoanLedgerEntry(ctx, &models.LoanLedgerEntry{
+			LoanID:        loan.ID,
+			EntryType:     enums.LedgerEntryTypeWriteOff,
+			Amount:        amount,
+			EffectiveDate: dateOf(req.WrittenOffAt),
+			Description:   reason,
+		}); err != nil {
+			return err
+		}
+
+		return s.transitionLoanStatus(ctx, txRepo, loan, enums.LoanStatusWrittenOff, req.EmployeeID, reason)
+	})
+	if err != nil {
+		return err
+	}
+
+	log := logging.FromContext(ctx).WithFields(logrus.Fields{
+		"loan_uuid": loan.UUID,
+		"actor":     req.EmployeeID,
+		"amount":    amount,
+	})
+	log.Info("loan written off")
+
+	// The write-off is already committed, so a failed notification is logged
+	// rather than undoing it.
+	for i, investment := range funded {
+		err := s.notificationClient.SendEmail(ctx, client.SendEmailRequest{
+			To:      investment.InvestorID, // notification service will get the email from the investor id
+			Subject: "Loan Written Off",
+			Body:    fmt.Sprintf("A loan you invested in has been written off. Your realized loss on your investment of %.2f is %.2f.", investment.Amount, losses[i]),
+		})
+		if err != nil {
+			log.WithError(err).WithField("investor_id", investment.InvestorID).Error("failed to send write-off notification")
+		}
+	}
+
+	return nil
+}
+
+// GetPayoffQuote prices settling a DISBURSED or DELINQUENT loan in full on
+// asOf.
+func (s *LoanService) GetPayoffQuote(ctx context.Context, uuid string, asOf time.Time) (dto.PayoffQuoteResponse, error) {
+	loan, err := getLoanByUUID(ctx, s.repo, uuid)
+	if err != nil {
+		return dto.PayoffQuoteResponse{}, err
+	}
+
+	quote, err := s.payoffQuote(ctx, s.repo, loan, dateOf(asOf))
+	if err != nil {
+		return dto.PayoffQuoteResponse{}, err
+	}
+
+	return dto.PayoffQuoteResponse{
+		LoanUUID:             loan.UUID,
+		AsOf:                 quote.asOf,
+		OutstandingPrincipal: quote.outstandingPrincipal,
+		AccruedInterest:      quote.accruedInterest,
+		LateFees:             quote.lateFees,
+		PrepaymentPenalty:    quote.prepaymentPenalty,
+		Total:                quote.total(),
+	}, nil
+}
+
+// payoffQuote loads through repo what is needed to price an early payoff of
+// loan on asOf.
+func (s *LoanService) payoffQuote(ctx context.Context, repo repository.LoanRepositoryInterface, loan *models.Loan, asOf time.Time) (payoffQuote, error) {
+	if loan.Status != enums.LoanStatusDisbursed && loan.Status != enums.LoanStatusDelinquent {
+		return payoffQuote{}, fmt.Errorf("%w: only disbursed or delinquent loans can be paid off", ErrInvalidLoanStatus)
+	}
+
+	disbursements, err := repo.GetLoanDisbursementsByLoanID(ctx, loan.ID)
+	if err != nil {
+		return payoffQuote{}, err
+	}
+	disbursements = paidOutDisbursements(disbursements)
+	if len(disbursements) == 0 {
+		return payoffQuote{}, errors.New("loan has no disbursement")
+	}
+	// The schedule starts with the tranche that completed the disbursement.
+	disbursedAt := disbursements[len(disbursements)-1].DisbursedAt
+	if asOf.Before(dateOf(disbursedAt)) {
+		return payoffQuote{}, fmt.Errorf("%w: the loan was disbursed on %s", ErrInvalidPayoffDate, dateOf(disbursedAt).Format(time.DateOnly))
+	}
+
+	instalments, err := repo.GetLoanInstalmentsByLoanID(ctx, loan.ID)
+	if err != nil {
+		return payoffQuote{}, err
+	}
+
+	entries, err := repo.GetLoanLedgerEntriesByLoanID(ctx, loan.ID)
+	if err != nil {
+		return payoffQuote{}, err
+	}
+
+	return buildPayoffQuote(loan, instalments, entries, disbursedAt, asOf, s.config), nil
+}
+
+// PrepayLoan settles a loan in full ahead of its schedule. Instalments
+// already due are marked paid and the rest are cancelled. Investors receive
+// the outstanding principal, their ROI share of the accrued interest and the
+// prepayment penalty pro rata to their investments; the interest spread and
+// late fees stay with the platform.
+func (s *LoanService) PrepayLoan(ctx context.Context, req dto.PrepayLoanRequest) error {
+	var loan *models.Loan
+	var payouts []models.InvestorPayout
+	err := s.repo.WithinTx(ctx, func(txRepo repository.LoanRepositoryInterface) error {
+		var err error
+		loan, err = getLoanByUUID(ctx, txRepo, req.LoanUUID)
+		if err != nil {
+			return err
+		}
+
+		if err := checkExpectedVersion(loan, req.ExpectedVersion); err != nil {
+			return err
+		}
+
+		asOf := dateOf(req.PaidAt)
+		quote, err := s.payoffQuote(ctx, txRepo, loan, asOf)
+		if err != nil {
+			return err
+		}
+		if roundAmount(req.Amount) != quote.total() {
+			return fmt.Errorf("%w: %.2f is due on %s", ErrPayoffAmountMismatch, quote.total(), asOf.Format(time.DateOnly))
+		}
+
+		instalments, err := txRepo.GetLoanInstalmentsByLoanID(ctx, loan.ID)
+		if err != nil {
+			return err
+		}
+		for i := range instalments {
+			instalment := &instalments[i]
+			if instalment.IsSettled() {
+				continue
+			}
+
+			fields := []string{"cancelled_at"}
+			if instalment.DueDate.After(asOf) {
+				instalment.CancelledAt = sql.NullTime{Time: req.PaidAt, Valid: true}
+			} else {
+				instalment.PaidAmount = instalment.AmountDue()
+				instalment.PaidAt = sql.NullTime{Time: req.PaidAt, Valid: true}
+				fields = []string{"paid_amount", "paid_at"}
+			}
+			if err := txRepo.UpdateLoanInstalment(ctx, instalment, fields); err != nil {
+				return err
+			}
+		}
+
+		if err := txRepo.CreateLoanLedgerEntry(ctx, &models.LoanLedgerEntry{
+			LoanID:        loan.ID,
+			EntryType:     enums.LedgerEntryTypePrepayment,
+			Amount:        quote.total(),
+			EffectiveDate: asOf,
+			Description:   "paid off early",
+		}); err != nil {
+			return err
+		}
+		if quote.prepaymentPenalty > 0 {
+			if err := txRepo.CreateLoanLedgerEntry(ctx, &models.LoanLedgerEntry{
+				LoanID:        loan.ID,
+				EntryType:     enums.LedgerEntryTypePrepaymentPenalty,
+				Amount:        quote.prepaymentPenalty,
+				EffectiveDate: asOf,
+				Description:   "prepayment penalty",
+			}); err != nil {
+				return err
+			}
+		}
+
+		investments, err := txRepo.GetInvestmentsByLoanID(ctx, loan.ID)
+		if err != nil {
+			return err
+		}
+		var funded []models.Investment
+		for _, investment := range investments {
+			if investment.Status == enums.InvestmentStatusFunded {
+				funded = append(funded, investment)
+			}
+		}
+
+		investorInterest := quote.accruedInterest
+		if loan.InterestRate > 0 && loan.ROIRate < loan.InterestRate {
+			investorInterest = roundAmount(quote.accruedInterest * loan.ROIRate / loan.InterestRate)
+		}
+		principalShares := allocateProRata(quote.outstandingPrincipal, funded)
+		interestShares := allocateProRata(investorInterest, funded)
+		penaltyShares := allocateProRata(quote.prepaymentPenalty, funded)
+		for i, investment := range funded {
+			payout := models.InvestorPayout{
+				LoanID:          loan.ID,
+				InvestmentID:    investment.ID,
+				InvestorID:      investment.InvestorID,
+				PrincipalAmount: principalShares[i],
+				InterestAmount:  interestShares[i],
+				PenaltyAmount:   penaltyShares[i],
+				PaidAt:          req.PaidAt,
+			}
+			if err := txRepo.CreateInvestorPayout(ctx, &payout); err != nil {
+				return err
+			}
+			payouts = append(payouts, payout)
+		}
+
+		loan.DaysPastDue = 0
+		loan.DPDBucket = enums.DPDBucketCurrent
+		return s.transitionLoanStatus(ctx, txRepo, loan, enums.LoanStatusRepaid, loan.BorrowerID, "paid off early", "days_past_due", "dpd_bucket")
+	})
+	if err != nil {
+		return err
+	}
+
+	log := logging.FromContext(ctx).WithFields(logrus.Fields{
+		"loan_uuid": loan.UUID,
+		"actor":     loan.BorrowerID,
+		"amount":    req.Amount,
+	})
+	log.Info("loan prepaid")
+
+	// The payoff is already committed, so a failed notification is logged
+	// rather than undoing it.
+	for _, payout := range payouts {
+		err := s.notificationClient.SendEmail(ctx, client.SendEmailRequest{
+			To:      payout.InvestorID, // notification service will get the email from the investor id
+			Subject: "Loan Repaid Early",
+			Body:    fmt.Sprintf("A loan you invested in has been paid off early. You will receive %.2f.", payout.Amount()),
+		})
+		if err != nil {
+			log.WithError(err).WithField("investor_id", payout.InvestorID).Error("failed to send prepayment notification")
+		}
+	}
+
+	return nil
+}
+
+// ExpireOverdueLoans moves APPROVED loans whose funding deadline has passed
+// to EXPIRED, refunds their investments and tells the investors. It returns
+// how many loans were expired; a failure on one loan does not stop the
+// others.
+func (s *LoanService) ExpireOverdueLoans(ctx context.Context) (int, error) {
+	now := time.Now()
+	loans, err := s.repo.GetLoansPastFundingDeadline(ctx, now)
+	if err != nil {
+		return 0, err
+	}
+
+	expired := 0
+	var errs []error
+	for _, candidate := range loans {
+		ok, err := s.expireLoan(ctx, candidate.UUID, now)
+		if err != nil {
+			logging.FromContext(ctx).WithError(err).WithField("loan_uuid", candidate.UUID).Error("failed to expire loan")
+			errs = append(errs, err)
+			continue
+		}
+		if ok {
+			expired++
+		}
+	}
+
+	return expired, errors.Join(errs...)
+}
+
+// expireLoan expires a single loan. It re-checks the loan inside the
+// transaction and reports false if it no longer qualifies, e.g. because it
+// was fully funded since it was listed.
+func (s *LoanService) expireLoan(ctx context.Context, loanUUID string, now time.Time) (bool, error) {
+	var loan *models.Loan
+	expired := false
+	err := s.repo.WithinTx(ctx, func(txRepo repository.LoanRepositoryInterface) error {
+		var err error
+		loan, err = getLoanByUUID(ctx, txRepo, loanUUID)
+		if err != nil {
+			return err
+		}
+
+		if loan.Status != enums.LoanStatusApproved || !loan.FundingDeadline.Valid || !loan.FundingDeadline.Time.Before(now) {
+			return nil
+		}
+
+		investments, err := txRepo.GetInvestmentsByLoanID(ctx, loan.ID)
+		if err != nil {
+			return err
+		}
+		for i := range investments {
+			investment := &investments[i]
+			switch investment.Status {
+			case enums.InvestmentStatusFunded:
+				loan.InvestmentAmount = roundAmount(loan.InvestmentAmount - investment.Amount)
+				if err := s.recordEscrowEntry(ctx, txRepo, loan, enums.EscrowEntryTypeRefund, investment.Amount, &investment.ID, nil, now); err != nil {
+					return err
+				}
+			case enums.InvestmentStatusPendingFunding:
+				investment.Status = enums.InvestmentStatusExpired
+				if err := txRepo.UpdateInvestment(ctx, investment, []string{"status"}); err != nil {
+					return err
+				}
+			}
+		}
+
+		if err := s.transitionLoanStatus(ctx, txRepo, loan, enums.LoanStatusExpired, systemActor, "funding deadline passed", "investment_amount", "escrow_balance"); err != nil {
+			return err
+		}
+		expired = true
+
+		return txRepo.RefundInvestmentsByLoanID(ctx, loan.ID, now)
+	})
+	if err != nil || !expired {
+		return false, err
+	}
+
+	log := logging.FromContext(ctx).WithFields(logrus.Fields{
+		"loan_uuid": loan.UUID,
+		"actor":     systemActor,
+	})
+	log.Info("loan expired")
+
+	investments, err := s.repo.GetInvestmentsByLoanID(ctx, loan.ID)
+	if err != nil {
+		log.WithError(err).Error("failed to load refunded investments")
+		return true, nil
+	}
+
+	// The refund is already committed, so a failed notification is logged
+	// rather than undoing the expiry.
+	for _, investment := range investments {
+		if investment.Status != enums.InvestmentStatusRefunded {
+			continue
+		}
+		err := s.notificationClient.SendEmail(ctx, client.SendEmailRequest{
+			To:      investment.InvestorID, // notification service will get the email from the investor id
+			Subject: "Loan Funding Expired",
+			Body:    fmt.Sprintf("The loan you invested in was not fully funded before its deadline. Your investment of %.2f has been refunded.", investment.Amount),
+		})
+		if err != nil {
+			log.WithError(err).WithField("investor_id", investment.InvestorID).Error("failed to send refund notification")
+		}
+	}
+
+	return true, nil
+}
+
+// ExpireInvestmentReservations releases the share of a loan held by
+// investments whose payment didn't arrive before their reservation ran out,
+// so other investors can take it up. It returns how many reservations were
+// released; a failure on one does not stop the others.
+func (s *LoanService) ExpireInvestmentReservations(ctx context.Context) (int, error) {
+	now := time.Now()
+	investments, err := s.repo.GetInvestmentsPastFundingExpiry(ctx, now)
+	if err != nil {
+		return 0, err
+	}
+
+	expired := 0
+	var errs []error
+	for _, candidate := range investments {
+		ok, err := s.expireReservation(ctx, candidate.UUID, now)
+		if err != nil {
+			logging.FromContext(ctx).WithError(err).WithField("investment_uuid", candidate.UUID).Error("failed to expire investment reservation")
+			errs = append(errs, err)
+			continue
+		}
+		if ok {
+			expired++
+		}
+	}
+
+	return expired, errors.Join(errs...)
+}
+
+// expireReservation expires a single investment. It re-checks the
+// investment inside the transaction and reports false if it no longer
+// qualifies, e.g. because its payment was confirmed since it was listed.
+func (s *LoanService) expireReservation(ctx context.Context, investmentUUID string, now time.Time) (bool, error) {
+	var investment *models.Investment
+	expired := false
+	err := s.repo.WithinTx(ctx, func(txRepo repository.LoanRepositoryInterface) error {
+		var err error
+		investment, err = txRepo.GetInvestmentByUUID(ctx, investmentUUID)
+		if err != nil || investment == nil {
+			return err
+		}
+
+		if investment.Status != enums.InvestmentStatusPendingFunding || !investment.FundingExpiresAt.Valid || !investment.FundingExpiresAt.Time.Before(now) {
+			return nil
+		}
+
+		investment.Status = enums.InvestmentStatusExpired
+		expired = true
+		return txRepo.UpdateInvestment(ctx, investment, []string{"status"})
+	})
+	if err != nil || !expired {
+		return false, err
+	}
+
+	log := logging.FromContext(ctx).WithFields(logrus.Fields{
+		"investment_uuid": investment.UUID,
+		"actor":           systemActor,
+	})
+	log.WithField("amount", investment.Amount).Info("investment reservation expired")
+
+	err = s.notificationClient.SendEmail(ctx, client.SendEmailRequest{
+		To:      investment.InvestorID, // notification service will get the email from the investor id
+		Subject: "Investment Reservation Expired",
+		Body:    fmt.Sprintf("We did not receive your payment of %.2f in time, so your investment reservation has been released.", investment.Amount),
+	})
+	if err != nil {
+		log.WithError(err).WithField("investor_id", investment.InvestorID).Error("failed to send reservation expiry notification")
+	}
+
+	return true, nil
+}
+
+// RefreshDelinquency recomputes days past due and the DPD bucket of every
+// disbursed loan from its instalment schedule, charges late fees on
+// instalments past the grace period and moves loans between DISBURSED and
+// DELINQUENT. It returns how many loans changed; a failure on one loan does
+// not stop the others.
+func (s *LoanService) RefreshDelinquency(ctx context.Context) (int, error) {
+	today := dateOf(time.Now())
+	loans, err := s.repo.GetLoansByStatuses(ctx, []enums.LoanStatus{enums.LoanStatusDisbursed, enums.LoanStatusDelinquent})
+	if err != nil {
+		return 0, err
+	}
+
+	changed := 0
+	var errs []error
+	for _, candidate := range loans {
+		ok, err := s.refreshLoanDelinquency(ctx, candidate.UUID, today)
+		if err != nil {
+			logging.FromContext(ctx).WithError(err).WithField("loan_uuid", candidate.UUID).Error("failed to refresh loan delinquency")
+			errs = append(errs, err)
+			continue
+		}
+		if ok {
+			changed++
+		}
+	}
+
+	return changed, errors.Join(errs...)
+}
+
+// refreshLoanDelinquency brings a single loan's arrears up to date as of
+// today and reports whether anything about the loan changed.
+func (s *LoanService) refreshLoanDelinquency(ctx context.Context, loanUUID string, today time.Time) (bool, error) {
+	var loan *models.Loan
+	var from enums.LoanStatus
+	changed := false
+	err := s.repo.WithinTx(ctx, func(txRepo repository.LoanRepositoryInterface) error {
+		var err error
+		loan, err = getLoanByUUID(ctx, txRepo, loanUUID)
+		if err != nil {
+			return err
+		}
+
+		if loan.Status != enums.LoanStatusDisbursed && loan.Status != enums.LoanStatusDelinquent {
+			return nil
+		}
+		from = loan.Status
+
+		instalments, err := txRepo.GetLoanInstalmentsByLoanID(ctx, loan.ID)
+		if err != nil {
+			return err
+		}
+
+		entries, err := txRepo.GetLoanLedgerEntriesByLoanID(ctx, loan.ID)
+		if err != nil {
+			return err
+		}
+
+		charged := make(map[int]bool, len(entries))
+		for _, entry := range entries {
+			if entry.EntryType == enums.LedgerEntryTypeLateFee && entry.LoanInstalmentID != nil {
+				charged[*entry.LoanInstalmentID] = true
+			}
+		}
+
+		graceDays := int(s.config.LateFeeGracePeriod.Hours() / 24)
+		daysPastDue := 0
+		for _, instalment := range instalments {
+			overdueDays := daysBetween(instalment.DueDate, today)
+			if instalment.IsSettled() || overdueDays <= 0 {
+				continue
+			}
+			// Instalments are ordered by due date, so the first unpaid
+			// overdue one sets the loan's days past due.
+			if daysPastDue == 0 {
+				daysPastDue = overdueDays
+			}
+
+			if overdueDays <= graceDays || charged[instalment.ID] {
+				continue
+			}
+			fee := roundAmount(s.config.LateFeeFlat + (instalment.AmountDue()-instalment.PaidAmount)*s.config.LateFeeRate/100)
+			if fee <= 0 {
+				continue
+			}
+			if err := txRepo.CreateLoanLedgerEntry(ctx, &models.LoanLedgerEntry{
+				LoanID:           loan.ID,
+				LoanInstalmentID: &instalment.ID,
+				EntryType:        enums.LedgerEntryTypeLateFee,
+				Amount:           fee,
+				EffectiveDate:    today,
+				Description:      fmt.Sprintf("late fee on instalment %d", instalment.Sequence),
+			}); err != nil {
+				return err
+			}
+			changed = true
+		}
+
+		bucket := enums.DPDBucketFromDays(daysPastDue)
+		status := enums.LoanStatusDisbursed
+		if daysPastDue > 0 {
+			status = enums.LoanStatusDelinquent
+		}
+		if loan.DaysPastDue == daysPastDue && loan.DPDBucket == bucket && loan.Status == status {
+			return nil
+		}
+		changed = true
+
+		loan.DaysPastDue = daysPastDue
+		loan.DPDBucket = bucket
+		if loan.Status == status {
+			return txRepo.UpdateLoan(ctx, loan, []string{"days_past_due", "dpd_bucket"})
+		}
+
+		reason := "instalment overdue"
+		if status == enums.LoanStatusDisbursed {
+			reason = "arrears cleared"
+		}
+		return s.transitionLoanStatus(ctx, txRepo, loan, status, systemActor, reason, "days_past_due", "dpd_bucket")
+	})
+	if err != nil || !changed {
+		return false, err
+	}
+
+	log := logging.FromContext(ctx).WithFields(logrus.Fields{
+		"loan_uuid":     loan.UUID,
+		"actor":         systemActor,
+		"days_past_due": loan.DaysPastDue,
+	})
+	switch {
+	case loan.Status == from:
+	case loan.Status == enums.LoanStatusDelinquent:
+		log.Info("loan delinquent")
+	default:
+		log.Info("loan arrears cleared")
+	}
+
+	return true, nil
+}
+
+// AccrueInterest recognises the interest every disbursed loan earned on each
+// day from through to, both inclusive, and posts it to the loan's ledger.
+// Accruals are stored once per loan and day: re-running a range leaves days
+// that still compute the same amount alone and corrects the rest, e.g. after
+// the day count convention was changed. It returns how many daily accruals
+// were posted or corrected; a failure on one loan does not stop the others.
+func (s *LoanService) AccrueInterest(ctx context.Context, from, to time.Time) (int, error) {
+	from, to = dateOf(from), dateOf(to)
+	if to.Before(from) {
+		return 0, fmt.Errorf("%w: %s is before %s", ErrInvalidAccrualRange, to.Format(time.DateOnly), from.Format(time.DateOnly))
+	}
+
+	loans, err := s.repo.GetLoansByStatuses(ctx, []enums.LoanStatus{
+		enums.LoanStatusPartiallyDisbursed,
+		enums.LoanStatusDisbursed,
+		enums.LoanStatusDelinquent,
+		enums.LoanStatusDefaulted,
+		enums.LoanStatusWrittenOff,
+		enums.LoanStatusRepaid,
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	posted := 0
+	var errs []error
+	for _, candidate := range loans {
+		count, err := s.accrueLoanInterest(ctx, candidate.UUID, from, to)
+		if err != nil {
+			logging.FromContext(ctx).WithError(err).WithField("loan_uuid", candidate.UUID).Error("failed to accrue loan interest")
+			errs = append(errs, err)
+			continue
+		}
+		posted += count
+	}
+
+	return posted, errors.Join(errs...)
+}
+
+// accrueLoanInterest brings a single loan's accruals for from through to in
+// line with what its terms compute and reports how many days it posted or
+// corrected.
+func (s *LoanService) accrueLoanInterest(ctx context.Context, loanUUID string, from, to time.Time) (int, error) {
+	convention := s.config.DayCountConvention
+	posted := 0
+	err := s.repo.WithinTx(ctx, func(txRepo repository.LoanRepositoryInterface) error {
+		posted = 0
+
+		loan, err := getLoanByUUID(ctx, txRepo, loanUUID)
+		if err != nil {
+			return err
+		}
+
+		disbursements, err := txRepo.GetLoanDisbursementsByLoanID(ctx, loan.ID)
+		if err != nil {
+			return err
+		}
+		disbursements = paidOutDisbursements(disbursements)
+		if len(disbursements) == 0 {
+			return nil
+		}
+
+		instalments, err := txRepo.GetLoanInstalmentsByLoanID(ctx, loan.ID)
+		if err != nil {
+			return err
+		}
+
+		transitions, err := txRepo.GetLoanStatusTransitionsByLoanID(ctx, loan.ID)
+		if err != nil {
+			return err
+		}
+
+		start, end := accrualPeriod(instalments, transitions, disbursements[0].DisbursedAt)
+		accruals := buildInterestAccruals(loan, disbursements, convention, start, end, from, to)
+		if len(accruals) == 0 {
+			return nil
+		}
+
+		existing, err := txRepo.GetLoanInterestAccruals(ctx, loan.ID, from, to)
+		if err != nil {
+			return err
+		}
+		byDate := make(map[time.Time]*models.LoanInterestAccrual, len(existing))
+		for i := range existing {
+			byDate[dateOf(existing[i].AccrualDate)] = &existing[i]
+		}
+
+		description := fmt.Sprintf("interest accrual (%s)", convention)
+		for _, accrual := range accruals {
+			current, ok := byDate[accrual.date]
+			if !ok {
+				entry := &models.LoanLedgerEntry{
+					LoanID:        loan.ID,
+					EntryType:     enums.LedgerEntryTypeInterestAccrual,
+					Amount:        accrual.posted,
+					EffectiveDate: accrual.date,
+					Description:   description,
+				}
+				if err := txRepo.CreateLoanLedgerEntry(ctx, entry); err != nil {
+					return err
+				}
+				if err := txRepo.CreateLoanInterestAccrual(ctx, &models.LoanInterestAccrual{
+					LoanID:             loan.ID,
+					LoanLedgerEntryID:  entry.ID,
+					AccrualDate:        accrual.date,
+					DayCountConvention: convention,
+					PrincipalAmount:    accrual.principal,
+					InterestRate:       loan.InterestRate,
+					Amount:             accrual.amount,
+				}); err != nil {
+					return err
+				}
+				posted++
+				continue
+			}
+
+			if current.Amount == accrual.amount && current.DayCountConvention == convention &&
+				current.PrincipalAmount == accrual.principal && current.InterestRate == loan.InterestRate {
+				continue
+			}
+			if err := txRepo.UpdateLoanLedgerEntry(ctx, &models.LoanLedgerEntry{
+				ID:          current.LoanLedgerEntryID,
+				Amount:      accrual.posted,
+				Description: description,
+			}, []string{"amount", "description"}); err != nil {
+				return err
+			}
+			current.DayCountConvention = convention
+			current.PrincipalAmount = accrual.principal
+			current.InterestRate = loan.InterestRate
+			current.Amount = accrual.amount
+			if err := txRepo.UpdateLoanInterestAccrual(ctx, current, []string{"day_count_convention", "principal_amount", "interest_rate", "amount"}); err != nil {
+				return err
+			}
+			posted++
+		}
+		return nil
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	if posted > 0 {
+		logging.FromContext(ctx).WithFields(logrus.Fields{
+			"loan_uuid": loanUUID,
+			"actor":     systemActor,
+			"days":      posted,
+		}).Info("loan interest accrued")
+	}
+
+	return posted, nil
+}
+
+// getLoanByUUID reads the loan through repo, reporting ErrLoanNotFound if
+// there is no loan with that UUID.
+func getLoanByUUID(ctx context.Context, repo repository.LoanRepositoryInterface, uuid string) (*models.Loan, error) {
+	loan, err := repo.GetLoanByUUID(ctx, uuid)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, fmt.Errorf("%w: %s", ErrLoanNotFound, uuid)
+	}
+	return loan, err
+}
+
+// checkExpectedVersion enforces a client precondition (If-Match) against the
+// loan as read inside the transaction.
+func checkExpectedVersion(loan *models.Loan, expectedVersion *int) error {
+	if expectedVersion != nil && *expectedVersion != loan.Version {
+		return ErrPreconditionFailed
+	}
 	return nil
 }
+
+// transitionLoanStatus moves the loan to the given status and records the
+// transition in the loan's status history. Fields the caller changed on loan
+// alongside the status can be passed as extraFields so they are written in
+// the same update. txRepo must be bound to the transaction performing the
+// status change.
+func (s *LoanService) transitionLoanStatus(ctx context.Context, txRepo repository.LoanRepositoryInterface, loan *models.Loan, to enums.LoanStatus, actor, reason string, extraFields ...string) error {
+	from := loan.Status
+	loan.Status = to
+	if err := txRepo.UpdateLoan(ctx, loan, append([]string{"status"}, extraFields...)); err != nil {
+		return err
+	}
+
+	logging.FromContext(ctx).WithFields(logrus.Fields{
+		"loan_uuid":   loan.UUID,
+		"actor":       actor,
+		"from_status": from.String(),
+		"to_status":   to.String(),
+	}).Debug("loan status changed")
+
+	return txRepo.CreateLoanStatusTransition(ctx, &models.LoanStatusTransition{
+		LoanID:     loan.ID,
+		FromStatus: &from,
+		ToStatus:   to,
+		Actor:      actor,
+		Reason:     reason,
+		At:         time.Now(),
+	})
+}
@@ -2,21 +2,38 @@ package service
 
 import (
 	"context"
+	"database/sql"
 	"errors"
 	"loan-service/enums"
 	"loan-service/internal/client"
+	"loan-service/internal/config"
 	"loan-service/internal/dto"
 	"loan-service/internal/models"
 	"loan-service/internal/repository"
 	"loan-service/mocks"
+	"math"
+	"reflect"
+	"strings"
+	"sync"
 	"testing"
 	"time"
 
 	"github.com/stretchr/testify/mock"
+	"gorm.io/driver/sqlite"
 	"gorm.io/gorm"
 )
 
+// withinTx makes a mocked WithinTx run the unit of work against txRepo and
+// return its result, the way the real repository does.
+func withinTx(txRepo repository.LoanRepositoryInterface) func(context.Context, func(repository.LoanRepositoryInterface) error) error {
+	return func(_ context.Context, fn func(repository.LoanRepositoryInterface) error) error {
+		return fn(txRepo)
+	}
+}
+
 func TestLoanService_CreateLoan(t *testing.T) {
+	disbursementDate := time.Now().AddDate(0, 0, 7)
+
 	type fields struct {
 		repo               repository.LoanRepositoryInterface
 		notificationClient client.NotificationClientInterface
@@ -36,12 +53,19 @@ func TestLoanService_CreateLoan(t *testing.T) {
 			fields: fields{
 				repo: func() *mocks.LoanRepositoryInterface {
 					m := mocks.NewLoanRepositoryInterface(t)
-					m.On("CreateLoan", context.Background(), &models.Loan{
-						BorrowerID:      "123",
-						PrincipalAmount: 100000000,
-						InterestRate:    5,
-						ROIRate:         3,
-						Status:          enums.LoanStatusProposed,
+					tx := mocks.NewLoanRepositoryInterface(t)
+					m.On("WithinTx", context.Background(), mock.Anything).Return(withinTx(tx))
+					tx.On("CreateLoan", context.Background(), &models.Loan{
+						BorrowerID:                "123",
+						PrincipalAmount:           100000000,
+						InterestRate:              5,
+						ROIRate:                   3,
+						Tenor:                     12,
+						TenorUnit:                 enums.TenorUnitMonth,
+						RepaymentFrequency:        enums.RepaymentFrequencyMonthly,
+						Purpose:                   enums.LoanPurposeWorkingCapital,
+						RequestedDisbursementDate: sql.NullTime{Time: disbursementDate, Valid: true},
+						Status:                    enums.LoanStatusProposed,
 					}).Return(nil)
 					return m
 				}(),
@@ -49,10 +73,15 @@ func TestLoanService_CreateLoan(t *testing.T) {
 			args: args{
 				ctx: context.Background(),
 				req: &dto.CreateLoanRequest{
-					BorrowerID:      "123",
-					PrincipalAmount: 100000000,
-					InterestRate:    5,
-					ROIRate:         3,
+					BorrowerID:                "123",
+					PrincipalAmount:           100000000,
+					InterestRate:              5,
+					ROIRate:                   3,
+					Tenor:                     12,
+					TenorUnit:                 enums.TenorUnitMonth,
+					RepaymentFrequency:        enums.RepaymentFrequencyMonthly,
+					Purpose:                   enums.LoanPurposeWorkingCapital,
+					RequestedDisbursementDate: disbursementDate,
 				},
 			},
 			wantErr: false,
@@ -62,12 +91,19 @@ func TestLoanService_CreateLoan(t *testing.T) {
 			fields: fields{
 				repo: func() *mocks.LoanRepositoryInterface {
 					m := mocks.NewLoanRepositoryInterface(t)
-					m.On("CreateLoan", context.Background(), &models.Loan{
-						BorrowerID:      "123",
-						PrincipalAmount: 100000000,
-						InterestRate:    5,
-						ROIRate:         3,
-						Status:          enums.LoanStatusProposed,
+					tx := mocks.NewLoanRepositoryInterface(t)
+					m.On("WithinTx", context.Background(), mock.Anything).Return(withinTx(tx))
+					tx.On("CreateLoan", context.Background(), &models.Loan{
+						BorrowerID:                "123",
+						PrincipalAmount:           100000000,
+						InterestRate:              5,
+						ROIRate:                   3,
+						Tenor:                     12,
+						TenorUnit:                 enums.TenorUnitMonth,
+						RepaymentFrequency:        enums.RepaymentFrequencyMonthly,
+						Purpose:                   enums.LoanPurposeWorkingCapital,
+						RequestedDisbursementDate: sql.NullTime{Time: disbursementDate, Valid: true},
+						Status:                    enums.LoanStatusProposed,
 					}).Return(errors.New("error"))
 					return m
 				}(),
@@ -75,10 +111,57 @@ func TestLoanService_CreateLoan(t *testing.T) {
 			args: args{
 				ctx: context.Background(),
 				req: &dto.CreateLoanRequest{
-					BorrowerID:      "123",
-					PrincipalAmount: 100000000,
-					InterestRate:    5,
-					ROIRate:         3,
+					BorrowerID:                "123",
+					PrincipalAmount:           100000000,
+					InterestRate:              5,
+					ROIRate:                   3,
+					Tenor:                     12,
+					TenorUnit:                 enums.TenorUnitMonth,
+					RepaymentFrequency:        enums.RepaymentFrequencyMonthly,
+					Purpose:                   enums.LoanPurposeWorkingCapital,
+					RequestedDisbursementDate: disbursementDate,
+				},
+			},
+			wantErr: true,
+		},
+		{
+			name: "error - weekly repayments on a tenor shorter than a week",
+			fields: fields{
+				repo: mocks.NewLoanRepositoryInterface(t),
+			},
+			args: args{
+				ctx: context.Background(),
+				req: &dto.CreateLoanRequest{
+					BorrowerID:                "123",
+					PrincipalAmount:           100000000,
+					InterestRate:              5,
+					ROIRate:                   3,
+					Tenor:                     3,
+					TenorUnit:                 enums.TenorUnitDay,
+					RepaymentFrequency:        enums.RepaymentFrequencyWeekly,
+					Purpose:                   enums.LoanPurposeInventory,
+					RequestedDisbursementDate: disbursementDate,
+				},
+			},
+			wantErr: true,
+		},
+		{
+			name: "error - requested disbursement date in the past",
+			fields: fields{
+				repo: mocks.NewLoanRepositoryInterface(t),
+			},
+			args: args{
+				ctx: context.Background(),
+				req: &dto.CreateLoanRequest{
+					BorrowerID:                "123",
+					PrincipalAmount:           100000000,
+					InterestRate:              5,
+					ROIRate:                   3,
+					Tenor:                     12,
+					TenorUnit:                 enums.TenorUnitMonth,
+					RepaymentFrequency:        enums.RepaymentFrequencyBullet,
+					Purpose:                   enums.LoanPurposeEquipment,
+					RequestedDisbursementDate: time.Now().AddDate(0, 0, -2),
 				},
 			},
 			wantErr: true,
@@ -97,6 +180,180 @@ func TestLoanService_CreateLoan(t *testing.T) {
 	}
 }
 
+func TestLoanService_CreateLoan_UnknownEnumValues(t *testing.T) {
+	valid := func() *dto.CreateLoanRequest {
+		return &dto.CreateLoanRequest{
+			BorrowerID:                "borrower123",
+			PrincipalAmount:           1000,
+			InterestRate:              5,
+			ROIRate:                   3,
+			Tenor:                     12,
+			TenorUnit:                 enums.TenorUnitMonth,
+			RepaymentFrequency:        enums.RepaymentFrequencyMonthly,
+			Purpose:                   enums.LoanPurposeWorkingCapital,
+			RequestedDisbursementDate: time.Now().AddDate(0, 0, 7),
+		}
+	}
+
+	tests := []struct {
+		name string
+		edit func(req *dto.CreateLoanRequest)
+	}{
+		{name: "tenor unit", edit: func(req *dto.CreateLoanRequest) { req.TenorUnit = enums.TenorUnit(len(enums.GetAllTenorUnits()) + 1) }},
+		{name: "repayment frequency", edit: func(req *dto.CreateLoanRequest) {
+			req.RepaymentFrequency = enums.RepaymentFrequency(len(enums.GetAllRepaymentFrequencies()) + 1)
+		}},
+		{name: "purpose", edit: func(req *dto.CreateLoanRequest) { req.Purpose = enums.LoanPurpose(len(enums.GetAllLoanPurposes()) + 1) }},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := valid()
+			tt.edit(req)
+
+			s := &LoanService{repo: mocks.NewLoanRepositoryInterface(t)}
+			if err := s.CreateLoan(context.Background(), req); !errors.Is(err, ErrInvalidLoanTerms) {
+				t.Errorf("LoanService.CreateLoan() error = %v, wantErr %v", err, ErrInvalidLoanTerms)
+			}
+		})
+	}
+}
+
+func TestLoanService_CreateLoan_BorrowerPolicies(t *testing.T) {
+	policies := config.LoanConfig{
+		MaxBorrowerOpenLoans:            3,
+		MaxBorrowerOutstandingPrincipal: 10000,
+		BorrowerRejectionCoolDown:       30 * 24 * time.Hour,
+	}
+	openLoans := []models.Loan{
+		{ID: 1, Status: enums.LoanStatusDisbursed, PrincipalAmount: 6000},
+		{ID: 2, Status: enums.LoanStatusProposed, PrincipalAmount: 2000},
+	}
+	instalments := []models.LoanInstalment{
+		{LoanID: 1, Sequence: 1, PrincipalDue: 3000, InterestDue: 120, PaidAmount: 3120},
+		{LoanID: 1, Sequence: 2, PrincipalDue: 3000, InterestDue: 120},
+	}
+
+	tests := []struct {
+		name      string
+		principal float64
+		rejection *models.LoanStatusTransition
+		openLoans []models.Loan
+		wantErr   error
+	}{
+		{name: "within every policy", principal: 5000, openLoans: openLoans,
+			rejection: &models.LoanStatusTransition{At: time.Now().AddDate(0, 0, -31)}},
+		{name: "rejected too recently", principal: 1000,
+			rejection: &models.LoanStatusTransition{At: time.Now().AddDate(0, 0, -10)}, wantErr: ErrBorrowerCoolingDown},
+		{name: "too many open loans", principal: 1000,
+			openLoans: append([]models.Loan{{ID: 3, Status: enums.LoanStatusApproved, PrincipalAmount: 500}}, openLoans...), wantErr: ErrBorrowerOpenLoanLimit},
+		{name: "above the outstanding principal", principal: 5001, openLoans: openLoans, wantErr: ErrBorrowerPrincipalLimit},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m := mocks.NewLoanRepositoryInterface(t)
+			tx := mocks.NewLoanRepositoryInterface(t)
+			m.On("WithinTx", context.Background(), mock.Anything).Return(withinTx(tx))
+			tx.On("GetLatestBorrowerLoanTransition", context.Background(), "borrower123", enums.LoanStatusRejected).Return(tt.rejection, nil)
+			if tt.wantErr != ErrBorrowerCoolingDown {
+				lock := tx.On("LockBorrower", context.Background(), "borrower123").Return(nil)
+				tx.On("GetBorrowerLoansByStatuses", context.Background(), "borrower123", openLoanStatuses).Return(tt.openLoans, nil).NotBefore(lock)
+			}
+			if tt.wantErr == nil || tt.wantErr == ErrBorrowerPrincipalLimit {
+				tx.On("GetLoanInstalmentsByLoanIDs", context.Background(), []int{1, 2}).Return(instalments, nil)
+			}
+			if tt.wantErr == nil {
+				tx.On("CreateLoan", context.Background(), mock.MatchedBy(func(loan *models.Loan) bool {
+					return loan.BorrowerID == "borrower123" && loan.PrincipalAmount == tt.principal
+				})).Return(nil)
+			}
+
+			s := &LoanService{repo: m, config: policies}
+			err := s.CreateLoan(context.Background(), &dto.CreateLoanRequest{
+				BorrowerID:                "borrower123",
+				PrincipalAmount:           tt.principal,
+				InterestRate:              5,
+				ROIRate:                   3,
+				Tenor:                     12,
+				TenorUnit:                 enums.TenorUnitMonth,
+				RepaymentFrequency:        enums.RepaymentFrequencyMonthly,
+				Purpose:                   enums.LoanPurposeWorkingCapital,
+				RequestedDisbursementDate: time.Now().AddDate(0, 0, 7),
+			})
+			if tt.wantErr == nil && err != nil || tt.wantErr != nil && !errors.Is(err, tt.wantErr) {
+				t.Errorf("LoanService.CreateLoan() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestLoanService_CreateLoan_ConcurrentProposals(t *testing.T) {
+	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{})
+	if err != nil {
+		t.Fatalf("gorm.Open() error = %v", err)
+	}
+	// Every connection to :memory: opens its own database, so the pool is
+	// kept to the one holding the schema. Concurrent transactions queue for
+	// it the way they queue for the borrower's lock row in MySQL.
+	sqlDB, err := db.DB()
+	if err != nil {
+		t.Fatalf("db.DB() error = %v", err)
+	}
+	sqlDB.SetMaxOpenConns(1)
+	if err := db.AutoMigrate(&models.Loan{}, &models.LoanStatusTransition{}, &models.LoanTermSheet{},
+		&models.LoanInstalment{}, &models.BorrowerLock{}); err != nil {
+		t.Fatalf("AutoMigrate() error = %v", err)
+	}
+
+	s := &LoanService{repo: repository.NewLoanRepository(db), config: config.LoanConfig{MaxBorrowerOpenLoans: 1}}
+
+	errs := make([]error, 2)
+	start := make(chan struct{})
+	var wg sync.WaitGroup
+	for i := range errs {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			<-start
+			errs[i] = s.CreateLoan(context.Background(), &dto.CreateLoanRequest{
+				BorrowerID:                "borrower123",
+				PrincipalAmount:           1000,
+				InterestRate:              5,
+				ROIRate:                   3,
+				Tenor:                     12,
+				TenorUnit:                 enums.TenorUnitMonth,
+				RepaymentFrequency:        enums.RepaymentFrequencyMonthly,
+				Purpose:                   enums.LoanPurposeWorkingCapital,
+				RequestedDisbursementDate: time.Now().AddDate(0, 0, 7),
+			})
+		}(i)
+	}
+	close(start)
+	wg.Wait()
+
+	var created, refused int
+	for _, err := range errs {
+		switch {
+		case err == nil:
+			created++
+		case errors.Is(err, ErrBorrowerOpenLoanLimit):
+			refused++
+		default:
+			t.Errorf("LoanService.CreateLoan() unexpected error = %v", err)
+		}
+	}
+	if created != 1 || refused != 1 {
+		t.Errorf("created %d and refused %d proposals, want 1 and 1", created, refused)
+	}
+
+	var count int64
+	if err := db.Model(&models.Loan{}).Where("borrower_id = ?", "borrower123").Count(&count).Error; err != nil {
+		t.Fatalf("count loans error = %v", err)
+	}
+	if count != 1 {
+		t.Errorf("borrower has %d loans, want 1", count)
+	}
+}
+
 func TestLoanService_ApproveLoanWithValidators(t *testing.T) {
 	type fields struct {
 		repo               repository.LoanRepositoryInterface
@@ -117,21 +374,25 @@ func TestLoanService_ApproveLoanWithValidators(t *testing.T) {
 			fields: fields{
 				repo: func() *mocks.LoanRepositoryInterface {
 					m := mocks.NewLoanRepositoryInterface(t)
-					m.On("BeginTransaction", context.Background()).Return(&gorm.DB{}, nil)
-					m.On("GetLoanByUUID", context.Background(), "loan-uuid-123").Return(&models.Loan{
+					tx := mocks.NewLoanRepositoryInterface(t)
+					m.On("WithinTx", context.Background(), mock.Anything).Return(withinTx(tx))
+					tx.On("GetLoanByUUID", context.Background(), "loan-uuid-123").Return(&models.Loan{
 						ID:     1,
 						UUID:   "loan-uuid-123",
 						Status: enums.LoanStatusProposed,
 					}, nil)
-					m.On("CreateLoanApproval", context.Background(), mock.Anything, mock.MatchedBy(func(approval *models.LoanApproval) bool {
-						return approval.LoanID == 1
+					tx.On("GetLatestLoanTermSheet", context.Background(), 1).Return(&models.LoanTermSheet{ID: 7, LoanID: 1, Version: 2}, nil)
+					tx.On("CreateLoanApproval", context.Background(), mock.MatchedBy(func(approval *models.LoanApproval) bool {
+						return approval.LoanID == 1 && approval.LoanTermSheetID != nil && *approval.LoanTermSheetID == 7
 					})).Return(nil)
-					m.On("CreateLoanApprovalValidator", context.Background(), mock.Anything, mock.MatchedBy(func(validator *models.LoanApprovalValidator) bool {
+					tx.On("CreateLoanApprovalValidator", context.Background(), mock.MatchedBy(func(validator *models.LoanApprovalValidator) bool {
 						return validator.EmployeeID == "emp123"
 					})).Return(nil)
-					m.On("CreateLoanApprovalValidatorProof", context.Background(), mock.Anything, mock.Anything).Return(nil)
-					m.On("UpdateLoan", context.Background(), mock.Anything, mock.Anything, []string{"status"}).Return(nil)
-					m.On("Commit", context.Background(), mock.Anything).Return(nil)
+					tx.On("CreateLoanApprovalValidatorProof", context.Background(), mock.Anything).Return(nil)
+					tx.On("UpdateLoan", context.Background(), mock.Anything, []string{"status", "funding_deadline"}).Return(nil)
+					tx.On("CreateLoanStatusTransition", context.Background(), mock.MatchedBy(func(transition *models.LoanStatusTransition) bool {
+						return transition.LoanID == 1 && transition.Actor != ""
+					})).Return(nil)
 					return m
 				}(),
 				notificationClient: func() *mocks.NotificationClientInterface {
@@ -164,9 +425,9 @@ func TestLoanService_ApproveLoanWithValidators(t *testing.T) {
 			fields: fields{
 				repo: func() *mocks.LoanRepositoryInterface {
 					m := mocks.NewLoanRepositoryInterface(t)
-					m.On("BeginTransaction", context.Background()).Return(&gorm.DB{}, nil)
-					m.On("GetLoanByUUID", context.Background(), "loan-uuid-123").Return(nil, errors.New("loan not found"))
-					m.On("Rollback", context.Background(), mock.Anything).Return(nil)
+					tx := mocks.NewLoanRepositoryInterface(t)
+					m.On("WithinTx", context.Background(), mock.Anything).Return(withinTx(tx))
+					tx.On("GetLoanByUUID", context.Background(), "loan-uuid-123").Return(nil, errors.New("loan not found"))
 					return m
 				}(),
 				notificationClient: func() *mocks.NotificationClientInterface {
@@ -195,13 +456,15 @@ func TestLoanService_ApproveLoanWithValidators(t *testing.T) {
 			fields: fields{
 				repo: func() *mocks.LoanRepositoryInterface {
 					m := mocks.NewLoanRepositoryInterface(t)
-					m.On("BeginTransaction", context.Background()).Return(&gorm.DB{}, nil)
-					m.On("GetLoanByUUID", context.Background(), "loan-uuid-123").Return(&models.Loan{
+					tx := mocks.NewLoanRepositoryInterface(t)
+					m.On("WithinTx", context.Background(), mock.Anything).Return(withinTx(tx))
+					tx.On("GetLoanByUUID", context.Background(), "loan-uuid-123").Return(&models.Loan{
 						ID:     1,
 						UUID:   "loan-uuid-123",
 						Status: enums.LoanStatusProposed,
 					}, nil)
-					m.On("CreateLoanApproval", context.Background(), mock.Anything, mock.Anything).Return(errors.New("database error"))
+					tx.On("GetLatestLoanTermSheet", context.Background(), 1).Return(&models.LoanTermSheet{ID: 7, LoanID: 1, Version: 2}, nil)
+					tx.On("CreateLoanApproval", context.Background(), mock.Anything).Return(errors.New("database error"))
 					return m
 				}(),
 				notificationClient: func() *mocks.NotificationClientInterface {
@@ -230,17 +493,26 @@ func TestLoanService_ApproveLoanWithValidators(t *testing.T) {
 			fields: fields{
 				repo: func() *mocks.LoanRepositoryInterface {
 					m := mocks.NewLoanRepositoryInterface(t)
-					m.On("BeginTransaction", context.Background()).Return(&gorm.DB{}, nil)
-					m.On("GetLoanByUUID", context.Background(), "loan-uuid-123").Return(&models.Loan{
+					tx := mocks.NewLoanRepositoryInterface(t)
+					m.On("WithinTx", context.Background(), mock.Anything).Return(func(ctx context.Context, fn func(repository.LoanRepositoryInterface) error) error {
+						if err := fn(tx); err != nil {
+							return err
+						}
+						return errors.New("commit failed")
+					})
+					tx.On("GetLoanByUUID", context.Background(), "loan-uuid-123").Return(&models.Loan{
 						ID:     1,
 						UUID:   "loan-uuid-123",
 						Status: enums.LoanStatusProposed,
 					}, nil)
-					m.On("CreateLoanApproval", context.Background(), mock.Anything, mock.Anything).Return(nil)
-					m.On("CreateLoanApprovalValidator", context.Background(), mock.Anything, mock.Anything).Return(nil)
-					m.On("CreateLoanApprovalValidatorProof", context.Background(), mock.Anything, mock.Anything).Return(nil)
-					m.On("UpdateLoan", context.Background(), mock.Anything, mock.Anything, []string{"status"}).Return(nil)
-					m.On("Commit", context.Background(), mock.Anything).Return(errors.New("commit failed"))
+					tx.On("GetLatestLoanTermSheet", context.Background(), 1).Return(&models.LoanTermSheet{ID: 7, LoanID: 1, Version: 2}, nil)
+					tx.On("CreateLoanApproval", context.Background(), mock.Anything).Return(nil)
+					tx.On("CreateLoanApprovalValidator", context.Background(), mock.Anything).Return(nil)
+					tx.On("CreateLoanApprovalValidatorProof", context.Background(), mock.Anything).Return(nil)
+					tx.On("UpdateLoan", context.Background(), mock.Anything, []string{"status", "funding_deadline"}).Return(nil)
+					tx.On("CreateLoanStatusTransition", context.Background(), mock.MatchedBy(func(transition *models.LoanStatusTransition) bool {
+						return transition.LoanID == 1 && transition.Actor != ""
+					})).Return(nil)
 					return m
 				}(),
 				notificationClient: func() *mocks.NotificationClientInterface {
@@ -278,7 +550,107 @@ func TestLoanService_ApproveLoanWithValidators(t *testing.T) {
 	}
 }
 
+func TestLoanService_ApproveLoanWithValidators_FundingDeadline(t *testing.T) {
+	for _, approvedAt := range []time.Time{time.Now().AddDate(0, -1, 0), time.Now().AddDate(1, 0, 0)} {
+		m := mocks.NewLoanRepositoryInterface(t)
+		tx := mocks.NewLoanRepositoryInterface(t)
+		m.On("WithinTx", context.Background(), mock.Anything).Return(withinTx(tx))
+		tx.On("GetLoanByUUID", context.Background(), "loan-uuid-123").Return(&models.Loan{ID: 1, UUID: "loan-uuid-123", Status: enums.LoanStatusProposed}, nil)
+		tx.On("GetLatestLoanTermSheet", context.Background(), 1).Return(&models.LoanTermSheet{ID: 7, LoanID: 1, Version: 1}, nil)
+		tx.On("CreateLoanApproval", context.Background(), mock.Anything).Return(nil)
+		tx.On("CreateLoanApprovalValidator", context.Background(), mock.Anything).Return(nil)
+		tx.On("CreateLoanStatusTransition", context.Background(), mock.Anything).Return(nil)
+
+		var deadline time.Time
+		tx.On("UpdateLoan", context.Background(), mock.Anything, []string{"status", "funding_deadline"}).Run(func(args mock.Arguments) {
+			deadline = args.Get(1).(*models.Loan).FundingDeadline.Time
+		}).Return(nil)
+
+		s := &LoanService{repo: m, config: config.LoanConfig{FundingWindow: 14 * 24 * time.Hour}}
+		before := time.Now()
+		if err := s.ApproveLoanWithValidators(context.Background(), dto.ApproveLoanRequest{
+			LoanUUID:   "loan-uuid-123",
+			EmployeeID: "emp123",
+			ApprovedAt: approvedAt,
+		}); err != nil {
+			t.Fatalf("LoanService.ApproveLoanWithValidators() error = %v", err)
+		}
+
+		if deadline.Before(before.Add(14*24*time.Hour)) || deadline.After(time.Now().Add(14*24*time.Hour)) {
+			t.Errorf("LoanService.ApproveLoanWithValidators() funding deadline = %v for approved_at %v, want 14 days from now", deadline, approvedAt)
+		}
+	}
+}
+
+func TestLoanService_ApproveLoanWithValidators_NotProposed(t *testing.T) {
+	for _, status := range []enums.LoanStatus{enums.LoanStatusApproved, enums.LoanStatusInvested, enums.LoanStatusDisbursed, enums.LoanStatusDefaulted} {
+		t.Run(status.String(), func(t *testing.T) {
+			m := mocks.NewLoanRepositoryInterface(t)
+			tx := mocks.NewLoanRepositoryInterface(t)
+			m.On("WithinTx", context.Background(), mock.Anything).Return(withinTx(tx))
+			tx.On("GetLoanByUUID", context.Background(), "loan-uuid-123").Return(&models.Loan{
+				ID:     1,
+				UUID:   "loan-uuid-123",
+				Status: status,
+			}, nil)
+
+			s := &LoanService{repo: m}
+			err := s.ApproveLoanWithValidators(context.Background(), dto.ApproveLoanRequest{
+				LoanUUID:   "loan-uuid-123",
+				EmployeeID: "emp123",
+				ApprovedAt: time.Now(),
+			})
+			if !errors.Is(err, ErrInvalidLoanStatus) {
+				t.Errorf("LoanService.ApproveLoanWithValidators() error = %v, wantErr %v", err, ErrInvalidLoanStatus)
+			}
+		})
+	}
+}
+
+func TestLoanService_RejectLoan(t *testing.T) {
+	req := dto.RejectLoanRequest{LoanUUID: "loan-uuid-123", EmployeeID: "emp123", Reason: "income not verified"}
+
+	t.Run("proposed loan is rejected with the reason", func(t *testing.T) {
+		m := mocks.NewLoanRepositoryInterface(t)
+		tx := mocks.NewLoanRepositoryInterface(t)
+		m.On("WithinTx", context.Background(), mock.Anything).Return(withinTx(tx))
+		tx.On("GetLoanByUUID", context.Background(), "loan-uuid-123").Return(&models.Loan{
+			ID:     1,
+			UUID:   "loan-uuid-123",
+			Status: enums.LoanStatusProposed,
+		}, nil)
+		tx.On("UpdateLoan", context.Background(), mock.MatchedBy(func(loan *models.Loan) bool {
+			return loan.Status == enums.LoanStatusRejected
+		}), []string{"status"}).Return(nil)
+		tx.On("CreateLoanStatusTransition", context.Background(), mock.MatchedBy(func(transition *models.LoanStatusTransition) bool {
+			return *transition.FromStatus == enums.LoanStatusProposed && transition.ToStatus == enums.LoanStatusRejected &&
+				transition.Actor == "emp123" && transition.Reason == "income not verified"
+		})).Return(nil)
+
+		s := &LoanService{repo: m}
+		if err := s.RejectLoan(context.Background(), req); err != nil {
+			t.Fatalf("LoanService.RejectLoan() error = %v", err)
+		}
+	})
+
+	for _, status := range []enums.LoanStatus{enums.LoanStatusApproved, enums.LoanStatusRejected, enums.LoanStatusDisbursed} {
+		t.Run(status.String(), func(t *testing.T) {
+			m := mocks.NewLoanRepositoryInterface(t)
+			tx := mocks.NewLoanRepositoryInterface(t)
+			m.On("WithinTx", context.Background(), mock.Anything).Return(withinTx(tx))
+			tx.On("GetLoanByUUID", context.Background(), "loan-uuid-123").Return(&models.Loan{ID: 1, UUID: "loan-uuid-123", Status: status}, nil)
+
+			s := &LoanService{repo: m}
+			if err := s.RejectLoan(context.Background(), req); !errors.Is(err, ErrInvalidLoanStatus) {
+				t.Errorf("LoanService.RejectLoan() error = %v, wantErr %v", err, ErrInvalidLoanStatus)
+			}
+		})
+	}
+}
+
 func TestLoanService_InvestLoan(t *testing.T) {
+	fundingDeadline := time.Now().Add(time.Hour)
+
 	type fields struct {
 		repo               repository.LoanRepositoryInterface
 		notificationClient client.NotificationClientInterface
@@ -298,27 +670,28 @@ func TestLoanService_InvestLoan(t *testing.T) {
 			fields: fields{
 				repo: func() *mocks.LoanRepositoryInterface {
 					m := mocks.NewLoanRepositoryInterface(t)
-					m.On("GetLoanByUUID", context.Background(), "loan-uuid-123").Return(&models.Loan{
+					tx := mocks.NewLoanRepositoryInterface(t)
+					m.On("WithinTx", context.Background(), mock.Anything).Return(withinTx(tx))
+					tx.On("GetLoanByUUID", context.Background(), "loan-uuid-123").Return(&models.Loan{
 						ID:               1,
 						UUID:             "loan-uuid-123",
 						Status:           enums.LoanStatusApproved,
 						PrincipalAmount:  1000.0,
 						InvestmentAmount: 0,
+						FundingDeadline:  sql.NullTime{Time: fundingDeadline, Valid: true},
 					}, nil)
-					m.On("BeginTransaction", context.Background()).Return(&gorm.DB{}, nil)
-					m.On("UpdateLoan", context.Background(), mock.Anything, mock.Anything, []string{"investment_amount"}).Return(nil)
-					m.On("CreateInvestment", context.Background(), mock.Anything, mock.Anything).Return(nil)
-					m.On("Commit", context.Background(), mock.Anything).Return(nil)
-					m.On("GetInvestmentsByLoanID", context.Background(), 1).Return([]models.Investment{
-						{InvestorID: "investor123", AgreementLetterURL: "https://example.com/agreement.pdf"},
-					}, nil)
-					return m
-				}(),
-				notificationClient: func() *mocks.NotificationClientInterface {
-					m := mocks.NewNotificationClientInterface(t)
-					m.On("SendEmail", context.Background(), mock.Anything).Return(nil)
+					tx.On("GetInvestmentsByLoanID", context.Background(), 1).Return([]models.Investment{}, nil)
+					tx.On("CreateInvestment", context.Background(), mock.MatchedBy(func(investment *models.Investment) bool {
+						// The reservation can't outlive the loan's funding window.
+						return investment.Status == enums.InvestmentStatusPendingFunding && investment.Amount == 500 &&
+							investment.FundingExpiresAt.Valid && investment.FundingExpiresAt.Time.Equal(fundingDeadline)
+					})).Return(nil)
+					tx.On("UpdateLoan", context.Background(), mock.MatchedBy(func(loan *models.Loan) bool {
+						return loan.InvestmentAmount == 0
+					}), []string(nil)).Return(nil)
 					return m
 				}(),
+				notificationClient: mocks.NewNotificationClientInterface(t),
 			},
 			args: args{
 				ctx: context.Background(),
@@ -335,7 +708,9 @@ func TestLoanService_InvestLoan(t *testing.T) {
 			fields: fields{
 				repo: func() *mocks.LoanRepositoryInterface {
 					m := mocks.NewLoanRepositoryInterface(t)
-					m.On("GetLoanByUUID", context.Background(), "loan-uuid-123").Return(nil, errors.New("loan not found"))
+					tx := mocks.NewLoanRepositoryInterface(t)
+					m.On("WithinTx", context.Background(), mock.Anything).Return(withinTx(tx))
+					tx.On("GetLoanByUUID", context.Background(), "loan-uuid-123").Return(nil, errors.New("loan not found"))
 					return m
 				}(),
 				notificationClient: func() *mocks.NotificationClientInterface {
@@ -358,7 +733,9 @@ func TestLoanService_InvestLoan(t *testing.T) {
 			fields: fields{
 				repo: func() *mocks.LoanRepositoryInterface {
 					m := mocks.NewLoanRepositoryInterface(t)
-					m.On("GetLoanByUUID", context.Background(), "loan-uuid-123").Return(&models.Loan{
+					tx := mocks.NewLoanRepositoryInterface(t)
+					m.On("WithinTx", context.Background(), mock.Anything).Return(withinTx(tx))
+					tx.On("GetLoanByUUID", context.Background(), "loan-uuid-123").Return(&models.Loan{
 						ID:     1,
 						UUID:   "loan-uuid-123",
 						Status: enums.LoanStatusProposed,
@@ -385,13 +762,16 @@ func TestLoanService_InvestLoan(t *testing.T) {
 			fields: fields{
 				repo: func() *mocks.LoanRepositoryInterface {
 					m := mocks.NewLoanRepositoryInterface(t)
-					m.On("GetLoanByUUID", context.Background(), "loan-uuid-123").Return(&models.Loan{
+					tx := mocks.NewLoanRepositoryInterface(t)
+					m.On("WithinTx", context.Background(), mock.Anything).Return(withinTx(tx))
+					tx.On("GetLoanByUUID", context.Background(), "loan-uuid-123").Return(&models.Loan{
 						ID:               1,
 						UUID:             "loan-uuid-123",
 						Status:           enums.LoanStatusApproved,
 						PrincipalAmount:  1000.0,
 						InvestmentAmount: 600.0,
 					}, nil)
+					tx.On("GetInvestmentsByLoanID", context.Background(), 1).Return([]models.Investment{}, nil)
 					return m
 				}(),
 				notificationClient: func() *mocks.NotificationClientInterface {
@@ -409,73 +789,53 @@ func TestLoanService_InvestLoan(t *testing.T) {
 			},
 			wantErr: true,
 		},
-	}
-	for _, tt := range tests {
-		t.Run(tt.name, func(t *testing.T) {
-			s := &LoanService{
-				repo:               tt.fields.repo,
-				notificationClient: tt.fields.notificationClient,
-			}
-			if err := s.InvestLoan(tt.args.ctx, tt.args.req); (err != nil) != tt.wantErr {
-				t.Errorf("LoanService.InvestLoan() error = %v, wantErr %v", err, tt.wantErr)
-			}
-		})
-	}
-}
-
-func TestLoanService_CreateLoanDisbursement(t *testing.T) {
-	type fields struct {
-		repo               repository.LoanRepositoryInterface
-		notificationClient client.NotificationClientInterface
-	}
-	type args struct {
-		ctx context.Context
-		req dto.CreateLoanDisbursementRequest
-	}
-	tests := []struct {
-		name    string
-		fields  fields
-		args    args
-		wantErr bool
-	}{
 		{
-			name: "success",
+			name: "error - pending reservations count towards the principal",
 			fields: fields{
 				repo: func() *mocks.LoanRepositoryInterface {
 					m := mocks.NewLoanRepositoryInterface(t)
-					m.On("GetLoanByUUID", context.Background(), "loan-uuid-123").Return(&models.Loan{
-						ID:     1,
-						UUID:   "loan-uuid-123",
-						Status: enums.LoanStatusInvested,
+					tx := mocks.NewLoanRepositoryInterface(t)
+					m.On("WithinTx", context.Background(), mock.Anything).Return(withinTx(tx))
+					tx.On("GetLoanByUUID", context.Background(), "loan-uuid-123").Return(&models.Loan{
+						ID:               1,
+						UUID:             "loan-uuid-123",
+						Status:           enums.LoanStatusApproved,
+						PrincipalAmount:  1000.0,
+						InvestmentAmount: 300.0,
+					}, nil)
+					tx.On("GetInvestmentsByLoanID", context.Background(), 1).Return([]models.Investment{
+						{LoanID: 1, Amount: 300, Status: enums.InvestmentStatusFunded},
+						{LoanID: 1, Amount: 300, Status: enums.InvestmentStatusPendingFunding},
+						{LoanID: 1, Amount: 400, Status: enums.InvestmentStatusExpired},
 					}, nil)
-					m.On("BeginTransaction", context.Background()).Return(&gorm.DB{}, nil)
-					m.On("CreateLoanDisbursement", context.Background(), mock.Anything, mock.Anything).Return(nil)
-					m.On("UpdateLoan", context.Background(), mock.Anything, mock.Anything, []string{"status"}).Return(nil)
-					m.On("Commit", context.Background(), mock.Anything).Return(nil)
-					return m
-				}(),
-				notificationClient: func() *mocks.NotificationClientInterface {
-					m := mocks.NewNotificationClientInterface(t)
 					return m
 				}(),
+				notificationClient: mocks.NewNotificationClientInterface(t),
 			},
 			args: args{
 				ctx: context.Background(),
-				req: dto.CreateLoanDisbursementRequest{
-					LoanUUID:                 "loan-uuid-123",
-					EmployeeID:               "emp123",
-					SignedAgreementLetterURL: "https://example.com/signed-agreement.pdf",
-					DisbursedAt:              time.Now(),
+				req: dto.InvestLoanRequest{
+					LoanUUID:   "loan-uuid-123",
+					InvestorID: "investor123",
+					Amount:     500.0,
 				},
 			},
-			wantErr: false,
+			wantErr: true,
 		},
 		{
-			name: "error - loan not found",
+			name: "error - funding deadline passed",
 			fields: fields{
 				repo: func() *mocks.LoanRepositoryInterface {
 					m := mocks.NewLoanRepositoryInterface(t)
-					m.On("GetLoanByUUID", context.Background(), "loan-uuid-123").Return(nil, errors.New("loan not found"))
+					tx := mocks.NewLoanRepositoryInterface(t)
+					m.On("WithinTx", context.Background(), mock.Anything).Return(withinTx(tx))
+					tx.On("GetLoanByUUID", context.Background(), "loan-uuid-123").Return(&models.Loan{
+						ID:              1,
+						UUID:            "loan-uuid-123",
+						Status:          enums.LoanStatusApproved,
+						PrincipalAmount: 1000.0,
+						FundingDeadline: sql.NullTime{Time: time.Now().Add(-time.Minute), Valid: true},
+					}, nil)
 					return m
 				}(),
 				notificationClient: func() *mocks.NotificationClientInterface {
@@ -485,24 +845,27 @@ func TestLoanService_CreateLoanDisbursement(t *testing.T) {
 			},
 			args: args{
 				ctx: context.Background(),
-				req: dto.CreateLoanDisbursementRequest{
-					LoanUUID:                 "loan-uuid-123",
-					EmployeeID:               "emp123",
-					SignedAgreementLetterURL: "https://example.com/signed-agreement.pdf",
-					DisbursedAt:              time.Now(),
+				req: dto.InvestLoanRequest{
+					LoanUUID:   "loan-uuid-123",
+					InvestorID: "investor123",
+					Amount:     500.0,
 				},
 			},
 			wantErr: true,
 		},
 		{
-			name: "error - loan not invested",
+			name: "error - expected version is stale",
 			fields: fields{
 				repo: func() *mocks.LoanRepositoryInterface {
 					m := mocks.NewLoanRepositoryInterface(t)
-					m.On("GetLoanByUUID", context.Background(), "loan-uuid-123").Return(&models.Loan{
-						ID:     1,
-						UUID:   "loan-uuid-123",
-						Status: enums.LoanStatusApproved,
+					tx := mocks.NewLoanRepositoryInterface(t)
+					m.On("WithinTx", context.Background(), mock.Anything).Return(withinTx(tx))
+					tx.On("GetLoanByUUID", context.Background(), "loan-uuid-123").Return(&models.Loan{
+						ID:              1,
+						UUID:            "loan-uuid-123",
+						Status:          enums.LoanStatusApproved,
+						PrincipalAmount: 1000.0,
+						Version:         3,
 					}, nil)
 					return m
 				}(),
@@ -513,11 +876,11 @@ func TestLoanService_CreateLoanDisbursement(t *testing.T) {
 			},
 			args: args{
 				ctx: context.Background(),
-				req: dto.CreateLoanDisbursementRequest{
-					LoanUUID:                 "loan-uuid-123",
-					EmployeeID:               "emp123",
-					SignedAgreementLetterURL: "https://example.com/signed-agreement.pdf",
-					DisbursedAt:              time.Now(),
+				req: dto.InvestLoanRequest{
+					LoanUUID:        "loan-uuid-123",
+					InvestorID:      "investor123",
+					Amount:          500.0,
+					ExpectedVersion: func() *int { v := 2; return &v }(),
 				},
 			},
 			wantErr: true,
@@ -528,10 +891,2326 @@ func TestLoanService_CreateLoanDisbursement(t *testing.T) {
 			s := &LoanService{
 				repo:               tt.fields.repo,
 				notificationClient: tt.fields.notificationClient,
+				config:             config.LoanConfig{InvestmentReservationTTL: 24 * time.Hour},
 			}
-			if err := s.CreateLoanDisbursement(tt.args.ctx, tt.args.req); (err != nil) != tt.wantErr {
-				t.Errorf("LoanService.CreateLoanDisbursement() error = %v, wantErr %v", err, tt.wantErr)
+			if _, err := s.InvestLoan(tt.args.ctx, tt.args.req); (err != nil) != tt.wantErr {
+				t.Errorf("LoanService.InvestLoan() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestLoanService_InvestLoan_NotApproved(t *testing.T) {
+	for _, status := range []enums.LoanStatus{enums.LoanStatusProposed, enums.LoanStatusInvested, enums.LoanStatusExpired} {
+		t.Run(status.String(), func(t *testing.T) {
+			m := mocks.NewLoanRepositoryInterface(t)
+			tx := mocks.NewLoanRepositoryInterface(t)
+			m.On("WithinTx", context.Background(), mock.Anything).Return(withinTx(tx))
+			tx.On("GetLoanByUUID", context.Background(), "loan-uuid-123").Return(&models.Loan{ID: 1, UUID: "loan-uuid-123", Status: status}, nil)
+
+			s := &LoanService{repo: m, config: config.LoanConfig{MinInvestmentAmount: 100, MaxInvestmentAmount: 5000}}
+			_, err := s.InvestLoan(context.Background(), dto.InvestLoanRequest{LoanUUID: "loan-uuid-123", InvestorID: "investor123", Amount: 500})
+			if !errors.Is(err, ErrInvalidLoanStatus) {
+				t.Errorf("LoanService.InvestLoan() error = %v, wantErr %v", err, ErrInvalidLoanStatus)
+			}
+		})
+	}
+}
+
+func TestLoanService_InvestLoan_Limits(t *testing.T) {
+	limits := config.LoanConfig{
+		InvestmentReservationTTL: 24 * time.Hour,
+		MinInvestmentAmount:      100,
+		MaxInvestmentAmount:      5000,
+		MaxInvestorLoanShare:     25,
+		MaxInvestorExposure:      10000,
+	}
+
+	tests := []struct {
+		name     string
+		amount   float64
+		held     []models.Investment
+		exposure float64
+		wantErr  error
+	}{
+		{name: "within every limit", amount: 1000, exposure: 8000},
+		{name: "below the minimum ticket", amount: 50, wantErr: ErrInvestmentBelowMinimum},
+		{name: "above the maximum ticket", amount: 6000, wantErr: ErrInvestmentAboveMaximum},
+		{name: "above the remaining principal", amount: 3000, held: []models.Investment{
+			{InvestorID: "other", Amount: 2000, Status: enums.InvestmentStatusPendingFunding},
+		}, wantErr: ErrInvestmentExceedsPrincipal},
+		{name: "above the investor's share of the loan", amount: 1000, held: []models.Investment{
+			{InvestorID: "investor123", Amount: 200, Status: enums.InvestmentStatusFunded},
+			{InvestorID: "investor123", Amount: 300, Status: enums.InvestmentStatusPendingFunding},
+			{InvestorID: "investor123", Amount: 500, Status: enums.InvestmentStatusExpired},
+			{InvestorID: "other", Amount: 300, Status: enums.InvestmentStatusFunded},
+		}, wantErr: ErrInvestorLoanShareExceeded},
+		{name: "above the investor's exposure", amount: 1000, exposure: 9500, wantErr: ErrInvestorExposureExceeded},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m := mocks.NewLoanRepositoryInterface(t)
+			tx := mocks.NewLoanRepositoryInterface(t)
+			if tt.wantErr != ErrInvestmentBelowMinimum && tt.wantErr != ErrInvestmentAboveMaximum {
+				m.On("WithinTx", context.Background(), mock.Anything).Return(withinTx(tx))
+				tx.On("GetLoanByUUID", context.Background(), "loan-uuid-123").Return(&models.Loan{
+					ID:               1,
+					UUID:             "loan-uuid-123",
+					Status:           enums.LoanStatusApproved,
+					PrincipalAmount:  4000,
+					InvestmentAmount: 500,
+				}, nil)
+				tx.On("GetInvestmentsByLoanID", context.Background(), 1).Return(tt.held, nil)
+			}
+			if tt.wantErr == nil || tt.wantErr == ErrInvestorExposureExceeded {
+				lock := tx.On("LockInvestor", context.Background(), "investor123").Return(nil)
+				tx.On("GetInvestorExposure", context.Background(), "investor123").Return(tt.exposure, nil).NotBefore(lock)
+			}
+			if tt.wantErr == nil {
+				tx.On("CreateInvestment", context.Background(), mock.Anything).Return(nil)
+				tx.On("UpdateLoan", context.Background(), mock.Anything, []string(nil)).Return(nil)
+			}
+
+			s := &LoanService{repo: m, config: limits}
+			_, err := s.InvestLoan(context.Background(), dto.InvestLoanRequest{
+				LoanUUID:   "loan-uuid-123",
+				InvestorID: "investor123",
+				Amount:     tt.amount,
+			})
+			if tt.wantErr == nil && err != nil || tt.wantErr != nil && !errors.Is(err, tt.wantErr) {
+				t.Errorf("LoanService.InvestLoan() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestLoanService_ConfirmInvestmentFunding(t *testing.T) {
+	paidAt := time.Date(2025, 10, 1, 9, 0, 0, 0, time.UTC)
+	req := dto.ConfirmInvestmentFundingRequest{
+		LoanUUID:         "loan-uuid-123",
+		InvestmentUUID:   "investment-uuid",
+		EmployeeID:       "emp123",
+		PaymentReference: "TRF-001",
+		PaidAt:           paidAt,
+	}
+
+	t.Run("funded investment completes the loan's funding", func(t *testing.T) {
+		m := mocks.NewLoanRepositoryInterface(t)
+		tx := mocks.NewLoanRepositoryInterface(t)
+		m.On("WithinTx", context.Background(), mock.Anything).Return(withinTx(tx))
+		tx.On("GetLoanByUUID", context.Background(), "loan-uuid-123").Return(&models.Loan{
+			ID:               1,
+			UUID:             "loan-uuid-123",
+			Status:           enums.LoanStatusApproved,
+			PrincipalAmount:  1000,
+			InvestmentAmount: 600,
+			EscrowBalance:    600,
+		}, nil)
+		tx.On("GetInvestmentByUUID", context.Background(), "investment-uuid").Return(&models.Investment{
+			ID:         7,
+			UUID:       "investment-uuid",
+			LoanID:     1,
+			InvestorID: "investor123",
+			Amount:     400,
+			Status:     enums.InvestmentStatusPendingFunding,
+		}, nil)
+		tx.On("UpdateInvestment", context.Background(), mock.MatchedBy(func(investment *models.Investment) bool {
+			return investment.Status == enums.InvestmentStatusFunded && investment.FundedAt.Time.Equal(paidAt) && investment.PaymentReference == "TRF-001"
+		}), []string{"status", "funded_at", "payment_reference"}).Return(nil)
+		tx.On("CreateLoanEscrowEntry", context.Background(), mock.MatchedBy(func(entry *models.LoanEscrowEntry) bool {
+			return entry.EntryType == enums.EscrowEntryTypeDeposit && entry.Amount == 400 && entry.BalanceAfter == 1000 && *entry.InvestmentID == 7
+		})).Return(nil)
+		tx.On("UpdateLoan", context.Background(), mock.MatchedBy(func(loan *models.Loan) bool {
+			return loan.Status == enums.LoanStatusInvested && loan.InvestmentAmount == 1000 && loan.EscrowBalance == 1000
+		}), []string{"status", "investment_amount", "escrow_balance"}).Return(nil)
+		tx.On("CreateLoanStatusTransition", context.Background(), mock.MatchedBy(func(transition *models.LoanStatusTransition) bool {
+			return transition.ToStatus == enums.LoanStatusInvested && transition.Actor == "emp123"
+		})).Return(nil)
+
+		notificationClient := mocks.NewNotificationClientInterface(t)
+		notificationClient.On("SendEmail", context.Background(), mock.MatchedBy(func(req client.SendEmailRequest) bool {
+			return req.To == "investor123" && req.Subject == "Loan Agreement Letter"
+		})).Return(nil)
+
+		s := &LoanService{repo: m, notificationClient: notificationClient}
+		if err := s.ConfirmInvestmentFunding(context.Background(), req); err != nil {
+			t.Fatalf("ConfirmInvestmentFunding() error = %v", err)
+		}
+	})
+
+	t.Run("partial funding keeps the loan open", func(t *testing.T) {
+		m := mocks.NewLoanRepositoryInterface(t)
+		tx := mocks.NewLoanRepositoryInterface(t)
+		m.On("WithinTx", context.Background(), mock.Anything).Return(withinTx(tx))
+		tx.On("GetLoanByUUID", context.Background(), "loan-uuid-123").Return(&models.Loan{
+			ID:              1,
+			UUID:            "loan-uuid-123",
+			Status:          enums.LoanStatusApproved,
+			PrincipalAmount: 1000,
+		}, nil)
+		tx.On("GetInvestmentByUUID", context.Background(), "investment-uuid").Return(&models.Investment{
+			ID:         7,
+			UUID:       "investment-uuid",
+			LoanID:     1,
+			InvestorID: "investor123",
+			Amount:     400,
+			Status:     enums.InvestmentStatusPendingFunding,
+		}, nil)
+		tx.On("UpdateInvestment", context.Background(), mock.Anything, []string{"status", "funded_at", "payment_reference"}).Return(nil)
+		tx.On("CreateLoanEscrowEntry", context.Background(), mock.Anything).Return(nil)
+		tx.On("UpdateLoan", context.Background(), mock.MatchedBy(func(loan *models.Loan) bool {
+			return loan.Status == enums.LoanStatusApproved && loan.InvestmentAmount == 400 && loan.EscrowBalance == 400
+		}), []string{"investment_amount", "escrow_balance"}).Return(nil)
+
+		notificationClient := mocks.NewNotificationClientInterface(t)
+		notificationClient.On("SendEmail", context.Background(), mock.Anything).Return(errors.New("notification service down"))
+
+		s := &LoanService{repo: m, notificationClient: notificationClient}
+		if err := s.ConfirmInvestmentFunding(context.Background(), req); err != nil {
+			t.Fatalf("ConfirmInvestmentFunding() error = %v", err)
+		}
+	})
+
+	tests := []struct {
+		name       string
+		investment *models.Investment
+		wantErr    error
+	}{
+		{
+			name:       "already funded",
+			investment: &models.Investment{ID: 7, UUID: "investment-uuid", LoanID: 1, Amount: 400, Status: enums.InvestmentStatusFunded},
+		},
+		{
+			name:       "expired reservation",
+			investment: &models.Investment{ID: 7, UUID: "investment-uuid", LoanID: 1, Amount: 400, Status: enums.InvestmentStatusExpired},
+			wantErr:    ErrReservationExpired,
+		},
+		{
+			name: "reservation ran out before the expiry job released it",
+			investment: &models.Investment{ID: 7, UUID: "investment-uuid", LoanID: 1, Amount: 400, Status: enums.InvestmentStatusPendingFunding,
+				FundingExpiresAt: sql.NullTime{Time: time.Now().Add(-time.Minute), Valid: true}},
+			wantErr: ErrReservationExpired,
+		},
+		{
+			name:       "investment of another loan",
+			investment: &models.Investment{ID: 7, UUID: "investment-uuid", LoanID: 2, Amount: 400, Status: enums.InvestmentStatusPendingFunding},
+			wantErr:    ErrInvestmentNotFound,
+		},
+		{
+			name:    "unknown investment",
+			wantErr: ErrInvestmentNotFound,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m := mocks.NewLoanRepositoryInterface(t)
+			tx := mocks.NewLoanRepositoryInterface(t)
+			m.On("WithinTx", context.Background(), mock.Anything).Return(withinTx(tx))
+			tx.On("GetLoanByUUID", context.Background(), "loan-uuid-123").Return(&models.Loan{
+				ID:              1,
+				UUID:            "loan-uuid-123",
+				Status:          enums.LoanStatusApproved,
+				PrincipalAmount: 1000,
+			}, nil)
+			tx.On("GetInvestmentByUUID", context.Background(), "investment-uuid").Return(tt.investment, nil)
+
+			s := &LoanService{repo: m, notificationClient: mocks.NewNotificationClientInterface(t)}
+			err := s.ConfirmInvestmentFunding(context.Background(), req)
+			if tt.wantErr == nil && err != nil || tt.wantErr != nil && !errors.Is(err, tt.wantErr) {
+				t.Errorf("ConfirmInvestmentFunding() error = %v, wantErr %v", err, tt.wantErr)
 			}
 		})
 	}
 }
+
+func TestLoanService_GetInvestorPortfolio(t *testing.T) {
+	fundedAt := time.Date(2026, 1, 10, 0, 0, 0, 0, time.UTC)
+	m := mocks.NewLoanRepositoryInterface(t)
+	m.On("GetInvestmentsByInvestorID", context.Background(), "investor123", []enums.InvestmentStatus{enums.InvestmentStatusFunded},
+		repository.Page{Limit: 2, Offset: 2}).Return([]models.Investment{
+		{ID: 11, UUID: "investment-active", LoanID: 1, InvestorID: "investor123", Amount: 250, Status: enums.InvestmentStatusFunded,
+			FundedAt: sql.NullTime{Time: fundedAt, Valid: true}},
+		{ID: 12, UUID: "investment-written-off", LoanID: 2, InvestorID: "investor123", Amount: 100, Status: enums.InvestmentStatusFunded,
+			RealizedLoss: 80},
+	}, int64(5), nil)
+	m.On("GetLoansByIDs", context.Background(), []int{1, 2}).Return([]models.Loan{
+		{ID: 1, UUID: "loan-active", Status: enums.LoanStatusDisbursed, PrincipalAmount: 1000, ROIRate: 8, Tenor: 6, TenorUnit: enums.TenorUnitMonth},
+		{ID: 2, UUID: "loan-written-off", Status: enums.LoanStatusWrittenOff, PrincipalAmount: 500, ROIRate: 10, Tenor: 12, TenorUnit: enums.TenorUnitMonth},
+	}, nil)
+	m.On("GetLoanInstalmentsByLoanIDs", context.Background(), mock.MatchedBy(func(ids []int) bool {
+		return len(ids) == 2 && ids[0]+ids[1] == 3
+	})).Return([]models.LoanInstalment{
+		{LoanID: 1, Sequence: 1, PrincipalDue: 500, InterestDue: 40, PaidAmount: 540},
+		{LoanID: 1, Sequence: 2, PrincipalDue: 500, InterestDue: 40},
+		{LoanID: 2, Sequence: 1, PrincipalDue: 500, InterestDue: 50},
+	}, nil)
+	m.On("GetInvestorPayoutsByInvestmentIDs", context.Background(), []int{11, 12}).Return([]models.InvestorPayout{
+		{InvestmentID: 11, PrincipalAmount: 125, InterestAmount: 8},
+		{InvestmentID: 12, PrincipalAmount: 20, InterestAmount: 1, PenaltyAmount: 0.5},
+	}, nil)
+
+	s := &LoanService{repo: m}
+	portfolio, err := s.GetInvestorPortfolio(context.Background(), "investor123", dto.PageRequest{Page: 2, PageSize: 2})
+	if err != nil {
+		t.Fatalf("LoanService.GetInvestorPortfolio() error = %v", err)
+	}
+
+	if want := (dto.PageInfo{Page: 2, PageSize: 2, TotalItems: 5, TotalPages: 3}); portfolio.Page != want {
+		t.Errorf("LoanService.GetInvestorPortfolio() page = %+v, want %+v", portfolio.Page, want)
+	}
+	want := []dto.PortfolioHolding{
+		{
+			InvestmentUUID:       "investment-active",
+			LoanUUID:             "loan-active",
+			LoanStatus:           enums.LoanStatusDisbursed,
+			InvestedAmount:       250,
+			ROIRate:              8,
+			ExpectedReturn:       10,
+			ReceivedPrincipal:    125,
+			ReceivedInterest:     8,
+			ReceivedPayouts:      133,
+			OutstandingPrincipal: 125,
+			FundedAt:             &fundedAt,
+		},
+		{
+			InvestmentUUID:    "investment-written-off",
+			LoanUUID:          "loan-written-off",
+			LoanStatus:        enums.LoanStatusWrittenOff,
+			InvestedAmount:    100,
+			ROIRate:           10,
+			ExpectedReturn:    10,
+			ReceivedPrincipal: 20,
+			ReceivedInterest:  1,
+			ReceivedPayouts:   21.5,
+			RealizedLoss:      80,
+		},
+	}
+	if !reflect.DeepEqual(portfolio.Holdings, want) {
+		t.Errorf("LoanService.GetInvestorPortfolio() holdings = %+v, want %+v", portfolio.Holdings, want)
+	}
+}
+
+func TestLoanService_GetInvestorInvestments(t *testing.T) {
+	m := mocks.NewLoanRepositoryInterface(t)
+	m.On("GetInvestmentsByInvestorID", context.Background(), "investor123", []enums.InvestmentStatus(nil),
+		repository.Page{Limit: 20, Offset: 0}).Return([]models.Investment{
+		{ID: 11, UUID: "investment-pending", LoanID: 1, Amount: 250, Status: enums.InvestmentStatusPendingFunding,
+			FundingExpiresAt: sql.NullTime{Time: time.Now().Add(time.Hour), Valid: true}},
+		{ID: 12, UUID: "investment-cancelled", LoanID: 1, Amount: 100, Status: enums.InvestmentStatusCancelled,
+			CancelledAt: sql.NullTime{Time: time.Now(), Valid: true}},
+	}, int64(2), nil)
+	m.On("GetLoansByIDs", context.Background(), []int{1, 1}).Return([]models.Loan{{ID: 1, UUID: "loan-uuid-123"}}, nil)
+
+	s := &LoanService{repo: m}
+	response, err := s.GetInvestorInvestments(context.Background(), "investor123", dto.PageRequest{Page: 1, PageSize: 20})
+	if err != nil {
+		t.Fatalf("LoanService.GetInvestorInvestments() error = %v", err)
+	}
+
+	if want := (dto.PageInfo{Page: 1, PageSize: 20, TotalItems: 2, TotalPages: 1}); response.Page != want {
+		t.Errorf("LoanService.GetInvestorInvestments() page = %+v, want %+v", response.Page, want)
+	}
+	if len(response.Investments) != 2 {
+		t.Fatalf("LoanService.GetInvestorInvestments() returned %d investments, want 2", len(response.Investments))
+	}
+	pending, cancelled := response.Investments[0], response.Investments[1]
+	if pending.LoanUUID != "loan-uuid-123" || pending.FundingExpiresAt == nil || pending.CancelledAt != nil {
+		t.Errorf("LoanService.GetInvestorInvestments() pending investment = %+v", pending)
+	}
+	if cancelled.Status != enums.InvestmentStatusCancelled || cancelled.CancelledAt == nil {
+		t.Errorf("LoanService.GetInvestorInvestments() cancelled investment = %+v", cancelled)
+	}
+
+	empty := mocks.NewLoanRepositoryInterface(t)
+	empty.On("GetInvestmentsByInvestorID", context.Background(), "nobody", []enums.InvestmentStatus(nil), mock.Anything).Return([]models.Investment{}, int64(0), nil)
+	s = &LoanService{repo: empty}
+	response, err = s.GetInvestorInvestments(context.Background(), "nobody", dto.PageRequest{Page: 1, PageSize: 20})
+	if err != nil {
+		t.Fatalf("LoanService.GetInvestorInvestments() error = %v", err)
+	}
+	if response.Investments == nil || len(response.Investments) != 0 {
+		t.Errorf("LoanService.GetInvestorInvestments() investments = %#v, want an empty list", response.Investments)
+	}
+}
+
+func TestLoanService_GetBorrowerLoans(t *testing.T) {
+	today := dateOf(time.Now())
+	createdAt := time.Date(2026, 1, 10, 0, 0, 0, 0, time.UTC)
+	m := mocks.NewLoanRepositoryInterface(t)
+	m.On("GetLoansByBorrowerID", context.Background(), "borrower123", repository.Page{Limit: 20, Offset: 0}).Return([]models.Loan{
+		{ID: 3, UUID: "loan-disbursed", Status: enums.LoanStatusDisbursed, PrincipalAmount: 1500, InterestRate: 8,
+			InvestmentAmount: 1500, DisbursedAmount: 1500, DaysPastDue: 10, CreatedAt: createdAt},
+		{ID: 2, UUID: "loan-partially-disbursed", Status: enums.LoanStatusPartiallyDisbursed, PrincipalAmount: 1000, InterestRate: 8,
+			InvestmentAmount: 1000, DisbursedAmount: 400, CreatedAt: createdAt},
+		{ID: 1, UUID: "loan-repaid", Status: enums.LoanStatusRepaid, PrincipalAmount: 500, InterestRate: 8,
+			InvestmentAmount: 500, DisbursedAmount: 500, CreatedAt: createdAt},
+	}, int64(3), nil)
+	m.On("GetLoanInstalmentsByLoanIDs", context.Background(), []int{3, 2, 1}).Return([]models.LoanInstalment{
+		{LoanID: 1, Sequence: 1, DueDate: today.AddDate(0, -1, 0), PrincipalDue: 500, InterestDue: 40, PaidAmount: 540},
+		{LoanID: 3, Sequence: 1, DueDate: today.AddDate(0, -1, -10), PrincipalDue: 500, InterestDue: 40, PaidAmount: 540},
+		{LoanID: 3, Sequence: 2, DueDate: today.AddDate(0, 0, -10), PrincipalDue: 500, InterestDue: 40, PaidAmount: 100},
+		{LoanID: 3, Sequence: 3, DueDate: today.AddDate(0, 0, 20), PrincipalDue: 500, InterestDue: 40},
+	}, nil)
+	m.On("GetLoanLedgerEntriesByLoanIDs", context.Background(), []int{3, 2, 1}, []enums.LedgerEntryType{enums.LedgerEntryTypeLateFee}).Return([]models.LoanLedgerEntry{
+		{LoanID: 1, EntryType: enums.LedgerEntryTypeLateFee, Amount: 10},
+		{LoanID: 3, EntryType: enums.LedgerEntryTypeLateFee, Amount: 15},
+	}, nil)
+
+	s := &LoanService{repo: m}
+	response, err := s.GetBorrowerLoans(context.Background(), "borrower123", dto.PageRequest{Page: 1, PageSize: 20})
+	if err != nil {
+		t.Fatalf("LoanService.GetBorrowerLoans() error = %v", err)
+	}
+
+	if want := (dto.PageInfo{Page: 1, PageSize: 20, TotalItems: 3, TotalPages: 1}); response.Page != want {
+		t.Errorf("LoanService.GetBorrowerLoans() page = %+v, want %+v", response.Page, want)
+	}
+	want := []dto.BorrowerLoanItem{
+		{
+			LoanUUID:           "loan-disbursed",
+			Status:             enums.LoanStatusDisbursed,
+			PrincipalAmount:    1500,
+			InterestRate:       8,
+			DisbursedAmount:    1500,
+			OutstandingBalance: 995,
+			DaysPastDue:        10,
+			NextInstalment: &dto.BorrowerInstalmentItem{
+				Sequence:  2,
+				DueDate:   today.AddDate(0, 0, -10),
+				AmountDue: 440,
+				Overdue:   true,
+			},
+			DocumentsToSign: []dto.BorrowerDocumentItem{},
+			CreatedAt:       createdAt,
+		},
+		{
+			LoanUUID:           "loan-partially-disbursed",
+			Status:             enums.LoanStatusPartiallyDisbursed,
+			PrincipalAmount:    1000,
+			InterestRate:       8,
+			DisbursedAmount:    400,
+			OutstandingBalance: 400,
+			DocumentsToSign: []dto.BorrowerDocumentItem{
+				{Type: enums.BorrowerDocumentTypeLoanAgreement, Amount: 600},
+			},
+			CreatedAt: createdAt,
+		},
+		{
+			LoanUUID:        "loan-repaid",
+			Status:          enums.LoanStatusRepaid,
+			PrincipalAmount: 500,
+			InterestRate:    8,
+			DisbursedAmount: 500,
+			DocumentsToSign: []dto.BorrowerDocumentItem{},
+			CreatedAt:       createdAt,
+		},
+	}
+	if !reflect.DeepEqual(response.Loans, want) {
+		t.Errorf("LoanService.GetBorrowerLoans() loans = %+v, want %+v", response.Loans, want)
+	}
+
+	empty := mocks.NewLoanRepositoryInterface(t)
+	empty.On("GetLoansByBorrowerID", context.Background(), "nobody", mock.Anything).Return([]models.Loan{}, int64(0), nil)
+	s = &LoanService{repo: empty}
+	response, err = s.GetBorrowerLoans(context.Background(), "nobody", dto.PageRequest{Page: 1, PageSize: 20})
+	if err != nil {
+		t.Fatalf("LoanService.GetBorrowerLoans() error = %v", err)
+	}
+	if response.Loans == nil || len(response.Loans) != 0 {
+		t.Errorf("LoanService.GetBorrowerLoans() loans = %#v, want an empty list", response.Loans)
+	}
+}
+
+func TestLoanService_CancelInvestment(t *testing.T) {
+	cfg := config.LoanConfig{InvestmentCoolingOffPeriod: 48 * time.Hour}
+	req := dto.CancelInvestmentRequest{InvestmentUUID: "investment-uuid", InvestorID: "investor123", Reason: "changed my mind"}
+	investment := func(status enums.InvestmentStatus, createdAt time.Time) *models.Investment {
+		investment := &models.Investment{
+			ID:         7,
+			UUID:       "investment-uuid",
+			LoanID:     1,
+			InvestorID: "investor123",
+			Amount:     400,
+			Status:     status,
+			CreatedAt:  createdAt,
+		}
+		if status == enums.InvestmentStatusFunded {
+			investment.FundedAt = sql.NullTime{Time: createdAt, Valid: true}
+		}
+		return investment
+	}
+
+	t.Run("funded investment reopens an invested loan", func(t *testing.T) {
+		m := mocks.NewLoanRepositoryInterface(t)
+		tx := mocks.NewLoanRepositoryInterface(t)
+		m.On("WithinTx", context.Background(), mock.Anything).Return(withinTx(tx))
+		tx.On("GetInvestmentByUUID", context.Background(), "investment-uuid").Return(investment(enums.InvestmentStatusFunded, time.Now().Add(-time.Hour)), nil)
+		tx.On("GetLoanByID", context.Background(), 1).Return(&models.Loan{
+			ID:               1,
+			UUID:             "loan-uuid-123",
+			Status:           enums.LoanStatusInvested,
+			PrincipalAmount:  1000,
+			InvestmentAmount: 1000,
+			EscrowBalance:    1000,
+		}, nil)
+		tx.On("GetLoanDisbursementsByLoanID", context.Background(), 1).Return([]models.LoanDisbursement{
+			{ID: 3, LoanID: 1, Amount: 1000, PayoutStatus: enums.PayoutStatusFailed},
+		}, nil)
+		tx.On("UpdateInvestment", context.Background(), mock.MatchedBy(func(investment *models.Investment) bool {
+			return investment.Status == enums.InvestmentStatusCancelled && investment.CancelledAt.Valid && investment.AgreementLetterVoidedAt.Valid
+		}), []string{"status", "cancelled_at", "agreement_letter_voided_at"}).Return(nil)
+		tx.On("CreateLoanEscrowEntry", context.Background(), mock.MatchedBy(func(entry *models.LoanEscrowEntry) bool {
+			return entry.EntryType == enums.EscrowEntryTypeRefund && entry.Amount == 400 && entry.BalanceAfter == 600 && *entry.InvestmentID == 7
+		})).Return(nil)
+		tx.On("UpdateLoan", context.Background(), mock.MatchedBy(func(loan *models.Loan) bool {
+			return loan.Status == enums.LoanStatusApproved && loan.InvestmentAmount == 600 && loan.EscrowBalance == 600
+		}), []string{"status", "investment_amount", "escrow_balance"}).Return(nil)
+		tx.On("CreateLoanStatusTransition", context.Background(), mock.MatchedBy(func(transition *models.LoanStatusTransition) bool {
+			return *transition.FromStatus == enums.LoanStatusInvested && transition.ToStatus == enums.LoanStatusApproved && transition.Actor == "investor123"
+		})).Return(nil)
+
+		notificationClient := mocks.NewNotificationClientInterface(t)
+		notificationClient.On("SendEmail", context.Background(), mock.MatchedBy(func(req client.SendEmailRequest) bool {
+			return req.To == "investor123" && req.Subject == "Investment Cancelled"
+		})).Return(nil)
+
+		s := &LoanService{repo: m, notificationClient: notificationClient, config: cfg}
+		if err := s.CancelInvestment(context.Background(), req); err != nil {
+			t.Fatalf("CancelInvestment() error = %v", err)
+		}
+	})
+
+	t.Run("reservation frees its share", func(t *testing.T) {
+		m := mocks.NewLoanRepositoryInterface(t)
+		tx := mocks.NewLoanRepositoryInterface(t)
+		m.On("WithinTx", context.Background(), mock.Anything).Return(withinTx(tx))
+		tx.On("GetInvestmentByUUID", context.Background(), "investment-uuid").Return(investment(enums.InvestmentStatusPendingFunding, time.Now().Add(-time.Hour)), nil)
+		tx.On("GetLoanByID", context.Background(), 1).Return(&models.Loan{
+			ID:               1,
+			UUID:             "loan-uuid-123",
+			Status:           enums.LoanStatusApproved,
+			PrincipalAmount:  1000,
+			InvestmentAmount: 300,
+		}, nil)
+		tx.On("GetLoanDisbursementsByLoanID", context.Background(), 1).Return(nil, nil)
+		tx.On("UpdateInvestment", context.Background(), mock.Anything, []string{"status", "cancelled_at", "agreement_letter_voided_at"}).Return(nil)
+		tx.On("UpdateLoan", context.Background(), mock.MatchedBy(func(loan *models.Loan) bool {
+			return loan.InvestmentAmount == 300
+		}), []string(nil)).Return(nil)
+
+		notificationClient := mocks.NewNotificationClientInterface(t)
+		notificationClient.On("SendEmail", context.Background(), mock.Anything).Return(errors.New("notification service down"))
+
+		s := &LoanService{repo: m, notificationClient: notificationClient, config: cfg}
+		if err := s.CancelInvestment(context.Background(), req); err != nil {
+			t.Fatalf("CancelInvestment() error = %v", err)
+		}
+	})
+
+	tests := []struct {
+		name          string
+		investment    *models.Investment
+		loanStatus    enums.LoanStatus
+		disbursements []models.LoanDisbursement
+		wantErr       error
+	}{
+		{
+			name:       "cooling-off period ended",
+			investment: investment(enums.InvestmentStatusFunded, time.Now().Add(-49*time.Hour)),
+			wantErr:    ErrCoolingOffPeriodEnded,
+		},
+		{
+			name: "cooling-off period ended after funding",
+			investment: func() *models.Investment {
+				investment := investment(enums.InvestmentStatusFunded, time.Now().Add(-60*time.Hour))
+				investment.FundedAt = sql.NullTime{Time: time.Now().Add(-49 * time.Hour), Valid: true}
+				return investment
+			}(),
+			wantErr: ErrCoolingOffPeriodEnded,
+		},
+		{
+			name: "cooling-off period runs from funding, not reservation",
+			investment: func() *models.Investment {
+				investment := investment(enums.InvestmentStatusFunded, time.Now().Add(-60*time.Hour))
+				investment.FundedAt = sql.NullTime{Time: time.Now().Add(-time.Hour), Valid: true}
+				return investment
+			}(),
+			loanStatus: enums.LoanStatusDisbursed,
+			wantErr:    ErrInvalidLoanStatus,
+		},
+		{
+			name:       "loan already disbursed",
+			investment: investment(enums.InvestmentStatusFunded, time.Now().Add(-time.Hour)),
+			loanStatus: enums.LoanStatusDisbursed,
+			wantErr:    ErrInvalidLoanStatus,
+		},
+		{
+			name:       "loan partially disbursed",
+			investment: investment(enums.InvestmentStatusFunded, time.Now().Add(-time.Hour)),
+			loanStatus: enums.LoanStatusPartiallyDisbursed,
+			wantErr:    ErrInvalidLoanStatus,
+		},
+		{
+			name:       "payout to the borrower pending",
+			investment: investment(enums.InvestmentStatusFunded, time.Now().Add(-time.Hour)),
+			loanStatus: enums.LoanStatusInvested,
+			disbursements: []models.LoanDisbursement{
+				{ID: 3, UUID: "disbursement-uuid", LoanID: 1, Amount: 1000, PayoutStatus: enums.PayoutStatusPending},
+			},
+			wantErr: ErrInvestmentNotCancellable,
+		},
+		{
+			name:       "payout to the borrower succeeded",
+			investment: investment(enums.InvestmentStatusFunded, time.Now().Add(-time.Hour)),
+			loanStatus: enums.LoanStatusInvested,
+			disbursements: []models.LoanDisbursement{
+				{ID: 2, UUID: "failed-uuid", LoanID: 1, Amount: 1000, PayoutStatus: enums.PayoutStatusFailed},
+				{ID: 3, UUID: "disbursement-uuid", LoanID: 1, Amount: 1000, PayoutStatus: enums.PayoutStatusSucceeded},
+			},
+			wantErr: ErrInvestmentNotCancellable,
+		},
+		{
+			name:       "already cancelled",
+			investment: investment(enums.InvestmentStatusCancelled, time.Now().Add(-time.Hour)),
+			wantErr:    ErrInvestmentNotCancellable,
+		},
+		{
+			name: "investment of another investor",
+			investment: func() *models.Investment {
+				investment := investment(enums.InvestmentStatusFunded, time.Now().Add(-time.Hour))
+				investment.InvestorID = "investor456"
+				return investment
+			}(),
+			wantErr: ErrInvestmentNotFound,
+		},
+		{
+			name:    "unknown investment",
+			wantErr: ErrInvestmentNotFound,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m := mocks.NewLoanRepositoryInterface(t)
+			tx := mocks.NewLoanRepositoryInterface(t)
+			m.On("WithinTx", context.Background(), mock.Anything).Return(withinTx(tx))
+			tx.On("GetInvestmentByUUID", context.Background(), "investment-uuid").Return(tt.investment, nil)
+			if tt.loanStatus != 0 {
+				tx.On("GetLoanByID", context.Background(), 1).Return(&models.Loan{ID: 1, Status: tt.loanStatus}, nil)
+			}
+			if tt.disbursements != nil {
+				tx.On("GetLoanDisbursementsByLoanID", context.Background(), 1).Return(tt.disbursements, nil)
+			}
+
+			s := &LoanService{repo: m, notificationClient: mocks.NewNotificationClientInterface(t), config: cfg}
+			if err := s.CancelInvestment(context.Background(), req); !errors.Is(err, tt.wantErr) {
+				t.Errorf("CancelInvestment() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestLoanService_HandleCollectionCallback(t *testing.T) {
+	paidAt := time.Date(2025, 10, 1, 9, 0, 0, 0, time.UTC)
+	pending := func() *models.Investment {
+		return &models.Investment{
+			ID:         7,
+			UUID:       "investment-uuid",
+			LoanID:     1,
+			InvestorID: "investor123",
+			Amount:     400,
+			Status:     enums.InvestmentStatusPendingFunding,
+		}
+	}
+
+	t.Run("collected payment funds the investment", func(t *testing.T) {
+		gateway := client.NewFakePaymentGateway("secret")
+		payload, signature, err := gateway.CollectionCallback("investment-uuid", enums.CollectionStatusSucceeded.String(), 400, paidAt)
+		if err != nil {
+			t.Fatalf("CollectionCallback() error = %v", err)
+		}
+
+		m := mocks.NewLoanRepositoryInterface(t)
+		tx := mocks.NewLoanRepositoryInterface(t)
+		m.On("WithinTx", context.Background(), mock.Anything).Return(withinTx(tx))
+		tx.On("GetInvestmentByUUID", context.Background(), "investment-uuid").Return(pending(), nil)
+		tx.On("GetLoanEscrowEntryByCollectionID", context.Background(), "collection-1").Return(nil, nil)
+		tx.On("GetLoanByID", context.Background(), 1).Return(&models.Loan{
+			ID:              1,
+			UUID:            "loan-uuid-123",
+			Status:          enums.LoanStatusApproved,
+			PrincipalAmount: 1000,
+		}, nil)
+		tx.On("UpdateInvestment", context.Background(), mock.MatchedBy(func(investment *models.Investment) bool {
+			return investment.Status == enums.InvestmentStatusFunded && investment.FundedAt.Time.Equal(paidAt) && investment.PaymentReference == "collection-1"
+		}), []string{"status", "funded_at", "payment_reference"}).Return(nil)
+		tx.On("CreateLoanEscrowEntry", context.Background(), mock.MatchedBy(func(entry *models.LoanEscrowEntry) bool {
+			return entry.EntryType == enums.EscrowEntryTypeDeposit && entry.CollectionID != nil && *entry.CollectionID == "collection-1"
+		})).Return(nil)
+		tx.On("UpdateLoan", context.Background(), mock.MatchedBy(func(loan *models.Loan) bool {
+			return loan.InvestmentAmount == 400 && loan.EscrowBalance == 400
+		}), []string{"investment_amount", "escrow_balance"}).Return(nil)
+
+		notificationClient := mocks.NewNotificationClientInterface(t)
+		notificationClient.On("SendEmail", context.Background(), mock.Anything).Return(nil)
+
+		s := &LoanService{repo: m, notificationClient: notificationClient, paymentGateway: gateway}
+		if err := s.HandleCollectionCallback(context.Background(), payload, signature); err != nil {
+			t.Fatalf("HandleCollectionCallback() error = %v", err)
+		}
+	})
+
+	t.Run("amount differs from the reservation", func(t *testing.T) {
+		gateway := client.NewFakePaymentGateway("secret")
+		payload, signature, _ := gateway.CollectionCallback("investment-uuid", enums.CollectionStatusSucceeded.String(), 350, paidAt)
+
+		m := mocks.NewLoanRepositoryInterface(t)
+		tx := mocks.NewLoanRepositoryInterface(t)
+		m.On("WithinTx", context.Background(), mock.Anything).Return(withinTx(tx))
+		tx.On("GetInvestmentByUUID", context.Background(), "investment-uuid").Return(pending(), nil)
+		tx.On("GetLoanEscrowEntryByCollectionID", context.Background(), "collection-1").Return(nil, nil)
+		tx.On("GetLoanByID", context.Background(), 1).Return(&models.Loan{ID: 1, Status: enums.LoanStatusApproved, PrincipalAmount: 1000}, nil)
+
+		s := &LoanService{repo: m, paymentGateway: gateway}
+		if err := s.HandleCollectionCallback(context.Background(), payload, signature); !errors.Is(err, ErrFundingAmountMismatch) {
+			t.Fatalf("HandleCollectionCallback() error = %v, want %v", err, ErrFundingAmountMismatch)
+		}
+	})
+
+	t.Run("redelivered callback is ignored", func(t *testing.T) {
+		gateway := client.NewFakePaymentGateway("secret")
+		payload, signature, _ := gateway.CollectionCallback("investment-uuid", enums.CollectionStatusSucceeded.String(), 400, paidAt)
+
+		funded := pending()
+		funded.Status = enums.InvestmentStatusFunded
+		funded.PaymentReference = "collection-1"
+		m := mocks.NewLoanRepositoryInterface(t)
+		tx := mocks.NewLoanRepositoryInterface(t)
+		m.On("WithinTx", context.Background(), mock.Anything).Return(withinTx(tx))
+		tx.On("GetInvestmentByUUID", context.Background(), "investment-uuid").Return(funded, nil)
+
+		s := &LoanService{repo: m, paymentGateway: gateway}
+		if err := s.HandleCollectionCallback(context.Background(), payload, signature); err != nil {
+			t.Fatalf("HandleCollectionCallback() error = %v", err)
+		}
+	})
+
+	lapsed := []struct {
+		name       string
+		investment func() *models.Investment
+		wantStatus enums.InvestmentStatus
+		expires    bool
+	}{
+		{
+			name: "expired reservation",
+			investment: func() *models.Investment {
+				investment := pending()
+				investment.Status = enums.InvestmentStatusExpired
+				return investment
+			},
+			wantStatus: enums.InvestmentStatusExpired,
+			expires:    true,
+		},
+		{
+			name: "cancelled reservation",
+			investment: func() *models.Investment {
+				investment := pending()
+				investment.Status = enums.InvestmentStatusCancelled
+				investment.PaymentReference = "collection-0"
+				return investment
+			},
+			wantStatus: enums.InvestmentStatusCancelled,
+		},
+		{
+			name: "reservation that ran out before the expiry job released it",
+			investment: func() *models.Investment {
+				investment := pending()
+				investment.FundingExpiresAt = sql.NullTime{Time: time.Now().Add(-time.Minute), Valid: true}
+				return investment
+			},
+			wantStatus: enums.InvestmentStatusExpired,
+			expires:    true,
+		},
+		{
+			name: "second payment for a funded investment",
+			investment: func() *models.Investment {
+				investment := pending()
+				investment.Status = enums.InvestmentStatusFunded
+				investment.PaymentReference = "collection-0"
+				investment.FundedAt = sql.NullTime{Time: paidAt.Add(-time.Hour), Valid: true}
+				return investment
+			},
+			wantStatus: enums.InvestmentStatusFunded,
+		},
+	}
+	for _, tt := range lapsed {
+		t.Run(tt.name+" is refunded from escrow", func(t *testing.T) {
+			gateway := client.NewFakePaymentGateway("secret")
+			payload, signature, _ := gateway.CollectionCallback("investment-uuid", enums.CollectionStatusSucceeded.String(), 400, paidAt)
+
+			investment := tt.investment()
+			reference := investment.PaymentReference
+			var entries []*models.LoanEscrowEntry
+			m := mocks.NewLoanRepositoryInterface(t)
+			tx := mocks.NewLoanRepositoryInterface(t)
+			m.On("WithinTx", context.Background(), mock.Anything).Return(withinTx(tx))
+			tx.On("GetInvestmentByUUID", context.Background(), "investment-uuid").Return(investment, nil)
+			tx.On("GetLoanEscrowEntryByCollectionID", context.Background(), "collection-1").Return(nil, nil)
+			tx.On("GetLoanByID", context.Background(), 1).Return(&models.Loan{
+				ID:               1,
+				UUID:             "loan-uuid-123",
+				Status:           enums.LoanStatusApproved,
+				PrincipalAmount:  1000,
+				InvestmentAmount: 200,
+				EscrowBalance:    200,
+			}, nil)
+			if tt.expires {
+				tx.On("UpdateInvestment", context.Background(), mock.MatchedBy(func(investment *models.Investment) bool {
+					return investment.Status == enums.InvestmentStatusExpired && investment.RefundedAt.Valid
+				}), []string{"status", "refunded_at"}).Return(nil)
+			}
+			tx.On("CreateLoanEscrowEntry", context.Background(), mock.MatchedBy(func(entry *models.LoanEscrowEntry) bool {
+				entries = append(entries, entry)
+				return entry.Amount == 400 && *entry.InvestmentID == 7
+			})).Return(nil)
+			tx.On("UpdateLoan", context.Background(), mock.MatchedBy(func(loan *models.Loan) bool {
+				return loan.InvestmentAmount == 200 && loan.EscrowBalance == 200
+			}), []string{"escrow_balance"}).Return(nil)
+
+			notificationClient := mocks.NewNotificationClientInterface(t)
+			notificationClient.On("SendEmail", context.Background(), mock.MatchedBy(func(req client.SendEmailRequest) bool {
+				return req.To == "investor123" && req.Subject == "Payment Refunded"
+			})).Return(nil).Once()
+
+			s := &LoanService{repo: m, notificationClient: notificationClient, paymentGateway: gateway}
+			if err := s.HandleCollectionCallback(context.Background(), payload, signature); err != nil {
+				t.Fatalf("HandleCollectionCallback() error = %v", err)
+			}
+
+			if len(entries) != 2 {
+				t.Fatalf("recorded %d escrow entries, want 2", len(entries))
+			}
+			if entries[0].EntryType != enums.EscrowEntryTypeDeposit || entries[0].CollectionID == nil || *entries[0].CollectionID != "collection-1" {
+				t.Errorf("first escrow entry = %+v, want a deposit for collection-1", entries[0])
+			}
+			if entries[1].EntryType != enums.EscrowEntryTypeRefund || entries[1].BalanceAfter != 200 {
+				t.Errorf("second escrow entry = %+v, want a refund back to 200", entries[1])
+			}
+			if investment.Status != tt.wantStatus {
+				t.Errorf("investment status = %v, want %v", investment.Status, tt.wantStatus)
+			}
+			if investment.PaymentReference != reference {
+				t.Errorf("investment payment reference = %q, want %q", investment.PaymentReference, reference)
+			}
+		})
+	}
+
+	t.Run("redelivered late payment is ignored", func(t *testing.T) {
+		gateway := client.NewFakePaymentGateway("secret")
+		payload, signature, _ := gateway.CollectionCallback("investment-uuid", enums.CollectionStatusSucceeded.String(), 400, paidAt)
+
+		// Funded by collection-0 and cancelled; collection-1 was refunded
+		// before and is delivered again.
+		cancelled := pending()
+		cancelled.Status = enums.InvestmentStatusCancelled
+		cancelled.PaymentReference = "collection-0"
+		collectionID := "collection-1"
+		m := mocks.NewLoanRepositoryInterface(t)
+		tx := mocks.NewLoanRepositoryInterface(t)
+		m.On("WithinTx", context.Background(), mock.Anything).Return(withinTx(tx))
+		tx.On("GetInvestmentByUUID", context.Background(), "investment-uuid").Return(cancelled, nil)
+		tx.On("GetLoanEscrowEntryByCollectionID", context.Background(), "collection-1").Return(&models.LoanEscrowEntry{
+			ID: 9, LoanID: 1, CollectionID: &collectionID, EntryType: enums.EscrowEntryTypeDeposit, Amount: 400,
+		}, nil)
+
+		s := &LoanService{repo: m, paymentGateway: gateway}
+		if err := s.HandleCollectionCallback(context.Background(), payload, signature); err != nil {
+			t.Fatalf("HandleCollectionCallback() error = %v", err)
+		}
+	})
+
+	t.Run("failed collection leaves the reservation", func(t *testing.T) {
+		gateway := client.NewFakePaymentGateway("secret")
+		payload, signature, _ := gateway.CollectionCallback("investment-uuid", enums.CollectionStatusFailed.String(), 400, paidAt)
+
+		s := &LoanService{repo: mocks.NewLoanRepositoryInterface(t), paymentGateway: gateway}
+		if err := s.HandleCollectionCallback(context.Background(), payload, signature); err != nil {
+			t.Fatalf("HandleCollectionCallback() error = %v", err)
+		}
+	})
+
+	t.Run("invalid signature", func(t *testing.T) {
+		gateway := client.NewFakePaymentGateway("secret")
+		payload, _, _ := gateway.CollectionCallback("investment-uuid", enums.CollectionStatusSucceeded.String(), 400, paidAt)
+
+		s := &LoanService{repo: mocks.NewLoanRepositoryInterface(t), paymentGateway: gateway}
+		if err := s.HandleCollectionCallback(context.Background(), payload, "forged"); !errors.Is(err, client.ErrInvalidSignature) {
+			t.Fatalf("HandleCollectionCallback() error = %v, want %v", err, client.ErrInvalidSignature)
+		}
+	})
+}
+
+func TestLoanService_ExpireInvestmentReservations(t *testing.T) {
+	expiredAt := sql.NullTime{Time: time.Now().Add(-time.Minute), Valid: true}
+
+	m := mocks.NewLoanRepositoryInterface(t)
+	tx := mocks.NewLoanRepositoryInterface(t)
+	m.On("GetInvestmentsPastFundingExpiry", context.Background(), mock.Anything).Return([]models.Investment{
+		{UUID: "investment-unpaid"},
+		{UUID: "investment-paid-meanwhile"},
+	}, nil)
+	m.On("WithinTx", context.Background(), mock.Anything).Return(withinTx(tx))
+	tx.On("GetInvestmentByUUID", context.Background(), "investment-unpaid").Return(&models.Investment{
+		ID:               1,
+		UUID:             "investment-unpaid",
+		InvestorID:       "investor1",
+		Amount:           300,
+		Status:           enums.InvestmentStatusPendingFunding,
+		FundingExpiresAt: expiredAt,
+	}, nil)
+	tx.On("GetInvestmentByUUID", context.Background(), "investment-paid-meanwhile").Return(&models.Investment{
+		ID:               2,
+		UUID:             "investment-paid-meanwhile",
+		InvestorID:       "investor2",
+		Amount:           200,
+		Status:           enums.InvestmentStatusFunded,
+		FundingExpiresAt: expiredAt,
+	}, nil)
+	tx.On("UpdateInvestment", context.Background(), mock.MatchedBy(func(investment *models.Investment) bool {
+		return investment.ID == 1 && investment.Status == enums.InvestmentStatusExpired
+	}), []string{"status"}).Return(nil)
+
+	notificationClient := mocks.NewNotificationClientInterface(t)
+	notificationClient.On("SendEmail", context.Background(), mock.MatchedBy(func(req client.SendEmailRequest) bool {
+		return req.To == "investor1" && req.Subject == "Investment Reservation Expired"
+	})).Return(nil)
+
+	s := &LoanService{repo: m, notificationClient: notificationClient}
+	expired, err := s.ExpireInvestmentReservations(context.Background())
+	if err != nil {
+		t.Fatalf("LoanService.ExpireInvestmentReservations() error = %v", err)
+	}
+	if expired != 1 {
+		t.Errorf("LoanService.ExpireInvestmentReservations() expired = %d, want 1", expired)
+	}
+}
+
+func TestLoanService_CreateLoanDisbursement(t *testing.T) {
+	bankAccount := dto.BankAccount{
+		AccountHolderName: "Budi Santoso",
+		BankCode:          "BCA",
+		AccountNumber:     "1234567890",
+	}
+
+	type fields struct {
+		repo           repository.LoanRepositoryInterface
+		paymentGateway client.PaymentGatewayClientInterface
+	}
+	type args struct {
+		ctx context.Context
+		req dto.CreateLoanDisbursementRequest
+	}
+	tests := []struct {
+		name    string
+		fields  fields
+		args    args
+		wantErr error
+	}{
+		{
+			name: "success - payout initiated for the undisbursed amount",
+			fields: fields{
+				repo: func() *mocks.LoanRepositoryInterface {
+					m := mocks.NewLoanRepositoryInterface(t)
+					tx := mocks.NewLoanRepositoryInterface(t)
+					m.On("WithinTx", context.Background(), mock.Anything).Return(withinTx(tx))
+					tx.On("GetLoanByUUID", context.Background(), "loan-uuid-123").Return(&models.Loan{
+						ID:               1,
+						UUID:             "loan-uuid-123",
+						PrincipalAmount:  900,
+						InvestmentAmount: 900,
+						Status:           enums.LoanStatusInvested,
+					}, nil)
+					tx.On("GetLoanDisbursementsByLoanID", context.Background(), 1).Return([]models.LoanDisbursement{}, nil)
+					tx.On("CreateLoanDisbursement", context.Background(), mock.MatchedBy(func(disbursement *models.LoanDisbursement) bool {
+						return disbursement.Amount == 900 && disbursement.PayoutStatus == enums.PayoutStatusPending &&
+							disbursement.BankAccountNumber == "1234567890"
+					})).Run(func(args mock.Arguments) {
+						args.Get(1).(*models.LoanDisbursement).UUID = "disbursement-uuid"
+					}).Return(nil)
+					tx.On("UpdateLoan", context.Background(), mock.MatchedBy(func(loan *models.Loan) bool {
+						return loan.Status == enums.LoanStatusInvested && loan.DisbursedAmount == 0
+					}), []string(nil)).Return(nil)
+					m.On("UpdateLoanDisbursement", context.Background(), mock.MatchedBy(func(disbursement *models.LoanDisbursement) bool {
+						return disbursement.GatewayPayoutID != nil && *disbursement.GatewayPayoutID == "payout-1"
+					}), []string{"gateway_payout_id"}).Return(nil)
+					return m
+				}(),
+				paymentGateway: func() *mocks.PaymentGatewayClientInterface {
+					m := mocks.NewPaymentGatewayClientInterface(t)
+					m.On("InitiatePayout", context.Background(), mock.MatchedBy(func(req client.InitiatePayoutRequest) bool {
+						return req.Reference == "disbursement-uuid" && req.Amount == 900 && req.BankAccount.BankCode == "BCA"
+					})).Return(client.InitiatePayoutResponse{PayoutID: "payout-1", Status: "PENDING"}, nil)
+					return m
+				}(),
+			},
+			args: args{
+				ctx: context.Background(),
+				req: dto.CreateLoanDisbursementRequest{
+					LoanUUID:                 "loan-uuid-123",
+					EmployeeID:               "emp123",
+					SignedAgreementLetterURL: "https://example.com/signed-agreement.pdf",
+					BankAccount:              bankAccount,
+					DisbursedAt:              time.Now(),
+				},
+			},
+		},
+		{
+			name: "error - pending payouts are reserved against the funded amount",
+			fields: fields{
+				repo: func() *mocks.LoanRepositoryInterface {
+					m := mocks.NewLoanRepositoryInterface(t)
+					tx := mocks.NewLoanRepositoryInterface(t)
+					m.On("WithinTx", context.Background(), mock.Anything).Return(withinTx(tx))
+					tx.On("GetLoanByUUID", context.Background(), "loan-uuid-123").Return(&models.Loan{
+						ID:               1,
+						UUID:             "loan-uuid-123",
+						PrincipalAmount:  900,
+						InvestmentAmount: 900,
+						DisbursedAmount:  400,
+						Status:           enums.LoanStatusPartiallyDisbursed,
+					}, nil)
+					tx.On("GetLoanDisbursementsByLoanID", context.Background(), 1).Return([]models.LoanDisbursement{
+						{LoanID: 1, Amount: 400, PayoutStatus: enums.PayoutStatusSucceeded},
+						{LoanID: 1, Amount: 300, PayoutStatus: enums.PayoutStatusPending},
+						{LoanID: 1, Amount: 200, PayoutStatus: enums.PayoutStatusFailed},
+					}, nil)
+					return m
+				}(),
+				paymentGateway: mocks.NewPaymentGatewayClientInterface(t),
+			},
+			args: args{
+				ctx: context.Background(),
+				req: dto.CreateLoanDisbursementRequest{
+					LoanUUID:                 "loan-uuid-123",
+					EmployeeID:               "emp123",
+					Amount:                   300,
+					SignedAgreementLetterURL: "https://example.com/signed-agreement.pdf",
+					BankAccount:              bankAccount,
+					DisbursedAt:              time.Now(),
+				},
+			},
+			wantErr: ErrDisbursementExceedsFunding,
+		},
+		{
+			name: "error - payout rejected by the gateway",
+			fields: fields{
+				repo: func() *mocks.LoanRepositoryInterface {
+					m := mocks.NewLoanRepositoryInterface(t)
+					tx := mocks.NewLoanRepositoryInterface(t)
+					m.On("WithinTx", context.Background(), mock.Anything).Return(withinTx(tx))
+					tx.On("GetLoanByUUID", context.Background(), "loan-uuid-123").Return(&models.Loan{
+						ID:               1,
+						UUID:             "loan-uuid-123",
+						PrincipalAmount:  900,
+						InvestmentAmount: 900,
+						Status:           enums.LoanStatusInvested,
+					}, nil)
+					tx.On("GetLoanDisbursementsByLoanID", context.Background(), 1).Return([]models.LoanDisbursement{}, nil)
+					tx.On("CreateLoanDisbursement", context.Background(), mock.Anything).Return(nil)
+					tx.On("UpdateLoan", context.Background(), mock.Anything, []string(nil)).Return(nil)
+					m.On("UpdateLoanDisbursement", context.Background(), mock.MatchedBy(func(disbursement *models.LoanDisbursement) bool {
+						return disbursement.PayoutStatus == enums.PayoutStatusFailed && disbursement.FailureReason != ""
+					}), []string{"payout_status", "failure_reason"}).Return(nil)
+					return m
+				}(),
+				paymentGateway: func() client.PaymentGatewayClientInterface {
+					gateway := client.NewFakePaymentGateway("secret")
+					gateway.Reject = true
+					return gateway
+				}(),
+			},
+			args: args{
+				ctx: context.Background(),
+				req: dto.CreateLoanDisbursementRequest{
+					LoanUUID:                 "loan-uuid-123",
+					EmployeeID:               "emp123",
+					SignedAgreementLetterURL: "https://example.com/signed-agreement.pdf",
+					BankAccount:              bankAccount,
+					DisbursedAt:              time.Now(),
+				},
+			},
+			wantErr: ErrPayoutFailed,
+		},
+		{
+			name: "error - gateway unreachable leaves the payout pending",
+			fields: fields{
+				repo: func() *mocks.LoanRepositoryInterface {
+					m := mocks.NewLoanRepositoryInterface(t)
+					tx := mocks.NewLoanRepositoryInterface(t)
+					m.On("WithinTx", context.Background(), mock.Anything).Return(withinTx(tx))
+					tx.On("GetLoanByUUID", context.Background(), "loan-uuid-123").Return(&models.Loan{
+						ID:               1,
+						UUID:             "loan-uuid-123",
+						PrincipalAmount:  900,
+						InvestmentAmount: 900,
+						Status:           enums.LoanStatusInvested,
+					}, nil)
+					tx.On("GetLoanDisbursementsByLoanID", context.Background(), 1).Return([]models.LoanDisbursement{}, nil)
+					tx.On("CreateLoanDisbursement", context.Background(), mock.Anything).Return(nil)
+					tx.On("UpdateLoan", context.Background(), mock.Anything, []string(nil)).Return(nil)
+					return m
+				}(),
+				paymentGateway: func() *mocks.PaymentGatewayClientInterface {
+					m := mocks.NewPaymentGatewayClientInterface(t)
+					m.On("InitiatePayout", context.Background(), mock.Anything).Return(client.InitiatePayoutResponse{}, errors.New("connection reset"))
+					return m
+				}(),
+			},
+			args: args{
+				ctx: context.Background(),
+				req: dto.CreateLoanDisbursementRequest{
+					LoanUUID:                 "loan-uuid-123",
+					EmployeeID:               "emp123",
+					SignedAgreementLetterURL: "https://example.com/signed-agreement.pdf",
+					BankAccount:              bankAccount,
+					DisbursedAt:              time.Now(),
+				},
+			},
+			wantErr: ErrPayoutFailed,
+		},
+		{
+			name: "error - loan not found",
+			fields: fields{
+				repo: func() *mocks.LoanRepositoryInterface {
+					m := mocks.NewLoanRepositoryInterface(t)
+					tx := mocks.NewLoanRepositoryInterface(t)
+					m.On("WithinTx", context.Background(), mock.Anything).Return(withinTx(tx))
+					tx.On("GetLoanByUUID", context.Background(), "loan-uuid-123").Return(nil, sql.ErrNoRows)
+					return m
+				}(),
+				paymentGateway: mocks.NewPaymentGatewayClientInterface(t),
+			},
+			args: args{
+				ctx: context.Background(),
+				req: dto.CreateLoanDisbursementRequest{
+					LoanUUID:                 "loan-uuid-123",
+					EmployeeID:               "emp123",
+					SignedAgreementLetterURL: "https://example.com/signed-agreement.pdf",
+					BankAccount:              bankAccount,
+					DisbursedAt:              time.Now(),
+				},
+			},
+			wantErr: sql.ErrNoRows,
+		},
+		{
+			name: "error - loan not invested",
+			fields: fields{
+				repo: func() *mocks.LoanRepositoryInterface {
+					m := mocks.NewLoanRepositoryInterface(t)
+					tx := mocks.NewLoanRepositoryInterface(t)
+					m.On("WithinTx", context.Background(), mock.Anything).Return(withinTx(tx))
+					tx.On("GetLoanByUUID", context.Background(), "loan-uuid-123").Return(&models.Loan{
+						ID:     1,
+						UUID:   "loan-uuid-123",
+						Status: enums.LoanStatusApproved,
+					}, nil)
+					return m
+				}(),
+				paymentGateway: mocks.NewPaymentGatewayClientInterface(t),
+			},
+			args: args{
+				ctx: context.Background(),
+				req: dto.CreateLoanDisbursementRequest{
+					LoanUUID:                 "loan-uuid-123",
+					EmployeeID:               "emp123",
+					SignedAgreementLetterURL: "https://example.com/signed-agreement.pdf",
+					BankAccount:              bankAccount,
+					DisbursedAt:              time.Now(),
+				},
+			},
+			wantErr: ErrInvalidLoanStatus,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := &LoanService{
+				repo:           tt.fields.repo,
+				paymentGateway: tt.fields.paymentGateway,
+			}
+			err := s.CreateLoanDisbursement(tt.args.ctx, tt.args.req)
+			if tt.wantErr == nil && err != nil || tt.wantErr != nil && !errors.Is(err, tt.wantErr) {
+				t.Errorf("LoanService.CreateLoanDisbursement() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestLoanService_HandlePayoutCallback(t *testing.T) {
+	completedAt := time.Date(2025, 10, 1, 9, 0, 0, 0, time.UTC)
+
+	// initiate registers a payout for reference with the fake gateway so it
+	// can sign callbacks for it.
+	initiate := func(t *testing.T, gateway *client.FakePaymentGateway, reference string) {
+		t.Helper()
+		if _, err := gateway.InitiatePayout(context.Background(), client.InitiatePayoutRequest{Reference: reference, Amount: 400}); err != nil {
+			t.Fatalf("InitiatePayout() error = %v", err)
+		}
+	}
+
+	t.Run("succeeded payout completes the loan's disbursement", func(t *testing.T) {
+		gateway := client.NewFakePaymentGateway("secret")
+		initiate(t, gateway, "disbursement-uuid")
+		payload, signature, err := gateway.Callback("disbursement-uuid", enums.PayoutStatusSucceeded.String(), "", completedAt)
+		if err != nil {
+			t.Fatalf("Callback() error = %v", err)
+		}
+
+		m := mocks.NewLoanRepositoryInterface(t)
+		tx := mocks.NewLoanRepositoryInterface(t)
+		m.On("WithinTx", context.Background(), mock.Anything).Return(withinTx(tx))
+		tx.On("GetLoanDisbursementByUUID", context.Background(), "disbursement-uuid").Return(&models.LoanDisbursement{
+			UUID:                   "disbursement-uuid",
+			LoanID:                 1,
+			Amount:                 400,
+			FieldOfficerEmployeeID: "emp123",
+			PayoutStatus:           enums.PayoutStatusPending,
+		}, nil)
+		tx.On("UpdateLoanDisbursement", context.Background(), mock.MatchedBy(func(disbursement *models.LoanDisbursement) bool {
+			return disbursement.PayoutStatus == enums.PayoutStatusSucceeded && disbursement.DisbursedAt.Equal(completedAt) &&
+				*disbursement.GatewayPayoutID == "payout-1"
+		}), []string{"payout_status", "gateway_payout_id", "disbursed_at"}).Return(nil)
+		tx.On("GetLoanByID", context.Background(), 1).Return(&models.Loan{
+			ID:                 1,
+			UUID:               "loan-uuid-123",
+			PrincipalAmount:    900,
+			InterestRate:       12,
+			Tenor:              3,
+			TenorUnit:          enums.TenorUnitMonth,
+			RepaymentFrequency: enums.RepaymentFrequencyMonthly,
+			InvestmentAmount:   900,
+			DisbursedAmount:    500,
+			EscrowBalance:      400,
+			Status:             enums.LoanStatusPartiallyDisbursed,
+		}, nil)
+		tx.On("CreateLoanEscrowEntry", context.Background(), mock.MatchedBy(func(entry *models.LoanEscrowEntry) bool {
+			return entry.EntryType == enums.EscrowEntryTypeRelease && entry.Amount == 400 && entry.BalanceAfter == 0 &&
+				entry.LoanDisbursementID != nil
+		})).Return(nil)
+		tx.On("CreateLoanInstalments", context.Background(), mock.MatchedBy(func(instalments []models.LoanInstalment) bool {
+			return len(instalments) == 3 && instalments[0].PrincipalDue == 300 && instalments[0].InterestDue == 9
+		})).Return(nil)
+		tx.On("UpdateLoan", context.Background(), mock.MatchedBy(func(loan *models.Loan) bool {
+			return loan.Status == enums.LoanStatusDisbursed && loan.DisbursedAmount == 900 && loan.EscrowBalance == 0
+		}), []string{"status", "disbursed_amount", "escrow_balance"}).Return(nil)
+		tx.On("CreateLoanStatusTransition", context.Background(), mock.MatchedBy(func(transition *models.LoanStatusTransition) bool {
+			return transition.ToStatus == enums.LoanStatusDisbursed && transition.Actor == "emp123"
+		})).Return(nil)
+
+		s := &LoanService{repo: m, paymentGateway: gateway}
+		if err := s.HandlePayoutCallback(context.Background(), payload, signature); err != nil {
+			t.Fatalf("HandlePayoutCallback() error = %v", err)
+		}
+	})
+
+	t.Run("succeeded tranche leaves the loan partially disbursed", func(t *testing.T) {
+		gateway := client.NewFakePaymentGateway("secret")
+		initiate(t, gateway, "disbursement-uuid")
+		payload, signature, _ := gateway.Callback("disbursement-uuid", enums.PayoutStatusSucceeded.String(), "", completedAt)
+
+		m := mocks.NewLoanRepositoryInterface(t)
+		tx := mocks.NewLoanRepositoryInterface(t)
+		m.On("WithinTx", context.Background(), mock.Anything).Return(withinTx(tx))
+		tx.On("GetLoanDisbursementByUUID", context.Background(), "disbursement-uuid").Return(&models.LoanDisbursement{
+			UUID:         "disbursement-uuid",
+			LoanID:       1,
+			Amount:       400,
+			PayoutStatus: enums.PayoutStatusPending,
+		}, nil)
+		tx.On("UpdateLoanDisbursement", context.Background(), mock.Anything, []string{"payout_status", "gateway_payout_id", "disbursed_at"}).Return(nil)
+		tx.On("GetLoanByID", context.Background(), 1).Return(&models.Loan{
+			ID:               1,
+			UUID:             "loan-uuid-123",
+			InvestmentAmount: 900,
+			EscrowBalance:    900,
+			Status:           enums.LoanStatusInvested,
+		}, nil)
+		tx.On("CreateLoanEscrowEntry", context.Background(), mock.Anything).Return(nil)
+		tx.On("UpdateLoan", context.Background(), mock.MatchedBy(func(loan *models.Loan) bool {
+			return loan.Status == enums.LoanStatusPartiallyDisbursed && loan.DisbursedAmount == 400 && loan.EscrowBalance == 500
+		}), []string{"status", "disbursed_amount", "escrow_balance"}).Return(nil)
+		tx.On("CreateLoanStatusTransition", context.Background(), mock.Anything).Return(nil)
+
+		s := &LoanService{repo: m, paymentGateway: gateway}
+		if err := s.HandlePayoutCallback(context.Background(), payload, signature); err != nil {
+			t.Fatalf("HandlePayoutCallback() error = %v", err)
+		}
+	})
+
+	t.Run("succeeded payout for a loan in an unexpected status flags the loan", func(t *testing.T) {
+		gateway := client.NewFakePaymentGateway("secret")
+		initiate(t, gateway, "disbursement-uuid")
+		payload, signature, _ := gateway.Callback("disbursement-uuid", enums.PayoutStatusSucceeded.String(), "", completedAt)
+
+		m := mocks.NewLoanRepositoryInterface(t)
+		tx := mocks.NewLoanRepositoryInterface(t)
+		m.On("WithinTx", context.Background(), mock.Anything).Return(withinTx(tx))
+		tx.On("GetLoanDisbursementByUUID", context.Background(), "disbursement-uuid").Return(&models.LoanDisbursement{
+			UUID:         "disbursement-uuid",
+			LoanID:       1,
+			Amount:       400,
+			PayoutStatus: enums.PayoutStatusPending,
+		}, nil)
+		tx.On("UpdateLoanDisbursement", context.Background(), mock.MatchedBy(func(disbursement *models.LoanDisbursement) bool {
+			return disbursement.PayoutStatus == enums.PayoutStatusSucceeded
+		}), []string{"payout_status", "gateway_payout_id", "disbursed_at"}).Return(nil)
+		tx.On("GetLoanByID", context.Background(), 1).Return(&models.Loan{
+			ID:               1,
+			UUID:             "loan-uuid-123",
+			InvestmentAmount: 500,
+			EscrowBalance:    500,
+			Status:           enums.LoanStatusApproved,
+		}, nil)
+		tx.On("CreateLoanEscrowEntry", context.Background(), mock.MatchedBy(func(entry *models.LoanEscrowEntry) bool {
+			return entry.EntryType == enums.EscrowEntryTypeRelease && entry.Amount == 400 && entry.BalanceAfter == 100
+		})).Return(nil)
+		tx.On("UpdateLoan", context.Background(), mock.MatchedBy(func(loan *models.Loan) bool {
+			return loan.Status == enums.LoanStatusApproved && loan.DisbursedAmount == 400 && loan.EscrowBalance == 100 &&
+				loan.FlaggedAt.Valid && strings.Contains(loan.FlagReason, "disbursement-uuid")
+		}), []string{"disbursed_amount", "escrow_balance", "flagged_at", "flag_reason"}).Return(nil)
+
+		s := &LoanService{repo: m, paymentGateway: gateway}
+		if err := s.HandlePayoutCallback(context.Background(), payload, signature); err != nil {
+			t.Fatalf("HandlePayoutCallback() error = %v", err)
+		}
+	})
+
+	t.Run("failed payout records the reason", func(t *testing.T) {
+		gateway := client.NewFakePaymentGateway("secret")
+		initiate(t, gateway, "disbursement-uuid")
+		payload, signature, _ := gateway.Callback("disbursement-uuid", enums.PayoutStatusFailed.String(), "account closed", completedAt)
+
+		m := mocks.NewLoanRepositoryInterface(t)
+		tx := mocks.NewLoanRepositoryInterface(t)
+		m.On("WithinTx", context.Background(), mock.Anything).Return(withinTx(tx))
+		tx.On("GetLoanDisbursementByUUID", context.Background(), "disbursement-uuid").Return(&models.LoanDisbursement{
+			UUID:         "disbursement-uuid",
+			LoanID:       1,
+			Amount:       400,
+			PayoutStatus: enums.PayoutStatusPending,
+		}, nil)
+		tx.On("UpdateLoanDisbursement", context.Background(), mock.MatchedBy(func(disbursement *models.LoanDisbursement) bool {
+			return disbursement.PayoutStatus == enums.PayoutStatusFailed && disbursement.FailureReason == "account closed"
+		}), []string{"payout_status", "gateway_payout_id", "failure_reason"}).Return(nil)
+
+		s := &LoanService{repo: m, paymentGateway: gateway}
+		if err := s.HandlePayoutCallback(context.Background(), payload, signature); err != nil {
+			t.Fatalf("HandlePayoutCallback() error = %v", err)
+		}
+	})
+
+	t.Run("redelivered callback is ignored", func(t *testing.T) {
+		gateway := client.NewFakePaymentGateway("secret")
+		initiate(t, gateway, "disbursement-uuid")
+		payload, signature, _ := gateway.Callback("disbursement-uuid", enums.PayoutStatusSucceeded.String(), "", completedAt)
+
+		m := mocks.NewLoanRepositoryInterface(t)
+		tx := mocks.NewLoanRepositoryInterface(t)
+		m.On("WithinTx", context.Background(), mock.Anything).Return(withinTx(tx))
+		tx.On("GetLoanDisbursementByUUID", context.Background(), "disbursement-uuid").Return(&models.LoanDisbursement{
+			UUID:         "disbursement-uuid",
+			LoanID:       1,
+			Amount:       400,
+			PayoutStatus: enums.PayoutStatusSucceeded,
+		}, nil)
+
+		s := &LoanService{repo: m, paymentGateway: gateway}
+		if err := s.HandlePayoutCallback(context.Background(), payload, signature); err != nil {
+			t.Fatalf("HandlePayoutCallback() error = %v", err)
+		}
+	})
+
+	t.Run("unknown reference", func(t *testing.T) {
+		gateway := client.NewFakePaymentGateway("secret")
+		initiate(t, gateway, "disbursement-uuid")
+		payload, signature, _ := gateway.Callback("disbursement-uuid", enums.PayoutStatusSucceeded.String(), "", completedAt)
+
+		m := mocks.NewLoanRepositoryInterface(t)
+		tx := mocks.NewLoanRepositoryInterface(t)
+		m.On("WithinTx", context.Background(), mock.Anything).Return(withinTx(tx))
+		tx.On("GetLoanDisbursementByUUID", context.Background(), "disbursement-uuid").Return(nil, nil)
+
+		s := &LoanService{repo: m, paymentGateway: gateway}
+		if err := s.HandlePayoutCallback(context.Background(), payload, signature); !errors.Is(err, ErrPayoutNotFound) {
+			t.Fatalf("HandlePayoutCallback() error = %v, want %v", err, ErrPayoutNotFound)
+		}
+	})
+
+	t.Run("invalid signature", func(t *testing.T) {
+		gateway := client.NewFakePaymentGateway("secret")
+		initiate(t, gateway, "disbursement-uuid")
+		payload, _, _ := gateway.Callback("disbursement-uuid", enums.PayoutStatusSucceeded.String(), "", completedAt)
+
+		s := &LoanService{repo: mocks.NewLoanRepositoryInterface(t), paymentGateway: gateway}
+		if err := s.HandlePayoutCallback(context.Background(), payload, client.SignPayload("other", payload)); !errors.Is(err, client.ErrInvalidSignature) {
+			t.Fatalf("HandlePayoutCallback() error = %v, want %v", err, client.ErrInvalidSignature)
+		}
+	})
+}
+
+func TestLoanService_GetLoanByUUID(t *testing.T) {
+	proposed := enums.LoanStatusProposed
+	proposedAt := time.Date(2025, 9, 1, 8, 0, 0, 0, time.UTC)
+	approvedAt := time.Date(2025, 9, 3, 10, 30, 0, 0, time.UTC)
+
+	type fields struct {
+		repo repository.LoanRepositoryInterface
+	}
+	tests := []struct {
+		name           string
+		fields         fields
+		uuid           string
+		wantTimeline   []dto.LoanStatusTransitionItem
+		wantTermSheets []dto.LoanTermSheetItem
+		wantErr        bool
+	}{
+		{
+			name: "success - includes status timeline",
+			fields: fields{
+				repo: func() *mocks.LoanRepositoryInterface {
+					m := mocks.NewLoanRepositoryInterface(t)
+					m.On("GetLoanByUUID", context.Background(), "loan-uuid-123").Return(&models.Loan{
+						ID:     1,
+						UUID:   "loan-uuid-123",
+						Status: enums.LoanStatusApproved,
+					}, nil)
+					m.On("GetLoanStatusTransitionsByLoanID", context.Background(), 1).Return([]models.LoanStatusTransition{
+						{LoanID: 1, ToStatus: enums.LoanStatusProposed, Actor: "borrower1", At: proposedAt},
+						{LoanID: 1, FromStatus: &proposed, ToStatus: enums.LoanStatusApproved, Actor: "emp123", Reason: "approved by field validator", At: approvedAt},
+					}, nil)
+					m.On("GetLoanTermSheetsByLoanID", context.Background(), 1).Return([]models.LoanTermSheet{
+						{LoanID: 1, Version: 1, PrincipalAmount: 1000.0, InterestRate: 5, ROIRate: 3, Actor: "borrower1", CreatedAt: proposedAt},
+					}, nil)
+					m.On("GetLoanDisbursementsByLoanID", context.Background(), 1).Return([]models.LoanDisbursement{}, nil)
+					m.On("GetLoanInstalmentsByLoanID", context.Background(), 1).Return([]models.LoanInstalment{}, nil)
+					m.On("GetLoanLedgerEntriesByLoanID", context.Background(), 1).Return([]models.LoanLedgerEntry{}, nil)
+					m.On("GetLoanEscrowEntriesByLoanID", context.Background(), 1).Return([]models.LoanEscrowEntry{}, nil)
+					return m
+				}(),
+			},
+			uuid: "loan-uuid-123",
+			wantTimeline: []dto.LoanStatusTransitionItem{
+				{ToStatus: enums.LoanStatusProposed, Actor: "borrower1", At: proposedAt},
+				{FromStatus: &proposed, ToStatus: enums.LoanStatusApproved, Actor: "emp123", Reason: "approved by field validator", At: approvedAt},
+			},
+			wantTermSheets: []dto.LoanTermSheetItem{
+				{Version: 1, PrincipalAmount: 1000.0, InterestRate: 5, ROIRate: 3, Actor: "borrower1", CreatedAt: proposedAt},
+			},
+			wantErr: false,
+		},
+		{
+			name: "error - transitions lookup fails",
+			fields: fields{
+				repo: func() *mocks.LoanRepositoryInterface {
+					m := mocks.NewLoanRepositoryInterface(t)
+					m.On("GetLoanByUUID", context.Background(), "loan-uuid-123").Return(&models.Loan{
+						ID:   1,
+						UUID: "loan-uuid-123",
+					}, nil)
+					m.On("GetLoanStatusTransitionsByLoanID", context.Background(), 1).Return(nil, errors.New("database error"))
+					return m
+				}(),
+			},
+			uuid:    "loan-uuid-123",
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := &LoanService{
+				repo: tt.fields.repo,
+			}
+			got, err := s.GetLoanByUUID(context.Background(), tt.uuid)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("LoanService.GetLoanByUUID() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+			if !reflect.DeepEqual(got.StatusTimeline, tt.wantTimeline) {
+				t.Errorf("LoanService.GetLoanByUUID() timeline = %+v, want %+v", got.StatusTimeline, tt.wantTimeline)
+			}
+			if !reflect.DeepEqual(got.TermSheets, tt.wantTermSheets) {
+				t.Errorf("LoanService.GetLoanByUUID() term sheets = %+v, want %+v", got.TermSheets, tt.wantTermSheets)
+			}
+		})
+	}
+}
+
+func TestLoanService_AmendLoan(t *testing.T) {
+	principal := 1500.0
+	sameRate := 5.0
+
+	type fields struct {
+		repo repository.LoanRepositoryInterface
+	}
+	tests := []struct {
+		name    string
+		fields  fields
+		req     dto.AmendLoanRequest
+		wantErr error
+	}{
+		{
+			name: "success - records next term sheet version",
+			fields: fields{
+				repo: func() *mocks.LoanRepositoryInterface {
+					m := mocks.NewLoanRepositoryInterface(t)
+					tx := mocks.NewLoanRepositoryInterface(t)
+					m.On("WithinTx", context.Background(), mock.Anything).Return(withinTx(tx))
+					tx.On("GetLoanByUUID", context.Background(), "loan-uuid-123").Return(&models.Loan{
+						ID:              1,
+						UUID:            "loan-uuid-123",
+						Status:          enums.LoanStatusProposed,
+						PrincipalAmount: 1000.0,
+						InterestRate:    5,
+						ROIRate:         3,
+					}, nil)
+					tx.On("UpdateLoan", context.Background(), mock.MatchedBy(func(loan *models.Loan) bool {
+						return loan.PrincipalAmount == 1500.0
+					}), []string{"principal_amount"}).Return(nil)
+					tx.On("GetLatestLoanTermSheet", context.Background(), 1).Return(&models.LoanTermSheet{ID: 7, LoanID: 1, Version: 1}, nil)
+					tx.On("CreateLoanTermSheet", context.Background(), mock.MatchedBy(func(termSheet *models.LoanTermSheet) bool {
+						return termSheet.Version == 2 && termSheet.PrincipalAmount == 1500.0 && termSheet.InterestRate == 5 && termSheet.Actor == "emp123"
+					})).Return(nil)
+					return m
+				}(),
+			},
+			req: dto.AmendLoanRequest{
+				LoanUUID:        "loan-uuid-123",
+				EmployeeID:      "emp123",
+				PrincipalAmount: &principal,
+				InterestRate:    &sameRate,
+				Reason:          "borrower asked for more",
+			},
+		},
+		{
+			name: "error - loan already approved",
+			fields: fields{
+				repo: func() *mocks.LoanRepositoryInterface {
+					m := mocks.NewLoanRepositoryInterface(t)
+					tx := mocks.NewLoanRepositoryInterface(t)
+					m.On("WithinTx", context.Background(), mock.Anything).Return(withinTx(tx))
+					tx.On("GetLoanByUUID", context.Background(), "loan-uuid-123").Return(&models.Loan{
+						ID:     1,
+						UUID:   "loan-uuid-123",
+						Status: enums.LoanStatusApproved,
+					}, nil)
+					return m
+				}(),
+			},
+			req: dto.AmendLoanRequest{
+				LoanUUID:        "loan-uuid-123",
+				EmployeeID:      "emp123",
+				PrincipalAmount: &principal,
+			},
+			wantErr: ErrLoanNotAmendable,
+		},
+		{
+			name: "error - nothing changes",
+			fields: fields{
+				repo: func() *mocks.LoanRepositoryInterface {
+					m := mocks.NewLoanRepositoryInterface(t)
+					tx := mocks.NewLoanRepositoryInterface(t)
+					m.On("WithinTx", context.Background(), mock.Anything).Return(withinTx(tx))
+					tx.On("GetLoanByUUID", context.Background(), "loan-uuid-123").Return(&models.Loan{
+						ID:           1,
+						UUID:         "loan-uuid-123",
+						Status:       enums.LoanStatusProposed,
+						InterestRate: 5,
+					}, nil)
+					return m
+				}(),
+			},
+			req: dto.AmendLoanRequest{
+				LoanUUID:     "loan-uuid-123",
+				EmployeeID:   "emp123",
+				InterestRate: &sameRate,
+			},
+			wantErr: ErrNoTermsChanged,
+		},
+		{
+			name: "error - unknown loan",
+			fields: fields{
+				repo: func() *mocks.LoanRepositoryInterface {
+					m := mocks.NewLoanRepositoryInterface(t)
+					tx := mocks.NewLoanRepositoryInterface(t)
+					m.On("WithinTx", context.Background(), mock.Anything).Return(withinTx(tx))
+					tx.On("GetLoanByUUID", context.Background(), "loan-uuid-123").Return(nil, gorm.ErrRecordNotFound)
+					return m
+				}(),
+			},
+			req: dto.AmendLoanRequest{
+				LoanUUID:     "loan-uuid-123",
+				EmployeeID:   "emp123",
+				InterestRate: &sameRate,
+			},
+			wantErr: ErrLoanNotFound,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := &LoanService{
+				repo: tt.fields.repo,
+			}
+			err := s.AmendLoan(context.Background(), tt.req)
+			if !errors.Is(err, tt.wantErr) {
+				t.Errorf("LoanService.AmendLoan() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestLoanService_ExpireOverdueLoans(t *testing.T) {
+	deadline := sql.NullTime{Time: time.Now().Add(-time.Hour), Valid: true}
+
+	m := mocks.NewLoanRepositoryInterface(t)
+	tx := mocks.NewLoanRepositoryInterface(t)
+	m.On("GetLoansPastFundingDeadline", context.Background(), mock.Anything).Return([]models.Loan{
+		{ID: 1, UUID: "loan-overdue"},
+		{ID: 2, UUID: "loan-funded-meanwhile"},
+	}, nil)
+	m.On("WithinTx", context.Background(), mock.Anything).Return(withinTx(tx))
+	tx.On("GetLoanByUUID", context.Background(), "loan-overdue").Return(&models.Loan{
+		ID:               1,
+		UUID:             "loan-overdue",
+		Status:           enums.LoanStatusApproved,
+		FundingDeadline:  deadline,
+		InvestmentAmount: 500,
+		EscrowBalance:    500,
+	}, nil)
+	tx.On("GetLoanByUUID", context.Background(), "loan-funded-meanwhile").Return(&models.Loan{
+		ID:              2,
+		UUID:            "loan-funded-meanwhile",
+		Status:          enums.LoanStatusInvested,
+		FundingDeadline: deadline,
+	}, nil)
+	tx.On("GetInvestmentsByLoanID", context.Background(), 1).Return([]models.Investment{
+		{ID: 11, InvestorID: "investor1", Amount: 300, Status: enums.InvestmentStatusFunded},
+		{ID: 12, InvestorID: "investor2", Amount: 200, Status: enums.InvestmentStatusFunded},
+		{ID: 13, InvestorID: "investor3", Amount: 100, Status: enums.InvestmentStatusPendingFunding},
+	}, nil)
+	tx.On("CreateLoanEscrowEntry", context.Background(), mock.MatchedBy(func(entry *models.LoanEscrowEntry) bool {
+		return entry.EntryType == enums.EscrowEntryTypeRefund && *entry.InvestmentID == 11 && entry.BalanceAfter == 200
+	})).Return(nil)
+	tx.On("CreateLoanEscrowEntry", context.Background(), mock.MatchedBy(func(entry *models.LoanEscrowEntry) bool {
+		return entry.EntryType == enums.EscrowEntryTypeRefund && *entry.InvestmentID == 12 && entry.BalanceAfter == 0
+	})).Return(nil)
+	tx.On("UpdateInvestment", context.Background(), mock.MatchedBy(func(investment *models.Investment) bool {
+		return investment.ID == 13 && investment.Status == enums.InvestmentStatusExpired
+	}), []string{"status"}).Return(nil)
+	tx.On("UpdateLoan", context.Background(), mock.MatchedBy(func(loan *models.Loan) bool {
+		return loan.ID == 1 && loan.Status == enums.LoanStatusExpired && loan.InvestmentAmount == 0 && loan.EscrowBalance == 0
+	}), []string{"status", "investment_amount", "escrow_balance"}).Return(nil)
+	tx.On("CreateLoanStatusTransition", context.Background(), mock.MatchedBy(func(transition *models.LoanStatusTransition) bool {
+		return transition.LoanID == 1 && transition.ToStatus == enums.LoanStatusExpired && transition.Actor == systemActor
+	})).Return(nil)
+	tx.On("RefundInvestmentsByLoanID", context.Background(), 1, mock.Anything).Return(nil)
+	m.On("GetInvestmentsByLoanID", context.Background(), 1).Return([]models.Investment{
+		{InvestorID: "investor1", Amount: 300, Status: enums.InvestmentStatusRefunded},
+		{InvestorID: "investor2", Amount: 200, Status: enums.InvestmentStatusRefunded},
+	}, nil)
+
+	notificationClient := mocks.NewNotificationClientInterface(t)
+	notificationClient.On("SendEmail", context.Background(), mock.MatchedBy(func(req client.SendEmailRequest) bool {
+		return req.To == "investor1"
+	})).Return(nil)
+	notificationClient.On("SendEmail", context.Background(), mock.MatchedBy(func(req client.SendEmailRequest) bool {
+		return req.To == "investor2"
+	})).Return(errors.New("notification service down"))
+
+	s := &LoanService{
+		repo:               m,
+		notificationClient: notificationClient,
+	}
+	expired, err := s.ExpireOverdueLoans(context.Background())
+	if err != nil {
+		t.Fatalf("LoanService.ExpireOverdueLoans() error = %v", err)
+	}
+	if expired != 1 {
+		t.Errorf("LoanService.ExpireOverdueLoans() expired = %d, want 1", expired)
+	}
+}
+
+func TestLoanService_RefreshDelinquency(t *testing.T) {
+	today := dateOf(time.Now())
+
+	m := mocks.NewLoanRepositoryInterface(t)
+	tx := mocks.NewLoanRepositoryInterface(t)
+	m.On("GetLoansByStatuses", context.Background(), []enums.LoanStatus{enums.LoanStatusDisbursed, enums.LoanStatusDelinquent}).Return([]models.Loan{
+		{ID: 1, UUID: "loan-late"},
+		{ID: 2, UUID: "loan-current"},
+	}, nil)
+	m.On("WithinTx", context.Background(), mock.Anything).Return(withinTx(tx))
+
+	tx.On("GetLoanByUUID", context.Background(), "loan-late").Return(&models.Loan{
+		ID:        1,
+		UUID:      "loan-late",
+		Status:    enums.LoanStatusDisbursed,
+		DPDBucket: enums.DPDBucketCurrent,
+	}, nil)
+	tx.On("GetLoanInstalmentsByLoanID", context.Background(), 1).Return([]models.LoanInstalment{
+		{ID: 11, LoanID: 1, Sequence: 1, DueDate: today.AddDate(0, 0, -40), PrincipalDue: 100, InterestDue: 10, PaidAmount: 110},
+		{ID: 12, LoanID: 1, Sequence: 2, DueDate: today.AddDate(0, 0, -10), PrincipalDue: 100, InterestDue: 10, PaidAmount: 10},
+		{ID: 13, LoanID: 1, Sequence: 3, DueDate: today.AddDate(0, 0, -2), PrincipalDue: 100, InterestDue: 10},
+		{ID: 14, LoanID: 1, Sequence: 4, DueDate: today.AddDate(0, 0, 20), PrincipalDue: 100, InterestDue: 10},
+	}, nil)
+	tx.On("GetLoanLedgerEntriesByLoanID", context.Background(), 1).Return([]models.LoanLedgerEntry{}, nil)
+	tx.On("CreateLoanLedgerEntry", context.Background(), mock.MatchedBy(func(entry *models.LoanLedgerEntry) bool {
+		return entry.LoanID == 1 && *entry.LoanInstalmentID == 12 && entry.EntryType == enums.LedgerEntryTypeLateFee && entry.Amount == 15
+	})).Return(nil).Once()
+	tx.On("UpdateLoan", context.Background(), mock.MatchedBy(func(loan *models.Loan) bool {
+		return loan.ID == 1 && loan.Status == enums.LoanStatusDelinquent && loan.DaysPastDue == 10 && loan.DPDBucket == enums.DPDBucket1To30
+	}), []string{"status", "days_past_due", "dpd_bucket"}).Return(nil)
+	tx.On("CreateLoanStatusTransition", context.Background(), mock.MatchedBy(func(transition *models.LoanStatusTransition) bool {
+		return transition.LoanID == 1 && transition.ToStatus == enums.LoanStatusDelinquent && transition.Actor == systemActor
+	})).Return(nil)
+
+	tx.On("GetLoanByUUID", context.Background(), "loan-current").Return(&models.Loan{
+		ID:        2,
+		UUID:      "loan-current",
+		Status:    enums.LoanStatusDisbursed,
+		DPDBucket: enums.DPDBucketCurrent,
+	}, nil)
+	tx.On("GetLoanInstalmentsByLoanID", context.Background(), 2).Return([]models.LoanInstalment{
+		{ID: 21, LoanID: 2, Sequence: 1, DueDate: today.AddDate(0, 0, 5), PrincipalDue: 100, InterestDue: 10},
+	}, nil)
+	tx.On("GetLoanLedgerEntriesByLoanID", context.Background(), 2).Return([]models.LoanLedgerEntry{}, nil)
+
+	s := &LoanService{
+		repo: m,
+		config: config.LoanConfig{
+			LateFeeFlat:        5,
+			LateFeeRate:        10,
+			LateFeeGracePeriod: 3 * 24 * time.Hour,
+		},
+	}
+	changed, err := s.RefreshDelinquency(context.Background())
+	if err != nil {
+		t.Fatalf("LoanService.RefreshDelinquency() error = %v", err)
+	}
+	if changed != 1 {
+		t.Errorf("LoanService.RefreshDelinquency() changed = %d, want 1", changed)
+	}
+}
+
+func TestLoanService_RefreshDelinquency_ClearsArrears(t *testing.T) {
+	today := dateOf(time.Now())
+	instalmentID := 11
+
+	m := mocks.NewLoanRepositoryInterface(t)
+	tx := mocks.NewLoanRepositoryInterface(t)
+	m.On("GetLoansByStatuses", context.Background(), mock.Anything).Return([]models.Loan{{ID: 1, UUID: "loan-cured"}}, nil)
+	m.On("WithinTx", context.Background(), mock.Anything).Return(withinTx(tx))
+	tx.On("GetLoanByUUID", context.Background(), "loan-cured").Return(&models.Loan{
+		ID:          1,
+		UUID:        "loan-cured",
+		Status:      enums.LoanStatusDelinquent,
+		DaysPastDue: 12,
+		DPDBucket:   enums.DPDBucket1To30,
+	}, nil)
+	tx.On("GetLoanInstalmentsByLoanID", context.Background(), 1).Return([]models.LoanInstalment{
+		{ID: instalmentID, LoanID: 1, Sequence: 1, DueDate: today.AddDate(0, 0, -12), PrincipalDue: 100, InterestDue: 10, PaidAmount: 110},
+	}, nil)
+	tx.On("GetLoanLedgerEntriesByLoanID", context.Background(), 1).Return([]models.LoanLedgerEntry{
+		{LoanID: 1, LoanInstalmentID: &instalmentID, EntryType: enums.LedgerEntryTypeLateFee, Amount: 5},
+	}, nil)
+	tx.On("UpdateLoan", context.Background(), mock.MatchedBy(func(loan *models.Loan) bool {
+		return loan.Status == enums.LoanStatusDisbursed && loan.DaysPastDue == 0 && loan.DPDBucket == enums.DPDBucketCurrent
+	}), []string{"status", "days_past_due", "dpd_bucket"}).Return(nil)
+	tx.On("CreateLoanStatusTransition", context.Background(), mock.MatchedBy(func(transition *models.LoanStatusTransition) bool {
+		return transition.ToStatus == enums.LoanStatusDisbursed && transition.Reason == "arrears cleared"
+	})).Return(nil)
+
+	s := &LoanService{repo: m, config: config.LoanConfig{LateFeeFlat: 5}}
+	changed, err := s.RefreshDelinquency(context.Background())
+	if err != nil {
+		t.Fatalf("LoanService.RefreshDelinquency() error = %v", err)
+	}
+	if changed != 1 {
+		t.Errorf("LoanService.RefreshDelinquency() changed = %d, want 1", changed)
+	}
+}
+
+func TestBuildInstalmentSchedule(t *testing.T) {
+	disbursedAt := time.Date(2026, time.January, 15, 9, 30, 0, 0, time.UTC)
+
+	tests := []struct {
+		name          string
+		loan          models.Loan
+		wantDueDates  []time.Time
+		wantPrincipal []float64
+		wantInterest  []float64
+	}{
+		{
+			name: "monthly over months",
+			loan: models.Loan{PrincipalAmount: 1000, InterestRate: 12, Tenor: 3, TenorUnit: enums.TenorUnitMonth, RepaymentFrequency: enums.RepaymentFrequencyMonthly},
+			wantDueDates: []time.Time{
+				time.Date(2026, time.February, 15, 0, 0, 0, 0, time.UTC),
+				time.Date(2026, time.March, 15, 0, 0, 0, 0, time.UTC),
+				time.Date(2026, time.April, 15, 0, 0, 0, 0, time.UTC),
+			},
+			wantPrincipal: []float64{333.33, 333.33, 333.34},
+			wantInterest:  []float64{10, 10, 10},
+		},
+		{
+			name: "weekly over days ends at maturity",
+			loan: models.Loan{PrincipalAmount: 700, InterestRate: 36.5, Tenor: 20, TenorUnit: enums.TenorUnitDay, RepaymentFrequency: enums.RepaymentFrequencyWeekly},
+			wantDueDates: []time.Time{
+				time.Date(2026, time.January, 22, 0, 0, 0, 0, time.UTC),
+				time.Date(2026, time.February, 4, 0, 0, 0, 0, time.UTC),
+			},
+			wantPrincipal: []float64{350, 350},
+			wantInterest:  []float64{7, 7},
+		},
+		{
+			name: "bullet",
+			loan: models.Loan{PrincipalAmount: 1000, InterestRate: 10, Tenor: 6, TenorUnit: enums.TenorUnitMonth, RepaymentFrequency: enums.RepaymentFrequencyBullet},
+			wantDueDates: []time.Time{
+				time.Date(2026, time.July, 15, 0, 0, 0, 0, time.UTC),
+			},
+			wantPrincipal: []float64{1000},
+			wantInterest:  []float64{50},
+		},
+		{
+			name: "legacy loan without tenor",
+			loan: models.Loan{PrincipalAmount: 1000, InterestRate: 10},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			instalments := buildInstalmentSchedule(&tt.loan, disbursedAt)
+			if len(instalments) != len(tt.wantDueDates) {
+				t.Fatalf("buildInstalmentSchedule() returned %d instalments, want %d", len(instalments), len(tt.wantDueDates))
+			}
+			for i, instalment := range instalments {
+				if instalment.Sequence != i+1 {
+					t.Errorf("instalment %d sequence = %d", i, instalment.Sequence)
+				}
+				if !instalment.DueDate.Equal(tt.wantDueDates[i]) {
+					t.Errorf("instalment %d due date = %v, want %v", i, instalment.DueDate, tt.wantDueDates[i])
+				}
+				if instalment.PrincipalDue != tt.wantPrincipal[i] || instalment.InterestDue != tt.wantInterest[i] {
+					t.Errorf("instalment %d = %.2f + %.2f, want %.2f + %.2f", i, instalment.PrincipalDue, instalment.InterestDue, tt.wantPrincipal[i], tt.wantInterest[i])
+				}
+			}
+		})
+	}
+}
+
+func TestLoanService_DeclareDefault(t *testing.T) {
+	req := dto.DeclareDefaultRequest{
+		LoanUUID:   "loan-uuid-123",
+		EmployeeID: "emp123",
+		Reason:     "borrower unreachable for 120 days",
+		Evidence: []dto.LoanDefaultEvidence{
+			{EvidenceURL: "https://example.com/visit-report.pdf", Category: "field_visit"},
+			{EvidenceURL: "https://example.com/call-log.pdf", Category: "collection_calls"},
+		},
+		DeclaredAt: time.Now(),
+	}
+
+	tests := []struct {
+		name    string
+		status  enums.LoanStatus
+		setup   func(tx *mocks.LoanRepositoryInterface)
+		wantErr error
+	}{
+		{
+			name:   "success",
+			status: enums.LoanStatusDelinquent,
+			setup: func(tx *mocks.LoanRepositoryInterface) {
+				tx.On("CreateLoanDefault", context.Background(), mock.MatchedBy(func(loanDefault *models.LoanDefault) bool {
+					return loanDefault.LoanID == 1 && loanDefault.Reason == req.Reason
+				})).Run(func(args mock.Arguments) {
+					args.Get(1).(*models.LoanDefault).ID = 7
+				}).Return(nil)
+				tx.On("CreateLoanDefaultEvidence", context.Background(), mock.MatchedBy(func(evidence *models.LoanDefaultEvidence) bool {
+					return evidence.LoanDefaultID == 7
+				})).Return(nil).Twice()
+				tx.On("UpdateLoan", context.Background(), mock.MatchedBy(func(loan *models.Loan) bool {
+					return loan.Status == enums.LoanStatusDefaulted
+				}), []string{"status"}).Return(nil)
+				tx.On("CreateLoanStatusTransition", context.Background(), mock.MatchedBy(func(transition *models.LoanStatusTransition) bool {
+					return transition.ToStatus == enums.LoanStatusDefaulted && transition.Actor == "emp123" && transition.Reason == req.Reason
+				})).Return(nil)
+			},
+		},
+		{
+			name:    "error - loan not disbursed",
+			status:  enums.LoanStatusInvested,
+			setup:   func(tx *mocks.LoanRepositoryInterface) {},
+			wantErr: ErrInvalidLoanStatus,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m := mocks.NewLoanRepositoryInterface(t)
+			tx := mocks.NewLoanRepositoryInterface(t)
+			m.On("WithinTx", context.Background(), mock.Anything).Return(withinTx(tx))
+			tx.On("GetLoanByUUID", context.Background(), "loan-uuid-123").Return(&models.Loan{
+				ID:     1,
+				UUID:   "loan-uuid-123",
+				Status: tt.status,
+			}, nil)
+			tt.setup(tx)
+
+			s := &LoanService{repo: m}
+			err := s.DeclareDefault(context.Background(), req)
+			if !errors.Is(err, tt.wantErr) {
+				t.Errorf("LoanService.DeclareDefault() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestLoanService_WriteOffLoan(t *testing.T) {
+	m := mocks.NewLoanRepositoryInterface(t)
+	tx := mocks.NewLoanRepositoryInterface(t)
+	m.On("WithinTx", context.Background(), mock.Anything).Return(withinTx(tx))
+	tx.On("GetLoanByUUID", context.Background(), "loan-uuid-123").Return(&models.Loan{
+		ID:              1,
+		UUID:            "loan-uuid-123",
+		PrincipalAmount: 900,
+		Status:          enums.LoanStatusDefaulted,
+	}, nil)
+	tx.On("GetLoanInstalmentsByLoanID", context.Background(), 1).Return([]models.LoanInstalment{
+		{Sequence: 1, PrincipalDue: 300, InterestDue: 9, PaidAmount: 309},
+		{Sequence: 2, PrincipalDue: 300, InterestDue: 9, PaidAmount: 109},
+		{Sequence: 3, PrincipalDue: 300, InterestDue: 9},
+	}, nil)
+	tx.On("GetInvestmentsByLoanID", context.Background(), 1).Return([]models.Investment{
+		{ID: 1, InvestorID: "investor1", Amount: 600, Status: enums.InvestmentStatusFunded},
+		{ID: 2, InvestorID: "investor2", Amount: 300, Status: enums.InvestmentStatusFunded},
+		{ID: 3, InvestorID: "investor3", Amount: 100, Status: enums.InvestmentStatusRefunded},
+	}, nil)
+	tx.On("UpdateInvestment", context.Background(), mock.MatchedBy(func(investment *models.Investment) bool {
+		return investment.ID == 1 && investment.RealizedLoss == 333.33
+	}), []string{"realized_loss"}).Return(nil)
+	tx.On("UpdateInvestment", context.Background(), mock.MatchedBy(func(investment *models.Investment) bool {
+		return investment.ID == 2 && investment.RealizedLoss == 166.67
+	}), []string{"realized_loss"}).Return(nil)
+	tx.On("CreateLoanLedgerEntry", context.Background(), mock.MatchedBy(func(entry *models.LoanLedgerEntry) bool {
+		return entry.EntryType == enums.LedgerEntryTypeWriteOff && entry.Amount == 500 && entry.Description == "remaining balance written off"
+	})).Return(nil)
+	tx.On("UpdateLoan", context.Background(), mock.MatchedBy(func(loan *models.Loan) bool {
+		return loan.Status == enums.LoanStatusWrittenOff
+	}), []string{"status"}).Return(nil)
+	tx.On("CreateLoanStatusTransition", context.Background(), mock.MatchedBy(func(transition *models.LoanStatusTransition) bool {
+		return transition.ToStatus == enums.LoanStatusWrittenOff && transition.Actor == "emp123"
+	})).Return(nil)
+
+	notificationClient := mocks.NewNotificationClientInterface(t)
+	notificationClient.On("SendEmail", context.Background(), mock.MatchedBy(func(req client.SendEmailRequest) bool {
+		return req.To == "investor1" && req.Subject == "Loan Written Off"
+	})).Return(nil)
+	notificationClient.On("SendEmail", context.Background(), mock.MatchedBy(func(req client.SendEmailRequest) bool {
+		return req.To == "investor2"
+	})).Return(errors.New("notification service down"))
+
+	s := &LoanService{repo: m, notificationClient: notificationClient}
+	err := s.WriteOffLoan(context.Background(), dto.WriteOffLoanRequest{
+		LoanUUID:     "loan-uuid-123",
+		EmployeeID:   "emp123",
+		WrittenOffAt: time.Now(),
+	})
+	if err != nil {
+		t.Fatalf("LoanService.WriteOffLoan() error = %v", err)
+	}
+}
+
+func TestLoanService_WriteOffLoan_RequiresDefault(t *testing.T) {
+	m := mocks.NewLoanRepositoryInterface(t)
+	tx := mocks.NewLoanRepositoryInterface(t)
+	m.On("WithinTx", context.Background(), mock.Anything).Return(withinTx(tx))
+	tx.On("GetLoanByUUID", context.Background(), "loan-uuid-123").Return(&models.Loan{
+		ID:     1,
+		UUID:   "loan-uuid-123",
+		Status: enums.LoanStatusDelinquent,
+	}, nil)
+
+	s := &LoanService{repo: m}
+	err := s.WriteOffLoan(context.Background(), dto.WriteOffLoanRequest{
+		LoanUUID:     "loan-uuid-123",
+		EmployeeID:   "emp123",
+		WrittenOffAt: time.Now(),
+	})
+	if !errors.Is(err, ErrInvalidLoanStatus) {
+		t.Errorf("LoanService.WriteOffLoan() error = %v, want %v", err, ErrInvalidLoanStatus)
+	}
+}
+
+func TestBuildPayoffQuote(t *testing.T) {
+	disbursedAt := time.Date(2026, time.January, 15, 9, 30, 0, 0, time.UTC)
+	loan := &models.Loan{ID: 1, PrincipalAmount: 900, InterestRate: 12}
+	instalments := []models.LoanInstalment{
+		{ID: 1, Sequence: 1, DueDate: time.Date(2026, time.February, 15, 0, 0, 0, 0, time.UTC), PrincipalDue: 300, InterestDue: 9, PaidAmount: 309},
+		{ID: 2, Sequence: 2, DueDate: time.Date(2026, time.March, 15, 0, 0, 0, 0, time.UTC), PrincipalDue: 300, InterestDue: 9},
+		{ID: 3, Sequence: 3, DueDate: time.Date(2026, time.April, 15, 0, 0, 0, 0, time.UTC), PrincipalDue: 300, InterestDue: 9},
+	}
+	entries := []models.LoanLedgerEntry{
+		{EntryType: enums.LedgerEntryTypeLateFee, Amount: 5},
+	}
+	asOf := time.Date(2026, time.March, 1, 0, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name        string
+		cfg         config.LoanConfig
+		wantPenalty float64
+		wantTotal   float64
+	}{
+		{
+			name:        "penalty for the whole tenor",
+			cfg:         config.LoanConfig{PrepaymentPenaltyRate: 2},
+			wantPenalty: 12,
+			wantTotal:   621.5,
+		},
+		{
+			name:        "penalty period has passed",
+			cfg:         config.LoanConfig{PrepaymentPenaltyRate: 2, PrepaymentPenaltyPeriod: 30 * 24 * time.Hour},
+			wantPenalty: 0,
+			wantTotal:   609.5,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			quote := buildPayoffQuote(loan, instalments, entries, disbursedAt, asOf, tt.cfg)
+			if quote.outstandingPrincipal != 600 {
+				t.Errorf("outstanding principal = %.2f, want 600", quote.outstandingPrincipal)
+			}
+			if quote.accruedInterest != 4.5 {
+				t.Errorf("accrued interest = %.2f, want 4.5", quote.accruedInterest)
+			}
+			if quote.lateFees != 5 {
+				t.Errorf("late fees = %.2f, want 5", quote.lateFees)
+			}
+			if quote.prepaymentPenalty != tt.wantPenalty {
+				t.Errorf("prepayment penalty = %.2f, want %.2f", quote.prepaymentPenalty, tt.wantPenalty)
+			}
+			if quote.total() != tt.wantTotal {
+				t.Errorf("total = %.2f, want %.2f", quote.total(), tt.wantTotal)
+			}
+		})
+	}
+}
+
+func TestLoanService_PrepayLoan(t *testing.T) {
+	disbursedAt := time.Date(2026, time.January, 15, 9, 30, 0, 0, time.UTC)
+	paidAt := time.Date(2026, time.March, 20, 10, 0, 0, 0, time.UTC)
+	instalments := func() []models.LoanInstalment {
+		return []models.LoanInstalment{
+			{ID: 1, Sequence: 1, DueDate: time.Date(2026, time.February, 15, 0, 0, 0, 0, time.UTC), PrincipalDue: 300, InterestDue: 9, PaidAmount: 309},
+			{ID: 2, Sequence: 2, DueDate: time.Date(2026, time.March, 15, 0, 0, 0, 0, time.UTC), PrincipalDue: 300, InterestDue: 9},
+			{ID: 3, Sequence: 3, DueDate: time.Date(2026, time.April, 15, 0, 0, 0, 0, time.UTC), PrincipalDue: 300, InterestDue: 9},
+		}
+	}
+
+	tests := []struct {
+		name    string
+		amount  float64
+		setup   func(tx *mocks.LoanRepositoryInterface, notificationClient *mocks.NotificationClientInterface)
+		wantErr error
+	}{
+		{
+			// 600 principal + 9 overdue interest + 9 * 5/31 accrued on the
+			// current period.
+			name:   "success",
+			amount: 610.45,
+			setup: func(tx *mocks.LoanRepositoryInterface, notificationClient *mocks.NotificationClientInterface) {
+				tx.On("UpdateLoanInstalment", context.Background(), mock.MatchedBy(func(instalment *models.LoanInstalment) bool {
+					return instalment.ID == 2 && instalment.PaidAmount == 309 && instalment.PaidAt.Valid
+				}), []string{"paid_amount", "paid_at"}).Return(nil)
+				tx.On("UpdateLoanInstalment", context.Background(), mock.MatchedBy(func(instalment *models.LoanInstalment) bool {
+					return instalment.ID == 3 && instalment.CancelledAt.Valid
+				}), []string{"cancelled_at"}).Return(nil)
+				tx.On("CreateLoanLedgerEntry", context.Background(), mock.MatchedBy(func(entry *models.LoanLedgerEntry) bool {
+					return entry.EntryType == enums.LedgerEntryTypePrepayment && entry.Amount == 610.45
+				})).Return(nil)
+				tx.On("GetInvestmentsByLoanID", context.Background(), 1).Return([]models.Investment{
+					{ID: 1, InvestorID: "investor1", Amount: 600, Status: enums.InvestmentStatusFunded},
+					{ID: 2, InvestorID: "investor2", Amount: 300, Status: enums.InvestmentStatusFunded},
+				}, nil)
+				tx.On("CreateInvestorPayout", context.Background(), mock.MatchedBy(func(payout *models.InvestorPayout) bool {
+					return payout.InvestmentID == 1 && payout.PrincipalAmount == 400 && payout.InterestAmount == 5.23
+				})).Return(nil)
+				tx.On("CreateInvestorPayout", context.Background(), mock.MatchedBy(func(payout *models.InvestorPayout) bool {
+					return payout.InvestmentID == 2 && payout.PrincipalAmount == 200 && payout.InterestAmount == 2.61
+				})).Return(nil)
+				tx.On("UpdateLoan", context.Background(), mock.MatchedBy(func(loan *models.Loan) bool {
+					return loan.Status == enums.LoanStatusRepaid && loan.DaysPastDue == 0
+				}), []string{"status", "days_past_due", "dpd_bucket"}).Return(nil)
+				tx.On("CreateLoanStatusTransition", context.Background(), mock.MatchedBy(func(transition *models.LoanStatusTransition) bool {
+					return transition.ToStatus == enums.LoanStatusRepaid && transition.Actor == "borrower1"
+				})).Return(nil)
+				notificationClient.On("SendEmail", context.Background(), mock.MatchedBy(func(req client.SendEmailRequest) bool {
+					return req.Subject == "Loan Repaid Early"
+				})).Return(nil).Twice()
+			},
+		},
+		{
+			name:    "error - stale quote",
+			amount:  609,
+			setup:   func(tx *mocks.LoanRepositoryInterface, notificationClient *mocks.NotificationClientInterface) {},
+			wantErr: ErrPayoffAmountMismatch,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m := mocks.NewLoanRepositoryInterface(t)
+			tx := mocks.NewLoanRepositoryInterface(t)
+			notificationClient := mocks.NewNotificationClientInterface(t)
+			m.On("WithinTx", context.Background(), mock.Anything).Return(withinTx(tx))
+			tx.On("GetLoanByUUID", context.Background(), "loan-uuid-123").Return(&models.Loan{
+				ID:              1,
+				UUID:            "loan-uuid-123",
+				BorrowerID:      "borrower1",
+				PrincipalAmount: 900,
+				InterestRate:    12,
+				ROIRate:         9,
+				Status:          enums.LoanStatusDelinquent,
+				DaysPastDue:     5,
+			}, nil)
+			tx.On("GetLoanDisbursementsByLoanID", context.Background(), 1).Return([]models.LoanDisbursement{
+				{LoanID: 1, PayoutStatus: enums.PayoutStatusSucceeded, DisbursedAt: disbursedAt},
+			}, nil)
+			tx.On("GetLoanInstalmentsByLoanID", context.Background(), 1).Return(instalments(), nil)
+			tx.On("GetLoanLedgerEntriesByLoanID", context.Background(), 1).Return([]models.LoanLedgerEntry{}, nil)
+			tt.setup(tx, notificationClient)
+
+			s := &LoanService{repo: m, notificationClient: notificationClient}
+			err := s.PrepayLoan(context.Background(), dto.PrepayLoanRequest{
+				LoanUUID: "loan-uuid-123",
+				Amount:   tt.amount,
+				PaidAt:   paidAt,
+			})
+			if !errors.Is(err, tt.wantErr) {
+				t.Errorf("LoanService.PrepayLoan() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestLoanService_GetPayoffQuote_BeforeDisbursement(t *testing.T) {
+	m := mocks.NewLoanRepositoryInterface(t)
+	m.On("GetLoanByUUID", context.Background(), "loan-uuid-123").Return(&models.Loan{
+		ID:     1,
+		UUID:   "loan-uuid-123",
+		Status: enums.LoanStatusDisbursed,
+	}, nil)
+	m.On("GetLoanDisbursementsByLoanID", context.Background(), 1).Return([]models.LoanDisbursement{
+		{LoanID: 1, PayoutStatus: enums.PayoutStatusSucceeded, DisbursedAt: time.Date(2026, time.January, 15, 9, 30, 0, 0, time.UTC)},
+	}, nil)
+
+	s := &LoanService{repo: m}
+	_, err := s.GetPayoffQuote(context.Background(), "loan-uuid-123", time.Date(2026, time.January, 1, 0, 0, 0, 0, time.UTC))
+	if !errors.Is(err, ErrInvalidPayoffDate) {
+		t.Errorf("LoanService.GetPayoffQuote() error = %v, want %v", err, ErrInvalidPayoffDate)
+	}
+}
+
+func TestBuildInterestAccruals(t *testing.T) {
+	loan := &models.Loan{PrincipalAmount: 1000, InterestRate: 36.5}
+	jan := func(day int) time.Time { return time.Date(2026, time.January, day, 0, 0, 0, 0, time.UTC) }
+
+	tests := []struct {
+		name          string
+		convention    enums.DayCountConvention
+		disbursements []models.LoanDisbursement
+		start         time.Time
+		end           time.Time
+		from          time.Time
+		to            time.Time
+		wantDates     []time.Time
+		wantPosted    []float64
+	}{
+		{
+			name:       "ACT/365",
+			convention: enums.DayCountConventionAct365,
+			start:      jan(1), from: jan(1), to: jan(3),
+			wantDates:  []time.Time{jan(1), jan(2), jan(3)},
+			wantPosted: []float64{1, 1, 1},
+		},
+		{
+			name:       "ACT/360 posts the change in the rounded running total",
+			convention: enums.DayCountConventionAct360,
+			start:      jan(1), from: jan(1), to: jan(3),
+			wantDates:  []time.Time{jan(1), jan(2), jan(3)},
+			wantPosted: []float64{1.01, 1.02, 1.01},
+		},
+		{
+			name:       "range after start matches a full run",
+			convention: enums.DayCountConventionAct360,
+			start:      jan(1), from: jan(3), to: jan(3),
+			wantDates:  []time.Time{jan(3)},
+			wantPosted: []float64{1.01},
+		},
+		{
+			name:       "30/360 treats the 31st as the 30th",
+			convention: enums.DayCountConvention30360,
+			start:      jan(30), from: jan(30), to: time.Date(2026, time.February, 1, 0, 0, 0, 0, time.UTC),
+			wantDates:  []time.Time{jan(30), jan(31), time.Date(2026, time.February, 1, 0, 0, 0, 0, time.UTC)},
+			wantPosted: []float64{0, 1.01, 1.02},
+		},
+		{
+			name:       "tranches accrue from their disbursement date",
+			convention: enums.DayCountConventionAct365,
+			disbursements: []models.LoanDisbursement{
+				{Amount: 500, DisbursedAt: jan(1).Add(9 * time.Hour)},
+				{Amount: 500, DisbursedAt: jan(3).Add(15 * time.Hour)},
+			},
+			start: jan(1), from: jan(1), to: jan(4),
+			wantDates:  []time.Time{jan(1), jan(2), jan(3), jan(4)},
+			wantPosted: []float64{0.5, 0.5, 1, 1},
+		},
+		{
+			name:       "stops at the end of the accrual period",
+			convention: enums.DayCountConventionAct365,
+			start:      jan(1), end: jan(3), from: jan(2), to: jan(10),
+			wantDates:  []time.Time{jan(2)},
+			wantPosted: []float64{1},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			disbursements := tt.disbursements
+			if disbursements == nil {
+				disbursements = []models.LoanDisbursement{{Amount: 1000, DisbursedAt: tt.start}}
+			}
+			accruals := buildInterestAccruals(loan, disbursements, tt.convention, tt.start, tt.end, tt.from, tt.to)
+			if len(accruals) != len(tt.wantDates) {
+				t.Fatalf("buildInterestAccruals() returned %d accruals, want %d", len(accruals), len(tt.wantDates))
+			}
+			for i, accrual := range accruals {
+				if !accrual.date.Equal(tt.wantDates[i]) {
+					t.Errorf("accrual %d date = %v, want %v", i, accrual.date, tt.wantDates[i])
+				}
+				if accrual.posted != tt.wantPosted[i] {
+					t.Errorf("accrual %d posted = %.2f, want %.2f", i, accrual.posted, tt.wantPosted[i])
+				}
+			}
+		})
+	}
+}
+
+func TestDayCountFraction_ConventionsAddUpToAYear(t *testing.T) {
+	tests := []struct {
+		convention enums.DayCountConvention
+		want       float64
+	}{
+		{enums.DayCountConventionAct365, 1},
+		{enums.DayCountConventionAct360, 365.0 / 360},
+		{enums.DayCountConvention30360, 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.convention.String(), func(t *testing.T) {
+			total := 0.0
+			for day := time.Date(2026, time.January, 1, 0, 0, 0, 0, time.UTC); day.Year() == 2026; day = day.AddDate(0, 0, 1) {
+				total += dayCountFraction(tt.convention, day)
+			}
+			if math.Abs(total-tt.want) > 1e-9 {
+				t.Errorf("fractions over 2026 add up to %v, want %v", total, tt.want)
+			}
+		})
+	}
+}
+
+func TestAccrualPeriod(t *testing.T) {
+	disbursedAt := time.Date(2026, time.January, 15, 9, 30, 0, 0, time.UTC)
+	instalments := []models.LoanInstalment{
+		{Sequence: 1, DueDate: time.Date(2026, time.February, 15, 0, 0, 0, 0, time.UTC)},
+		{Sequence: 2, DueDate: time.Date(2026, time.March, 15, 0, 0, 0, 0, time.UTC)},
+	}
+
+	start, end := accrualPeriod(instalments, nil, disbursedAt)
+	if !start.Equal(time.Date(2026, time.January, 15, 0, 0, 0, 0, time.UTC)) || !end.Equal(instalments[1].DueDate) {
+		t.Errorf("accrualPeriod() = %v, %v, want disbursement day to maturity", start, end)
+	}
+
+	repaidAt := time.Date(2026, time.February, 20, 16, 0, 0, 0, time.UTC)
+	_, end = accrualPeriod(instalments, []models.LoanStatusTransition{
+		{ToStatus: enums.LoanStatusDisbursed, At: disbursedAt},
+		{ToStatus: enums.LoanStatusRepaid, At: repaidAt},
+	}, disbursedAt)
+	if !end.Equal(dateOf(repaidAt)) {
+		t.Errorf("accrualPeriod() end = %v, want %v", end, dateOf(repaidAt))
+	}
+
+	_, end = accrualPeriod(nil, nil, disbursedAt)
+	if !end.IsZero() {
+		t.Errorf("accrualPeriod() end = %v for a loan without schedule, want zero", end)
+	}
+}
+
+func TestLoanService_AccrueInterest(t *testing.T) {
+	day := func(d int) time.Time { return time.Date(2026, time.January, d, 0, 0, 0, 0, time.UTC) }
+
+	m := mocks.NewLoanRepositoryInterface(t)
+	tx := mocks.NewLoanRepositoryInterface(t)
+	m.On("GetLoansByStatuses", context.Background(), []enums.LoanStatus{
+		enums.LoanStatusPartiallyDisbursed,
+		enums.LoanStatusDisbursed,
+		enums.LoanStatusDelinquent,
+		enums.LoanStatusDefaulted,
+		enums.LoanStatusWrittenOff,
+		enums.LoanStatusRepaid,
+	}).Return([]models.Loan{{ID: 1, UUID: "loan-1"}}, nil)
+	m.On("WithinTx", context.Background(), mock.Anything).Return(withinTx(tx))
+
+	tx.On("GetLoanByUUID", context.Background(), "loan-1").Return(&models.Loan{
+		ID:              1,
+		UUID:            "loan-1",
+		Status:          enums.LoanStatusDisbursed,
+		PrincipalAmount: 1000,
+		InterestRate:    36.5,
+	}, nil)
+	tx.On("GetLoanDisbursementsByLoanID", context.Background(), 1).Return([]models.LoanDisbursement{{LoanID: 1, Amount: 1000, PayoutStatus: enums.PayoutStatusSucceeded, DisbursedAt: day(1).Add(10 * time.Hour)}}, nil)
+	tx.On("GetLoanInstalmentsByLoanID", context.Background(), 1).Return([]models.LoanInstalment{
+		{ID: 11, LoanID: 1, Sequence: 1, DueDate: time.Date(2026, time.February, 1, 0, 0, 0, 0, time.UTC)},
+	}, nil)
+	tx.On("GetLoanStatusTransitionsByLoanID", context.Background(), 1).Return([]models.LoanStatusTransition{}, nil)
+	tx.On("GetLoanInterestAccruals", context.Background(), 1, day(2), day(4)).Return([]models.LoanInterestAccrual{
+		{ID: 21, LoanID: 1, LoanLedgerEntryID: 31, AccrualDate: day(2), DayCountConvention: enums.DayCountConventionAct365, PrincipalAmount: 1000, InterestRate: 36.5, Amount: 1},
+		{ID: 22, LoanID: 1, LoanLedgerEntryID: 32, AccrualDate: day(3), DayCountConvention: enums.DayCountConventionAct360, PrincipalAmount: 1000, InterestRate: 36.5, Amount: 1.013889},
+	}, nil)
+
+	tx.On("UpdateLoanLedgerEntry", context.Background(), mock.MatchedBy(func(entry *models.LoanLedgerEntry) bool {
+		return entry.ID == 32 && entry.Amount == 1 && entry.Description == "interest accrual (ACT/365)"
+	}), []string{"amount", "description"}).Return(nil).Once()
+	tx.On("UpdateLoanInterestAccrual", context.Background(), mock.MatchedBy(func(accrual *models.LoanInterestAccrual) bool {
+		return accrual.ID == 22 && accrual.Amount == 1 && accrual.DayCountConvention == enums.DayCountConventionAct365
+	}), []string{"day_count_convention", "principal_amount", "interest_rate", "amount"}).Return(nil).Once()
+
+	tx.On("CreateLoanLedgerEntry", context.Background(), mock.MatchedBy(func(entry *models.LoanLedgerEntry) bool {
+		return entry.LoanID == 1 && entry.EntryType == enums.LedgerEntryTypeInterestAccrual && entry.Amount == 1 && entry.EffectiveDate.Equal(day(4))
+	})).Run(func(args mock.Arguments) {
+		args.Get(1).(*models.LoanLedgerEntry).ID = 33
+	}).Return(nil).Once()
+	tx.On("CreateLoanInterestAccrual", context.Background(), mock.MatchedBy(func(accrual *models.LoanInterestAccrual) bool {
+		return accrual.LoanID == 1 && accrual.LoanLedgerEntryID == 33 && accrual.AccrualDate.Equal(day(4)) &&
+			accrual.DayCountConvention == enums.DayCountConventionAct365 && accrual.Amount == 1
+	})).Return(nil).Once()
+
+	s := NewLoanService(m, nil, nil, config.LoanConfig{DayCountConvention: enums.DayCountConventionAct365})
+	posted, err := s.AccrueInterest(context.Background(), day(2).Add(8*time.Hour), day(4))
+	if err != nil {
+		t.Fatalf("LoanService.AccrueInterest() error = %v", err)
+	}
+	if posted != 2 {
+		t.Errorf("LoanService.AccrueInterest() posted = %d, want 2", posted)
+	}
+}
+
+func TestLoanService_AccrueInterest_InvalidRange(t *testing.T) {
+	s := NewLoanService(mocks.NewLoanRepositoryInterface(t), nil, nil, config.LoanConfig{})
+	_, err := s.AccrueInterest(context.Background(), time.Date(2026, time.January, 5, 0, 0, 0, 0, time.UTC), time.Date(2026, time.January, 4, 0, 0, 0, 0, time.UTC))
+	if !errors.Is(err, ErrInvalidAccrualRange) {
+		t.Errorf("LoanService.AccrueInterest() error = %v, want %v", err, ErrInvalidAccrualRange)
+	}
+}
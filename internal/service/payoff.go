@@ -0,0 +1,72 @@
+package service
+
+import (
+	"loan-service/enums"
+	"loan-service/internal/config"
+	"loan-service/internal/models"
+	"math"
+	"time"
+)
+
+// payoffQuote is what a borrower owes to settle a loan in full on asOf.
+type payoffQuote struct {
+	asOf                 time.Time
+	outstandingPrincipal float64
+	accruedInterest      float64
+	lateFees             float64
+	prepaymentPenalty    float64
+}
+
+func (q payoffQuote) total() float64 {
+	return roundAmount(q.outstandingPrincipal + q.accruedInterest + q.lateFees + q.prepaymentPenalty)
+}
+
+// buildPayoffQuote prices an early payoff on asOf. Interest is owed in full
+// on instalments already due and pro rata for the days elapsed in the
+// instalment period that asOf falls in; nothing is owed for later periods.
+func buildPayoffQuote(loan *models.Loan, instalments []models.LoanInstalment, entries []models.LoanLedgerEntry, disbursedAt, asOf time.Time, cfg config.LoanConfig) payoffQuote {
+	quote := payoffQuote{
+		asOf:                 asOf,
+		outstandingPrincipal: outstandingPrincipal(loan, instalments),
+		accruedInterest:      accruedInterest(instalments, dateOf(disbursedAt), asOf),
+	}
+
+	for _, entry := range entries {
+		if entry.EntryType == enums.LedgerEntryTypeLateFee {
+			quote.lateFees += entry.Amount
+		}
+	}
+	quote.lateFees = roundAmount(quote.lateFees)
+
+	if cfg.PrepaymentPenaltyPeriod == 0 || asOf.Before(disbursedAt.Add(cfg.PrepaymentPenaltyPeriod)) {
+		quote.prepaymentPenalty = roundAmount(quote.outstandingPrincipal * cfg.PrepaymentPenaltyRate / 100)
+	}
+
+	return quote
+}
+
+// accruedInterest is the unpaid interest earned up to asOf. Payments on an
+// instalment cover its interest first.
+func accruedInterest(instalments []models.LoanInstalment, periodStart, asOf time.Time) float64 {
+	accrued := 0.0
+	for _, instalment := range instalments {
+		if instalment.CancelledAt.Valid {
+			break
+		}
+
+		interestPaid := math.Min(instalment.PaidAmount, instalment.InterestDue)
+		if !instalment.DueDate.After(asOf) {
+			accrued += instalment.InterestDue - interestPaid
+			periodStart = instalment.DueDate
+			continue
+		}
+
+		periodDays := daysBetween(periodStart, instalment.DueDate)
+		elapsedDays := daysBetween(periodStart, asOf)
+		if periodDays > 0 && elapsedDays > 0 {
+			accrued += math.Max(instalment.InterestDue*float64(elapsedDays)/float64(periodDays)-interestPaid, 0)
+		}
+		break
+	}
+	return roundAmount(accrued)
+}
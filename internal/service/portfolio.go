@@ -0,0 +1,76 @@
+package service
+
+import (
+	"database/sql"
+	"loan-service/enums"
+	"loan-service/internal/dto"
+	"loan-service/internal/models"
+	"loan-service/internal/repository"
+	"time"
+)
+
+// buildPortfolioHolding values a funded investment. The expected return is
+// the loan's ROI rate on the investment over its tenor, charged flat like
+// the borrower's interest. The outstanding principal is the investment's
+// share of what the borrower still owes; nothing is outstanding on a loan
+// that was repaid or written off.
+func buildPortfolioHolding(investment models.Investment, loan *models.Loan, instalments []models.LoanInstalment, payouts []models.InvestorPayout) dto.PortfolioHolding {
+	holding := dto.PortfolioHolding{
+		InvestmentUUID: investment.UUID,
+		LoanUUID:       loan.UUID,
+		LoanStatus:     loan.Status,
+		InvestedAmount: investment.Amount,
+		ROIRate:        loan.ROIRate,
+		ExpectedReturn: roundAmount(investment.Amount * loan.ROIRate / 100 * tenorInYears(loan)),
+		RealizedLoss:   investment.RealizedLoss,
+		FundedAt:       timeOrNil(investment.FundedAt),
+	}
+
+	for _, payout := range payouts {
+		holding.ReceivedPrincipal += payout.PrincipalAmount
+		holding.ReceivedInterest += payout.InterestAmount
+		holding.ReceivedPayouts += payout.Amount()
+	}
+	holding.ReceivedPrincipal = roundAmount(holding.ReceivedPrincipal)
+	holding.ReceivedInterest = roundAmount(holding.ReceivedInterest)
+	holding.ReceivedPayouts = roundAmount(holding.ReceivedPayouts)
+
+	if loan.Status != enums.LoanStatusRepaid && loan.Status != enums.LoanStatusWrittenOff && loan.PrincipalAmount > 0 {
+		holding.OutstandingPrincipal = roundAmount(outstandingPrincipal(loan, instalments) * investment.Amount / loan.PrincipalAmount)
+	}
+
+	return holding
+}
+
+func toInvestorInvestmentItem(investment models.Investment, loan *models.Loan) dto.InvestorInvestmentItem {
+	return dto.InvestorInvestmentItem{
+		InvestmentUUID:   investment.UUID,
+		LoanUUID:         loan.UUID,
+		Amount:           investment.Amount,
+		Status:           investment.Status,
+		FundingExpiresAt: timeOrNil(investment.FundingExpiresAt),
+		FundedAt:         timeOrNil(investment.FundedAt),
+		RefundedAt:       timeOrNil(investment.RefundedAt),
+		CancelledAt:      timeOrNil(investment.CancelledAt),
+		CreatedAt:        investment.CreatedAt,
+	}
+}
+
+func toRepositoryPage(page dto.PageRequest) repository.Page {
+	return repository.Page{Limit: page.PageSize, Offset: (page.Page - 1) * page.PageSize}
+}
+
+func toPageInfo(page dto.PageRequest, total int64) dto.PageInfo {
+	info := dto.PageInfo{Page: page.Page, PageSize: page.PageSize, TotalItems: total}
+	if page.PageSize > 0 {
+		info.TotalPages = int((total + int64(page.PageSize) - 1) / int64(page.PageSize))
+	}
+	return info
+}
+
+func timeOrNil(t sql.NullTime) *time.Time {
+	if !t.Valid {
+		return nil
+	}
+	return &t.Time
+}
@@ -0,0 +1,148 @@
+package service
+
+import (
+	"loan-service/enums"
+	"loan-service/internal/models"
+	"math"
+	"time"
+)
+
+// buildInstalmentSchedule lays out the repayments of a loan disbursed at
+// disbursedAt. InterestRate is an annual percentage charged flat on the
+// principal for the whole tenor; principal and interest are split evenly
+// across the instalments, with the last one absorbing rounding differences.
+// Loans created before tenor and repayment frequency were captured get no
+// schedule.
+func buildInstalmentSchedule(loan *models.Loan, disbursedAt time.Time) []models.LoanInstalment {
+	dueDates := instalmentDueDates(loan, dateOf(disbursedAt))
+	if len(dueDates) == 0 {
+		return nil
+	}
+
+	totalInterest := roundAmount(loan.PrincipalAmount * loan.InterestRate / 100 * tenorInYears(loan))
+	count := float64(len(dueDates))
+	principalPerInstalment := roundAmount(loan.PrincipalAmount / count)
+	interestPerInstalment := roundAmount(totalInterest / count)
+
+	instalments := make([]models.LoanInstalment, 0, len(dueDates))
+	for i, dueDate := range dueDates {
+		instalment := models.LoanInstalment{
+			LoanID:       loan.ID,
+			Sequence:     i + 1,
+			DueDate:      dueDate,
+			PrincipalDue: principalPerInstalment,
+			InterestDue:  interestPerInstalment,
+		}
+		if i == len(dueDates)-1 {
+			instalment.PrincipalDue = roundAmount(loan.PrincipalAmount - principalPerInstalment*(count-1))
+			instalment.InterestDue = roundAmount(totalInterest - interestPerInstalment*(count-1))
+		}
+		instalments = append(instalments, instalment)
+	}
+
+	return instalments
+}
+
+// instalmentDueDates returns the due date of every instalment, the last one
+// always falling on the loan's maturity date.
+func instalmentDueDates(loan *models.Loan, start time.Time) []time.Time {
+	if loan.Tenor <= 0 {
+		return nil
+	}
+
+	var maturity time.Time
+	switch loan.TenorUnit {
+	case enums.TenorUnitMonth:
+		maturity = start.AddDate(0, loan.Tenor, 0)
+	case enums.TenorUnitDay:
+		maturity = start.AddDate(0, 0, loan.Tenor)
+	default:
+		return nil
+	}
+
+	var dueDates []time.Time
+	switch {
+	case loan.RepaymentFrequency == enums.RepaymentFrequencyMonthly && loan.TenorUnit == enums.TenorUnitMonth:
+		for i := 1; i < loan.Tenor; i++ {
+			dueDates = append(dueDates, start.AddDate(0, i, 0))
+		}
+	case loan.RepaymentFrequency == enums.RepaymentFrequencyWeekly || loan.RepaymentFrequency == enums.RepaymentFrequencyMonthly:
+		periodDays := 7
+		if loan.RepaymentFrequency == enums.RepaymentFrequencyMonthly {
+			periodDays = 30
+		}
+		for i := 1; i < daysBetween(start, maturity)/periodDays; i++ {
+			dueDates = append(dueDates, start.AddDate(0, 0, i*periodDays))
+		}
+	case loan.RepaymentFrequency != enums.RepaymentFrequencyBullet:
+		return nil
+	}
+
+	return append(dueDates, maturity)
+}
+
+// outstandingPrincipal is the principal the borrower still owes. A payment on
+// an instalment covers its interest before its principal. Loans without a
+// schedule owe their full principal.
+func outstandingPrincipal(loan *models.Loan, instalments []models.LoanInstalment) float64 {
+	if len(instalments) == 0 {
+		return loan.PrincipalAmount
+	}
+
+	outstanding := 0.0
+	for _, instalment := range instalments {
+		if instalment.CancelledAt.Valid {
+			continue
+		}
+		principalPaid := math.Min(math.Max(instalment.PaidAmount-instalment.InterestDue, 0), instalment.PrincipalDue)
+		outstanding += instalment.PrincipalDue - principalPaid
+	}
+	return roundAmount(outstanding)
+}
+
+// allocateProRata splits amount across the investments in proportion to what
+// each one put in; the last share absorbs rounding differences.
+func allocateProRata(amount float64, investments []models.Investment) []float64 {
+	total := 0.0
+	for _, investment := range investments {
+		total += investment.Amount
+	}
+
+	shares := make([]float64, len(investments))
+	if total <= 0 {
+		return shares
+	}
+
+	allocated := 0.0
+	for i, investment := range investments {
+		if i == len(investments)-1 {
+			shares[i] = roundAmount(amount - allocated)
+			break
+		}
+		shares[i] = roundAmount(amount * investment.Amount / total)
+		allocated += shares[i]
+	}
+	return shares
+}
+
+func tenorInYears(loan *models.Loan) float64 {
+	if loan.TenorUnit == enums.TenorUnitMonth {
+		return float64(loan.Tenor) / 12
+	}
+	return float64(loan.Tenor) / 365
+}
+
+// dateOf truncates t to midnight UTC of its calendar day.
+func dateOf(t time.Time) time.Time {
+	year, month, day := t.UTC().Date()
+	return time.Date(year, month, day, 0, 0, 0, 0, time.UTC)
+}
+
+// daysBetween counts whole calendar days from one date to another.
+func daysBetween(from, to time.Time) int {
+	return int(dateOf(to).Sub(dateOf(from)).Hours() / 24)
+}
+
+func roundAmount(amount float64) float64 {
+	return math.Round(amount*100) / 100
+}
@@ -0,0 +1,246 @@
+package service
+
+import (
+	"context"
+	"loan-service/internal/dto"
+	"loan-service/internal/tracing"
+	"time"
+
+	"go.opentelemetry.io/otel/attribute"
+)
+
+// TracedLoanService wraps a LoanServiceInterface and records a span around
+// every call. Repository queries and outbound calls made by the wrapped
+// service become children of that span through the context.
+type TracedLoanService struct {
+	next LoanServiceInterface
+}
+
+// Ensure TracedLoanService implements LoanServiceInterface
+var _ LoanServiceInterface = (*TracedLoanService)(nil)
+
+func NewTracedLoanService(next LoanServiceInterface) *TracedLoanService {
+	return &TracedLoanService{next: next}
+}
+
+func (s *TracedLoanService) CreateLoan(ctx context.Context, req *dto.CreateLoanRequest) (err error) {
+	ctx, span := tracing.StartSpan(ctx, "LoanService.CreateLoan",
+		attribute.String("loan.borrower_id", req.BorrowerID),
+	)
+	defer func() { tracing.EndSpan(span, err) }()
+
+	return s.next.CreateLoan(ctx, req)
+}
+
+func (s *TracedLoanService) GetAllLoans(ctx context.Context, filter dto.GetLoansFilter) (response []dto.GetLoansResponseItem, err error) {
+	ctx, span := tracing.StartSpan(ctx, "LoanService.GetAllLoans")
+	defer func() { tracing.EndSpan(span, err) }()
+
+	return s.next.GetAllLoans(ctx, filter)
+}
+
+func (s *TracedLoanService) GetLoanByUUID(ctx context.Context, uuid string) (response dto.GetLoanDetailResponse, err error) {
+	ctx, span := tracing.StartSpan(ctx, "LoanService.GetLoanByUUID",
+		attribute.String("loan.uuid", uuid),
+	)
+	defer func() { tracing.EndSpan(span, err) }()
+
+	return s.next.GetLoanByUUID(ctx, uuid)
+}
+
+func (s *TracedLoanService) AmendLoan(ctx context.Context, req dto.AmendLoanRequest) (err error) {
+	ctx, span := tracing.StartSpan(ctx, "LoanService.AmendLoan",
+		attribute.String("loan.uuid", req.LoanUUID),
+		attribute.String("loan.actor", req.EmployeeID),
+	)
+	defer func() { tracing.EndSpan(span, err) }()
+
+	return s.next.AmendLoan(ctx, req)
+}
+
+func (s *TracedLoanService) ApproveLoanWithValidators(ctx context.Context, req dto.ApproveLoanRequest) (err error) {
+	ctx, span := tracing.StartSpan(ctx, "LoanService.ApproveLoanWithValidators",
+		attribute.String("loan.uuid", req.LoanUUID),
+		attribute.String("loan.actor", req.EmployeeID),
+	)
+	defer func() { tracing.EndSpan(span, err) }()
+
+	return s.next.ApproveLoanWithValidators(ctx, req)
+}
+
+func (s *TracedLoanService) RejectLoan(ctx context.Context, req dto.RejectLoanRequest) (err error) {
+	ctx, span := tracing.StartSpan(ctx, "LoanService.RejectLoan",
+		attribute.String("loan.uuid", req.LoanUUID),
+		attribute.String("loan.actor", req.EmployeeID),
+	)
+	defer func() { tracing.EndSpan(span, err) }()
+
+	return s.next.RejectLoan(ctx, req)
+}
+
+func (s *TracedLoanService) InvestLoan(ctx context.Context, req dto.InvestLoanRequest) (response dto.InvestLoanResponse, err error) {
+	ctx, span := tracing.StartSpan(ctx, "LoanService.InvestLoan",
+		attribute.String("loan.uuid", req.LoanUUID),
+		attribute.String("loan.actor", req.InvestorID),
+		attribute.Float64("investment.amount", req.Amount),
+	)
+	defer func() { tracing.EndSpan(span, err) }()
+
+	return s.next.InvestLoan(ctx, req)
+}
+
+func (s *TracedLoanService) ConfirmInvestmentFunding(ctx context.Context, req dto.ConfirmInvestmentFundingRequest) (err error) {
+	ctx, span := tracing.StartSpan(ctx, "LoanService.ConfirmInvestmentFunding",
+		attribute.String("loan.uuid", req.LoanUUID),
+		attribute.String("loan.actor", req.EmployeeID),
+		attribute.String("investment.uuid", req.InvestmentUUID),
+	)
+	defer func() { tracing.EndSpan(span, err) }()
+
+	return s.next.ConfirmInvestmentFunding(ctx, req)
+}
+
+func (s *TracedLoanService) HandleCollectionCallback(ctx context.Context, payload []byte, signature string) (err error) {
+	ctx, span := tracing.StartSpan(ctx, "LoanService.HandleCollectionCallback")
+	defer func() { tracing.EndSpan(span, err) }()
+
+	return s.next.HandleCollectionCallback(ctx, payload, signature)
+}
+
+func (s *TracedLoanService) CancelInvestment(ctx context.Context, req dto.CancelInvestmentRequest) (err error) {
+	ctx, span := tracing.StartSpan(ctx, "LoanService.CancelInvestment",
+		attribute.String("loan.actor", req.InvestorID),
+		attribute.String("investment.uuid", req.InvestmentUUID),
+	)
+	defer func() { tracing.EndSpan(span, err) }()
+
+	return s.next.CancelInvestment(ctx, req)
+}
+
+func (s *TracedLoanService) GetInvestorInvestments(ctx context.Context, investorID string, page dto.PageRequest) (response dto.InvestorInvestmentsResponse, err error) {
+	ctx, span := tracing.StartSpan(ctx, "LoanService.GetInvestorInvestments",
+		attribute.String("investor.id", investorID),
+		attribute.Int("page", page.Page),
+	)
+	defer func() { tracing.EndSpan(span, err) }()
+
+	return s.next.GetInvestorInvestments(ctx, investorID, page)
+}
+
+func (s *TracedLoanService) GetInvestorPortfolio(ctx context.Context, investorID string, page dto.PageRequest) (response dto.InvestorPortfolioResponse, err error) {
+	ctx, span := tracing.StartSpan(ctx, "LoanService.GetInvestorPortfolio",
+		attribute.String("investor.id", investorID),
+		attribute.Int("page", page.Page),
+	)
+	defer func() { tracing.EndSpan(span, err) }()
+
+	return s.next.GetInvestorPortfolio(ctx, investorID, page)
+}
+
+func (s *TracedLoanService) GetBorrowerLoans(ctx context.Context, borrowerID string, page dto.PageRequest) (response dto.BorrowerLoansResponse, err error) {
+	ctx, span := tracing.StartSpan(ctx, "LoanService.GetBorrowerLoans",
+		attribute.String("borrower.id", borrowerID),
+		attribute.Int("page", page.Page),
+	)
+	defer func() { tracing.EndSpan(span, err) }()
+
+	return s.next.GetBorrowerLoans(ctx, borrowerID, page)
+}
+
+func (s *TracedLoanService) CreateLoanDisbursement(ctx context.Context, req dto.CreateLoanDisbursementRequest) (err error) {
+	ctx, span := tracing.StartSpan(ctx, "LoanService.CreateLoanDisbursement",
+		attribute.String("loan.uuid", req.LoanUUID),
+		attribute.String("loan.actor", req.EmployeeID),
+	)
+	defer func() { tracing.EndSpan(span, err) }()
+
+	return s.next.CreateLoanDisbursement(ctx, req)
+}
+
+func (s *TracedLoanService) HandlePayoutCallback(ctx context.Context, payload []byte, signature string) (err error) {
+	ctx, span := tracing.StartSpan(ctx, "LoanService.HandlePayoutCallback")
+	defer func() { tracing.EndSpan(span, err) }()
+
+	return s.next.HandlePayoutCallback(ctx, payload, signature)
+}
+
+func (s *TracedLoanService) ExpireOverdueLoans(ctx context.Context) (expired int, err error) {
+	ctx, span := tracing.StartSpan(ctx, "LoanService.ExpireOverdueLoans")
+	defer func() {
+		span.SetAttributes(attribute.Int("loan.expired_count", expired))
+		tracing.EndSpan(span, err)
+	}()
+
+	return s.next.ExpireOverdueLoans(ctx)
+}
+
+func (s *TracedLoanService) ExpireInvestmentReservations(ctx context.Context) (expired int, err error) {
+	ctx, span := tracing.StartSpan(ctx, "LoanService.ExpireInvestmentReservations")
+	defer func() {
+		span.SetAttributes(attribute.Int("investment.expired_count", expired))
+		tracing.EndSpan(span, err)
+	}()
+
+	return s.next.ExpireInvestmentReservations(ctx)
+}
+
+func (s *TracedLoanService) RefreshDelinquency(ctx context.Context) (changed int, err error) {
+	ctx, span := tracing.StartSpan(ctx, "LoanService.RefreshDelinquency")
+	defer func() {
+		span.SetAttributes(attribute.Int("loan.changed_count", changed))
+		tracing.EndSpan(span, err)
+	}()
+
+	return s.next.RefreshDelinquency(ctx)
+}
+
+func (s *TracedLoanService) AccrueInterest(ctx context.Context, from, to time.Time) (posted int, err error) {
+	ctx, span := tracing.StartSpan(ctx, "LoanService.AccrueInterest",
+		attribute.String("accrual.from", from.Format(time.DateOnly)),
+		attribute.String("accrual.to", to.Format(time.DateOnly)),
+	)
+	defer func() {
+		span.SetAttributes(attribute.Int("accrual.posted_count", posted))
+		tracing.EndSpan(span, err)
+	}()
+
+	return s.next.AccrueInterest(ctx, from, to)
+}
+
+func (s *TracedLoanService) DeclareDefault(ctx context.Context, req dto.DeclareDefaultRequest) (err error) {
+	ctx, span := tracing.StartSpan(ctx, "LoanService.DeclareDefault",
+		attribute.String("loan.uuid", req.LoanUUID),
+		attribute.String("loan.actor", req.EmployeeID),
+	)
+	defer func() { tracing.EndSpan(span, err) }()
+
+	return s.next.DeclareDefault(ctx, req)
+}
+
+func (s *TracedLoanService) WriteOffLoan(ctx context.Context, req dto.WriteOffLoanRequest) (err error) {
+	ctx, span := tracing.StartSpan(ctx, "LoanService.WriteOffLoan",
+		attribute.String("loan.uuid", req.LoanUUID),
+		attribute.String("loan.actor", req.EmployeeID),
+	)
+	defer func() { tracing.EndSpan(span, err) }()
+
+	return s.next.WriteOffLoan(ctx, req)
+}
+
+func (s *TracedLoanService) GetPayoffQuote(ctx context.Context, uuid string, asOf time.Time) (response dto.PayoffQuoteResponse, err error) {
+	ctx, span := tracing.StartSpan(ctx, "LoanService.GetPayoffQuote",
+		attribute.String("loan.uuid", uuid),
+	)
+	defer func() { tracing.EndSpan(span, err) }()
+
+	return s.next.GetPayoffQuote(ctx, uuid, asOf)
+}
+
+func (s *TracedLoanService) PrepayLoan(ctx context.Context, req dto.PrepayLoanRequest) (err error) {
+	ctx, span := tracing.StartSpan(ctx, "LoanService.PrepayLoan",
+		attribute.String("loan.uuid", req.LoanUUID),
+	)
+	defer func() { tracing.EndSpan(span, err) }()
+
+	return s.next.PrepayLoan(ctx, req)
+}
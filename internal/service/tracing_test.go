@@ -0,0 +1,41 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"loan-service/internal/dto"
+	"loan-service/mocks"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace"
+)
+
+func TestTracedLoanService_RecordsSpanAroundCall(t *testing.T) {
+	exporter := tracetest.NewInMemoryExporter()
+	previous := otel.GetTracerProvider()
+	otel.SetTracerProvider(sdktrace.NewTracerProvider(sdktrace.WithSyncer(exporter)))
+	t.Cleanup(func() { otel.SetTracerProvider(previous) })
+
+	var innerSpan trace.SpanContext
+	next := mocks.NewLoanServiceInterface(t)
+	next.On("InvestLoan", mock.Anything, mock.Anything).Run(func(args mock.Arguments) {
+		innerSpan = trace.SpanContextFromContext(args.Get(0).(context.Context))
+	}).Return(dto.InvestLoanResponse{}, errors.New("loan is not approved"))
+
+	s := NewTracedLoanService(next)
+	_, err := s.InvestLoan(context.Background(), dto.InvestLoanRequest{LoanUUID: "loan-uuid-123", InvestorID: "investor123", Amount: 500})
+	assert.Error(t, err)
+
+	spans := exporter.GetSpans()
+	require.Len(t, spans, 1)
+	assert.Equal(t, "LoanService.InvestLoan", spans[0].Name)
+	assert.Equal(t, codes.Error, spans[0].Status.Code)
+	assert.Equal(t, spans[0].SpanContext.SpanID(), innerSpan.SpanID(), "wrapped service must receive the span context")
+}
@@ -0,0 +1,102 @@
+package tracing
+
+import (
+	"errors"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	semconv "go.opentelemetry.io/otel/semconv/v1.26.0"
+	"go.opentelemetry.io/otel/trace"
+	"gorm.io/gorm"
+)
+
+const gormSpanKey = "tracing:span"
+
+// GormPlugin creates a client span around every GORM operation, parented to
+// the span carried by the statement context (set through db.WithContext).
+type GormPlugin struct {
+	dbSystem string
+}
+
+// Ensure GormPlugin implements gorm.Plugin
+var _ gorm.Plugin = (*GormPlugin)(nil)
+
+func NewGormPlugin(dbSystem string) *GormPlugin {
+	return &GormPlugin{dbSystem: dbSystem}
+}
+
+func (p *GormPlugin) Name() string {
+	return "tracing"
+}
+
+func (p *GormPlugin) Initialize(db *gorm.DB) error {
+	callback := db.Callback()
+	registrations := []struct {
+		register func(name string, fn func(*gorm.DB)) error
+		name     string
+		fn       func(*gorm.DB)
+	}{
+		{callback.Create().Before("gorm:create").Register, "tracing:before_create", p.before("create")},
+		{callback.Create().After("gorm:create").Register, "tracing:after_create", p.after},
+		{callback.Query().Before("gorm:query").Register, "tracing:before_query", p.before("query")},
+		{callback.Query().After("gorm:query").Register, "tracing:after_query", p.after},
+		{callback.Update().Before("gorm:update").Register, "tracing:before_update", p.before("update")},
+		{callback.Update().After("gorm:update").Register, "tracing:after_update", p.after},
+		{callback.Delete().Before("gorm:delete").Register, "tracing:before_delete", p.before("delete")},
+		{callback.Delete().After("gorm:delete").Register, "tracing:after_delete", p.after},
+		{callback.Row().Before("gorm:row").Register, "tracing:before_row", p.before("row")},
+		{callback.Row().After("gorm:row").Register, "tracing:after_row", p.after},
+		{callback.Raw().Before("gorm:raw").Register, "tracing:before_raw", p.before("raw")},
+		{callback.Raw().After("gorm:raw").Register, "tracing:after_raw", p.after},
+	}
+
+	for _, registration := range registrations {
+		if err := registration.register(registration.name, registration.fn); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (p *GormPlugin) before(operation string) func(*gorm.DB) {
+	return func(db *gorm.DB) {
+		if db.Statement == nil || db.Statement.Context == nil {
+			return
+		}
+
+		_, span := Tracer().Start(db.Statement.Context, "gorm."+operation,
+			trace.WithSpanKind(trace.SpanKindClient),
+			trace.WithAttributes(
+				semconv.DBSystemKey.String(p.dbSystem),
+				semconv.DBOperationName(operation),
+			),
+		)
+		db.InstanceSet(gormSpanKey, span)
+	}
+}
+
+func (p *GormPlugin) after(db *gorm.DB) {
+	value, ok := db.InstanceGet(gormSpanKey)
+	if !ok {
+		return
+	}
+	span, ok := value.(trace.Span)
+	if !ok {
+		return
+	}
+	defer span.End()
+
+	span.SetAttributes(
+		semconv.DBQueryText(db.Statement.SQL.String()),
+		attribute.Int64("db.rows_affected", db.RowsAffected),
+	)
+	if db.Statement.Table != "" {
+		span.SetAttributes(semconv.DBCollectionName(db.Statement.Table))
+	}
+
+	if db.Error != nil && !errors.Is(db.Error, gorm.ErrRecordNotFound) {
+		span.RecordError(db.Error)
+		span.SetStatus(codes.Error, db.Error.Error())
+	}
+}
@@ -0,0 +1,120 @@
+package tracing
+
+import (
+	"context"
+	"testing"
+
+	"loan-service/internal/config"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	semconv "go.opentelemetry.io/otel/semconv/v1.26.0"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+)
+
+type testRecord struct {
+	ID   int `gorm:"primaryKey"`
+	Name string
+}
+
+func setupTestTracer(t *testing.T) *tracetest.InMemoryExporter {
+	exporter := tracetest.NewInMemoryExporter()
+	provider := sdktrace.NewTracerProvider(sdktrace.WithSyncer(exporter))
+
+	previous := otel.GetTracerProvider()
+	otel.SetTracerProvider(provider)
+	t.Cleanup(func() {
+		otel.SetTracerProvider(previous)
+	})
+
+	return exporter
+}
+
+func setupTestDB(t *testing.T) *gorm.DB {
+	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{})
+	require.NoError(t, err)
+	require.NoError(t, db.AutoMigrate(&testRecord{}))
+	require.NoError(t, db.Use(NewGormPlugin("sqlite")))
+	return db
+}
+
+func findSpan(spans tracetest.SpanStubs, name string) *tracetest.SpanStub {
+	for i := range spans {
+		if spans[i].Name == name {
+			return &spans[i]
+		}
+	}
+	return nil
+}
+
+func TestGormPlugin_CreatesChildSpansWithStatement(t *testing.T) {
+	exporter := setupTestTracer(t)
+	db := setupTestDB(t)
+
+	ctx, parent := StartSpan(context.Background(), "LoanService.Test")
+	require.NoError(t, db.WithContext(ctx).Create(&testRecord{Name: "loan"}).Error)
+
+	var records []testRecord
+	require.NoError(t, db.WithContext(ctx).Where("name = ?", "loan").Find(&records).Error)
+	EndSpan(parent, nil)
+
+	spans := exporter.GetSpans()
+	create := findSpan(spans, "gorm.create")
+	query := findSpan(spans, "gorm.query")
+	require.NotNil(t, create)
+	require.NotNil(t, query)
+
+	assert.Equal(t, parent.SpanContext().SpanID(), create.Parent.SpanID())
+	assert.Equal(t, parent.SpanContext().SpanID(), query.Parent.SpanID())
+	assert.Contains(t, query.Attributes, semconv.DBCollectionName("test_records"))
+	assert.Contains(t, query.Attributes, semconv.DBSystemKey.String("sqlite"))
+}
+
+func TestGormPlugin_RecordsQueryErrors(t *testing.T) {
+	exporter := setupTestTracer(t)
+	db := setupTestDB(t)
+
+	err := db.WithContext(context.Background()).Table("missing_table").Find(&[]testRecord{}).Error
+	require.Error(t, err)
+
+	query := findSpan(exporter.GetSpans(), "gorm.query")
+	require.NotNil(t, query)
+	assert.Equal(t, codes.Error, query.Status.Code)
+}
+
+func TestGormPlugin_IgnoresRecordNotFound(t *testing.T) {
+	exporter := setupTestTracer(t)
+	db := setupTestDB(t)
+
+	var record testRecord
+	err := db.WithContext(context.Background()).First(&record, 42).Error
+	require.ErrorIs(t, err, gorm.ErrRecordNotFound)
+
+	query := findSpan(exporter.GetSpans(), "gorm.query")
+	require.NotNil(t, query)
+	assert.Equal(t, codes.Unset, query.Status.Code)
+}
+
+func TestSetup_NoneExporterKeepsNoopProvider(t *testing.T) {
+	shutdown, err := Setup(context.Background(), configWithExporter(ExporterNone))
+	require.NoError(t, err)
+	assert.NoError(t, shutdown(context.Background()))
+}
+
+func TestSetup_UnknownExporter(t *testing.T) {
+	_, err := Setup(context.Background(), configWithExporter("zipkin"))
+	assert.ErrorContains(t, err, `unknown tracing exporter "zipkin"`)
+}
+
+func configWithExporter(exporter string) config.TracingConfig {
+	return config.TracingConfig{
+		Exporter:    exporter,
+		ServiceName: "loan-service-test",
+		SampleRatio: 1,
+	}
+}
@@ -1,12 +1,24 @@
 package main
 
 import (
+	"context"
+	"errors"
+	"flag"
 	"log"
 	"os"
+	"os/signal"
+	"syscall"
+	"time"
 
+	"loan-service/internal/client"
 	"loan-service/internal/config"
 	"loan-service/internal/database"
+	"loan-service/internal/logging"
+	"loan-service/internal/repository"
 	"loan-service/internal/server"
+	"loan-service/internal/service"
+
+	"github.com/sirupsen/logrus"
 )
 
 func main() {
@@ -16,26 +28,74 @@ func main() {
 
 	cfg := config.New()
 
+	logger := logging.New(cfg.Log)
+	logging.SetDefault(logger)
+
 	if len(os.Args) > 1 {
 		command := os.Args[1]
 		switch command {
 		case "migrate":
-			if err := database.RunMigrations(cfg); err != nil {
-				log.Fatal("Error running migrations:", err)
+			if err := database.RunMigrations(cfg, logger); err != nil {
+				logger.Fatal("Error running migrations: ", err)
 			}
-			log.Println("Migrations completed successfully")
 			return
 		case "migrate-down":
-			if err := database.RollbackMigration(cfg); err != nil {
-				log.Fatal("Error rolling back migrations:", err)
+			if err := database.RollbackMigration(cfg, logger); err != nil {
+				logger.Fatal("Error rolling back migrations: ", err)
+			}
+			return
+		case "backfill-accruals":
+			if err := backfillAccruals(cfg, logger, os.Args[2:]); err != nil {
+				logger.Fatal("Error backfilling interest accruals: ", err)
 			}
-			log.Println("Migration rollback completed successfully")
 			return
 		}
 	}
 
-	srv := server.New(cfg)
-	if err := srv.Start(); err != nil {
-		log.Fatal("Error starting server:", err)
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	srv := server.New(cfg, logger)
+	if err := srv.Run(ctx); err != nil {
+		logger.Fatal("Error running server: ", err)
+	}
+}
+
+// backfillAccruals re-runs the daily interest accrual of every disbursed loan
+// for each day from -from through -to (YYYY-MM-DD, both inclusive). Days that
+// were already accrued with the same result are left untouched, so a range
+// can be backfilled any number of times.
+func backfillAccruals(cfg *config.Config, logger *logrus.Logger, args []string) error {
+	flags := flag.NewFlagSet("backfill-accruals", flag.ContinueOnError)
+	fromFlag := flags.String("from", "", "first day to accrue (YYYY-MM-DD)")
+	toFlag := flags.String("to", "", "last day to accrue (YYYY-MM-DD, default: -from)")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	if *fromFlag == "" {
+		return errors.New("-from is required")
+	}
+	if *toFlag == "" {
+		*toFlag = *fromFlag
+	}
+
+	from, err := time.Parse(time.DateOnly, *fromFlag)
+	if err != nil {
+		return err
 	}
+	to, err := time.Parse(time.DateOnly, *toFlag)
+	if err != nil {
+		return err
+	}
+
+	db, err := database.New(cfg, logger)
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	loanService := service.NewLoanService(repository.NewLoanRepository(db.DB), client.NewNotificationClient(&cfg.Notification), client.NewPaymentGatewayClient(&cfg.Payment), cfg.Loan)
+	posted, err := loanService.AccrueInterest(context.Background(), from, to)
+	logger.Infof("Posted %d interest accruals from %s to %s", posted, from.Format(time.DateOnly), to.Format(time.DateOnly))
+	return err
 }
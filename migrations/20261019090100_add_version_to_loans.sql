@@ -0,0 +1,9 @@
+-- +goose Up
+-- +goose StatementBegin
+ALTER TABLE loans ADD COLUMN version INT NOT NULL DEFAULT 1 AFTER status;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+ALTER TABLE loans DROP COLUMN version;
+-- +goose StatementEnd
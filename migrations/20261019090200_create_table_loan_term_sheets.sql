@@ -0,0 +1,47 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE TABLE loan_term_sheets (
+    id INT AUTO_INCREMENT PRIMARY KEY,
+    uuid VARCHAR(255) NOT NULL,
+    loan_id INT NOT NULL,
+    version INT NOT NULL,
+    principal_amount DECIMAL(15,2) NOT NULL,
+    interest_rate DECIMAL(5,2) NOT NULL,
+    roi_rate DECIMAL(5,2) NOT NULL,
+    actor VARCHAR(255) NOT NULL,
+    reason VARCHAR(255) NOT NULL DEFAULT '',
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
+    INDEX idx_uuid (uuid),
+    UNIQUE INDEX idx_loan_id_version (loan_id, version),
+    FOREIGN KEY (loan_id) REFERENCES loans(id)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci;
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+INSERT INTO loan_term_sheets (uuid, loan_id, version, principal_amount, interest_rate, roi_rate, actor, created_at)
+SELECT UUID(), id, 1, principal_amount, interest_rate, roi_rate, borrower_id, created_at FROM loans;
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+ALTER TABLE loan_approvals
+    ADD COLUMN loan_term_sheet_id INT NULL AFTER loan_id,
+    ADD CONSTRAINT fk_loan_approvals_loan_term_sheet FOREIGN KEY (loan_term_sheet_id) REFERENCES loan_term_sheets(id);
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+UPDATE loan_approvals
+JOIN loan_term_sheets ON loan_term_sheets.loan_id = loan_approvals.loan_id
+SET loan_approvals.loan_term_sheet_id = loan_term_sheets.id;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+ALTER TABLE loan_approvals
+    DROP FOREIGN KEY fk_loan_approvals_loan_term_sheet,
+    DROP COLUMN loan_term_sheet_id;
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+DROP TABLE IF EXISTS loan_term_sheets;
+-- +goose StatementEnd
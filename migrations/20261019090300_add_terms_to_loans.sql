@@ -0,0 +1,21 @@
+-- +goose Up
+-- +goose StatementBegin
+ALTER TABLE loans
+    ADD COLUMN tenor INT NOT NULL DEFAULT 0 AFTER roi_rate,
+    ADD COLUMN tenor_unit INT NOT NULL DEFAULT 0 AFTER tenor,
+    ADD COLUMN repayment_frequency INT NOT NULL DEFAULT 0 AFTER tenor_unit,
+    ADD COLUMN purpose INT NOT NULL DEFAULT 0 AFTER repayment_frequency,
+    ADD COLUMN requested_disbursement_date DATE NULL AFTER purpose,
+    ADD INDEX idx_purpose (purpose);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+ALTER TABLE loans
+    DROP INDEX idx_purpose,
+    DROP COLUMN requested_disbursement_date,
+    DROP COLUMN purpose,
+    DROP COLUMN repayment_frequency,
+    DROP COLUMN tenor_unit,
+    DROP COLUMN tenor;
+-- +goose StatementEnd
@@ -0,0 +1,27 @@
+-- +goose Up
+-- +goose StatementBegin
+ALTER TABLE loans
+    ADD COLUMN funding_deadline TIMESTAMP NULL AFTER requested_disbursement_date,
+    ADD INDEX idx_status_funding_deadline (status, funding_deadline);
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+ALTER TABLE investments
+    ADD COLUMN status INT NOT NULL DEFAULT 1 AFTER agreement_letter_url,
+    ADD COLUMN refunded_at TIMESTAMP NULL AFTER status,
+    ADD INDEX idx_loan_id_status (loan_id, status);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+ALTER TABLE investments
+    DROP INDEX idx_loan_id_status,
+    DROP COLUMN refunded_at,
+    DROP COLUMN status;
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+ALTER TABLE loans
+    DROP INDEX idx_status_funding_deadline,
+    DROP COLUMN funding_deadline;
+-- +goose StatementEnd
@@ -0,0 +1,62 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE TABLE loan_instalments (
+    id INT AUTO_INCREMENT PRIMARY KEY,
+    uuid VARCHAR(255) NOT NULL,
+    loan_id INT NOT NULL,
+    sequence INT NOT NULL,
+    due_date DATE NOT NULL,
+    principal_due DECIMAL(15,2) NOT NULL,
+    interest_due DECIMAL(15,2) NOT NULL,
+    paid_amount DECIMAL(15,2) NOT NULL DEFAULT 0,
+    paid_at TIMESTAMP NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
+    INDEX idx_uuid (uuid),
+    UNIQUE INDEX idx_loan_id_sequence (loan_id, sequence),
+    INDEX idx_due_date (due_date),
+    FOREIGN KEY (loan_id) REFERENCES loans(id)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci;
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+CREATE TABLE loan_ledger_entries (
+    id INT AUTO_INCREMENT PRIMARY KEY,
+    uuid VARCHAR(255) NOT NULL,
+    loan_id INT NOT NULL,
+    loan_instalment_id INT NULL,
+    entry_type INT NOT NULL,
+    amount DECIMAL(15,2) NOT NULL,
+    effective_date DATE NOT NULL,
+    description VARCHAR(255) NOT NULL DEFAULT '',
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
+    INDEX idx_uuid (uuid),
+    INDEX idx_loan_id_effective_date (loan_id, effective_date),
+    FOREIGN KEY (loan_id) REFERENCES loans(id),
+    FOREIGN KEY (loan_instalment_id) REFERENCES loan_instalments(id)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci;
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+ALTER TABLE loans
+    ADD COLUMN days_past_due INT NOT NULL DEFAULT 0 AFTER funding_deadline,
+    ADD COLUMN dpd_bucket INT NOT NULL DEFAULT 1 AFTER days_past_due,
+    ADD INDEX idx_dpd_bucket (dpd_bucket);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+ALTER TABLE loans
+    DROP INDEX idx_dpd_bucket,
+    DROP COLUMN dpd_bucket,
+    DROP COLUMN days_past_due;
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+DROP TABLE IF EXISTS loan_ledger_entries;
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+DROP TABLE IF EXISTS loan_instalments;
+-- +goose StatementEnd
@@ -0,0 +1,36 @@
+-- +goose Up
+-- +goose StatementBegin
+ALTER TABLE loan_instalments
+    ADD COLUMN cancelled_at TIMESTAMP NULL AFTER paid_at;
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+CREATE TABLE investor_payouts (
+    id INT AUTO_INCREMENT PRIMARY KEY,
+    uuid VARCHAR(255) NOT NULL,
+    loan_id INT NOT NULL,
+    investment_id INT NOT NULL,
+    investor_id VARCHAR(255) NOT NULL,
+    principal_amount DECIMAL(15,2) NOT NULL,
+    interest_amount DECIMAL(15,2) NOT NULL,
+    penalty_amount DECIMAL(15,2) NOT NULL DEFAULT 0,
+    paid_at TIMESTAMP NOT NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
+    INDEX idx_uuid (uuid),
+    INDEX idx_loan_id (loan_id),
+    INDEX idx_investor_id (investor_id),
+    FOREIGN KEY (loan_id) REFERENCES loans(id),
+    FOREIGN KEY (investment_id) REFERENCES investments(id)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE IF EXISTS investor_payouts;
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+ALTER TABLE loan_instalments
+    DROP COLUMN cancelled_at;
+-- +goose StatementEnd
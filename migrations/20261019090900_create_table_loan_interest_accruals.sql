@@ -0,0 +1,25 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE TABLE loan_interest_accruals (
+    id INT AUTO_INCREMENT PRIMARY KEY,
+    uuid VARCHAR(255) NOT NULL,
+    loan_id INT NOT NULL,
+    loan_ledger_entry_id INT NOT NULL,
+    accrual_date DATE NOT NULL,
+    day_count_convention INT NOT NULL,
+    principal_amount DECIMAL(15,2) NOT NULL,
+    interest_rate DECIMAL(5,2) NOT NULL,
+    amount DECIMAL(15,6) NOT NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
+    INDEX idx_uuid (uuid),
+    UNIQUE INDEX idx_loan_id_accrual_date (loan_id, accrual_date),
+    FOREIGN KEY (loan_id) REFERENCES loans(id),
+    FOREIGN KEY (loan_ledger_entry_id) REFERENCES loan_ledger_entries(id)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE IF EXISTS loan_interest_accruals;
+-- +goose StatementEnd
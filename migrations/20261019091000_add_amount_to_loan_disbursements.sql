@@ -0,0 +1,32 @@
+-- +goose Up
+-- +goose StatementBegin
+ALTER TABLE loan_disbursements
+    ADD COLUMN amount DECIMAL(15,2) NOT NULL DEFAULT 0 AFTER loan_id;
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+UPDATE loan_disbursements d
+    JOIN loans l ON l.id = d.loan_id
+SET d.amount = l.investment_amount;
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+ALTER TABLE loans
+    ADD COLUMN disbursed_amount DECIMAL(15,2) NOT NULL DEFAULT 0 AFTER investment_amount;
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+UPDATE loans l
+SET l.disbursed_amount = (SELECT COALESCE(SUM(d.amount), 0) FROM loan_disbursements d WHERE d.loan_id = l.id);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+ALTER TABLE loans
+    DROP COLUMN disbursed_amount;
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+ALTER TABLE loan_disbursements
+    DROP COLUMN amount;
+-- +goose StatementEnd
@@ -0,0 +1,26 @@
+-- +goose Up
+-- +goose StatementBegin
+ALTER TABLE loan_disbursements
+    ADD COLUMN account_holder_name VARCHAR(255) NOT NULL DEFAULT '' AFTER signed_agreement_letter_url,
+    ADD COLUMN bank_code VARCHAR(50) NOT NULL DEFAULT '' AFTER account_holder_name,
+    ADD COLUMN bank_account_number VARCHAR(50) NOT NULL DEFAULT '' AFTER bank_code,
+    ADD COLUMN payout_status INT NOT NULL DEFAULT 1 AFTER bank_account_number,
+    ADD COLUMN gateway_payout_id VARCHAR(255) NULL AFTER payout_status,
+    ADD COLUMN failure_reason VARCHAR(255) NOT NULL DEFAULT '' AFTER gateway_payout_id;
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+UPDATE loan_disbursements
+SET payout_status = 2;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+ALTER TABLE loan_disbursements
+    DROP COLUMN failure_reason,
+    DROP COLUMN gateway_payout_id,
+    DROP COLUMN payout_status,
+    DROP COLUMN bank_account_number,
+    DROP COLUMN bank_code,
+    DROP COLUMN account_holder_name;
+-- +goose StatementEnd
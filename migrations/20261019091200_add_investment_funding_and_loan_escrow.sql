@@ -0,0 +1,64 @@
+-- +goose Up
+-- +goose StatementBegin
+ALTER TABLE investments
+    ADD COLUMN funding_expires_at TIMESTAMP NULL AFTER status,
+    ADD COLUMN funded_at TIMESTAMP NULL AFTER funding_expires_at,
+    ADD COLUMN payment_reference VARCHAR(255) NOT NULL DEFAULT '' AFTER funded_at,
+    ADD INDEX idx_status_funding_expires_at (status, funding_expires_at);
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+UPDATE investments
+SET funded_at = created_at
+WHERE status IN (1, 2);
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+ALTER TABLE loans
+    ADD COLUMN escrow_balance DECIMAL(15,2) NOT NULL DEFAULT 0 AFTER disbursed_amount;
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+UPDATE loans
+SET escrow_balance = investment_amount - disbursed_amount
+WHERE status IN (2, 4, 11);
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+CREATE TABLE loan_escrow_entries (
+    id INT AUTO_INCREMENT PRIMARY KEY,
+    uuid VARCHAR(255) NOT NULL,
+    loan_id INT NOT NULL,
+    investment_id INT NULL,
+    loan_disbursement_id INT NULL,
+    entry_type INT NOT NULL,
+    amount DECIMAL(15,2) NOT NULL,
+    balance_after DECIMAL(15,2) NOT NULL,
+    effective_at TIMESTAMP NOT NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
+    INDEX idx_uuid (uuid),
+    INDEX idx_loan_id (loan_id),
+    FOREIGN KEY (loan_id) REFERENCES loans(id),
+    FOREIGN KEY (investment_id) REFERENCES investments(id),
+    FOREIGN KEY (loan_disbursement_id) REFERENCES loan_disbursements(id)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE IF EXISTS loan_escrow_entries;
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+ALTER TABLE loans
+    DROP COLUMN escrow_balance;
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+ALTER TABLE investments
+    DROP INDEX idx_status_funding_expires_at,
+    DROP COLUMN payment_reference,
+    DROP COLUMN funded_at,
+    DROP COLUMN funding_expires_at;
+-- +goose StatementEnd
@@ -0,0 +1,13 @@
+-- +goose Up
+-- +goose StatementBegin
+ALTER TABLE investments
+    ADD COLUMN cancelled_at TIMESTAMP NULL AFTER refunded_at,
+    ADD COLUMN agreement_letter_voided_at TIMESTAMP NULL AFTER cancelled_at;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+ALTER TABLE investments
+    DROP COLUMN agreement_letter_voided_at,
+    DROP COLUMN cancelled_at;
+-- +goose StatementEnd
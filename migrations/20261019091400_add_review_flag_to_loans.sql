@@ -0,0 +1,13 @@
+-- +goose Up
+-- +goose StatementBegin
+ALTER TABLE loans
+    ADD COLUMN flagged_at TIMESTAMP NULL AFTER escrow_balance,
+    ADD COLUMN flag_reason VARCHAR(255) NOT NULL DEFAULT '' AFTER flagged_at;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+ALTER TABLE loans
+    DROP COLUMN flag_reason,
+    DROP COLUMN flagged_at;
+-- +goose StatementEnd
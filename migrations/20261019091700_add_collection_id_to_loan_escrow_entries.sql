@@ -0,0 +1,13 @@
+-- +goose Up
+-- +goose StatementBegin
+ALTER TABLE loan_escrow_entries
+    ADD COLUMN collection_id VARCHAR(255) NULL AFTER loan_disbursement_id,
+    ADD UNIQUE INDEX idx_collection_id (collection_id);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+ALTER TABLE loan_escrow_entries
+    DROP INDEX idx_collection_id,
+    DROP COLUMN collection_id;
+-- +goose StatementEnd
@@ -0,0 +1,19 @@
+-- +goose Up
+-- +goose StatementBegin
+-- Expired loans have refunded every funded investment, so nothing of their
+-- principal is invested any more.
+UPDATE loans
+SET investment_amount = 0
+WHERE status = 6;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+UPDATE loans
+SET investment_amount = (
+    SELECT COALESCE(SUM(investments.amount), 0)
+    FROM investments
+    WHERE investments.loan_id = loans.id AND investments.status = 2
+)
+WHERE status = 6;
+-- +goose StatementEnd